@@ -0,0 +1,46 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/go-chi/render"
+)
+
+// renderJSONWithETag writes v as JSON with a strong ETag of the body, and answers
+// 304 Not Modified when the client already holds the same representation
+func renderJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(body)
+}
+
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
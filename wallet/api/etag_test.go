@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderJSONWithETag(t *testing.T) {
+	get := func(v interface{}, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/wallets/abc", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		renderJSONWithETag(rec, req, v)
+		return rec
+	}
+
+	first := get(map[string]int64{"balance": 100}, "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.JSONEq(t, `{"balance":100}`, first.Body.String())
+
+	notModified := get(map[string]int64{"balance": 100}, etag)
+	require.Equal(t, http.StatusNotModified, notModified.Code)
+	require.Empty(t, notModified.Body.String())
+
+	weak := get(map[string]int64{"balance": 100}, `"other", W/`+etag)
+	require.Equal(t, http.StatusNotModified, weak.Code)
+
+	changed := get(map[string]int64{"balance": 90}, etag)
+	require.Equal(t, http.StatusOK, changed.Code)
+	require.NotEqual(t, etag, changed.Header().Get("ETag"))
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type FXResource interface {
+	CreateQuote(w http.ResponseWriter, r *http.Request)
+	GetQuote(w http.ResponseWriter, r *http.Request)
+	Pay(w http.ResponseWriter, r *http.Request)
+}
+
+type fxResource struct {
+	fxSvc service.FXSvc
+}
+
+func NewFXResource(fxSvc service.FXSvc) FXResource {
+	return &fxResource{
+		fxSvc: fxSvc,
+	}
+}
+
+func (fr *fxResource) CreateQuote(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log CreateQuote in api/fx/CreateQuote ")
+
+	var req dto.CreateFXQuoteDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	quote, err := fr.fxSvc.CreateQuote(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, quote)
+}
+
+func (fr *fxResource) GetQuote(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log GetQuote in api/fx/GetQuote ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	quote, err := fr.fxSvc.GetQuote(ctx, payload.Username, chi.URLParam(r, "id"))
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, quote)
+}
+
+func (fr *fxResource) Pay(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Pay in api/fx/Pay ")
+
+	var req dto.PayFXDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := fr.fxSvc.Pay(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type HoldResource interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Capture(w http.ResponseWriter, r *http.Request)
+	Void(w http.ResponseWriter, r *http.Request)
+}
+
+type holdResource struct {
+	holdSvc service.HoldSvc
+}
+
+func NewHoldResource(holdSvc service.HoldSvc) HoldResource {
+	return &holdResource{
+		holdSvc: holdSvc,
+	}
+}
+
+func (hr *holdResource) Create(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Create in api/hold/Create ")
+
+	var req dto.CreateHoldDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	hold, err := hr.holdSvc.Create(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, hold)
+}
+
+func (hr *holdResource) Get(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Get in api/hold/Get ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	hold, err := hr.holdSvc.Get(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, hold)
+}
+
+// Capture serves POST /holds/{id}/capture, an empty body captures the whole hold
+func (hr *holdResource) Capture(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Capture in api/hold/Capture ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	var req dto.CaptureHoldDto
+	if err := decodeOptionalBody(r, &req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := hr.holdSvc.Capture(ctx, payload.Username, id, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+func (hr *holdResource) Void(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Void in api/hold/Void ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	hold, err := hr.holdSvc.Void(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, hold)
+}
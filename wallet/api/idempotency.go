@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/go-chi/render"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// IdempotencyMiddleware executes a request carrying an Idempotency-Key header at most once,
+// retries with the same key and body get the stored response back. It must run after AuthMiddleware.
+func IdempotencyMiddleware(idempotencySvc service.IdempotencySvc, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			key := r.Header.Get(idempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			payload, err := authPayload(r)
+			if err != nil {
+				_ = render.Render(w, r, types.ErrResponse(err))
+				return
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				_ = render.Render(w, r, types.ErrBadRequest(err))
+				return
+			}
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			idempotencyKey, replay, err := idempotencySvc.Begin(ctx, payload.Username, scope, key, requestFingerprint(r, body))
+			if err != nil {
+				_ = render.Render(w, r, types.ErrResponse(err))
+				return
+			}
+
+			if replay {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Header().Set(idempotentReplayedHeader, "true")
+				w.WriteHeader(idempotencyKey.StatusCode)
+				_, _ = io.WriteString(w, idempotencyKey.ResponseBody)
+				return
+			}
+
+			// the journal entry posted by the handler is linked to the key, a retry after a crash finds it
+			rec := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r.WithContext(store.WithIdempotencyKey(ctx, idempotencyKey.ID)))
+
+			// server errors are not final, the client may retry them with the same key
+			if rec.status >= http.StatusInternalServerError {
+				err = idempotencySvc.Release(ctx, idempotencyKey.ID)
+			} else {
+				err = idempotencySvc.Complete(ctx, idempotencyKey.ID, rec.statusCode(), rec.body.Bytes())
+			}
+			if err != nil {
+				logrus.Errorf("failed to store idempotency key %d: %v", idempotencyKey.ID, err)
+			}
+		})
+	}
+}
+
+// requestFingerprint identifies the request a key was first used with
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *responseRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
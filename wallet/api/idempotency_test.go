@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdempotencySvc keeps the keys in memory
+type fakeIdempotencySvc struct {
+	mu   sync.Mutex
+	keys map[string]*model.IdempotencyKey
+}
+
+func (f *fakeIdempotencySvc) Begin(ctx context.Context, username string, scope string, key string, fingerprint string) (model.IdempotencyKey, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := username + "/" + scope + "/" + key
+	k, ok := f.keys[id]
+	if !ok {
+		k = &model.IdempotencyKey{ID: int64(len(f.keys) + 1), Username: username, Scope: scope, Key: key, Fingerprint: fingerprint}
+		f.keys[id] = k
+		return *k, false, nil
+	}
+	if k.Fingerprint != fingerprint {
+		return *k, false, local_errors.ErrIdempotencyKeyMismatch
+	}
+	if !k.IsCompleted() {
+		return *k, false, local_errors.ErrIdempotencyKeyInProgress
+	}
+	return *k, true, nil
+}
+
+func (f *fakeIdempotencySvc) Complete(ctx context.Context, id int64, statusCode int, responseBody []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, k := range f.keys {
+		if k.ID == id {
+			k.StatusCode = statusCode
+			k.ResponseBody = string(responseBody)
+		}
+	}
+	return nil
+}
+
+func (f *fakeIdempotencySvc) Release(ctx context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, k := range f.keys {
+		if k.ID == id {
+			delete(f.keys, name)
+		}
+	}
+	return nil
+}
+
+func (f *fakeIdempotencySvc) PurgeExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		render.JSON(w, r, map[string]int{"call": calls})
+	})
+
+	svc := &fakeIdempotencySvc{keys: map[string]*model.IdempotencyKey{}}
+	h := IdempotencyMiddleware(svc, "pay")(handler)
+
+	send := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/wallet/pay", strings.NewReader(body))
+		req.Header.Set(idempotencyKeyHeader, key)
+		payload := &token.Payload{Username: "deepak", ExpiredAt: time.Now().Add(time.Minute)}
+		req = req.WithContext(context.WithValue(req.Context(), authorizationPayloadKey, payload))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("key-1", `{"amount":10}`)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, 1, calls)
+
+	replay := send("key-1", `{"amount":10}`)
+	require.Equal(t, http.StatusOK, replay.Code)
+	require.Equal(t, "true", replay.Header().Get(idempotentReplayedHeader))
+	require.Equal(t, first.Body.String(), replay.Body.String())
+	require.Equal(t, 1, calls)
+
+	mismatch := send("key-1", `{"amount":20}`)
+	require.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	require.Equal(t, 1, calls)
+
+	other := send("key-2", `{"amount":10}`)
+	require.Equal(t, http.StatusOK, other.Code)
+	require.Equal(t, 2, calls)
+}
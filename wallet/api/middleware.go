@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"github.com/dsthakur2711/wallet/constant"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/go-chi/render"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const authorizationPayloadKey contextKey = "authorization_payload"
+
+// AuthMiddleware validates the bearer access token and stores its payload in the request context.
+// The token is refused as soon as its session is logged out or its user blocked, not only once it expires.
+func AuthMiddleware(tokenMaker token.Maker, userSvc service.UserSvc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			authorizationHeader := r.Header.Get(constant.AuthorizationHeaderKey)
+			if len(authorizationHeader) == 0 {
+				_ = render.Render(w, r, types.ErrResponse(types.ErrMissingAuthHeader))
+				return
+			}
+
+			fields := strings.Fields(authorizationHeader)
+			if len(fields) != 2 {
+				_ = render.Render(w, r, types.ErrResponse(types.ErrInvalidAuthHeaderFormat))
+				return
+			}
+
+			authorizationType := strings.ToLower(fields[0])
+			if authorizationType != constant.AuthorizationTypeBearer {
+				_ = render.Render(w, r, types.ErrResponse(types.ErrUnsupportedAuth))
+				return
+			}
+
+			payload, err := tokenMaker.VerifyToken(fields[1])
+			if err == nil {
+				// a refresh token outlives logouts and blocks, it only renews access tokens
+				err = payload.Expect(token.TokenTypeAccess)
+			}
+			if err == nil {
+				err = userSvc.Authorize(r.Context(), payload)
+			}
+			if err != nil {
+				_ = render.Render(w, r, types.ErrResponse(err))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), authorizationPayloadKey, payload)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authPayload returns the token payload stored by AuthMiddleware
+func authPayload(r *http.Request) (*token.Payload, error) {
+	payload, ok := r.Context().Value(authorizationPayloadKey).(*token.Payload)
+	if !ok {
+		return nil, types.ErrUnauthorized
+	}
+	return payload, nil
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+
+	maker, err := token.NewPasetoMaker("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	m := store.NewMemoryStore()
+	userRepo := store.NewMemoryUserRepo(m)
+	userSvc := service.NewUserService(userRepo, store.NewMemorySessionRepo(m), maker)
+
+	_, err = userSvc.CreateUser(ctx, dto.CreateUserDto{Username: "deepak", Password: "password", Email: "deepak@example.com"})
+	require.NoError(t, err)
+	login := func() dto.LoggedInUserDto {
+		loggedIn, err := userSvc.LoginUser(ctx, dto.LoginCredentialsDto{Username: "deepak", Password: "password"})
+		require.NoError(t, err)
+		return loggedIn
+	}
+
+	handler := AuthMiddleware(maker, userSvc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	get := func(bearer string) int {
+		req := httptest.NewRequest(http.MethodGet, "/wallets", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	loggedIn := login()
+	require.Equal(t, http.StatusNoContent, get(loggedIn.AccessToken))
+	// a refresh token only renews access tokens
+	require.Equal(t, http.StatusUnauthorized, get(loggedIn.RefreshToken))
+
+	// a token that names no session is not accepted
+	bearer, _, err := maker.CreateToken("deepak", "", token.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, get(bearer))
+
+	// the access token of a session dies with it, before it expires
+	require.NoError(t, userSvc.Logout(ctx, "deepak", dto.LogoutDto{SessionID: loggedIn.SessionID}))
+	require.Equal(t, http.StatusUnauthorized, get(loggedIn.AccessToken))
+
+	// and with its user once he is blocked
+	loggedIn = login()
+	require.Equal(t, http.StatusNoContent, get(loggedIn.AccessToken))
+	_, err = userRepo.UpdateUserStatus(ctx, store.UpdateUserStatusParams{Username: "deepak", Status: model.UserStatusBLOCKED})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, get(loggedIn.AccessToken))
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+type PaymentRequestResource interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	ListPending(w http.ResponseWriter, r *http.Request)
+	Approve(w http.ResponseWriter, r *http.Request)
+	Refuse(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
+}
+
+type paymentRequestResource struct {
+	paymentRequestSvc service.PaymentRequestSvc
+}
+
+func NewPaymentRequestResource(paymentRequestSvc service.PaymentRequestSvc) PaymentRequestResource {
+	return &paymentRequestResource{
+		paymentRequestSvc: paymentRequestSvc,
+	}
+}
+
+func (pr *paymentRequestResource) Create(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log Create in api/payment_request/Create ")
+
+	var req dto.CreatePaymentRequestDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := pr.paymentRequestSvc.Create(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+func (pr *paymentRequestResource) ListPending(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log ListPending in api/payment_request/ListPending ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := pr.paymentRequestSvc.ListPending(ctx, payload.Username)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+func (pr *paymentRequestResource) Approve(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log Approve in api/payment_request/Approve ")
+	pr.act(w, r, pr.paymentRequestSvc.Approve)
+}
+
+func (pr *paymentRequestResource) Refuse(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log Refuse in api/payment_request/Refuse ")
+	pr.act(w, r, pr.paymentRequestSvc.Refuse)
+}
+
+func (pr *paymentRequestResource) Cancel(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log Cancel in api/payment_request/Cancel ")
+	pr.act(w, r, pr.paymentRequestSvc.Cancel)
+}
+
+// act runs one of the status changes on the payment request named in the url
+func (pr *paymentRequestResource) act(w http.ResponseWriter, r *http.Request,
+	action func(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error)) {
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := action(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+func int64URLParam(r *http.Request, name string) (int64, error) {
+	value, err := strconv.ParseInt(chi.URLParam(r, name), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s in url", name)
+	}
+	return value, nil
+}
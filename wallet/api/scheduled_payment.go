@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type ScheduledPaymentResource interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
+	ListRuns(w http.ResponseWriter, r *http.Request)
+}
+
+type scheduledPaymentResource struct {
+	scheduledPaymentSvc service.ScheduledPaymentSvc
+}
+
+func NewScheduledPaymentResource(scheduledPaymentSvc service.ScheduledPaymentSvc) ScheduledPaymentResource {
+	return &scheduledPaymentResource{
+		scheduledPaymentSvc: scheduledPaymentSvc,
+	}
+}
+
+func (sr *scheduledPaymentResource) Create(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Create in api/scheduled_payment/Create ")
+
+	var req dto.CreateScheduledPaymentDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	scheduledPayment, err := sr.scheduledPaymentSvc.Create(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, scheduledPayment)
+}
+
+func (sr *scheduledPaymentResource) List(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log List in api/scheduled_payment/List ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	scheduledPayments, err := sr.scheduledPaymentSvc.List(ctx, payload.Username)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, scheduledPayments)
+}
+
+func (sr *scheduledPaymentResource) Get(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Get in api/scheduled_payment/Get ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	scheduledPayment, err := sr.scheduledPaymentSvc.Get(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, scheduledPayment)
+}
+
+func (sr *scheduledPaymentResource) Cancel(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Cancel in api/scheduled_payment/Cancel ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	scheduledPayment, err := sr.scheduledPaymentSvc.Cancel(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, scheduledPayment)
+}
+
+// ListRuns serves GET /scheduled-payments/{id}/runs, the execution history of a schedule, newest first
+func (sr *scheduledPaymentResource) ListRuns(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log ListRuns in api/scheduled_payment/ListRuns ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	runs, err := sr.scheduledPaymentSvc.ListRuns(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, runs)
+}
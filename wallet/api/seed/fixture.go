@@ -0,0 +1,140 @@
+package seed
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+	"gopkg.in/yaml.v2"
+)
+
+//go:embed users.yaml
+var bundledFixture []byte
+
+// Fixture is the content of a fixture file, the users are created before the transfers are made
+type Fixture struct {
+	Users     []User     `yaml:"users" json:"users"`
+	Transfers []Transfer `yaml:"transfers" json:"transfers"`
+}
+
+// User is created with its plaintext password hashed, its wallets are opened in order so the first one is its primary wallet
+type User struct {
+	Username string         `yaml:"username" json:"username"`
+	Email    string         `yaml:"email" json:"email"`
+	Password string         `yaml:"password" json:"password"`
+	Tier     model.UserTier `yaml:"tier" json:"tier"`
+	Role     model.UserRole `yaml:"role" json:"role"`
+	Wallets  []Wallet       `yaml:"wallets" json:"wallets"`
+}
+
+// Wallet is credited with Balance, in minor units, from the float wallet of its currency
+type Wallet struct {
+	Currency string `yaml:"currency" json:"currency"`
+	Balance  int64  `yaml:"balance" json:"balance"`
+}
+
+// Transfer pays Amount from the wallet of From to the wallet of To in Currency, Refund is then sent back
+// by To. From is a user of the same fixture, To may be a user created before.
+type Transfer struct {
+	From     string `yaml:"from" json:"from"`
+	To       string `yaml:"to" json:"to"`
+	Currency string `yaml:"currency" json:"currency"`
+	Amount   int64  `yaml:"amount" json:"amount"`
+	Refund   int64  `yaml:"refund" json:"refund"`
+}
+
+// ReadFixture reads a .yaml, .yml or .json fixture file, unknown fields are rejected
+func ReadFixture(path string) (Fixture, error) {
+	var f Fixture
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return f, fmt.Errorf("fixture: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(content, &f)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&f)
+	default:
+		return f, fmt.Errorf("fixture %s: only .yaml, .yml and .json files are supported", path)
+	}
+	if err != nil {
+		return f, fmt.Errorf("fixture %s: %v", path, err)
+	}
+
+	return f, nil
+}
+
+// DefaultFixture returns the bundled users.yaml
+func DefaultFixture() Fixture {
+	var f Fixture
+	if err := yaml.UnmarshalStrict(bundledFixture, &f); err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// Validate checks the fixture before anything is written, the currencies must be enabled in the registry
+func (f Fixture) Validate(currencies *currency.Registry) error {
+
+	users := map[string]map[string]bool{}
+
+	for i, u := range f.Users {
+		if u.Username == "" {
+			return fmt.Errorf("user %d: username is required", i+1)
+		}
+		if _, ok := users[u.Username]; ok {
+			return fmt.Errorf("user %s: listed twice", u.Username)
+		}
+		if len(u.Password) < 6 {
+			return fmt.Errorf("user %s: password must have at least 6 characters", u.Username)
+		}
+
+		wallets := map[string]bool{}
+		for _, w := range u.Wallets {
+			c, err := currencies.Validate(strings.ToUpper(w.Currency))
+			if err != nil {
+				return fmt.Errorf("user %s: wallet %q: %v", u.Username, w.Currency, err)
+			}
+			if wallets[c.Code] {
+				return fmt.Errorf("user %s: two %s wallets", u.Username, c.Code)
+			}
+			if w.Balance < 0 {
+				return fmt.Errorf("user %s: %s balance is negative", u.Username, c.Code)
+			}
+			wallets[c.Code] = true
+		}
+		users[u.Username] = wallets
+	}
+
+	for i, t := range f.Transfers {
+		wallets, ok := users[t.From]
+		if !ok {
+			return fmt.Errorf("transfer %d: %q is not a user of the fixture", i+1, t.From)
+		}
+		if t.To == "" || t.To == t.From {
+			return fmt.Errorf("transfer %d: to must be another user", i+1)
+		}
+		if !wallets[strings.ToUpper(t.Currency)] {
+			return fmt.Errorf("transfer %d: %s has no %q wallet", i+1, t.From, t.Currency)
+		}
+		if t.Amount <= 0 {
+			return fmt.Errorf("transfer %d: amount must be positive", i+1)
+		}
+		if t.Refund < 0 || t.Refund > t.Amount {
+			return fmt.Errorf("transfer %d: refund must be between 0 and the amount", i+1)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	// SyntheticUsernamePrefix starts the names of the generated users, user00001 to userNNNNN
+	SyntheticUsernamePrefix = "user"
+	// syntheticTransfers is the most transfers a generated user pays
+	syntheticTransfers = 8
+)
+
+// Generate makes up n users with an INR wallet, some with a USD one too, and a random history of
+// INR transfers between them. The users are named by their index, so generating more users later
+// only adds the new ones. A user never pays more than its opening balance, whatever it receives,
+// so the history can be made even when some of the payees were seeded by an earlier run.
+func Generate(n int, password string, rng *rand.Rand) Fixture {
+
+	f := Fixture{Users: make([]User, 0, n)}
+
+	for i := 1; i <= n; i++ {
+		username := fmt.Sprintf("%s%05d", SyntheticUsernamePrefix, i)
+
+		// between 100.00 and 10,000.00 INR
+		balance := 100 * (100 + rng.Int63n(9901))
+		wallets := []Wallet{{Currency: "INR", Balance: balance}}
+		if rng.Intn(3) == 0 {
+			wallets = append(wallets, Wallet{Currency: "USD", Balance: 100 * (10 + rng.Int63n(491))})
+		}
+
+		f.Users = append(f.Users, User{
+			Username: username,
+			Email:    username + "@example.com",
+			Password: password,
+			Wallets:  wallets,
+		})
+
+		if n < 2 {
+			continue
+		}
+
+		budget := balance
+		for k := rng.Intn(syntheticTransfers + 1); k > 0 && budget >= 100; k-- {
+			to := 1 + rng.Intn(n-1)
+			if to >= i {
+				to++
+			}
+
+			amount := 100 + rng.Int63n(budget/2+1)
+			if amount > budget {
+				amount = budget
+			}
+			budget -= amount
+
+			t := Transfer{
+				From:     username,
+				To:       fmt.Sprintf("%s%05d", SyntheticUsernamePrefix, to),
+				Currency: "INR",
+				Amount:   amount,
+			}
+			// one transfer in ten is refunded, in part or in full
+			if rng.Intn(10) == 0 {
+				t.Refund = 1 + rng.Int63n(amount)
+			}
+			f.Transfers = append(f.Transfers, t)
+		}
+	}
+
+	return f
+}
@@ -1,25 +1,205 @@
+// Package seed fills a database with the users, wallets, balances and transfers of fixture files,
+// or of synthetic users made up by Generate
 package seed
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dsthakur2711/wallet/currency"
 	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/util"
 	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
 )
 
-var users = []model.User{
-	model.User{
-		Username: "deepak",
-		Email:    "deepak@gmail.com",
-		HashedPassword: "password",
-	},
-	model.User{
-		Username: "dk",
-		Email:    "dsthakur@gmail.com",
-		HashedPassword:  "password",
-	},
+// Report counts what a Load did
+type Report struct {
+	UsersCreated     int
+	UsersSkipped     int
+	WalletsCreated   int
+	TransfersMade    int
+	TransfersSkipped int
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("users: %d created, %d already there; wallets: %d created; transfers: %d made, %d skipped",
+		r.UsersCreated, r.UsersSkipped, r.WalletsCreated, r.TransfersMade, r.TransfersSkipped)
+}
+
+// Seeder writes fixtures through the repositories, so the balances are booked on the ledger like any other credit or transfer
+type Seeder struct {
+	userRepo   store.UserRepo
+	walletRepo store.WalletRepo
+	currencies *currency.Registry
+	// hashes caches the hash of each password, the synthetic users all share one
+	hashes map[string]string
+}
+
+func NewSeeder(userRepo store.UserRepo, walletRepo store.WalletRepo, currencies *currency.Registry) *Seeder {
+	return &Seeder{
+		userRepo:   userRepo,
+		walletRepo: walletRepo,
+		currencies: currencies,
+		hashes:     map[string]string{},
+	}
+}
+
+// Load seeds the database with the SQL repositories, in one transaction: the repositories join it, so a run
+// that fails leaves no user behind that the next run would take as seeded without its wallets or transfers
+func Load(ctx context.Context, db *gorm.DB, f Fixture) (Report, error) {
+	var report Report
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		transRepo := store.NewTransRepo(tx)
+		ledgerRepo := store.NewLedgerRepo(tx)
+		walletRepo := store.NewWalletRepo(tx, transRepo, ledgerRepo, store.NewFXQuoteRepo(tx))
+
+		var err error
+		report, err = NewSeeder(store.NewUserRepo(tx), walletRepo, currency.Default()).Load(ctx, f)
+		return err
+	})
+	if err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+// Load creates the users of the fixture with their wallets and balances, then makes the transfers they pay.
+// It can be run again: a user that already exists is left as it is, and so are the transfers it pays.
+// A run must succeed or change nothing, see the package Load.
+func (s *Seeder) Load(ctx context.Context, f Fixture) (Report, error) {
+
+	logrus.Println("log  Load in seed/seeder/Load ")
+
+	var report Report
+
+	if err := f.Validate(s.currencies); err != nil {
+		return report, err
+	}
+
+	created := map[string]bool{}
+
+	for _, u := range f.Users {
+		ok, err := s.createUser(ctx, u)
+		if err != nil {
+			return report, fmt.Errorf("user %s: %w", u.Username, err)
+		}
+		if !ok {
+			report.UsersSkipped++
+			continue
+		}
+		created[u.Username] = true
+		report.UsersCreated++
+
+		for _, w := range u.Wallets {
+			if err := s.createWallet(ctx, u.Username, w); err != nil {
+				return report, fmt.Errorf("user %s: %s wallet: %w", u.Username, w.Currency, err)
+			}
+			report.WalletsCreated++
+		}
+	}
+
+	for i, t := range f.Transfers {
+		if !created[t.From] {
+			report.TransfersSkipped++
+			continue
+		}
+		if err := s.transfer(ctx, t); err != nil {
+			return report, fmt.Errorf("transfer %d from %s to %s: %w", i+1, t.From, t.To, err)
+		}
+		report.TransfersMade++
+	}
+
+	return report, nil
+}
+
+// createUser returns false when the username is already taken
+func (s *Seeder) createUser(ctx context.Context, u User) (bool, error) {
+
+	if _, err := s.userRepo.GetUserByUsername(ctx, u.Username); err == nil {
+		return false, nil
+	}
+
+	hashedPassword, ok := s.hashes[u.Password]
+	if !ok {
+		var err error
+		hashedPassword, err = util.HashPassword(u.Password)
+		if err != nil {
+			return false, err
+		}
+		s.hashes[u.Password] = hashedPassword
+	}
+
+	_, err := s.userRepo.CreateUser(ctx, store.CreateUserParams{
+		Username:       u.Username,
+		HashedPassword: hashedPassword,
+		Email:          u.Email,
+		Status:         model.UserStatusACTIVE,
+		Tier:           u.Tier,
+		Role:           u.Role,
+	})
+	if err == local_errors.ErrUsernameAlreadyTaken {
+		return false, nil
+	}
+	return err == nil, err
 }
 
+func (s *Seeder) createWallet(ctx context.Context, username string, w Wallet) error {
 
+	code := strings.ToUpper(w.Currency)
 
-func Load(db *gorm.DB) {
+	wallet, err := s.walletRepo.CreateWallet(ctx, store.CreateWalletParams{Username: username, Currency: code})
+	if err != nil {
+		return err
+	}
+	if w.Balance == 0 {
+		return nil
+	}
 
-}
\ No newline at end of file
+	// the balance comes from the float, which exists once the server started but maybe not yet
+	_, err = s.walletRepo.EnsureOrganizationWallet(ctx, store.OrganizationWalletParams{
+		Currency: code,
+		Purpose:  model.OrganizationWalletFLOAT,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.walletRepo.AddWalletBalance(ctx, store.AddWalletBalanceParams{WalletAddress: wallet.WalletAddress, Amount: w.Balance})
+	return err
+}
+
+// transfer is made without fee and limits, those of the server do not apply to the fixtures
+func (s *Seeder) transfer(ctx context.Context, t Transfer) error {
+
+	code := strings.ToUpper(t.Currency)
+
+	from, err := s.walletRepo.GetWalletByUsernameAndCurrency(ctx, t.From, code)
+	if err != nil {
+		return err
+	}
+	to, err := s.walletRepo.GetWalletByUsernameAndCurrency(ctx, t.To, code)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.walletRepo.SendMoney(ctx, store.SendMoneyParams{
+		FromWalletAddress: from.WalletAddress,
+		ToWalletAddress:   to.WalletAddress,
+		Amount:            t.Amount,
+		Currency:          code,
+	})
+	if err != nil {
+		return err
+	}
+	if t.Refund == 0 {
+		return nil
+	}
+
+	_, err = s.walletRepo.RefundTransfer(ctx, store.RefundTransferParams{TransID: res.Trans.ID, Amount: t.Refund})
+	return err
+}
@@ -0,0 +1,203 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/database/databasetest"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/util"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepos struct {
+	user   store.UserRepo
+	wallet store.WalletRepo
+	trans  store.TransRepo
+	ledger store.LedgerRepo
+}
+
+func newTestSeeder() (*Seeder, testRepos) {
+	m := store.NewMemoryStore()
+	repos := testRepos{
+		user:   store.NewMemoryUserRepo(m),
+		wallet: store.NewMemoryWalletRepo(m),
+		trans:  store.NewMemoryTransRepo(m),
+		ledger: store.NewMemoryLedgerRepo(m),
+	}
+	return NewSeeder(repos.user, repos.wallet, currency.Default()), repos
+}
+
+func balance(t *testing.T, repos testRepos, username string, code string) int64 {
+	w, err := repos.wallet.GetWalletByUsernameAndCurrency(context.Background(), username, code)
+	require.NoError(t, err)
+	return w.Balance
+}
+
+func TestLoadDefaultFixture(t *testing.T) {
+	ctx := context.Background()
+	seeder, repos := newTestSeeder()
+
+	report, err := seeder.Load(ctx, DefaultFixture())
+	require.NoError(t, err)
+	require.Equal(t, Report{UsersCreated: 2, WalletsCreated: 3, TransfersMade: 3}, report)
+
+	user, err := repos.user.GetUserByUsername(ctx, "deepak")
+	require.NoError(t, err)
+	require.NotEqual(t, "password", user.HashedPassword)
+	require.NoError(t, util.CheckPassword("password", user.HashedPassword))
+
+	require.Equal(t, int64(500000-15000+4000), balance(t, repos, "deepak", "INR"))
+	require.Equal(t, int64(10000), balance(t, repos, "deepak", "USD"))
+	require.Equal(t, int64(200000+15000-4000), balance(t, repos, "dk", "INR"))
+
+	wallet, err := repos.wallet.GetWalletByUsername(ctx, "deepak")
+	require.NoError(t, err)
+	require.Equal(t, "INR", wallet.Currency)
+
+	// a second run changes nothing
+	report, err = seeder.Load(ctx, DefaultFixture())
+	require.NoError(t, err)
+	require.Equal(t, Report{UsersSkipped: 2, TransfersSkipped: 3}, report)
+	require.Equal(t, int64(500000-15000+4000), balance(t, repos, "deepak", "INR"))
+
+	transfers, err := repos.trans.ListTransfers(ctx, store.ListTransfersParams{WalletAddress: wallet.WalletAddress, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, transfers, 4)
+
+	totals, err := repos.ledger.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"INR": 0, "USD": 0}, totals)
+}
+
+func TestLoadRollsBackAFailedRun(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.Open(t)
+
+	payer := fmt.Sprintf("seed_payer_%d", time.Now().UnixNano())
+	payee := fmt.Sprintf("seed_payee_%d", time.Now().UnixNano())
+	f := Fixture{
+		Users: []User{
+			{Username: payer, Password: "password", Wallets: []Wallet{{Currency: "INR", Balance: 1000}}},
+			{Username: payee, Password: "password", Wallets: []Wallet{{Currency: "INR"}}},
+		},
+		// the payer does not have that much, the run fails after the users and their wallets were written
+		Transfers: []Transfer{{From: payer, To: payee, Currency: "INR", Amount: 5000}},
+	}
+
+	_, err := Load(ctx, db, f)
+	require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+
+	userRepo := store.NewUserRepo(db)
+	_, err = userRepo.GetUserByUsername(ctx, payer)
+	require.Error(t, err)
+
+	// the next run is a first run again
+	f.Transfers[0].Amount = 300
+	report, err := Load(ctx, db, f)
+	require.NoError(t, err)
+	require.Equal(t, Report{UsersCreated: 2, WalletsCreated: 2, TransfersMade: 1}, report)
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	wallet, err := walletRepo.GetWalletByUsernameAndCurrency(ctx, payer, "INR")
+	require.NoError(t, err)
+	require.Equal(t, int64(700), wallet.Balance)
+}
+
+func TestReadFixture(t *testing.T) {
+	ctx := context.Background()
+
+	f, err := ReadFixture("testdata/users.json")
+	require.NoError(t, err)
+
+	seeder, repos := newTestSeeder()
+	_, err = seeder.Load(ctx, f)
+	require.NoError(t, err)
+
+	user, err := repos.user.GetUserByUsername(ctx, "erin")
+	require.NoError(t, err)
+	require.Equal(t, model.UserTierPREMIUM, user.Tier)
+	require.Equal(t, int64(30000-12000+2000), balance(t, repos, "erin", "INR"))
+	require.Equal(t, int64(10000), balance(t, repos, "frank", "INR"))
+
+	_, err = ReadFixture("testdata/unknown_field.yaml")
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	user := func(username string, wallets ...Wallet) User {
+		return User{Username: username, Password: "secret1", Wallets: wallets}
+	}
+	inr := Wallet{Currency: "INR", Balance: 1000}
+
+	testCases := []struct {
+		name    string
+		fixture Fixture
+		ok      bool
+	}{
+		{name: "ok", ok: true, fixture: Fixture{
+			Users:     []User{user("a", inr), user("b", inr)},
+			Transfers: []Transfer{{From: "a", To: "b", Currency: "inr", Amount: 10, Refund: 10}},
+		}},
+		{name: "duplicate user", fixture: Fixture{Users: []User{user("a"), user("a")}}},
+		{name: "short password", fixture: Fixture{Users: []User{{Username: "a", Password: "12345"}}}},
+		{name: "unknown currency", fixture: Fixture{Users: []User{user("a", Wallet{Currency: "XYZ"})}}},
+		{name: "two wallets of a currency", fixture: Fixture{Users: []User{user("a", inr, inr)}}},
+		{name: "negative balance", fixture: Fixture{Users: []User{user("a", Wallet{Currency: "INR", Balance: -1})}}},
+		{name: "payer not in the fixture", fixture: Fixture{
+			Users:     []User{user("a", inr)},
+			Transfers: []Transfer{{From: "b", To: "a", Currency: "INR", Amount: 10}},
+		}},
+		{name: "payer without the wallet", fixture: Fixture{
+			Users:     []User{user("a", inr), user("b", inr)},
+			Transfers: []Transfer{{From: "a", To: "b", Currency: "USD", Amount: 10}},
+		}},
+		{name: "refund above the amount", fixture: Fixture{
+			Users:     []User{user("a", inr), user("b", inr)},
+			Transfers: []Transfer{{From: "a", To: "b", Currency: "INR", Amount: 10, Refund: 11}},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fixture.Validate(currency.Default())
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+
+	f := Generate(30, "password", rand.New(rand.NewSource(1)))
+	require.Len(t, f.Users, 30)
+	require.Equal(t, f, Generate(30, "password", rand.New(rand.NewSource(1))))
+	require.NoError(t, f.Validate(currency.Default()))
+
+	seeder, repos := newTestSeeder()
+	report, err := seeder.Load(ctx, Generate(20, "password", rand.New(rand.NewSource(2))))
+	require.NoError(t, err)
+	require.Equal(t, 20, report.UsersCreated)
+
+	// more users with another history only add the new ones, they may pay the users already there
+	report, err = seeder.Load(ctx, f)
+	require.NoError(t, err)
+	require.Equal(t, 10, report.UsersCreated)
+	require.Equal(t, 20, report.UsersSkipped)
+
+	totals, err := repos.ledger.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+	for code, total := range totals {
+		require.Zero(t, total, code)
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// streamHeartbeat is how often an idle event stream sends a comment to keep proxies from closing it
+	streamHeartbeat = 15 * time.Second
+	// streamRetry is how long an EventSource waits before it reconnects, in milliseconds
+	streamRetry = 3000
+	// streamSessionCheck is how often an open stream checks that its session was not revoked
+	streamSessionCheck = 15 * time.Second
+)
+
+type WalletStreamResource interface {
+	Stream(w http.ResponseWriter, r *http.Request)
+}
+
+type walletStreamResource struct {
+	walletStreamSvc service.WalletStreamSvc
+	userSvc         service.UserSvc
+	sessionCheck    time.Duration
+}
+
+func NewWalletStreamResource(walletStreamSvc service.WalletStreamSvc, userSvc service.UserSvc) WalletStreamResource {
+	return &walletStreamResource{
+		walletStreamSvc: walletStreamSvc,
+		userSvc:         userSvc,
+		sessionCheck:    streamSessionCheck,
+	}
+}
+
+// Stream serves GET /wallets/{address}/stream as Server-Sent Events, or as a WebSocket when the client asks to upgrade.
+// A client resumes after the last event it received with the Last-Event-ID header or the last_event_id query parameter.
+// The events of the last seconds before it may come again, a client drops the ids it has.
+// The stream ends when the access token expires or its session is revoked, the client reconnects with a fresh token.
+func (sr *walletStreamResource) Stream(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Stream in api/stream/Stream ")
+
+	lastEventID, err := lastEventIDParam(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	stream, err := sr.walletStreamSvc.Open(r.Context(), payload.Username, chi.URLParam(r, "address"), lastEventID)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+	defer stream.Close()
+
+	ctx, cancel := context.WithDeadline(r.Context(), payload.ExpiredAt)
+	defer cancel()
+	guard := &streamGuard{userSvc: sr.userSvc, payload: payload, interval: sr.sessionCheck, checked: time.Now()}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		// the client is authenticated by its token, so any origin is accepted
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			serveWebSocket(ctx, ws, stream, guard)
+		}}.ServeHTTP(w, r)
+		return
+	}
+
+	serveEvents(ctx, w, r, stream, guard)
+}
+
+// streamGuard ends a stream once its access token expires or its session is revoked
+type streamGuard struct {
+	userSvc  service.UserSvc
+	payload  *token.Payload
+	interval time.Duration
+	// checked is when the session was last found open, the middleware checked it on connect
+	checked time.Time
+}
+
+func (g *streamGuard) check(ctx context.Context) error {
+	if time.Now().After(g.payload.ExpiredAt) {
+		return types.ErrExpiredToken
+	}
+	if time.Since(g.checked) < g.interval {
+		return nil
+	}
+	if err := g.userSvc.Authorize(ctx, g.payload); err != nil {
+		return err
+	}
+	g.checked = time.Now()
+	return nil
+}
+
+// guardedEvent waits for the next event like nextEvent. The deadline of ctx is the expiry of the token, the guard is
+// checked on every event and heartbeat. It returns context.Canceled once the client went away.
+func guardedEvent(ctx context.Context, stream service.WalletStream, guard *streamGuard) (dto.WalletStreamEventDto, error) {
+	event, err := nextEvent(ctx, stream)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return event, context.Canceled
+	}
+	if err == nil || errors.Is(err, context.DeadlineExceeded) {
+		if guardErr := guard.check(ctx); guardErr != nil {
+			return event, guardErr
+		}
+	}
+	return event, err
+}
+
+// serveEvents writes the stream as Server-Sent Events until the client goes away or the guard ends it
+func serveEvents(ctx context.Context, w http.ResponseWriter, r *http.Request, stream service.WalletStream, guard *streamGuard) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		_ = render.Render(w, r, types.ErrResponse(fmt.Errorf("streaming is not supported")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	flusher.Flush()
+
+	for {
+		event, err := guardedEvent(ctx, stream, guard)
+		if errors.Is(err, context.DeadlineExceeded) {
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+			continue
+		}
+		if err != nil {
+			if err != context.Canceled {
+				// the client reconnects with the id of the last event it received
+				data, _ := json.Marshal(types.ErrResponse(err))
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+			return
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			logrus.Errorf("failed to encode stream event %d: %v", event.ID, err)
+			return
+		}
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		flusher.Flush()
+	}
+}
+
+// serveWebSocket sends the stream as JSON messages until the client closes the connection or the guard ends it
+func serveWebSocket(ctx context.Context, ws *websocket.Conn, stream service.WalletStream, guard *streamGuard) {
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// nothing is expected from the client, reading only notices when it goes away
+	go func() {
+		var msg []byte
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+		cancel()
+	}()
+
+	for {
+		event, err := guardedEvent(ctx, stream, guard)
+		if errors.Is(err, context.DeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			if err != context.Canceled {
+				_ = websocket.JSON.Send(ws, types.ErrResponse(err))
+			}
+			return
+		}
+
+		if err := websocket.JSON.Send(ws, event); err != nil {
+			return
+		}
+	}
+}
+
+// nextEvent waits for the next event of stream for at most streamHeartbeat
+func nextEvent(ctx context.Context, stream service.WalletStream) (dto.WalletStreamEventDto, error) {
+	ctx, cancel := context.WithTimeout(ctx, streamHeartbeat)
+	defer cancel()
+
+	return stream.Next(ctx)
+}
+
+// lastEventIDParam returns the id of the last event the client received, 0 when it starts afresh
+func lastEventIDParam(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid last event id")
+	}
+	return id, nil
+}
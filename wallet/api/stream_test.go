@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// fakeWalletStreamSvc streams the events after the last event id, then falls behind
+type fakeWalletStreamSvc struct {
+	events []dto.WalletStreamEventDto
+	opened int64
+}
+
+func (f *fakeWalletStreamSvc) Open(ctx context.Context, username string, address string, lastEventID int64) (service.WalletStream, error) {
+	if username != "deepak" {
+		return nil, local_errors.ErrUnauthorized
+	}
+	f.opened = lastEventID
+
+	var events []dto.WalletStreamEventDto
+	for _, e := range f.events {
+		if e.ID > lastEventID {
+			events = append(events, e)
+		}
+	}
+	return &fakeWalletStream{events: events}, nil
+}
+
+type fakeWalletStream struct {
+	events []dto.WalletStreamEventDto
+}
+
+func (f *fakeWalletStream) Next(ctx context.Context) (dto.WalletStreamEventDto, error) {
+	if len(f.events) == 0 {
+		return dto.WalletStreamEventDto{}, local_errors.ErrStreamBehind
+	}
+	e := f.events[0]
+	f.events = f.events[1:]
+	return e, nil
+}
+
+func (f *fakeWalletStream) Close() {}
+
+// fakeUserSvc authorizes the first sessions checks, then finds the session blocked
+type fakeUserSvc struct {
+	service.UserSvc
+	mu         sync.Mutex
+	authorized int
+	allowed    int
+}
+
+func (f *fakeUserSvc) Authorize(ctx context.Context, payload *token.Payload) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.authorized >= f.allowed {
+		return local_errors.ErrSessionBlocked
+	}
+	f.authorized++
+	return nil
+}
+
+func newStreamServer(svc service.WalletStreamSvc, username string) *httptest.Server {
+	return newGuardedStreamServer(svc, &fakeUserSvc{allowed: 1 << 30}, &token.Payload{Username: username, ExpiredAt: time.Now().Add(time.Minute)})
+}
+
+// newGuardedStreamServer checks the session of payload on every event and heartbeat
+func newGuardedStreamServer(svc service.WalletStreamSvc, userSvc service.UserSvc, payload *token.Payload) *httptest.Server {
+	resource := &walletStreamResource{walletStreamSvc: svc, userSvc: userSvc}
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authorizationPayloadKey, payload)))
+		})
+	})
+	r.Get("/wallets/{address}/stream", resource.Stream)
+	return httptest.NewServer(r)
+}
+
+// idleWalletStreamSvc streams nothing until the client or the server ends the stream
+type idleWalletStreamSvc struct{}
+
+func (idleWalletStreamSvc) Open(ctx context.Context, username string, address string, lastEventID int64) (service.WalletStream, error) {
+	return idleWalletStream{}, nil
+}
+
+type idleWalletStream struct{}
+
+func (idleWalletStream) Next(ctx context.Context) (dto.WalletStreamEventDto, error) {
+	<-ctx.Done()
+	return dto.WalletStreamEventDto{}, ctx.Err()
+}
+
+func (idleWalletStream) Close() {}
+
+// streamFrames reads an event stream to its end
+func streamFrames(t *testing.T, url string) []string {
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSpace(string(body)), "\n\n")
+}
+
+func streamEvents() []dto.WalletStreamEventDto {
+	var events []dto.WalletStreamEventDto
+	for id := int64(1); id <= 3; id++ {
+		e := dto.WalletStreamEventDto{ID: id, Type: model.EventTypeWalletCredited, WalletAddress: "w1", Change: 100}
+		e.UpdatedBalance = 100 * id
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestWalletStreamEvents(t *testing.T) {
+	svc := &fakeWalletStreamSvc{events: streamEvents()}
+	srv := newStreamServer(svc, "deepak")
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/wallets/w1/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	require.Equal(t, int64(1), svc.opened)
+
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	frames := strings.Split(strings.TrimSpace(string(body)), "\n\n")
+	require.Len(t, frames, 4)
+	require.Equal(t, "retry: 3000", frames[0])
+
+	lines := strings.Split(frames[1], "\n")
+	require.Equal(t, "id: 2", lines[0])
+	require.Equal(t, "event: WalletCredited", lines[1])
+	var event dto.WalletStreamEventDto
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &event))
+	require.Equal(t, int64(200), event.UpdatedBalance)
+
+	require.True(t, strings.HasPrefix(frames[2], "id: 3\n"))
+	// the stream ends with the reason, the client reconnects from the last id it received
+	require.True(t, strings.HasPrefix(frames[3], "event: error\n"))
+}
+
+func TestWalletStreamRejected(t *testing.T) {
+	srv := newStreamServer(&fakeWalletStreamSvc{}, "deepak")
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/wallets/w1/stream?last_event_id=abc")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	other := newStreamServer(&fakeWalletStreamSvc{}, "someone")
+	defer other.Close()
+
+	res, err = http.Get(other.URL + "/wallets/w1/stream")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestWalletStreamWebSocket(t *testing.T) {
+	svc := &fakeWalletStreamSvc{events: streamEvents()}
+	srv := newStreamServer(svc, "deepak")
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/wallets/w1/stream?last_event_id=2"
+	ws, err := websocket.Dial(url, "", srv.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	var event dto.WalletStreamEventDto
+	require.NoError(t, websocket.JSON.Receive(ws, &event))
+	require.Equal(t, int64(3), event.ID)
+	require.Equal(t, int64(300), event.UpdatedBalance)
+	require.Equal(t, int64(2), svc.opened)
+
+	var failure map[string]string
+	require.NoError(t, websocket.JSON.Receive(ws, &failure))
+	require.Equal(t, local_errors.ErrStreamBehind.Error(), failure["error"])
+}
+
+func TestWalletStreamEndsWithTheToken(t *testing.T) {
+	// an idle stream ends when the token expires, not at the next heartbeat
+	payload := &token.Payload{Username: "deepak", ExpiredAt: time.Now().Add(200 * time.Millisecond)}
+	srv := newGuardedStreamServer(idleWalletStreamSvc{}, &fakeUserSvc{allowed: 1 << 30}, payload)
+	defer srv.Close()
+
+	start := time.Now()
+	frames := streamFrames(t, srv.URL+"/wallets/w1/stream")
+	require.Less(t, int64(time.Since(start)), int64(streamHeartbeat))
+	require.Len(t, frames, 2)
+	require.Contains(t, frames[1], "event: error\n")
+	require.Contains(t, frames[1], local_errors.ErrExpiredToken.Error())
+
+	// a session revoked while events flow ends the stream before the next one
+	payload = &token.Payload{Username: "deepak", ExpiredAt: time.Now().Add(time.Minute)}
+	users := &fakeUserSvc{allowed: 1}
+	srv = newGuardedStreamServer(&fakeWalletStreamSvc{events: streamEvents()}, users, payload)
+	defer srv.Close()
+
+	frames = streamFrames(t, srv.URL+"/wallets/w1/stream")
+	require.Len(t, frames, 3)
+	require.True(t, strings.HasPrefix(frames[1], "id: 1\n"))
+	require.Contains(t, frames[2], "event: error\n")
+	require.Contains(t, frames[2], local_errors.ErrSessionBlocked.Error())
+
+	// the same goes for a websocket
+	srv = newGuardedStreamServer(&fakeWalletStreamSvc{events: streamEvents()}, &fakeUserSvc{allowed: 1}, payload)
+	defer srv.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/wallets/w1/stream", "", srv.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	var event dto.WalletStreamEventDto
+	require.NoError(t, websocket.JSON.Receive(ws, &event))
+	require.Equal(t, int64(1), event.ID)
+	var failure map[string]string
+	require.NoError(t, websocket.JSON.Receive(ws, &failure))
+	require.Equal(t, local_errors.ErrSessionBlocked.Error(), failure["error"])
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type TransResource interface {
+	ListByWallet(w http.ResponseWriter, r *http.Request)
+	Refund(w http.ResponseWriter, r *http.Request)
+	Reverse(w http.ResponseWriter, r *http.Request)
+}
+
+type transResource struct {
+	transSvc service.TransSvc
+}
+
+func NewTransResource(transSvc service.TransSvc) TransResource {
+	return &transResource{
+		transSvc: transSvc,
+	}
+}
+
+// ListByWallet serves GET /wallets/{address}/transactions?direction=&from=&to=&min_amount=&max_amount=&cursor=&limit=
+func (tr *transResource) ListByWallet(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log ListByWallet in api/trans/ListByWallet ")
+
+	ctx := r.Context()
+
+	req, err := parseListTransactionsQuery(r.URL.Query())
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := tr.transSvc.ListTransactions(ctx, payload.Username, chi.URLParam(r, "address"), req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+// Refund serves POST /transactions/{id}/refund, an empty body refunds all that is left
+func (tr *transResource) Refund(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log Refund in api/trans/Refund ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	var req dto.RefundDto
+	if err := decodeOptionalBody(r, &req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := tr.transSvc.Refund(ctx, payload.Username, id, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+// Reverse serves POST /transactions/{id}/reverse for admins
+func (tr *transResource) Reverse(w http.ResponseWriter, r *http.Request) {
+	logrus.Println("log Reverse in api/trans/Reverse ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	var req dto.ReverseDto
+	if err := decodeOptionalBody(r, &req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := tr.transSvc.Reverse(ctx, payload.Username, id, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+// decodeOptionalBody decodes a JSON body into v, an empty body leaves v untouched
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func parseListTransactionsQuery(query url.Values) (dto.ListTransactionsDto, error) {
+	req := dto.ListTransactionsDto{
+		Direction: query.Get("direction"),
+		Cursor:    query.Get("cursor"),
+	}
+
+	var err error
+	if req.CreatedFrom, err = timeQueryParam(query, "from"); err != nil {
+		return req, err
+	}
+	if req.CreatedTo, err = timeQueryParam(query, "to"); err != nil {
+		return req, err
+	}
+	if req.MinAmount, err = int64QueryParam(query, "min_amount"); err != nil {
+		return req, err
+	}
+	if req.MaxAmount, err = int64QueryParam(query, "max_amount"); err != nil {
+		return req, err
+	}
+
+	limit, err := int64QueryParam(query, "limit")
+	if err != nil {
+		return req, err
+	}
+	req.Limit = int(limit)
+
+	return req, nil
+}
+
+func timeQueryParam(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("%s must be an RFC3339 time", name)
+	}
+	return t, nil
+}
+
+func int64QueryParam(query url.Values, name string) (int64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return n, nil
+}
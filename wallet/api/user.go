@@ -5,15 +5,20 @@ import (
 	"github.com/dsthakur2711/wallet/dto"
 	types "github.com/dsthakur2711/wallet/pkg/local_errors"
 	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 )
 
 type UserResource interface {
 	Create(w http.ResponseWriter, r *http.Request)
 	Login(w http.ResponseWriter, r *http.Request)
+	RenewAccessToken(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	UpdateStatus(w http.ResponseWriter, r *http.Request)
 	//RegisterRoutes(r chi.Router)
 }
 
@@ -78,6 +83,9 @@ func (u *userResource) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	req.UserAgent = r.UserAgent()
+	req.ClientIp = clientIP(r)
+
 	loggedInUser, err := u.userSvc.LoginUser(ctx, req)
 	if err != nil {
 		_ = render.Render(w, r, types.ErrResponse(err))
@@ -85,4 +93,103 @@ func (u *userResource) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	render.JSON(w, r, loggedInUser)
-}
\ No newline at end of file
+}
+
+func (u *userResource) RenewAccessToken(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log RenewAccessToken in api/user/RenewAccessToken ")
+	var req dto.RenewAccessTokenDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	res, err := u.userSvc.RenewAccessToken(ctx, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, res)
+}
+
+func (u *userResource) Logout(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Logout in api/user/Logout ")
+	var req dto.LogoutDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	if err := u.userSvc.Logout(ctx, payload.Username, req); err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateStatus serves PUT /users/{username}/status for admins, blocking a user ends all of its sessions
+func (u *userResource) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log UpdateStatus in api/user/UpdateStatus ")
+	var req dto.UpdateUserStatusDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	user, err := u.userSvc.UpdateUserStatus(ctx, payload.Username, chi.URLParam(r, "username"), req.Status)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, user)
+}
+
+// clientIP returns the host part of the remote address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/dsthakur2711/wallet/dto"
 	types "github.com/dsthakur2711/wallet/pkg/local_errors"
 	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
 	"github.com/sirupsen/logrus"
@@ -15,7 +16,11 @@ type WalletResource interface {
 	AddWallet(w http.ResponseWriter, r *http.Request)
 	Pay(w http.ResponseWriter, r *http.Request)
 	Credit(w http.ResponseWriter, r *http.Request)
-	//Get(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	ListByUsername(w http.ResponseWriter, r *http.Request)
+	SetPrimary(w http.ResponseWriter, r *http.Request)
+	QuoteFee(w http.ResponseWriter, r *http.Request)
+	Limits(w http.ResponseWriter, r *http.Request)
 	//RegisterRoutes(r chi.Router)
 }
 
@@ -50,7 +55,13 @@ func (wr *walletResource) AddWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	wallet, err := wr.walletSvc.AddWallet(ctx, req)
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	wallet, err := wr.walletSvc.AddWallet(ctx, payload.Username, req)
 	if err != nil {
 		_ = render.Render(w, r, types.ErrResponse(err))
 		return
@@ -78,7 +89,13 @@ func (wr *walletResource) Pay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := wr.walletSvc.Pay(ctx, req)
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := wr.walletSvc.Pay(ctx, payload.Username, req)
 	if err != nil {
 		_ = render.Render(w, r, types.ErrResponse(err))
 		return
@@ -105,11 +122,138 @@ func (wr *walletResource) Credit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := wr.walletSvc.Credit(ctx, req)
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	res, err := wr.walletSvc.Credit(ctx, payload.Username, req)
 	if err != nil {
 		_ = render.Render(w, r, types.ErrResponse(err))
 		return
 	}
 
 	render.JSON(w, r, res)
-}
\ No newline at end of file
+}
+
+func (wr *walletResource) Get(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Get in api/wallet/Get ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	wallet, err := wr.walletSvc.GetWalletByAddress(ctx, payload.Username, chi.URLParam(r, "address"))
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	renderJSONWithETag(w, r, wallet)
+}
+
+func (wr *walletResource) ListByUsername(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log ListByUsername in api/wallet/ListByUsername ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	wallets, err := wr.walletSvc.ListWalletsByUsername(ctx, payload.Username, chi.URLParam(r, "username"))
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	renderJSONWithETag(w, r, wallets)
+}
+
+func (wr *walletResource) SetPrimary(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log SetPrimary in api/wallet/SetPrimary ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	wallet, err := wr.walletSvc.SetPrimaryWallet(ctx, payload.Username, chi.URLParam(r, "address"))
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, wallet)
+}
+
+func (wr *walletResource) QuoteFee(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log QuoteFee in api/wallet/QuoteFee ")
+
+	ctx := r.Context()
+
+	amount, err := int64QueryParam(r.URL.Query(), "amount")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	req := dto.FeeQuoteRequestDto{
+		FromWalletAddress: r.URL.Query().Get("from_wallet_address"),
+		Amount:            amount,
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	quote, err := wr.walletSvc.QuoteFee(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, quote)
+}
+
+func (wr *walletResource) Limits(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Limits in api/wallet/Limits ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	limits, err := wr.walletSvc.GetTransferLimits(ctx, payload.Username, chi.URLParam(r, "address"))
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, limits)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/dsthakur2711/wallet/dto"
+	types "github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/service"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+type WebhookResource interface {
+	Create(w http.ResponseWriter, r *http.Request)
+	List(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	ListDeliveries(w http.ResponseWriter, r *http.Request)
+	ReplayDelivery(w http.ResponseWriter, r *http.Request)
+}
+
+type webhookResource struct {
+	webhookSvc service.WebhookSvc
+}
+
+func NewWebhookResource(webhookSvc service.WebhookSvc) WebhookResource {
+	return &webhookResource{
+		webhookSvc: webhookSvc,
+	}
+}
+
+// Create serves POST /webhooks, the response holds the signing secret which is not shown again
+func (wr *webhookResource) Create(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Create in api/webhook/Create ")
+
+	var req dto.CreateWebhookDto
+	ctx := r.Context()
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validator.New().Struct(req); err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	subscription, err := wr.webhookSvc.CreateSubscription(ctx, payload.Username, req)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, subscription)
+}
+
+func (wr *webhookResource) List(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log List in api/webhook/List ")
+
+	ctx := r.Context()
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	subscriptions, err := wr.webhookSvc.ListSubscriptions(ctx, payload.Username)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, subscriptions)
+}
+
+func (wr *webhookResource) Delete(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log Delete in api/webhook/Delete ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	subscription, err := wr.webhookSvc.DeleteSubscription(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, subscription)
+}
+
+// ListDeliveries serves GET /webhooks/{id}/deliveries, the latest deliveries first
+func (wr *webhookResource) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log ListDeliveries in api/webhook/ListDeliveries ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	deliveries, err := wr.webhookSvc.ListDeliveries(ctx, payload.Username, id)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.JSON(w, r, deliveries)
+}
+
+func (wr *webhookResource) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+
+	logrus.Println("log ReplayDelivery in api/webhook/ReplayDelivery ")
+
+	ctx := r.Context()
+
+	id, err := int64URLParam(r, "id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	deliveryID, err := int64URLParam(r, "delivery_id")
+	if err != nil {
+		_ = render.Render(w, r, types.ErrBadRequest(err))
+		return
+	}
+
+	payload, err := authPayload(r)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	delivery, err := wr.webhookSvc.ReplayDelivery(ctx, payload.Username, id, deliveryID)
+	if err != nil {
+		_ = render.Render(w, r, types.ErrResponse(err))
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, delivery)
+}
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dsthakur2711/wallet/constant"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DevelopmentDSN and DevelopmentTokenKey are only meant for a local setup, Load warns when they are used.
+	// DevelopmentTokenKey is public, Validate refuses it unless nothing outlives the process.
+	DevelopmentDSN      = "root:password@tcp(127.0.0.1:3306)/walletDB?parseTime=true"
+	DevelopmentTokenKey = "12345678901234567890123456789012"
+
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+
+	// the database drivers, they are also the SQL dialects of the migrations
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+	// DriverMemory keeps everything in memory and loses it on exit, it needs no DSN
+	DriverMemory = "memory"
+)
+
+// Config is everything the server is configured with
+type Config struct {
+	Server    ServerConfig
+	Database  DatabaseConfig
+	RateLimit RateLimitConfig
+	Log       LogConfig
+	Token     TokenConfig
+	FX        FXConfig
+	Payments  PaymentsConfig
+	Events    EventsConfig
+}
+
+type ServerConfig struct {
+	// Addr is the host:port the server listens on
+	Addr string
+	// TLSCertFile and TLSKeyFile serve HTTPS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+	// Demo seeds the memory database with demo data, it is only set by `wallet serve --demo`
+	Demo bool
+}
+
+type DatabaseConfig struct {
+	// Driver is DriverMySQL, DriverPostgres, DriverSQLite or DriverMemory, a SQLite DSN is the path of the database file
+	Driver string
+	DSN    string
+	// MaxOpenConns and MaxIdleConns size the connection pool, 0 leaves it unlimited
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// RateLimitConfig limits every client IP to Requests requests per Window, 0 requests turns the limit off
+type RateLimitConfig struct {
+	Requests int
+	Window   time.Duration
+}
+
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+type TokenConfig struct {
+	// Type is token.TypePaseto or token.TypeJWT
+	Type         string
+	SymmetricKey string
+}
+
+// FXConfig is where the exchange rates come from: the rate service at RatesURL, or the rates file
+// at RatesFile, or else the bundled rates
+type FXConfig struct {
+	RatesURL  string
+	RatesFile string
+	// QuoteTTL is how long the rate of an fx quote stays locked
+	QuoteTTL time.Duration
+}
+
+type PaymentsConfig struct {
+	// FeeScheduleFile and TransferLimitsFile are JSON files, without them transfers are free and not limited
+	FeeScheduleFile    string
+	TransferLimitsFile string
+	// IdempotencyKeyTTL is how long a stored response can be replayed
+	IdempotencyKeyTTL time.Duration
+	// HoldTTL is how long a hold reserves funds when the request sets no expiry
+	HoldTTL time.Duration
+	// ScheduledRetryBackoff is the delay before the first retry of a failed scheduled payment, it doubles on every retry
+	ScheduledRetryBackoff time.Duration
+}
+
+// EventsConfig is where the outbox events are published besides the log
+type EventsConfig struct {
+	File       string
+	WebhookURL string
+	// WebhookRetryBackoff is the delay before the first retry of a webhook delivery, it doubles on every retry
+	WebhookRetryBackoff time.Duration
+}
+
+// Default is the configuration of a local development setup
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr: "127.0.0.1:8000",
+		},
+		Database: DatabaseConfig{
+			Driver:          DriverMySQL,
+			DSN:             DevelopmentDSN,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Requests: 100,
+			Window:   time.Minute,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+		Token: TokenConfig{
+			Type:         token.TypePaseto,
+			SymmetricKey: DevelopmentTokenKey,
+		},
+		FX: FXConfig{
+			QuoteTTL: constant.FXQuoteDuration,
+		},
+		Payments: PaymentsConfig{
+			IdempotencyKeyTTL:     constant.IdempotencyKeyTTL,
+			HoldTTL:               constant.HoldDuration,
+			ScheduledRetryBackoff: constant.ScheduledPaymentRetryBackoff,
+		},
+		Events: EventsConfig{
+			WebhookRetryBackoff: constant.WebhookRetryBackoff,
+		},
+	}
+}
+
+// Validate reports every invalid setting at once
+func (c Config) Validate() error {
+	var problems []string
+	invalid := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr %q is not a host:port", c.Server.Addr)
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		invalid("server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	files := []struct{ key, path string }{
+		{"server.tls_cert_file", c.Server.TLSCertFile},
+		{"server.tls_key_file", c.Server.TLSKeyFile},
+		{"fx.rates_file", c.FX.RatesFile},
+		{"payments.fee_schedule_file", c.Payments.FeeScheduleFile},
+		{"payments.transfer_limits_file", c.Payments.TransferLimitsFile},
+	}
+	for _, f := range files {
+		if f.path != "" {
+			if _, err := os.Stat(f.path); err != nil {
+				invalid("%s: %v", f.key, err)
+			}
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverMySQL, DriverPostgres, DriverSQLite, DriverMemory:
+	default:
+		invalid("database.driver %q is not %s, %s, %s or %s", c.Database.Driver, DriverMySQL, DriverPostgres, DriverSQLite, DriverMemory)
+	}
+	if c.Database.DSN == "" && c.Database.Driver != DriverMemory {
+		invalid("database.dsn is required")
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 {
+		invalid("database pool settings can not be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		invalid("database.max_idle_conns %d is more than database.max_open_conns %d", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	if c.RateLimit.Requests < 0 {
+		invalid("rate_limit.requests can not be negative")
+	}
+	if c.RateLimit.Requests > 0 && c.RateLimit.Window <= 0 {
+		invalid("rate_limit.window must be positive")
+	}
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		invalid("log.level: %v", err)
+	}
+	if c.Log.Format != LogFormatText && c.Log.Format != LogFormatJSON {
+		invalid("log.format %q is not %s or %s", c.Log.Format, LogFormatText, LogFormatJSON)
+	}
+
+	if _, err := token.NewMaker(c.Token.Type, c.Token.SymmetricKey); err != nil {
+		invalid("token: %v", err)
+	}
+	// anyone can sign a token for any user with the development key
+	if c.Token.SymmetricKey == DevelopmentTokenKey && c.Database.Driver != DriverMemory && !c.Server.Demo {
+		invalid("token.symmetric_key must be set, the development key is only accepted by the memory driver or --demo")
+	}
+
+	if c.FX.RatesURL != "" && c.FX.RatesFile != "" {
+		invalid("set only one of fx.rates_url and fx.rates_file")
+	}
+	urls := []struct{ key, value string }{
+		{"fx.rates_url", c.FX.RatesURL},
+		{"events.webhook_url", c.Events.WebhookURL},
+	}
+	for _, u := range urls {
+		if u.value != "" && !isHTTPURL(u.value) {
+			invalid("%s %q is not an http or https URL", u.key, u.value)
+		}
+	}
+
+	durations := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"fx.quote_ttl", c.FX.QuoteTTL},
+		{"payments.idempotency_key_ttl", c.Payments.IdempotencyKeyTTL},
+		{"payments.hold_ttl", c.Payments.HoldTTL},
+		{"payments.scheduled_retry_backoff", c.Payments.ScheduledRetryBackoff},
+		{"events.webhook_retry_backoff", c.Events.WebhookRetryBackoff},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			invalid("%s must be positive", d.key)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// TLS tells whether the server is served over HTTPS
+func (c ServerConfig) TLS() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "abcdefghijklmnopqrstuvwxyz123456"
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func testLoad(args []string, env map[string]string) (Config, error) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return load(fs, args, func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := testLoad(nil, map[string]string{"WALLET_TOKEN_SYMMETRIC_KEY": testKey})
+	require.NoError(t, err)
+	expected := Default()
+	expected.Token.SymmetricKey = testKey
+	require.Equal(t, expected, cfg)
+}
+
+func TestLoadRefusesDevelopmentTokenKey(t *testing.T) {
+	_, err := testLoad(nil, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "token.symmetric_key must be set")
+
+	_, err = testLoad(nil, map[string]string{"WALLET_DATABASE_DRIVER": "sqlite3", "WALLET_DATABASE_DSN": "wallet.db"})
+	require.Contains(t, err.Error(), "token.symmetric_key must be set")
+
+	// nothing signed with it outlives a memory database
+	cfg, err := testLoad(nil, map[string]string{"WALLET_DATABASE_DRIVER": DriverMemory})
+	require.NoError(t, err)
+	require.Equal(t, DevelopmentTokenKey, cfg.Token.SymmetricKey)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Bool("demo", false, "")
+	cfg, err = load(fs, []string{"--demo"}, func(string) (string, bool) { return "", false })
+	require.NoError(t, err)
+	require.True(t, cfg.Server.Demo)
+	require.Equal(t, DriverMemory, cfg.Database.Driver)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "wallet.yaml", `
+server:
+  addr: 0.0.0.0:9000
+database:
+  driver: postgres
+  dsn: file-dsn
+  max_open_conns: 50
+  conn_max_lifetime: 10m
+rate_limit:
+  requests: 10
+log:
+  level: debug
+`)
+
+	env := map[string]string{
+		"WALLET_CONFIG":              yamlFile,
+		"WALLET_DATABASE_DSN":        "env-dsn",
+		"WALLET_LOG_LEVEL":           "warn",
+		"WALLET_TOKEN_SYMMETRIC_KEY": testKey,
+		"WALLET_RATE_LIMIT_WINDOW":   "30s",
+	}
+
+	cfg, err := testLoad([]string{"--log-level", "error", "--log-format=json"}, env)
+	require.NoError(t, err)
+
+	// flags win over the environment, which wins over the file, which wins over the defaults
+	require.Equal(t, "0.0.0.0:9000", cfg.Server.Addr)
+	require.Equal(t, DriverPostgres, cfg.Database.Driver)
+	require.Equal(t, "env-dsn", cfg.Database.DSN)
+	require.Equal(t, 50, cfg.Database.MaxOpenConns)
+	require.Equal(t, 25, cfg.Database.MaxIdleConns)
+	require.Equal(t, 10*time.Minute, cfg.Database.ConnMaxLifetime)
+	require.Equal(t, RateLimitConfig{Requests: 10, Window: 30 * time.Second}, cfg.RateLimit)
+	require.Equal(t, LogConfig{Level: "error", Format: LogFormatJSON}, cfg.Log)
+	require.Equal(t, testKey, cfg.Token.SymmetricKey)
+}
+
+func TestLoadTOML(t *testing.T) {
+	tomlFile := writeFile(t, "wallet.toml", `
+[server]
+addr = "127.0.0.1:9443"
+
+[database]
+dsn = "toml-dsn"
+max_idle_conns = 5
+
+[token]
+type = "jwt"
+symmetric_key = "`+testKey+`"
+`)
+
+	cfg, err := testLoad([]string{"--config", tomlFile}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:9443", cfg.Server.Addr)
+	require.Equal(t, "toml-dsn", cfg.Database.DSN)
+	require.Equal(t, 5, cfg.Database.MaxIdleConns)
+	require.Equal(t, TokenConfig{Type: "jwt", SymmetricKey: testKey}, cfg.Token)
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	dsnFile := writeFile(t, "dsn", "secret-dsn\n")
+	keyFile := writeFile(t, "key", testKey+"\n")
+
+	cfg, err := testLoad([]string{"--database-dsn-file", dsnFile}, map[string]string{
+		"WALLET_DATABASE_DSN":             "env-dsn",
+		"WALLET_TOKEN_SYMMETRIC_KEY_FILE": keyFile,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "secret-dsn", cfg.Database.DSN)
+	require.Equal(t, testKey, cfg.Token.SymmetricKey)
+
+	// a secret is never given on the command line
+	_, err = testLoad([]string{"--database-dsn", "dsn"}, nil)
+	require.Error(t, err)
+
+	_, err = testLoad(nil, map[string]string{
+		"WALLET_DATABASE_DSN":      "env-dsn",
+		"WALLET_DATABASE_DSN_FILE": dsnFile,
+	})
+	require.EqualError(t, err, "environment: set only one of database.dsn and database.dsn_file")
+
+	_, err = testLoad([]string{"--token-symmetric-key-file", filepath.Join(t.TempDir(), "missing")}, nil)
+	require.Error(t, err)
+}
+
+func TestLoadIgnoresUnprefixedEnv(t *testing.T) {
+	cfg, err := testLoad(nil, map[string]string{
+		"HOLD_TTL":                   "2h",
+		"FX_QUOTE_TTL":               "soon",
+		"WALLET_TOKEN_SYMMETRIC_KEY": testKey,
+	})
+	require.NoError(t, err)
+	require.Equal(t, Default().Payments.HoldTTL, cfg.Payments.HoldTTL)
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := testLoad(nil, map[string]string{"WALLET_CONFIG": writeFile(t, "wallet.yaml", "databse:\n  dsn: x\n")})
+	require.Contains(t, err.Error(), "unknown setting databse.dsn")
+
+	_, err = testLoad(nil, map[string]string{"WALLET_CONFIG": writeFile(t, "wallet.json", "{}")})
+	require.Error(t, err)
+
+	_, err = testLoad([]string{"--rate-limit-window", "soon"}, nil)
+	require.EqualError(t, err, `flags: rate_limit.window: "soon" is not a duration like 30s`)
+
+	_, err = testLoad([]string{"--database-max-open-conns", "many"}, nil)
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Token.SymmetricKey = testKey
+	require.NoError(t, cfg.Validate())
+
+	cfg.Server.Addr = "localhost"
+	cfg.Server.TLSCertFile = "cert.pem"
+	cfg.Database.Driver = "oracle"
+	cfg.Database.DSN = ""
+	cfg.Database.MaxOpenConns = 5
+	cfg.Database.MaxIdleConns = 10
+	cfg.Log.Level = "loud"
+	cfg.Log.Format = "xml"
+	cfg.Token.SymmetricKey = "short"
+	cfg.FX.RatesURL = "ftp://rates"
+	cfg.FX.RatesFile = "missing-rates.json"
+	cfg.Payments.HoldTTL = 0
+	cfg.Events.WebhookURL = "localhost:8080"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, problem := range []string{"server.addr", "server.tls_cert_file and server.tls_key_file", "database.driver", "database.dsn is required",
+		"database.max_idle_conns 10", "log.level", "log.format", "token:", "set only one of fx.rates_url and fx.rates_file",
+		`fx.rates_url "ftp://rates"`, "fx.rates_file:", "payments.hold_ttl must be positive", "events.webhook_url"} {
+		require.Contains(t, err.Error(), problem)
+	}
+
+	// the memory driver has no dsn
+	cfg = Default()
+	cfg.Database.Driver = DriverMemory
+	cfg.Database.DSN = ""
+	require.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// EnvPrefix starts the environment variable of every setting, database.dsn is read from WALLET_DATABASE_DSN
+	EnvPrefix = "WALLET_"
+	// secretFileSuffix names the setting holding the path of the file a secret is read from
+	secretFileSuffix = "_file"
+)
+
+// setting is one configurable value. The value of a secret is never taken from a flag,
+// only the path of the file holding it is.
+type setting struct {
+	key    string
+	usage  string
+	secret bool
+	set    func(value string) error
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		stringSetting("server.addr", "host:port to listen on", &c.Server.Addr),
+		stringSetting("server.tls_cert_file", "TLS certificate, serves HTTPS with server.tls_key_file", &c.Server.TLSCertFile),
+		stringSetting("server.tls_key_file", "TLS private key", &c.Server.TLSKeyFile),
+		stringSetting("database.driver", "database driver: mysql, postgres, sqlite3 or memory", &c.Database.Driver),
+		secretSetting("database.dsn", "database connection string", &c.Database.DSN),
+		intSetting("database.max_open_conns", "maximum open database connections, 0 for no limit", &c.Database.MaxOpenConns),
+		intSetting("database.max_idle_conns", "maximum idle database connections", &c.Database.MaxIdleConns),
+		durationSetting("database.conn_max_lifetime", "how long a database connection is reused", &c.Database.ConnMaxLifetime),
+		intSetting("rate_limit.requests", "requests allowed per client IP and window, 0 turns the limit off", &c.RateLimit.Requests),
+		durationSetting("rate_limit.window", "rate limit window", &c.RateLimit.Window),
+		stringSetting("log.level", "log level: trace, debug, info, warn, error", &c.Log.Level),
+		stringSetting("log.format", "log format: text or json", &c.Log.Format),
+		stringSetting("token.type", "token type: paseto or jwt", &c.Token.Type),
+		secretSetting("token.symmetric_key", "key the tokens are signed with", &c.Token.SymmetricKey),
+		stringSetting("fx.rates_url", "exchange rate service, the bundled rates are used without it or fx.rates_file", &c.FX.RatesURL),
+		stringSetting("fx.rates_file", "JSON exchange rates file", &c.FX.RatesFile),
+		durationSetting("fx.quote_ttl", "how long the rate of an fx quote stays locked", &c.FX.QuoteTTL),
+		stringSetting("payments.fee_schedule_file", "JSON fee schedule, transfers are free without it", &c.Payments.FeeScheduleFile),
+		stringSetting("payments.transfer_limits_file", "JSON transfer limits, transfers are not limited without it", &c.Payments.TransferLimitsFile),
+		durationSetting("payments.idempotency_key_ttl", "how long a stored response can be replayed", &c.Payments.IdempotencyKeyTTL),
+		durationSetting("payments.hold_ttl", "how long a hold reserves funds when it sets no expiry", &c.Payments.HoldTTL),
+		durationSetting("payments.scheduled_retry_backoff", "first retry delay of a failed scheduled payment, doubled on every retry", &c.Payments.ScheduledRetryBackoff),
+		stringSetting("events.file", "file the outbox events are appended to", &c.Events.File),
+		stringSetting("events.webhook_url", "endpoint the outbox events are posted to", &c.Events.WebhookURL),
+		durationSetting("events.webhook_retry_backoff", "first retry delay of a webhook delivery, doubled on every retry", &c.Events.WebhookRetryBackoff),
+	}
+}
+
+func stringSetting(key string, usage string, p *string) setting {
+	return setting{key: key, usage: usage, set: func(value string) error {
+		*p = value
+		return nil
+	}}
+}
+
+func secretSetting(key string, usage string, p *string) setting {
+	s := stringSetting(key, usage, p)
+	s.secret = true
+	return s
+}
+
+func intSetting(key string, usage string, p *int) setting {
+	return setting{key: key, usage: usage, set: func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*p = n
+		return nil
+	}}
+}
+
+func durationSetting(key string, usage string, p *time.Duration) setting {
+	return setting{key: key, usage: usage, set: func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s", value)
+		}
+		*p = d
+		return nil
+	}}
+}
+
+// envName is the environment variable of a setting key
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+// flagName is the command line flag of a setting key
+func flagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(key)
+}
+
+// Load reads the configuration from, in increasing order of precedence, the defaults, the YAML or TOML
+// file given by --config or WALLET_CONFIG, the WALLET_ environment variables and the flags in args.
+// The flags are registered on fs, which may hold flags of its own, and the arguments left are in fs.Args().
+// A --demo flag of fs switches to the memory driver and sets server.demo.
+func Load(fs *flag.FlagSet, args []string) (Config, error) {
+	return load(fs, args, os.LookupEnv)
+}
+
+func load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	settings := map[string]setting{}
+	for _, s := range cfg.settings() {
+		settings[s.key] = s
+	}
+
+	configFile := fs.String("config", "", "YAML or TOML config file, overrides the defaults (env "+EnvPrefix+"CONFIG)")
+	flagKeys := map[string]string{}
+	for _, s := range cfg.settings() {
+		key := s.key
+		if s.secret {
+			key += secretFileSuffix
+			fs.String(flagName(key), "", "file holding the "+s.usage)
+		} else {
+			fs.String(flagName(key), "", s.usage)
+		}
+		flagKeys[flagName(key)] = key
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		if err := apply(settings, path, values); err != nil {
+			return cfg, err
+		}
+	}
+
+	env := map[string]string{}
+	for _, s := range settings {
+		keys := []string{s.key}
+		if s.secret {
+			keys = append(keys, s.key+secretFileSuffix)
+		}
+		for _, key := range keys {
+			if value, ok := lookupEnv(envName(key)); ok {
+				env[key] = value
+			}
+		}
+	}
+	if err := apply(settings, "environment", env); err != nil {
+		return cfg, err
+	}
+
+	flags := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			flags[key] = f.Value.String()
+		}
+	})
+	if err := apply(settings, "flags", flags); err != nil {
+		return cfg, err
+	}
+
+	// `wallet serve --demo` serves from memory
+	if demo := fs.Lookup("demo"); demo != nil && demo.Value.String() == "true" {
+		cfg.Database.Driver = DriverMemory
+		cfg.Server.Demo = true
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Database.DSN == DevelopmentDSN && cfg.Database.Driver != DriverMemory {
+		logrus.Warn("database.dsn not set, using the development database")
+	}
+	if cfg.Token.SymmetricKey == DevelopmentTokenKey {
+		logrus.Warn("token.symmetric_key not set, using the development key")
+	}
+
+	return cfg, nil
+}
+
+// apply sets the values of one source, a secret is either given or read from the file
+// at its _file key, but not both
+func apply(settings map[string]setting, source string, values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+
+		s, ok := settings[key]
+		if !ok {
+			s, ok = settings[strings.TrimSuffix(key, secretFileSuffix)]
+			if !ok || !s.secret {
+				return fmt.Errorf("%s: unknown setting %s", source, key)
+			}
+			if _, both := values[s.key]; both {
+				return fmt.Errorf("%s: set only one of %s and %s", source, s.key, key)
+			}
+
+			secret, err := ioutil.ReadFile(value)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %v", source, key, err)
+			}
+			value = strings.TrimRight(string(secret), "\r\n")
+		}
+
+		if err := s.set(value); err != nil {
+			return fmt.Errorf("%s: %s: %v", source, key, err)
+		}
+	}
+
+	return nil
+}
+
+// readFile reads a YAML or TOML config file into setting keys like database.dsn
+func readFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %v", err)
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, &tree); err != nil {
+			return nil, fmt.Errorf("config file %s: %v", path, err)
+		}
+	case ".toml":
+		t, err := toml.LoadBytes(content)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %v", path, err)
+		}
+		tree = t.ToMap()
+	default:
+		return nil, fmt.Errorf("config file %s: only .yaml, .yml and .toml files are supported", path)
+	}
+
+	values := map[string]string{}
+	if err := flatten("", tree, values); err != nil {
+		return nil, fmt.Errorf("config file %s: %v", path, err)
+	}
+	return values, nil
+}
+
+// flatten turns nested sections into dotted keys
+func flatten(prefix string, value interface{}, values map[string]string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if err := flatten(join(prefix, key), child, values); err != nil {
+				return err
+			}
+		}
+	case map[interface{}]interface{}:
+		for key, child := range v {
+			if err := flatten(join(prefix, fmt.Sprint(key)), child, values); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		return fmt.Errorf("%s: lists are not supported", prefix)
+	case nil:
+	default:
+		values[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+func join(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,37 @@
+package constant
+
+import "time"
+
+const (
+	// AuthorizationHeaderKey is the header carrying the access token
+	AuthorizationHeaderKey = "Authorization"
+	// AuthorizationTypeBearer is the only supported authorization scheme
+	AuthorizationTypeBearer = "bearer"
+
+	AccessTokenDuration  = 15 * time.Minute
+	RefreshTokenDuration = 7 * 24 * time.Hour
+
+	// IdempotencyKeyTTL is how long a stored response can be replayed
+	IdempotencyKeyTTL = 24 * time.Hour
+	// IdempotencyKeyLease is how long a request holds its key, a retry recovers the key of a request that died after it
+	IdempotencyKeyLease = time.Minute
+
+	// FXQuoteDuration is how long the rate of an fx quote stays locked
+	FXQuoteDuration = 30 * time.Second
+
+	// HoldDuration is how long a hold reserves funds when the request sets no expiry
+	HoldDuration = 7 * 24 * time.Hour
+
+	// ScheduledPaymentMaxAttempts is how many times an occurrence is tried on an insufficient balance
+	ScheduledPaymentMaxAttempts = 4
+	// ScheduledPaymentRetryBackoff is the delay before the first retry, it doubles on every retry
+	ScheduledPaymentRetryBackoff = time.Hour
+
+	// OutboxMaxAttempts is how many runs of the relay try an event before it is dead, the later events wait meanwhile
+	OutboxMaxAttempts = 30
+
+	// WebhookMaxAttempts is how many times a delivery is tried before it is dead
+	WebhookMaxAttempts = 8
+	// WebhookRetryBackoff is the delay before the first retry of a delivery, it doubles on every retry
+	WebhookRetryBackoff = 30 * time.Second
+)
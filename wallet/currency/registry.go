@@ -0,0 +1,146 @@
+package currency
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+)
+
+//go:embed currencies.json
+var bundledCurrencies []byte
+
+// Currency is an ISO 4217 currency, amounts are stored as integers of its minor unit
+type Currency struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	MinorUnits int    `json:"minor_units"`
+	Enabled    bool   `json:"enabled"`
+}
+
+// Registry holds the currencies known to the wallet
+type Registry struct {
+	currencies map[string]Currency
+}
+
+// NewRegistry builds a registry after checking the codes and minor units
+func NewRegistry(currencies []Currency) (*Registry, error) {
+	registry := &Registry{currencies: make(map[string]Currency, len(currencies))}
+
+	for _, c := range currencies {
+		if !isCurrencyCode(c.Code) {
+			return nil, fmt.Errorf("invalid currency code %q", c.Code)
+		}
+		if c.MinorUnits < 0 || c.MinorUnits > 4 {
+			return nil, fmt.Errorf("invalid minor units %d for currency %s", c.MinorUnits, c.Code)
+		}
+		if _, ok := registry.currencies[c.Code]; ok {
+			return nil, fmt.Errorf("duplicate currency %s", c.Code)
+		}
+		registry.currencies[c.Code] = c
+	}
+
+	return registry, nil
+}
+
+// LoadRegistry reads a JSON array of currencies
+func LoadRegistry(r io.Reader) (*Registry, error) {
+	var currencies []Currency
+	if err := json.NewDecoder(r).Decode(&currencies); err != nil {
+		return nil, fmt.Errorf("could not decode currencies: %w", err)
+	}
+	return NewRegistry(currencies)
+}
+
+var defaultRegistry = mustLoadBundled()
+
+func mustLoadBundled() *Registry {
+	registry, err := LoadRegistry(strings.NewReader(string(bundledCurrencies)))
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// Default returns the registry seeded from the bundled currencies.json
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Lookup returns the currency with the code, enabled or not
+func (r *Registry) Lookup(code string) (Currency, error) {
+	c, ok := r.currencies[code]
+	if !ok {
+		return Currency{}, local_errors.ErrCurrencyNotFound
+	}
+	return c, nil
+}
+
+// Validate returns the currency if wallets may be opened in it
+func (r *Registry) Validate(code string) (Currency, error) {
+	c, err := r.Lookup(code)
+	if err != nil {
+		return c, err
+	}
+	if !c.Enabled {
+		return c, local_errors.ErrCurrencyDisabled
+	}
+	return c, nil
+}
+
+// List returns all currencies ordered by code
+func (r *Registry) List() []Currency {
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, c := range r.currencies {
+		currencies = append(currencies, c)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// Format renders an amount of minor units as a decimal string, 12345 USD is "123.45"
+func (c Currency) Format(amount int64) string {
+	if c.MinorUnits == 0 {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	sign := ""
+	// the magnitude is kept unsigned so that math.MinInt64 does not overflow
+	magnitude := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		magnitude = -magnitude
+	}
+
+	scale := uint64(1)
+	for i := 0; i < c.MinorUnits; i++ {
+		scale *= 10
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, magnitude/scale, c.MinorUnits, magnitude%scale)
+}
+
+// FormatAmount formats the amount with the default registry, unknown currencies use two minor units
+func FormatAmount(code string, amount int64) string {
+	c, err := Default().Lookup(code)
+	if err != nil {
+		c = Currency{Code: code, MinorUnits: 2}
+	}
+	return c.Format(amount)
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, ch := range code {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
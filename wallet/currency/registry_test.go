@@ -0,0 +1,82 @@
+package currency
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	registry := Default()
+
+	inr, err := registry.Validate("INR")
+	require.NoError(t, err)
+	require.Equal(t, 2, inr.MinorUnits)
+
+	jpy, err := registry.Lookup("JPY")
+	require.NoError(t, err)
+	require.Equal(t, 0, jpy.MinorUnits)
+
+	_, err = registry.Validate("BDT")
+	require.ErrorIs(t, err, local_errors.ErrCurrencyDisabled)
+
+	_, err = registry.Validate("XYZ")
+	require.ErrorIs(t, err, local_errors.ErrCurrencyNotFound)
+
+	list := registry.List()
+	require.NotEmpty(t, list)
+	for i := 1; i < len(list); i++ {
+		require.Less(t, list[i-1].Code, list[i].Code)
+	}
+}
+
+func TestLoadRegistryRejectsInvalidData(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"lower case code", `[{"code": "usd", "minor_units": 2}]`},
+		{"long code", `[{"code": "USDT", "minor_units": 2}]`},
+		{"negative minor units", `[{"code": "USD", "minor_units": -1}]`},
+		{"too many minor units", `[{"code": "USD", "minor_units": 5}]`},
+		{"duplicate", `[{"code": "USD", "minor_units": 2}, {"code": "USD", "minor_units": 2}]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadRegistry(strings.NewReader(tc.data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		minorUnits int
+		amount     int64
+		want       string
+	}{
+		{2, 0, "0.00"},
+		{2, 5, "0.05"},
+		{2, 12345, "123.45"},
+		{2, -5, "-0.05"},
+		{2, -12345, "-123.45"},
+		{0, 1500, "1500"},
+		{0, -1500, "-1500"},
+		{3, 1001, "1.001"},
+		{4, 7, "0.0007"},
+		{2, math.MinInt64, "-92233720368547758.08"},
+	}
+
+	for _, tc := range testCases {
+		c := Currency{Code: "TST", MinorUnits: tc.minorUnits}
+		require.Equal(t, tc.want, c.Format(tc.amount))
+	}
+
+	require.Equal(t, "1.001", FormatAmount("KWD", 1001))
+	require.Equal(t, "10.01", FormatAmount("XYZ", 1001))
+}
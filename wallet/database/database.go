@@ -0,0 +1,78 @@
+// Package database opens the database of the configured driver and runs its migrations
+package database
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/migrate"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/sirupsen/logrus"
+)
+
+// sqliteOptions are added to a SQLite DSN that does not set them. SQLite has no row locks, a write
+// transaction takes the database lock when it begins instead of at its first write, so the transactions
+// that read a row before updating it run one after the other, waiting up to the busy timeout.
+// WAL lets the reads go on meanwhile, and the foreign keys are only enforced when they are turned on.
+var sqliteOptions = []struct{ name, value string }{
+	{"_txlock", "immediate"},
+	{"_busy_timeout", "10000"},
+	{"_journal_mode", "WAL"},
+	{"_foreign_keys", "1"},
+}
+
+// Open connects to the database and sizes its connection pool
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	if cfg.Driver == config.DriverMemory {
+		return nil, errors.New("the memory driver has no database to open")
+	}
+
+	dsn := cfg.DSN
+	if cfg.Driver == config.DriverSQLite {
+		dsn = sqliteDSN(dsn)
+	}
+
+	db, err := gorm.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Printf("%s db connection opened", cfg.Driver)
+
+	db.DB().SetMaxOpenConns(cfg.MaxOpenConns)
+	db.DB().SetMaxIdleConns(cfg.MaxIdleConns)
+	db.DB().SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return db, nil
+}
+
+// NewMigrator runs the schema migrations of the dialect of the database
+func NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	migrations, err := migrate.ForDialect(db.Dialect().GetName())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, migrations), nil
+}
+
+// sqliteDSN adds the missing sqliteOptions to the query of a SQLite DSN
+func sqliteDSN(dsn string) string {
+	path, query := dsn, ""
+	if i := strings.IndexByte(dsn, '?'); i >= 0 {
+		path, query = dsn[:i], dsn[i+1:]
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return dsn
+	}
+	for _, option := range sqliteOptions {
+		if values.Get(option.name) == "" {
+			values.Set(option.name, option.value)
+		}
+	}
+	return path + "?" + values.Encode()
+}
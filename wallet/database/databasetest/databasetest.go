@@ -0,0 +1,42 @@
+// Package databasetest opens migrated databases for tests
+package databasetest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/database"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+// Open returns a migrated database closed at the end of the test. It is a new SQLite database in
+// the temporary directory of the test, unless WALLET_TEST_DB_DSN names one of the WALLET_TEST_DB_DRIVER
+// driver, mysql by default. That database is shared by the tests, they must not depend on it being empty.
+func Open(t testing.TB) *gorm.DB {
+	cfg := config.DatabaseConfig{
+		Driver: config.DriverSQLite,
+		DSN:    filepath.Join(t.TempDir(), "wallet.db"),
+	}
+	if dsn := os.Getenv("WALLET_TEST_DB_DSN"); dsn != "" {
+		cfg.Driver, cfg.DSN = os.Getenv("WALLET_TEST_DB_DRIVER"), dsn
+		if cfg.Driver == "" {
+			cfg.Driver = config.DriverMySQL
+		}
+	}
+
+	db, err := database.Open(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// the schema comes from the migrations, like in production
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	return db
+}
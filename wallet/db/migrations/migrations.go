@@ -0,0 +1,25 @@
+// Package migrations embeds the versioned schema migrations, one directory per SQL dialect.
+// They are the only definition of the schema, run them with `wallet migrate up`.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite3/*.sql
+var files embed.FS
+
+// Dialects are the SQL dialects there are migrations for, named like the gorm dialects
+var Dialects = []string{"mysql", "postgres", "sqlite3"}
+
+// For returns the migrations of a dialect
+func For(dialect string) (fs.FS, error) {
+	for _, d := range Dialects {
+		if d == dialect {
+			return fs.Sub(files, dialect)
+		}
+	}
+	return nil, fmt.Errorf("no migrations for the %q dialect", dialect)
+}
@@ -0,0 +1,16 @@
+DROP TABLE `webhook_deliveries`;
+DROP TABLE `webhook_subscriptions`;
+DROP TABLE `outbox_events`;
+DROP TABLE `leases`;
+DROP TABLE `scheduled_payment_runs`;
+DROP TABLE `scheduled_payments`;
+DROP TABLE `holds`;
+DROP TABLE `fx_quotes`;
+DROP TABLE `idempotency_keys`;
+DROP TABLE `payment_requests`;
+DROP TABLE `postings`;
+DROP TABLE `journal_entries`;
+DROP TABLE `trans`;
+DROP TABLE `wallets`;
+DROP TABLE `sessions`;
+DROP TABLE `users`;
@@ -0,0 +1,313 @@
+-- The tables of the Go models in package model, the SQLite version of the mysql migration.
+-- Columns default to the zero values gorm writes, times that may be unset are nullable.
+-- SQLite only enforces the foreign keys when the connection enables them, database.Open does.
+
+CREATE TABLE users
+(
+    id                  integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    username            varchar(255) NOT NULL,
+    hashed_password     varchar(255) NOT NULL DEFAULT '',
+    status              varchar(255) NOT NULL DEFAULT 'ACTIVE',
+    tier                varchar(255) NOT NULL DEFAULT 'STANDARD',
+    role                varchar(255) NOT NULL DEFAULT 'USER',
+    email               varchar(255) NOT NULL DEFAULT '',
+    address             varchar(255) NOT NULL DEFAULT '',
+    nationality         varchar(255) NOT NULL DEFAULT '',
+    aadhar_no           varchar(255) NOT NULL DEFAULT '',
+    password_changed_at datetime     NULL,
+    created_at          datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at          datetime     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uix_users_username UNIQUE (username)
+);
+
+CREATE TABLE sessions
+(
+    id            varchar(255) NOT NULL,
+    username      varchar(255) NOT NULL,
+    refresh_token varchar(1024) NOT NULL,
+    user_agent    varchar(255) NOT NULL DEFAULT '',
+    client_ip     varchar(255) NOT NULL DEFAULT '',
+    is_blocked    boolean      NOT NULL DEFAULT false,
+    expires_at    datetime     NOT NULL,
+    created_at    datetime     NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_sessions_username FOREIGN KEY (username) REFERENCES users (username)
+);
+CREATE INDEX idx_sessions_username ON sessions (username);
+
+-- organization wallets belong to no user, their username is empty and their user_id 0,
+-- so wallets do not reference users. A user holds one wallet per currency.
+CREATE TABLE wallets
+(
+    id                 integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    username           varchar(255) NOT NULL DEFAULT '',
+    wallet_address     varchar(255) NOT NULL,
+    status             varchar(255) NOT NULL DEFAULT 'ACTIVE',
+    kind               varchar(255) NOT NULL DEFAULT 'USER',
+    purpose            varchar(255) NOT NULL DEFAULT '',
+    user_id            bigint       NOT NULL DEFAULT 0,
+    is_primary         boolean      NOT NULL DEFAULT false,
+    balance            bigint       NOT NULL DEFAULT 0,
+    held_balance       bigint       NOT NULL DEFAULT 0,
+    currency           varchar(255) NOT NULL,
+    limit_day          datetime     NULL,
+    limit_day_count    bigint       NOT NULL DEFAULT 0,
+    limit_day_amount   bigint       NOT NULL DEFAULT 0,
+    limit_month        datetime     NULL,
+    limit_month_count  bigint       NOT NULL DEFAULT 0,
+    limit_month_amount bigint       NOT NULL DEFAULT 0,
+    created_at         datetime     NOT NULL,
+    updated_at         datetime     NOT NULL,
+    CONSTRAINT uix_wallets_wallet_address UNIQUE (wallet_address),
+    CONSTRAINT idx_wallets_user_currency UNIQUE (purpose, user_id, currency)
+);
+CREATE INDEX idx_wallets_username ON wallets (username);
+
+CREATE TABLE trans
+(
+    id                integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    from_wallet_add   varchar(255) NOT NULL,
+    to_wallet_add     varchar(255) NOT NULL,
+    amount            bigint       NOT NULL,
+    fee               bigint       NOT NULL DEFAULT 0,
+    net_amount        bigint       NOT NULL DEFAULT 0,
+    currency          varchar(255) NOT NULL,
+    rate              varchar(255) NOT NULL DEFAULT '',
+    to_amount         bigint       NOT NULL DEFAULT 0,
+    to_currency       varchar(255) NOT NULL DEFAULT '',
+    kind              varchar(255) NOT NULL DEFAULT 'TRANSFER',
+    original_trans_id bigint       NOT NULL DEFAULT 0,
+    refunded_amount   bigint       NOT NULL DEFAULT 0,
+    reversal_trans_id bigint       NOT NULL DEFAULT 0,
+    shortfall         bigint       NOT NULL DEFAULT 0,
+    created_at        datetime     NOT NULL,
+    CONSTRAINT fk_trans_from_wallet_add FOREIGN KEY (from_wallet_add) REFERENCES wallets (wallet_address),
+    CONSTRAINT fk_trans_to_wallet_add FOREIGN KEY (to_wallet_add) REFERENCES wallets (wallet_address)
+);
+CREATE INDEX idx_trans_from_wallet_add ON trans (from_wallet_add);
+CREATE INDEX idx_trans_to_wallet_add ON trans (to_wallet_add);
+CREATE INDEX idx_trans_original_trans_id ON trans (original_trans_id);
+CREATE INDEX idx_trans_created_at ON trans (created_at);
+
+CREATE TABLE journal_entries
+(
+    id                 integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    kind               varchar(255) NOT NULL,
+    trans_id           bigint       NOT NULL DEFAULT 0,
+    description        varchar(255) NOT NULL DEFAULT '',
+    idempotency_key_id bigint       NOT NULL DEFAULT 0,
+    created_at         datetime     NOT NULL
+);
+CREATE INDEX idx_journal_entries_trans_id ON journal_entries (trans_id);
+CREATE INDEX idx_journal_entries_idempotency_key_id ON journal_entries (idempotency_key_id);
+
+-- the postings of an entry sum to zero per currency, a wallet balance is the sum of its postings
+CREATE TABLE postings
+(
+    id               integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    journal_entry_id bigint       NOT NULL,
+    wallet_address   varchar(255) NOT NULL,
+    currency         varchar(255) NOT NULL,
+    amount           bigint       NOT NULL,
+    created_at       datetime     NOT NULL,
+    CONSTRAINT fk_postings_journal_entry_id FOREIGN KEY (journal_entry_id) REFERENCES journal_entries (id),
+    CONSTRAINT fk_postings_wallet_address FOREIGN KEY (wallet_address) REFERENCES wallets (wallet_address)
+);
+CREATE INDEX idx_postings_journal_entry_id ON postings (journal_entry_id);
+CREATE INDEX idx_postings_wallet_address ON postings (wallet_address);
+
+CREATE TABLE payment_requests
+(
+    id              integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    from_wallet_add varchar(255) NOT NULL DEFAULT '',
+    to_wallet_add   varchar(255) NOT NULL DEFAULT '',
+    payer_username  varchar(255) NOT NULL,
+    payee_username  varchar(255) NOT NULL,
+    amount          bigint       NOT NULL,
+    currency        varchar(255) NOT NULL,
+    note            varchar(255) NOT NULL DEFAULT '',
+    status          varchar(255) NOT NULL DEFAULT 'WAITING_APPROVAL',
+    trans_id        bigint       NOT NULL DEFAULT 0,
+    failure_reason  varchar(255) NOT NULL DEFAULT '',
+    created_at      datetime     NOT NULL,
+    updated_at      datetime     NOT NULL,
+    CONSTRAINT fk_payment_requests_payer_username FOREIGN KEY (payer_username) REFERENCES users (username),
+    CONSTRAINT fk_payment_requests_payee_username FOREIGN KEY (payee_username) REFERENCES users (username)
+);
+CREATE INDEX idx_payment_requests_payer_username ON payment_requests (payer_username);
+CREATE INDEX idx_payment_requests_payee_username ON payment_requests (payee_username);
+
+CREATE TABLE idempotency_keys
+(
+    id              integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    username        varchar(255) NOT NULL,
+    scope           varchar(255) NOT NULL,
+    idempotency_key varchar(255) NOT NULL,
+    fingerprint     varchar(255) NOT NULL,
+    status_code     int          NOT NULL DEFAULT 0,
+    response_body   text         NULL,
+    created_at      datetime     NOT NULL,
+    expires_at      datetime     NOT NULL,
+    locked_until    datetime     NOT NULL,
+    CONSTRAINT idx_idempotency_keys_scope UNIQUE (username, scope, idempotency_key)
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE TABLE fx_quotes
+(
+    id              varchar(255) NOT NULL,
+    username        varchar(255) NOT NULL,
+    from_wallet_add varchar(255) NOT NULL,
+    to_wallet_add   varchar(255) NOT NULL,
+    from_currency   varchar(255) NOT NULL,
+    to_currency     varchar(255) NOT NULL,
+    rate            varchar(255) NOT NULL,
+    from_amount     bigint       NOT NULL,
+    to_amount       bigint       NOT NULL,
+    trans_id        bigint       NOT NULL DEFAULT 0,
+    expires_at      datetime     NOT NULL,
+    created_at      datetime     NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_fx_quotes_from_wallet_add FOREIGN KEY (from_wallet_add) REFERENCES wallets (wallet_address),
+    CONSTRAINT fk_fx_quotes_to_wallet_add FOREIGN KEY (to_wallet_add) REFERENCES wallets (wallet_address)
+);
+CREATE INDEX idx_fx_quotes_username ON fx_quotes (username);
+
+CREATE TABLE holds
+(
+    id              integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    wallet_address  varchar(255) NOT NULL,
+    to_wallet_add   varchar(255) NOT NULL DEFAULT '',
+    username        varchar(255) NOT NULL,
+    amount          bigint       NOT NULL,
+    currency        varchar(255) NOT NULL,
+    description     varchar(255) NOT NULL DEFAULT '',
+    status          varchar(255) NOT NULL DEFAULT 'ACTIVE',
+    captured_amount bigint       NOT NULL DEFAULT 0,
+    trans_id        bigint       NOT NULL DEFAULT 0,
+    expires_at      datetime     NOT NULL,
+    created_at      datetime     NOT NULL,
+    updated_at      datetime     NOT NULL,
+    CONSTRAINT fk_holds_wallet_address FOREIGN KEY (wallet_address) REFERENCES wallets (wallet_address)
+);
+CREATE INDEX idx_holds_wallet_address ON holds (wallet_address);
+CREATE INDEX idx_holds_to_wallet_add ON holds (to_wallet_add);
+CREATE INDEX idx_holds_username ON holds (username);
+CREATE INDEX idx_holds_status ON holds (status);
+CREATE INDEX idx_holds_expires_at ON holds (expires_at);
+
+-- the payee is to_wallet_add, or the wallet of to_username in the currency when it is set
+CREATE TABLE scheduled_payments
+(
+    id              integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    username        varchar(255) NOT NULL,
+    from_wallet_add varchar(255) NOT NULL,
+    to_wallet_add   varchar(255) NOT NULL DEFAULT '',
+    to_username     varchar(255) NOT NULL DEFAULT '',
+    amount          bigint       NOT NULL,
+    currency        varchar(255) NOT NULL,
+    note            varchar(255) NOT NULL DEFAULT '',
+    frequency       varchar(255) NOT NULL,
+    "interval"      int          NOT NULL DEFAULT 0,
+    cron            varchar(255) NOT NULL DEFAULT '',
+    start_at        datetime     NOT NULL,
+    end_at          datetime     NULL,
+    max_runs        bigint       NOT NULL DEFAULT 0,
+    run_count       bigint       NOT NULL DEFAULT 0,
+    status          varchar(255) NOT NULL DEFAULT 'ACTIVE',
+    occurrence_at   datetime     NULL,
+    next_run_at     datetime     NULL,
+    attempts        int          NOT NULL DEFAULT 0,
+    last_error      varchar(255) NOT NULL DEFAULT '',
+    created_at      datetime     NOT NULL,
+    updated_at      datetime     NOT NULL,
+    CONSTRAINT fk_scheduled_payments_from_wallet_add FOREIGN KEY (from_wallet_add) REFERENCES wallets (wallet_address)
+);
+CREATE INDEX idx_scheduled_payments_username ON scheduled_payments (username);
+CREATE INDEX idx_scheduled_payments_status ON scheduled_payments (status);
+CREATE INDEX idx_scheduled_payments_next_run_at ON scheduled_payments (next_run_at);
+
+CREATE TABLE scheduled_payment_runs
+(
+    id                   integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    scheduled_payment_id bigint       NOT NULL,
+    scheduled_for        datetime     NOT NULL,
+    attempt              int          NOT NULL,
+    status               varchar(255) NOT NULL DEFAULT 'PENDING',
+    trans_id             bigint       NOT NULL DEFAULT 0,
+    error                varchar(255) NOT NULL DEFAULT '',
+    created_at           datetime     NOT NULL,
+    updated_at           datetime     NOT NULL,
+    CONSTRAINT fk_scheduled_payment_runs_scheduled_payment_id FOREIGN KEY (scheduled_payment_id) REFERENCES scheduled_payments (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_scheduled_payment_runs_scheduled_payment_id ON scheduled_payment_runs (scheduled_payment_id);
+
+CREATE TABLE leases
+(
+    name       varchar(255) NOT NULL,
+    holder     varchar(255) NOT NULL,
+    expires_at datetime     NOT NULL,
+    updated_at datetime     NOT NULL,
+    PRIMARY KEY (name)
+);
+
+-- written in the transaction of the change they describe, the relay publishes them in id order
+CREATE TABLE outbox_events
+(
+    id                integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    event_id          varchar(255) NOT NULL,
+    type              varchar(255) NOT NULL,
+    aggregate_type    varchar(255) NOT NULL,
+    aggregate_id      varchar(255) NOT NULL,
+    wallet_address    varchar(255) NOT NULL DEFAULT '',
+    to_wallet_address varchar(255) NOT NULL DEFAULT '',
+    payload           text         NOT NULL,
+    occurred_at       datetime     NOT NULL,
+    published         boolean      NOT NULL DEFAULT false,
+    dead              boolean      NOT NULL DEFAULT false,
+    published_at      datetime     NULL,
+    attempts          int          NOT NULL DEFAULT 0,
+    last_error        varchar(255) NOT NULL DEFAULT '',
+    CONSTRAINT uix_outbox_events_event_id UNIQUE (event_id)
+);
+CREATE INDEX idx_outbox_events_published ON outbox_events (published, id);
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id);
+CREATE INDEX idx_outbox_events_wallet_address ON outbox_events (wallet_address, id);
+CREATE INDEX idx_outbox_events_to_wallet_address ON outbox_events (to_wallet_address, id);
+CREATE INDEX idx_outbox_events_occurred_at ON outbox_events (occurred_at);
+
+-- event_types is a comma separated filter, empty means every event
+CREATE TABLE webhook_subscriptions
+(
+    id          integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    username    varchar(255) NOT NULL,
+    url         varchar(2048) NOT NULL,
+    event_types varchar(255) NOT NULL DEFAULT '',
+    secret      varchar(255) NOT NULL,
+    active      boolean      NOT NULL DEFAULT true,
+    created_at  datetime     NOT NULL,
+    updated_at  datetime     NOT NULL,
+    CONSTRAINT fk_webhook_subscriptions_username FOREIGN KEY (username) REFERENCES users (username)
+);
+CREATE INDEX idx_webhook_subscriptions_username ON webhook_subscriptions (username);
+
+CREATE TABLE webhook_deliveries
+(
+    id               integer      NOT NULL PRIMARY KEY AUTOINCREMENT,
+    subscription_id  bigint       NOT NULL,
+    event_id         varchar(255) NOT NULL,
+    event_type       varchar(255) NOT NULL,
+    payload          text         NOT NULL,
+    status           varchar(255) NOT NULL DEFAULT 'PENDING',
+    attempts         int          NOT NULL DEFAULT 0,
+    next_attempt_at  datetime     NULL,
+    last_status_code int          NOT NULL DEFAULT 0,
+    last_error       varchar(255) NOT NULL DEFAULT '',
+    delivered_at     datetime     NULL,
+    created_at       datetime     NOT NULL,
+    updated_at       datetime     NOT NULL,
+    CONSTRAINT idx_webhook_delivery_event UNIQUE (subscription_id, event_id),
+    CONSTRAINT fk_webhook_deliveries_subscription_id FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions (id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/model"
+)
+
+type FeeQuoteRequestDto struct {
+	FromWalletAddress string `validate:"required"`
+	Amount            int64  `validate:"required,gt=0"`
+}
+
+// FeeQuoteDto is the split of a payment, Gross is debited from the payer and Net credited to the payee
+type FeeQuoteDto struct {
+	Currency       string          `json:"currency"`
+	Amount         int64           `json:"amount"`
+	Fee            int64           `json:"fee"`
+	FeeFormatted   string          `json:"fee_formatted"`
+	Gross          int64           `json:"gross"`
+	GrossFormatted string          `json:"gross_formatted"`
+	Net            int64           `json:"net"`
+	NetFormatted   string          `json:"net_formatted"`
+	Bearer         model.FeeBearer `json:"bearer"`
+}
+
+func NewFeeQuoteDto(code string, q fee.Quote) FeeQuoteDto {
+	return FeeQuoteDto{
+		Currency:       code,
+		Amount:         q.Amount,
+		Fee:            q.Fee,
+		FeeFormatted:   currency.FormatAmount(code, q.Fee),
+		Gross:          q.Gross,
+		GrossFormatted: currency.FormatAmount(code, q.Gross),
+		Net:            q.Net,
+		NetFormatted:   currency.FormatAmount(code, q.Net),
+		Bearer:         q.Bearer,
+	}
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+	"time"
+)
+
+type CreateFXQuoteDto struct {
+	FromWalletAddress string `json:"from_wallet_address" validate:"required"`
+	ToWalletAddress   string `json:"to_wallet_address" validate:"required"`
+	// Amount is debited from FromWalletAddress, in minor units of its currency
+	Amount int64 `json:"amount" validate:"required,gt=0"`
+}
+
+type PayFXDto struct {
+	QuoteID string `json:"quote_id" validate:"required"`
+}
+
+type FXQuoteDto struct {
+	ID                  string    `json:"id"`
+	FromWalletAddress   string    `json:"from_wallet_address"`
+	ToWalletAddress     string    `json:"to_wallet_address"`
+	FromCurrency        string    `json:"from_currency"`
+	ToCurrency          string    `json:"to_currency"`
+	Rate                string    `json:"rate"`
+	FromAmount          int64     `json:"from_amount"`
+	FromAmountFormatted string    `json:"from_amount_formatted"`
+	ToAmount            int64     `json:"to_amount"`
+	ToAmountFormatted   string    `json:"to_amount_formatted"`
+	TransID             int64     `json:"trans_id,omitempty"`
+	ExpiresAt           time.Time `json:"expires_at"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+func NewFXQuoteDto(q model.FXQuote) FXQuoteDto {
+	return FXQuoteDto{
+		ID:                  q.ID,
+		FromWalletAddress:   q.FromWalletAdd,
+		ToWalletAddress:     q.ToWalletAdd,
+		FromCurrency:        q.FromCurrency,
+		ToCurrency:          q.ToCurrency,
+		Rate:                q.Rate,
+		FromAmount:          q.FromAmount,
+		FromAmountFormatted: currency.FormatAmount(q.FromCurrency, q.FromAmount),
+		ToAmount:            q.ToAmount,
+		ToAmountFormatted:   currency.FormatAmount(q.ToCurrency, q.ToAmount),
+		TransID:             q.TransID,
+		ExpiresAt:           q.ExpiresAt,
+		CreatedAt:           q.CreatedAt,
+	}
+}
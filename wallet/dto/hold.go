@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+	"time"
+)
+
+type CreateHoldDto struct {
+	WalletAddress   string `json:"wallet_address" validate:"required"`
+	ToWalletAddress string `json:"to_wallet_address" validate:"required"`
+	Amount          int64  `json:"amount" validate:"required,gt=0"`
+	// ExpiresIn is the lifetime of the hold in seconds, zero uses the default
+	ExpiresIn   int64  `json:"expires_in" validate:"gte=0,lte=2592000"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+// CaptureHoldDto captures Amount of a hold, zero captures the whole hold
+type CaptureHoldDto struct {
+	Amount int64 `json:"amount" validate:"gte=0"`
+}
+
+type HoldDto struct {
+	ID                      int64            `json:"id"`
+	WalletAddress           string           `json:"wallet_address"`
+	ToWalletAddress         string           `json:"to_wallet_address"`
+	Amount                  int64            `json:"amount"`
+	AmountFormatted         string           `json:"amount_formatted"`
+	Currency                string           `json:"currency"`
+	Description             string           `json:"description,omitempty"`
+	Status                  model.HoldStatus `json:"status"`
+	CapturedAmount          int64            `json:"captured_amount,omitempty"`
+	CapturedAmountFormatted string           `json:"captured_amount_formatted,omitempty"`
+	TransID                 int64            `json:"trans_id,omitempty"`
+	ExpiresAt               time.Time        `json:"expires_at"`
+	CreatedAt               time.Time        `json:"created_at"`
+	UpdatedAt               time.Time        `json:"updated_at"`
+}
+
+func NewHoldDto(h model.Hold) HoldDto {
+	dto := HoldDto{
+		ID:              h.ID,
+		WalletAddress:   h.WalletAddress,
+		ToWalletAddress: h.ToWalletAdd,
+		Amount:          h.Amount,
+		AmountFormatted: currency.FormatAmount(h.Currency, h.Amount),
+		Currency:        h.Currency,
+		Description:     h.Description,
+		Status:          h.Status,
+		TransID:         h.TransID,
+		ExpiresAt:       h.ExpiresAt,
+		CreatedAt:       h.CreatedAt,
+		UpdatedAt:       h.UpdatedAt,
+	}
+
+	if h.CapturedAmount > 0 {
+		dto.CapturedAmount = h.CapturedAmount
+		dto.CapturedAmountFormatted = currency.FormatAmount(h.Currency, h.CapturedAmount)
+	}
+
+	return dto
+}
@@ -0,0 +1,7 @@
+package dto
+
+// RecoveredResponseDto is replayed for a request that posted its journal entry but never stored its response
+type RecoveredResponseDto struct {
+	JournalEntryID int64 `json:"journal_entry_id"`
+	TransID        int64 `json:"trans_id,omitempty"`
+}
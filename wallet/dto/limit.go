@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+)
+
+// LimitWindowDto is the usage of a daily or monthly limit, nil limits and remainders mean no limit
+type LimitWindowDto struct {
+	AmountLimit     *int64    `json:"amount_limit"`
+	AmountUsed      int64     `json:"amount_used"`
+	AmountRemaining *int64    `json:"amount_remaining"`
+	CountLimit      *int64    `json:"count_limit"`
+	CountUsed       int64     `json:"count_used"`
+	CountRemaining  *int64    `json:"count_remaining"`
+	ResetsAt        time.Time `json:"resets_at"`
+}
+
+// TransferLimitsDto shows how much a wallet can still send
+type TransferLimitsDto struct {
+	WalletAddress  string         `json:"wallet_address"`
+	Currency       string         `json:"currency"`
+	Tier           model.UserTier `json:"tier"`
+	PerTransaction *int64         `json:"per_transaction"`
+	Daily          LimitWindowDto `json:"daily"`
+	Monthly        LimitWindowDto `json:"monthly"`
+}
+
+func NewTransferLimitsDto(wallet model.Wallet, tier model.UserTier, limit model.TransferLimit, now time.Time) TransferLimitsDto {
+	daily, monthly := wallet.TransferUsage(now)
+
+	return TransferLimitsDto{
+		WalletAddress:  wallet.WalletAddress,
+		Currency:       wallet.Currency,
+		Tier:           tier,
+		PerTransaction: limitValue(limit.PerTransaction),
+		Daily:          newLimitWindowDto(limit.DailyAmount, limit.DailyCount, daily, model.DayStart(now).AddDate(0, 0, 1)),
+		Monthly:        newLimitWindowDto(limit.MonthlyAmount, limit.MonthlyCount, monthly, model.MonthStart(now).AddDate(0, 1, 0)),
+	}
+}
+
+func newLimitWindowDto(amountLimit int64, countLimit int64, usage model.TransferUsage, resetsAt time.Time) LimitWindowDto {
+	return LimitWindowDto{
+		AmountLimit:     limitValue(amountLimit),
+		AmountUsed:      usage.Amount,
+		AmountRemaining: remaining(amountLimit, usage.Amount),
+		CountLimit:      limitValue(countLimit),
+		CountUsed:       usage.Count,
+		CountRemaining:  remaining(countLimit, usage.Count),
+		ResetsAt:        resetsAt,
+	}
+}
+
+// limitValue maps the zero value, which means no limit, to nil
+func limitValue(limit int64) *int64 {
+	if limit == 0 {
+		return nil
+	}
+	return &limit
+}
+
+func remaining(limit int64, used int64) *int64 {
+	if limit == 0 {
+		return nil
+	}
+	left := limit - used
+	if left < 0 {
+		left = 0
+	}
+	return &left
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+	"time"
+)
+
+type CreatePaymentRequestDto struct {
+	// FromWalletAddress is the wallet asked to pay
+	FromWalletAddress string `json:"from_wallet_address" validate:"required"`
+	// ToWalletAddress is the requester's wallet receiving the money
+	ToWalletAddress string `json:"to_wallet_address" validate:"required"`
+	Amount          int64  `json:"amount" validate:"required,gt=0"`
+	Note            string `json:"note" validate:"max=255"`
+}
+
+type PaymentRequestDto struct {
+	ID                int64                      `json:"id"`
+	FromWalletAddress string                     `json:"from_wallet_address"`
+	ToWalletAddress   string                     `json:"to_wallet_address"`
+	PayerUsername     string                     `json:"payer_username"`
+	PayeeUsername     string                     `json:"payee_username"`
+	Amount            int64                      `json:"amount"`
+	AmountFormatted   string                     `json:"amount_formatted"`
+	Currency          string                     `json:"currency"`
+	Note              string                     `json:"note"`
+	Status            model.PaymentRequestStatus `json:"status"`
+	TransID           int64                      `json:"trans_id,omitempty"`
+	FailureReason     string                     `json:"failure_reason,omitempty"`
+	CreatedAt         time.Time                  `json:"created_at"`
+	UpdatedAt         time.Time                  `json:"updated_at"`
+}
+
+func NewPaymentRequestDto(p model.PaymentRequest) PaymentRequestDto {
+	return PaymentRequestDto{
+		ID:                p.ID,
+		FromWalletAddress: p.FromWalletAdd,
+		ToWalletAddress:   p.ToWalletAdd,
+		PayerUsername:     p.PayerUsername,
+		PayeeUsername:     p.PayeeUsername,
+		Amount:            p.Amount,
+		AmountFormatted:   currency.FormatAmount(p.Currency, p.Amount),
+		Currency:          p.Currency,
+		Note:              p.Note,
+		Status:            p.Status,
+		TransID:           p.TransID,
+		FailureReason:     p.FailureReason,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+	}
+}
+
+func NewPaymentRequestDtos(requests []model.PaymentRequest) []PaymentRequestDto {
+	dtos := make([]PaymentRequestDto, 0, len(requests))
+	for _, p := range requests {
+		dtos = append(dtos, NewPaymentRequestDto(p))
+	}
+	return dtos
+}
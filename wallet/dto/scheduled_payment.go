@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/recurrence"
+	"time"
+)
+
+// CreateScheduledPaymentDto schedules the payment described by TransferMoneyDto
+type CreateScheduledPaymentDto struct {
+	TransferMoneyDto
+	Note string `json:"note" validate:"max=255"`
+	// Frequency defaults to ONCE, Cron is a five field cron expression used with the CRON frequency
+	Frequency string `json:"frequency" validate:"omitempty,oneof=ONCE DAILY WEEKLY MONTHLY CRON"`
+	Interval  int    `json:"interval" validate:"gte=0,lte=366"`
+	Cron      string `json:"cron"`
+	// StartAt defaults to now, EndAt and MaxRuns are optional
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+	MaxRuns int64     `json:"max_runs" validate:"gte=0"`
+}
+
+type ScheduledPaymentDto struct {
+	ID                int64                        `json:"id"`
+	FromWalletAddress string                       `json:"from_wallet_address"`
+	ToWalletAddress   string                       `json:"to_wallet_address,omitempty"`
+	ToUsername        string                       `json:"to_username,omitempty"`
+	Amount            int64                        `json:"amount"`
+	AmountFormatted   string                       `json:"amount_formatted"`
+	Currency          string                       `json:"currency"`
+	Note              string                       `json:"note,omitempty"`
+	Frequency         recurrence.Frequency         `json:"frequency"`
+	Interval          int                          `json:"interval,omitempty"`
+	Cron              string                       `json:"cron,omitempty"`
+	StartAt           time.Time                    `json:"start_at"`
+	EndAt             *time.Time                   `json:"end_at,omitempty"`
+	MaxRuns           int64                        `json:"max_runs,omitempty"`
+	RunCount          int64                        `json:"run_count"`
+	Status            model.ScheduledPaymentStatus `json:"status"`
+	// NextRunAt is only set on active schedules
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	Attempts  int        `json:"attempts,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func NewScheduledPaymentDto(s model.ScheduledPayment) ScheduledPaymentDto {
+	dto := ScheduledPaymentDto{
+		ID:                s.ID,
+		FromWalletAddress: s.FromWalletAdd,
+		ToWalletAddress:   s.ToWalletAdd,
+		ToUsername:        s.ToUsername,
+		Amount:            s.Amount,
+		AmountFormatted:   currency.FormatAmount(s.Currency, s.Amount),
+		Currency:          s.Currency,
+		Note:              s.Note,
+		Frequency:         s.Frequency,
+		Interval:          s.Interval,
+		Cron:              s.Cron,
+		StartAt:           s.StartAt,
+		MaxRuns:           s.MaxRuns,
+		RunCount:          s.RunCount,
+		Status:            s.Status,
+		Attempts:          s.Attempts,
+		LastError:         s.LastError,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+
+	if !s.EndAt.IsZero() {
+		endAt := s.EndAt
+		dto.EndAt = &endAt
+	}
+	if s.Status == model.ScheduledPaymentStatusACTIVE {
+		nextRunAt := s.NextRunAt
+		dto.NextRunAt = &nextRunAt
+	}
+
+	return dto
+}
+
+func NewScheduledPaymentDtos(schedules []model.ScheduledPayment) []ScheduledPaymentDto {
+	dtos := make([]ScheduledPaymentDto, 0, len(schedules))
+	for _, s := range schedules {
+		dtos = append(dtos, NewScheduledPaymentDto(s))
+	}
+	return dtos
+}
+
+type ScheduledPaymentRunDto struct {
+	ID           int64                           `json:"id"`
+	ScheduledFor time.Time                       `json:"scheduled_for"`
+	Attempt      int                             `json:"attempt"`
+	Status       model.ScheduledPaymentRunStatus `json:"status"`
+	TransID      int64                           `json:"trans_id,omitempty"`
+	Error        string                          `json:"error,omitempty"`
+	CreatedAt    time.Time                       `json:"created_at"`
+	UpdatedAt    time.Time                       `json:"updated_at"`
+}
+
+func NewScheduledPaymentRunDtos(runs []model.ScheduledPaymentRun) []ScheduledPaymentRunDto {
+	dtos := make([]ScheduledPaymentRunDto, 0, len(runs))
+	for _, r := range runs {
+		dtos = append(dtos, ScheduledPaymentRunDto{
+			ID:           r.ID,
+			ScheduledFor: r.ScheduledFor,
+			Attempt:      r.Attempt,
+			Status:       r.Status,
+			TransID:      r.TransID,
+			Error:        r.Error,
+			CreatedAt:    r.CreatedAt,
+			UpdatedAt:    r.UpdatedAt,
+		})
+	}
+	return dtos
+}
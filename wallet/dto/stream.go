@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+)
+
+// WalletStreamEventDto is an UpdatedWalletBalanceDto pushed to the streams of a wallet, ID is the id to resume from
+type WalletStreamEventDto struct {
+	ID            int64           `json:"id"`
+	Type          model.EventType `json:"type"`
+	WalletAddress string          `json:"wallet_address"`
+	UpdatedWalletBalanceDto
+	// Change is what the event moved in or out of the wallet, negative when money left it
+	Change          int64              `json:"change"`
+	ChangeFormatted string             `json:"change_formatted"`
+	Status          model.WalletStatus `json:"status,omitempty"`
+	// TransID and CounterpartyWalletAddress are set on transfer events
+	TransID                   int64  `json:"trans_id,omitempty"`
+	CounterpartyWalletAddress string `json:"counterparty_wallet_address,omitempty"`
+}
+
+// NewWalletStreamEventDto describes the outbox event e as seen from the wallet at address
+func NewWalletStreamEventDto(e model.OutboxEvent, address string) (WalletStreamEventDto, error) {
+	dto := WalletStreamEventDto{
+		ID:            e.ID,
+		Type:          e.Type,
+		WalletAddress: address,
+	}
+	dto.UpdatedAt = e.OccurredAt
+
+	switch e.AggregateType {
+	case model.AggregateTypeWALLET:
+		var w model.WalletEvent
+		if err := json.Unmarshal([]byte(e.Payload), &w); err != nil {
+			return dto, err
+		}
+		dto.Currency = w.Currency
+		dto.UpdatedBalance = w.Balance
+		dto.Change = w.Amount
+		dto.Status = w.Status
+
+	case model.AggregateTypeTRANS:
+		var t model.TransferEvent
+		if err := json.Unmarshal([]byte(e.Payload), &t); err != nil {
+			return dto, err
+		}
+		dto.TransID = t.TransID
+		if address == t.FromWalletAddress {
+			dto.Currency = t.Currency
+			dto.UpdatedBalance = t.FromBalance
+			dto.Change = t.FromChange
+			dto.CounterpartyWalletAddress = t.ToWalletAddress
+		} else {
+			// the payee of a cross-currency transfer holds the target currency
+			dto.Currency = t.Currency
+			if t.ToCurrency != "" {
+				dto.Currency = t.ToCurrency
+			}
+			dto.UpdatedBalance = t.ToBalance
+			dto.Change = t.ToChange
+			dto.CounterpartyWalletAddress = t.FromWalletAddress
+		}
+	}
+
+	dto.UpdatedBalanceFormatted = currency.FormatAmount(dto.Currency, dto.UpdatedBalance)
+	dto.ChangeFormatted = currency.FormatAmount(dto.Currency, dto.Change)
+	return dto, nil
+}
@@ -1,14 +1,34 @@
 package dto
 
 import (
+	"github.com/dsthakur2711/wallet/currency"
 	"github.com/dsthakur2711/wallet/model"
 	"time"
 )
 
 type TransResultDto struct{
+	ID              int64       `json:"id"`
 	FromWalletAdd   string      `json:"from_wallet_address"`
 	ToWalletAdd     string      `json:"to_wallet_address"`
+	// Amount is the gross amount debited from the payer, NetAmount the amount credited to the payee
 	Amount       int64     		`json:"amount"`
+	AmountFormatted string      `json:"amount_formatted"`
+	Fee             int64       `json:"fee"`
+	FeeFormatted    string      `json:"fee_formatted"`
+	NetAmount       int64       `json:"net_amount"`
+	NetAmountFormatted string   `json:"net_amount_formatted"`
+	Currency     string     	`json:"currency"`
+	// the fx fields are only set on cross-currency transfers
+	Rate              string    `json:"rate,omitempty"`
+	ToAmount          int64     `json:"to_amount,omitempty"`
+	ToAmountFormatted string    `json:"to_amount_formatted,omitempty"`
+	ToCurrency        string    `json:"to_currency,omitempty"`
+	Kind              model.TransKind `json:"kind"`
+	// OriginalTransID is set on refunds and reversals
+	OriginalTransID   int64     `json:"original_trans_id,omitempty"`
+	RefundedAmount    int64     `json:"refunded_amount,omitempty"`
+	ReversalTransID   int64     `json:"reversal_trans_id,omitempty"`
+	Shortfall         int64     `json:"shortfall,omitempty"`
 	CreatedAt    time.Time 		`json:"created_at"`
 }
 
@@ -18,10 +38,69 @@ type CreditDto struct {
 }
 
 func NewTransResultDto(trans model.Trans) TransResultDto{
-	return TransResultDto{
+	dto := TransResultDto{
+		ID:            trans.ID,
 		FromWalletAdd: trans.FromWalletAdd,
 		ToWalletAdd: 	trans.ToWalletAdd,
 		Amount: 		trans.Amount,
+		AmountFormatted: currency.FormatAmount(trans.Currency, trans.Amount),
+		Fee:            trans.Fee,
+		FeeFormatted:   currency.FormatAmount(trans.Currency, trans.Fee),
+		NetAmount:      trans.NetAmount,
+		NetAmountFormatted: currency.FormatAmount(trans.Currency, trans.NetAmount),
+		Currency: 		trans.Currency,
+		Kind:           trans.Kind,
+		OriginalTransID: trans.OriginalTransID,
+		RefundedAmount: trans.RefundedAmount,
+		ReversalTransID: trans.ReversalTransID,
+		Shortfall:      trans.Shortfall,
 		CreatedAt: 		trans.CreatedAt,
 	}
-}
\ No newline at end of file
+
+	// transfers made before refunds existed have no kind
+	if dto.Kind == "" {
+		dto.Kind = model.TransKindTRANSFER
+	}
+
+	if trans.ToCurrency != "" {
+		dto.Rate = trans.Rate
+		dto.ToAmount = trans.ToAmount
+		dto.ToAmountFormatted = currency.FormatAmount(trans.ToCurrency, trans.ToAmount)
+		dto.ToCurrency = trans.ToCurrency
+	}
+
+	return dto
+}
+
+// RefundDto refunds Amount of a transfer, zero refunds all that is left
+type RefundDto struct {
+	Amount int64 `json:"amount" validate:"gte=0"`
+}
+
+type ReverseDto struct {
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+type ListTransactionsDto struct {
+	Direction   string    `validate:"omitempty,oneof=incoming outgoing"`
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	MinAmount   int64 `validate:"gte=0"`
+	MaxAmount   int64 `validate:"gte=0"`
+	Cursor      string
+	Limit       int `validate:"gte=0,lte=100"`
+}
+
+type TransactionPageDto struct {
+	Transactions []TransResultDto `json:"transactions"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func NewTransResultDtos(transfers []model.Trans) []TransResultDto {
+	dtos := make([]TransResultDto, 0, len(transfers))
+	for _, trans := range transfers {
+		dtos = append(dtos, NewTransResultDto(trans))
+	}
+	return dtos
+}
@@ -31,11 +31,35 @@ type UserDto struct {
 type LoginCredentialsDto struct {
 	Username string `json:"username" validate:"required,alphanum"`
 	Password string `json:"password" validate:"required,min=6"`
+	// filled from the request, stored on the session
+	UserAgent string `json:"-"`
+	ClientIp  string `json:"-"`
 }
 
 type LoggedInUserDto struct {
-	//AccessToken string  `json:"access_token"`
-	User        UserDto `json:"user"`
+	SessionID             string    `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	User                  UserDto   `json:"user"`
+}
+
+type RenewAccessTokenDto struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RenewAccessTokenResultDto struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+type LogoutDto struct {
+	SessionID string `json:"session_id" validate:"required"`
+}
+
+type UpdateUserStatusDto struct {
+	Status model.UserStatus `json:"status" validate:"required,oneof=ACTIVE BLOCKED"`
 }
 
 func NewUserDto(user model.User) UserDto {
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"github.com/dsthakur2711/wallet/currency"
 	"github.com/dsthakur2711/wallet/model"
 	"github.com/dsthakur2711/wallet/store"
 	"time"
@@ -8,12 +9,18 @@ import (
 
 type TransferMoneyDto struct {
 	FromWalletAddress string `json:"from_wallet_address" binding:"required"`
-	ToWalletAddress   string `json:"to_wallet_address" binding:"required"`
+	ToWalletAddress   string `json:"to_wallet_address"`
+	// ToUsername pays the recipient's primary wallet, or its wallet in Currency, used when ToWalletAddress is empty
+	ToUsername        string `json:"to_username"`
+	// Currency fixes the currency paid in, it has to be the one of the payer's wallet
+	Currency          string `json:"currency"`
 	Amount            int64  `json:"amount" validate:"required"`
 }
 
 type UpdatedWalletBalanceDto struct {
 	UpdatedBalance	int64		`json:"updated_balance" validate:"required" `
+	// UpdatedBalanceFormatted is UpdatedBalance as a decimal string in the currency's major unit
+	UpdatedBalanceFormatted string `json:"updated_balance_formatted"`
 	Currency        string  	`json:"currency" validate:"required" `
 	UpdatedAt       time.Time   `json:"updated_at" `
 }
@@ -29,11 +36,18 @@ type CreateWalletDto struct {
 
 type WalletDto struct {
 	ID                   int64        `json:"id" validate:"required" `
-	Username          	 string       `json:"username;unique" validate:"required" `
-	WalletAddress 		 string       `json:"address;unique" validate:"required" `
+	Username          	 string       `json:"username" validate:"required" `
+	WalletAddress 		 string       `json:"wallet_address" validate:"required" `
 	Status               model.WalletStatus `json:"status" validate:"required" `
-	//UserID               int64        `json:"user_id" validate:"required" `
+	UserID               int64        `json:"user_id" validate:"required" `
+	IsPrimary            bool         `json:"is_primary"`
 	Balance              int64        `json:"balance" validate:"required" `
+	// BalanceFormatted is Balance as a decimal string in the currency's major unit
+	BalanceFormatted     string       `json:"balance_formatted"`
+	// AvailableBalance is Balance without the funds reserved by active holds
+	HeldBalance          int64        `json:"held_balance"`
+	AvailableBalance     int64        `json:"available_balance"`
+	AvailableBalanceFormatted string  `json:"available_balance_formatted"`
 	Currency             string       `json:"currency" validate:"required" `
 	CreatedAt            time.Time    `json:"created_at" validate:"required" `
 	UpdatedAt            time.Time    `json:"updated_at" validate:"required" `
@@ -53,16 +67,31 @@ func NewWalletDto(wallet model.Wallet) WalletDto {
 		Username:          	  wallet.Username,
 		WalletAddress:        wallet.WalletAddress,
 		Status:               wallet.Status,
+		UserID:               wallet.UserID,
+		IsPrimary:            wallet.IsPrimary,
 		Balance:              wallet.Balance,
+		BalanceFormatted:     currency.FormatAmount(wallet.Currency, wallet.Balance),
+		HeldBalance:          wallet.HeldBalance,
+		AvailableBalance:     wallet.AvailableBalance(),
+		AvailableBalanceFormatted: currency.FormatAmount(wallet.Currency, wallet.AvailableBalance()),
 		Currency:             wallet.Currency,
 		CreatedAt:            wallet.CreatedAt,
 		UpdatedAt:            wallet.UpdatedAt,
 	}
 }
 
+func NewWalletDtos(wallets []model.Wallet) []WalletDto {
+	dtos := make([]WalletDto, 0, len(wallets))
+	for _, wallet := range wallets {
+		dtos = append(dtos, NewWalletDto(wallet))
+	}
+	return dtos
+}
+
 func NewUpdatedWalletBalanceDto(wallet model.Wallet) UpdatedWalletBalanceDto {
 	return UpdatedWalletBalanceDto{
 		UpdatedBalance: wallet.Balance,
+		UpdatedBalanceFormatted: currency.FormatAmount(wallet.Currency, wallet.Balance),
 		Currency:       wallet.Currency,
 		UpdatedAt:      wallet.UpdatedAt,
 	}
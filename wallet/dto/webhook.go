@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+)
+
+// CreateWebhookDto subscribes URL to the events of the user's wallets, no event types means every event
+type CreateWebhookDto struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"dive,required"`
+}
+
+type WebhookSubscriptionDto struct {
+	ID         int64             `json:"id"`
+	URL        string            `json:"url"`
+	EventTypes []model.EventType `json:"event_types"`
+	// Secret signs the deliveries, it is only returned when the subscription is created
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewWebhookSubscriptionDto(s model.WebhookSubscription) WebhookSubscriptionDto {
+	eventTypes := s.Filter()
+	if eventTypes == nil {
+		eventTypes = []model.EventType{}
+	}
+	return WebhookSubscriptionDto{
+		ID:         s.ID,
+		URL:        s.URL,
+		EventTypes: eventTypes,
+		Active:     s.Active,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func NewWebhookSubscriptionDtos(subscriptions []model.WebhookSubscription) []WebhookSubscriptionDto {
+	dtos := make([]WebhookSubscriptionDto, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		dtos = append(dtos, NewWebhookSubscriptionDto(s))
+	}
+	return dtos
+}
+
+type WebhookDeliveryDto struct {
+	ID             int64                       `json:"id"`
+	SubscriptionID int64                       `json:"subscription_id"`
+	EventID        string                      `json:"event_id"`
+	EventType      model.EventType             `json:"event_type"`
+	Status         model.WebhookDeliveryStatus `json:"status"`
+	Attempts       int                         `json:"attempts"`
+	// NextAttemptAt is only set on pending deliveries, DeliveredAt on successful ones
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func NewWebhookDeliveryDto(d model.WebhookDelivery) WebhookDeliveryDto {
+	dto := WebhookDeliveryDto{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		Payload:        json.RawMessage(d.Payload),
+		CreatedAt:      d.CreatedAt,
+	}
+
+	switch d.Status {
+	case model.WebhookDeliveryStatusPENDING:
+		nextAttemptAt := d.NextAttemptAt
+		dto.NextAttemptAt = &nextAttemptAt
+	case model.WebhookDeliveryStatusSUCCEEDED:
+		deliveredAt := d.DeliveredAt
+		dto.DeliveredAt = &deliveredAt
+	}
+
+	return dto
+}
+
+func NewWebhookDeliveryDtos(deliveries []model.WebhookDelivery) []WebhookDeliveryDto {
+	dtos := make([]WebhookDeliveryDto, 0, len(deliveries))
+	for _, d := range deliveries {
+		dtos = append(dtos, NewWebhookDeliveryDto(d))
+	}
+	return dtos
+}
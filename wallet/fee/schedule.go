@@ -0,0 +1,261 @@
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/dsthakur2711/wallet/model"
+)
+
+type Kind string
+
+const (
+	// KindFLAT charges Flat minor units whatever the amount
+	KindFLAT Kind = "FLAT"
+	// KindPERCENTAGE charges Percentage percent of the amount
+	KindPERCENTAGE Kind = "PERCENTAGE"
+	// KindTIERED charges the flat and percentage parts of the band the amount falls in
+	KindTIERED Kind = "TIERED"
+)
+
+// AnyTier matches users of every tier
+const AnyTier model.UserTier = ""
+
+// Band is a step of a tiered rule, it applies to amounts up to UpTo, the last band has UpTo 0 and no limit
+type Band struct {
+	UpTo       int64  `json:"up_to"`
+	Flat       int64  `json:"flat"`
+	Percentage string `json:"percentage"`
+}
+
+// Rule is the fee of a currency for a user tier, amounts are minor units of the currency
+type Rule struct {
+	Currency   string         `json:"currency"`
+	Tier       model.UserTier `json:"tier"`
+	Kind       Kind           `json:"kind"`
+	Flat       int64          `json:"flat"`
+	Percentage string         `json:"percentage"`
+	Bands      []Band         `json:"bands"`
+	// Min and Max cap the computed fee, a Max of 0 means no cap
+	Min    int64           `json:"min"`
+	Max    int64           `json:"max"`
+	Bearer model.FeeBearer `json:"bearer"`
+
+	percentage *big.Rat
+	bands      []band
+}
+
+type band struct {
+	upTo       int64
+	flat       int64
+	percentage *big.Rat
+}
+
+// Quote is the split of a transfer, Gross is debited from the payer and Net credited to the payee
+type Quote struct {
+	Amount int64
+	Fee    int64
+	Gross  int64
+	Net    int64
+	Bearer model.FeeBearer
+}
+
+// Schedule holds the fee rules, a transfer without a matching rule is free
+type Schedule struct {
+	rules map[string]Rule
+}
+
+type scheduleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// NewSchedule checks the rules and builds a schedule, there is at most one rule per currency and tier
+func NewSchedule(rules []Rule) (*Schedule, error) {
+	s := &Schedule{rules: make(map[string]Rule, len(rules))}
+
+	for _, r := range rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("fee rule %s/%s: %w", r.Currency, r.Tier, err)
+		}
+
+		k := ruleKey(r.Currency, r.Tier)
+		if _, ok := s.rules[k]; ok {
+			return nil, fmt.Errorf("duplicate fee rule for %s/%s", r.Currency, r.Tier)
+		}
+		s.rules[k] = r
+	}
+
+	return s, nil
+}
+
+// Free returns a schedule without rules, every transfer is free
+func Free() *Schedule {
+	return &Schedule{rules: map[string]Rule{}}
+}
+
+// LoadSchedule reads a schedule file: {"rules": [{"currency": "INR", "kind": "FLAT", "flat": 500}]}
+func LoadSchedule(r io.Reader) (*Schedule, error) {
+	var file scheduleFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not decode fee schedule: %w", err)
+	}
+	return NewSchedule(file.Rules)
+}
+
+// Quote computes the fee of a transfer of amount in currency made by a user of tier.
+// The rule of the user's tier wins over the rule for any tier of the same currency.
+func (s *Schedule) Quote(currency string, tier model.UserTier, amount int64) (Quote, error) {
+
+	if amount <= 0 {
+		return Quote{}, fmt.Errorf("amount should be positive")
+	}
+
+	rule, ok := s.rules[ruleKey(currency, tier)]
+	if !ok {
+		rule, ok = s.rules[ruleKey(currency, AnyTier)]
+	}
+	if !ok {
+		return Quote{Amount: amount, Gross: amount, Net: amount, Bearer: model.FeeBearerPAYER}, nil
+	}
+
+	fee := rule.fee(amount)
+
+	q := Quote{Amount: amount, Fee: fee, Bearer: rule.Bearer}
+	if rule.Bearer == model.FeeBearerPAYEE {
+		if fee >= amount {
+			return Quote{}, fmt.Errorf("amount does not cover the fee of %d", fee)
+		}
+		q.Gross = amount
+		q.Net = amount - fee
+	} else {
+		q.Gross = amount + fee
+		q.Net = amount
+	}
+
+	return q, nil
+}
+
+// Rules returns the rules ordered by currency and tier
+func (s *Schedule) Rules() []Rule {
+	rules := make([]Rule, 0, len(s.rules))
+	for _, r := range s.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return ruleKey(rules[i].Currency, rules[i].Tier) < ruleKey(rules[j].Currency, rules[j].Tier)
+	})
+	return rules
+}
+
+func (r *Rule) compile() error {
+
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if r.Bearer == "" {
+		r.Bearer = model.FeeBearerPAYER
+	}
+	if r.Bearer != model.FeeBearerPAYER && r.Bearer != model.FeeBearerPAYEE {
+		return fmt.Errorf("invalid bearer %q", r.Bearer)
+	}
+	if r.Min < 0 || r.Max < 0 || (r.Max > 0 && r.Min > r.Max) {
+		return fmt.Errorf("invalid min %d and max %d", r.Min, r.Max)
+	}
+
+	switch r.Kind {
+	case KindFLAT:
+		if r.Flat < 0 {
+			return fmt.Errorf("flat fee can not be negative")
+		}
+	case KindPERCENTAGE:
+		p, err := parsePercentage(r.Percentage)
+		if err != nil {
+			return err
+		}
+		r.percentage = p
+	case KindTIERED:
+		if len(r.Bands) == 0 {
+			return fmt.Errorf("tiered rule needs bands")
+		}
+		r.bands = make([]band, 0, len(r.Bands))
+		for i, b := range r.Bands {
+			last := i == len(r.Bands)-1
+			if last != (b.UpTo == 0) {
+				return fmt.Errorf("only the last band has no up_to")
+			}
+			if i > 0 && !last && b.UpTo <= r.Bands[i-1].UpTo {
+				return fmt.Errorf("bands must be ordered by up_to")
+			}
+			if b.Flat < 0 {
+				return fmt.Errorf("flat fee can not be negative")
+			}
+			p := new(big.Rat)
+			if b.Percentage != "" {
+				var err error
+				if p, err = parsePercentage(b.Percentage); err != nil {
+					return err
+				}
+			}
+			r.bands = append(r.bands, band{upTo: b.UpTo, flat: b.Flat, percentage: p})
+		}
+	default:
+		return fmt.Errorf("invalid kind %q", r.Kind)
+	}
+
+	return nil
+}
+
+// fee computes the capped fee of the rule for the amount
+func (r *Rule) fee(amount int64) int64 {
+
+	var fee int64
+	switch r.Kind {
+	case KindFLAT:
+		fee = r.Flat
+	case KindPERCENTAGE:
+		fee = percentOf(amount, r.percentage)
+	case KindTIERED:
+		for _, b := range r.bands {
+			if b.upTo == 0 || amount <= b.upTo {
+				fee = b.flat + percentOf(amount, b.percentage)
+				break
+			}
+		}
+	}
+
+	if fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return fee
+}
+
+// percentOf returns percentage percent of amount in minor units, halves are rounded up
+func percentOf(amount int64, percentage *big.Rat) int64 {
+	if percentage.Sign() == 0 {
+		return 0
+	}
+
+	exact := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), percentage)
+	exact.Quo(exact, big.NewRat(100, 1))
+	exact.Add(exact, big.NewRat(1, 2))
+
+	return new(big.Int).Quo(exact.Num(), exact.Denom()).Int64()
+}
+
+func parsePercentage(s string) (*big.Rat, error) {
+	p, ok := new(big.Rat).SetString(s)
+	if !ok || p.Sign() < 0 || p.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, fmt.Errorf("invalid percentage %q", s)
+	}
+	return p, nil
+}
+
+func ruleKey(currency string, tier model.UserTier) string {
+	return currency + "/" + string(tier)
+}
@@ -0,0 +1,122 @@
+package fee
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestSchedule(t *testing.T) *Schedule {
+	f, err := os.Open("testdata/schedule.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	s, err := LoadSchedule(f)
+	require.NoError(t, err)
+	return s
+}
+
+func TestQuote(t *testing.T) {
+	s := loadTestSchedule(t)
+
+	testCases := []struct {
+		name     string
+		currency string
+		tier     model.UserTier
+		amount   int64
+		want     Quote
+	}{
+		{
+			// 1.5% of 100.00 is 1.50
+			name: "percentage", currency: "INR", tier: model.UserTierSTANDARD, amount: 10000,
+			want: Quote{Amount: 10000, Fee: 150, Gross: 10150, Net: 10000, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			// 1.5% of 0.33 is 0.00495, halves round up to 0.01 but the minimum is 1.00
+			name: "min", currency: "INR", tier: model.UserTierSTANDARD, amount: 33,
+			want: Quote{Amount: 33, Fee: 100, Gross: 133, Net: 33, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			// 1.5% of 10000.00 is 150.00, capped at 50.00
+			name: "max", currency: "INR", tier: model.UserTierSTANDARD, amount: 1000000,
+			want: Quote{Amount: 1000000, Fee: 5000, Gross: 1005000, Net: 1000000, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			// 1.5% of 123.30 is 1.8495, rounded to 1.85
+			name: "rounds half up", currency: "INR", tier: model.UserTierSTANDARD, amount: 12330,
+			want: Quote{Amount: 12330, Fee: 185, Gross: 12515, Net: 12330, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			name: "tier rule wins", currency: "INR", tier: model.UserTierPREMIUM, amount: 10000,
+			want: Quote{Amount: 10000, Fee: 0, Gross: 10000, Net: 10000, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			name: "payee bears the fee", currency: "USD", tier: model.UserTierSTANDARD, amount: 1000,
+			want: Quote{Amount: 1000, Fee: 25, Gross: 1000, Net: 975, Bearer: model.FeeBearerPAYEE},
+		},
+		{
+			name: "first band", currency: "EUR", tier: model.UserTierSTANDARD, amount: 10000,
+			want: Quote{Amount: 10000, Fee: 50, Gross: 10050, Net: 10000, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			// 0.50 + 0.5% of 500.00
+			name: "second band", currency: "EUR", tier: model.UserTierSTANDARD, amount: 50000,
+			want: Quote{Amount: 50000, Fee: 300, Gross: 50300, Net: 50000, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			// 0.25% of 10000.00 is 25.00, capped at 20.00
+			name: "last band", currency: "EUR", tier: model.UserTierSTANDARD, amount: 1000000,
+			want: Quote{Amount: 1000000, Fee: 2000, Gross: 1002000, Net: 1000000, Bearer: model.FeeBearerPAYER},
+		},
+		{
+			name: "no rule", currency: "GBP", tier: model.UserTierSTANDARD, amount: 1000,
+			want: Quote{Amount: 1000, Fee: 0, Gross: 1000, Net: 1000, Bearer: model.FeeBearerPAYER},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := s.Quote(tc.currency, tc.tier, tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, q)
+		})
+	}
+}
+
+func TestQuotePayeeFeeExceedsAmount(t *testing.T) {
+	s := loadTestSchedule(t)
+
+	_, err := s.Quote("USD", model.UserTierSTANDARD, 25)
+	require.Error(t, err)
+
+	_, err = s.Quote("USD", model.UserTierSTANDARD, 0)
+	require.Error(t, err)
+}
+
+func TestLoadScheduleRejectsInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"no currency", `{"rules": [{"kind": "FLAT", "flat": 1}]}`},
+		{"unknown kind", `{"rules": [{"currency": "INR", "kind": "FREE"}]}`},
+		{"bad percentage", `{"rules": [{"currency": "INR", "kind": "PERCENTAGE", "percentage": "abc"}]}`},
+		{"percentage above 100", `{"rules": [{"currency": "INR", "kind": "PERCENTAGE", "percentage": "101"}]}`},
+		{"min above max", `{"rules": [{"currency": "INR", "kind": "FLAT", "min": 10, "max": 5}]}`},
+		{"bad bearer", `{"rules": [{"currency": "INR", "kind": "FLAT", "bearer": "BANK"}]}`},
+		{"duplicate", `{"rules": [{"currency": "INR", "kind": "FLAT"}, {"currency": "INR", "kind": "FLAT"}]}`},
+		{"no bands", `{"rules": [{"currency": "INR", "kind": "TIERED"}]}`},
+		{"unbounded band not last", `{"rules": [{"currency": "INR", "kind": "TIERED", "bands": [{"flat": 1}, {"up_to": 5, "flat": 1}]}]}`},
+		{"unordered bands", `{"rules": [{"currency": "INR", "kind": "TIERED", "bands": [{"up_to": 10}, {"up_to": 5}, {}]}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadSchedule(strings.NewReader(tc.data))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/sirupsen/logrus"
+)
+
+// HTTPRateProvider asks a rate service with GET {baseURL}?from=USD&to=INR,
+// answered by {"from": "USD", "to": "INR", "rate": "83.10"}
+type HTTPRateProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+type httpRateResponse struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rate string `json:"rate"`
+}
+
+// NewHTTPRateProvider creates a provider for the rate service at baseURL
+func NewHTTPRateProvider(baseURL string, client *http.Client) (*HTTPRateProvider, error) {
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("invalid rate service url: %w", err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &HTTPRateProvider{baseURL: baseURL, client: client}, nil
+}
+
+func (p *HTTPRateProvider) GetRate(ctx context.Context, from string, to string) (Rate, error) {
+
+	u, err := url.Parse(p.baseURL)
+	if err != nil {
+		return Rate{}, err
+	}
+	q := u.Query()
+	q.Set("from", from)
+	q.Set("to", to)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		logrus.Println("log  rate service request failed in fx/http_provider/GetRate ", err)
+		return Rate{}, local_errors.ErrFXRateUnavailable
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logrus.Println("log  rate service answered with status in fx/http_provider/GetRate ", resp.StatusCode)
+		return Rate{}, local_errors.ErrFXRateUnavailable
+	}
+
+	var body httpRateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Rate{}, local_errors.ErrFXRateUnavailable
+	}
+
+	if body.From != from || body.To != to {
+		return Rate{}, fmt.Errorf("rate service answered for %s/%s instead of %s/%s", body.From, body.To, from, to)
+	}
+
+	value, err := ParseRate(body.Rate)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{From: from, To: to, Value: value, AsOf: time.Now()}, nil
+}
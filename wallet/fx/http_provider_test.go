@@ -0,0 +1,51 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPRateProvider(t *testing.T) {
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		if from != "USD" || to != "INR" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(httpRateResponse{From: from, To: to, Rate: "83.25"})
+	}))
+	defer stub.Close()
+
+	provider, err := NewHTTPRateProvider(stub.URL+"/rates", nil)
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(context.Background(), "USD", "INR")
+	require.NoError(t, err)
+	require.Equal(t, "83.25000000", FormatRate(rate.Value))
+
+	_, err = provider.GetRate(context.Background(), "USD", "EUR")
+	require.ErrorIs(t, err, local_errors.ErrFXRateUnavailable)
+}
+
+func TestHTTPRateProviderUnreachable(t *testing.T) {
+	stub := httptest.NewServer(http.NotFoundHandler())
+	url := stub.URL
+	stub.Close()
+
+	provider, err := NewHTTPRateProvider(url, nil)
+	require.NoError(t, err)
+
+	_, err = provider.GetRate(context.Background(), "USD", "INR")
+	require.ErrorIs(t, err, local_errors.ErrFXRateUnavailable)
+}
+
+func TestNewHTTPRateProviderInvalidURL(t *testing.T) {
+	_, err := NewHTTPRateProvider("not a url", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,31 @@
+package fx
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Rate is the price of one unit of From expressed in To
+type Rate struct {
+	From  string
+	To    string
+	Value *big.Rat
+	AsOf  time.Time
+}
+
+// FXRateProvider is an interface for looking up exchange rates
+type FXRateProvider interface {
+	// GetRate returns the current rate to convert from into to
+	GetRate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+// ParseRate parses a positive decimal rate like "83.125"
+func ParseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q", s)
+	}
+	return r, nil
+}
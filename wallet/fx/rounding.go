@@ -0,0 +1,59 @@
+package fx
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// RateScale is the number of decimal places a quoted rate is rounded to
+const RateScale = 8
+
+// RoundRate rounds the rate to RateScale decimal places, halves are rounded up.
+// The rounded rate is the one shown on the quote and used for the conversion.
+func RoundRate(rate *big.Rat) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(RateScale), nil)
+
+	scaled := new(big.Rat).Mul(rate, new(big.Rat).SetInt(scale))
+	// floor(x + 1/2) for the positive rates we deal with
+	scaled.Add(scaled, big.NewRat(1, 2))
+	units := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	return new(big.Rat).SetFrac(units, scale)
+}
+
+// FormatRate renders the rate with RateScale decimal places
+func FormatRate(rate *big.Rat) string {
+	return rate.FloatString(RateScale)
+}
+
+// Convert converts an amount of minor units of the source currency into minor units
+// of the target currency. The exact result is truncated toward zero, so the payee is
+// never credited more than the exact converted value and fractions of the target
+// minor unit stay with the platform.
+func Convert(amount int64, rate *big.Rat, fromMinorUnits int, toMinorUnits int) (int64, error) {
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+
+	shift := toMinorUnits - fromMinorUnits
+	pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil))
+	if shift >= 0 {
+		converted.Mul(converted, pow)
+	} else {
+		converted.Quo(converted, pow)
+	}
+
+	// big.Int.Quo truncates toward zero
+	result := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows")
+	}
+
+	return result.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package fx
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundRate(t *testing.T) {
+	testCases := []struct {
+		rate *big.Rat
+		want string
+	}{
+		{big.NewRat(8310, 100), "83.10000000"},
+		// 1/3 rounds down at the 8th place
+		{big.NewRat(1, 3), "0.33333333"},
+		// 2/3 rounds up at the 8th place
+		{big.NewRat(2, 3), "0.66666667"},
+		// exactly half of the last place rounds up
+		{big.NewRat(5, 1000000000), "0.00000001"},
+		{big.NewRat(4, 1000000000), "0.00000000"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, FormatRate(RoundRate(tc.rate)))
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name      string
+		amount    int64
+		rate      string
+		fromMinor int
+		toMinor   int
+		want      int64
+	}{
+		// 10.00 USD at 83.10 is 831.00 INR
+		{"exact", 1000, "83.10", 2, 2, 83100},
+		// 0.01 USD at 0.92 is 0.0092 EUR, truncated to 0.00
+		{"below one minor unit", 1, "0.92", 2, 2, 0},
+		// 1.99 EUR at 1.0869565 is 2.16304343... USD, truncated to 2.16
+		{"truncates", 199, "1.0869565", 2, 2, 216},
+		// 10.00 USD at 149.50 is 1495 JPY, JPY has no minor unit
+		{"to zero minor units", 1000, "149.50", 2, 0, 1495},
+		// 1495 JPY at 0.00668896 is 10.0000 USD minus a fraction, truncated to 9.99
+		{"from zero minor units", 1495, "0.00668896", 0, 2, 999},
+		// 1.00 USD at 0.3075 is 0.307500 KWD, KWD has three minor units
+		{"to three minor units", 100, "0.3075", 2, 3, 307},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := ParseRate(tc.rate)
+			require.NoError(t, err)
+
+			got, err := Convert(tc.amount, rate, tc.fromMinor, tc.toMinor)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestConvertOverflow(t *testing.T) {
+	_, err := Convert(math.MaxInt64, big.NewRat(2, 1), 2, 2)
+	require.Error(t, err)
+}
+
+func TestParseRate(t *testing.T) {
+	for _, s := range []string{"", "abc", "0", "-1.5"} {
+		_, err := ParseRate(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,85 @@
+package fx
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+)
+
+//go:embed rates.json
+var bundledRates []byte
+
+// StaticRateProvider serves fixed rates quoted against a base currency,
+// cross rates are derived through the base
+type StaticRateProvider struct {
+	base  string
+	rates map[string]*big.Rat
+	asOf  time.Time
+}
+
+// RatesFile is the layout of a rates file: {"base": "USD", "rates": {"INR": "83.10"}}
+type RatesFile struct {
+	Base  string            `json:"base"`
+	Rates map[string]string `json:"rates"`
+}
+
+// NewStaticRateProvider creates a provider where rates[c] is the price of one base unit in c
+func NewStaticRateProvider(base string, rates map[string]string) (*StaticRateProvider, error) {
+	if base == "" {
+		return nil, fmt.Errorf("base currency is required")
+	}
+
+	provider := &StaticRateProvider{
+		base:  base,
+		rates: map[string]*big.Rat{base: big.NewRat(1, 1)},
+		asOf:  time.Now(),
+	}
+
+	for code, value := range rates {
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate for %s: %w", code, err)
+		}
+		provider.rates[code] = rate
+	}
+
+	return provider, nil
+}
+
+// LoadStaticRateProvider reads a rates file
+func LoadStaticRateProvider(r io.Reader) (*StaticRateProvider, error) {
+	var file RatesFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not decode rates: %w", err)
+	}
+	return NewStaticRateProvider(file.Base, file.Rates)
+}
+
+// DefaultStaticRateProvider returns the provider for the bundled rates.json
+func DefaultStaticRateProvider() (*StaticRateProvider, error) {
+	return LoadStaticRateProvider(strings.NewReader(string(bundledRates)))
+}
+
+func (p *StaticRateProvider) GetRate(ctx context.Context, from string, to string) (Rate, error) {
+
+	fromRate, ok := p.rates[from]
+	if !ok {
+		return Rate{}, local_errors.ErrFXRateUnavailable
+	}
+	toRate, ok := p.rates[to]
+	if !ok {
+		return Rate{}, local_errors.ErrFXRateUnavailable
+	}
+
+	// one unit of from is 1/fromRate base units, which is toRate/fromRate units of to
+	value := new(big.Rat).Quo(toRate, fromRate)
+
+	return Rate{From: from, To: to, Value: value, AsOf: p.asOf}, nil
+}
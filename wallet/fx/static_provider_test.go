@@ -0,0 +1,50 @@
+package fx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	provider, err := LoadStaticRateProvider(strings.NewReader(`{"base": "USD", "rates": {"INR": "80", "EUR": "0.8"}}`))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	rate, err := provider.GetRate(ctx, "USD", "INR")
+	require.NoError(t, err)
+	require.Equal(t, "80.00000000", FormatRate(rate.Value))
+
+	rate, err = provider.GetRate(ctx, "INR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "0.01250000", FormatRate(rate.Value))
+
+	// cross rate through the base
+	rate, err = provider.GetRate(ctx, "EUR", "INR")
+	require.NoError(t, err)
+	require.Equal(t, "100.00000000", FormatRate(rate.Value))
+
+	_, err = provider.GetRate(ctx, "USD", "GBP")
+	require.ErrorIs(t, err, local_errors.ErrFXRateUnavailable)
+}
+
+func TestDefaultStaticRateProvider(t *testing.T) {
+	provider, err := DefaultStaticRateProvider()
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(context.Background(), "USD", "INR")
+	require.NoError(t, err)
+	require.Equal(t, 1, rate.Value.Sign())
+}
+
+func TestLoadStaticRateProviderRejectsInvalidRates(t *testing.T) {
+	_, err := LoadStaticRateProvider(strings.NewReader(`{"base": "USD", "rates": {"INR": "-1"}}`))
+	require.Error(t, err)
+
+	_, err = LoadStaticRateProvider(strings.NewReader(`{"rates": {"INR": "80"}}`))
+	require.Error(t, err)
+}
@@ -3,22 +3,29 @@ module github.com/dsthakur2711/wallet
 go 1.16
 
 require (
-	github.com/go-chi/chi v1.5.4 // indirect
-	github.com/go-chi/httprate v0.5.1 // indirect
-	github.com/go-chi/render v1.0.1 // indirect
-	github.com/go-playground/validator v9.31.0+incompatible // indirect
+	github.com/aead/chacha20poly1305 v0.0.0-20201124145622-1a5aba2a8b29
+	github.com/go-chi/chi v1.5.4
+	github.com/go-chi/httprate v0.5.1
+	github.com/go-chi/render v1.0.1
+	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/go-playground/validator/v10 v10.9.0 // indirect
-	github.com/go-sql-driver/mysql v1.6.0 // indirect
+	github.com/go-sql-driver/mysql v1.6.0
+	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/gorilla/mux v1.8.0 // indirect
-	github.com/jinzhu/gorm v1.9.16 // indirect
+	github.com/jinzhu/gorm v1.9.16
 	github.com/lib/pq v1.1.1 // indirect
-	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
+	github.com/mattn/go-sqlite3 v1.14.0 // indirect
+	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d
+	github.com/o1egl/paseto v1.0.0
+	github.com/pelletier/go-toml v1.2.0
+	github.com/sirupsen/logrus v1.8.1
+	github.com/stretchr/testify v1.7.0
 	github.com/vektra/mockery/v2 v2.9.0 // indirect
-	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97 // indirect
-	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110 // indirect
+	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97
+	golang.org/x/net v0.0.0-20210226172049-e18ecbb05110
 	golang.org/x/sys v0.0.0-20210823070655-63515b42dcdf // indirect
 	gopkg.in/tylerb/graceful.v1 v1.2.15 // indirect
+	gopkg.in/yaml.v2 v2.2.4
 	gorm.io/driver/mysql v1.1.2 // indirect
 	gorm.io/gorm v1.21.14 // indirect
 )
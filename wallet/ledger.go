@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/database"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/store"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// ledgerCommand runs `wallet ledger verify | open | rebuild`.
+// verify lists the wallets whose cached balance is not the sum of their postings, open books the balance of the
+// wallets that predate the ledger as opening entries against the float, rebuild resets the cache of the other
+// wallets to the sum of their postings.
+func ledgerCommand(args []string) {
+
+	fs := flag.NewFlagSet("wallet ledger", flag.ExitOnError)
+	cfg := loadConfig(fs, args)
+	ctx := context.Background()
+
+	if cfg.Database.Driver == config.DriverMemory {
+		fail(errors.New("the memory driver keeps no ledger to check"))
+	}
+
+	action := "verify"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		fail(err)
+	}
+	if err := migrator.Verify(ctx); err != nil {
+		fail(fmt.Errorf("the database schema is not the one of this build, run `wallet migrate up`: %v", err))
+	}
+
+	ledgerRepo := store.NewLedgerRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), ledgerRepo, store.NewFXQuoteRepo(db))
+
+	mismatches, err := ledgerRepo.ListBalanceMismatches(ctx)
+	if err != nil {
+		fail(err)
+	}
+
+	switch action {
+	case "verify":
+		totals, err := ledgerRepo.GetBalanceTotals(ctx)
+		if err != nil {
+			fail(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "WALLET\tCURRENCY\tBALANCE\tPOSTINGS\tFIX")
+		for _, m := range mismatches {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", m.WalletAddress, m.Currency, m.Balance, m.PostingsBalance, ledgerFix(m))
+		}
+		w.Flush()
+
+		codes := make([]string, 0, len(totals))
+		for code, total := range totals {
+			if total != 0 {
+				codes = append(codes, code)
+			}
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Printf("wallet balances in %s add up to %d instead of 0\n", code, totals[code])
+		}
+
+		if len(mismatches) > 0 || len(codes) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println("the ledger is consistent")
+	case "open":
+		opened := 0
+		for _, m := range mismatches {
+			if ledgerFix(m) != "open" {
+				continue
+			}
+			// the float takes the other side of the opening entries
+			if _, err := walletRepo.EnsureOrganizationWallet(ctx, store.OrganizationWalletParams{Currency: m.Currency, Purpose: model.OrganizationWalletFLOAT}); err != nil {
+				fail(err)
+			}
+			if _, err := ledgerRepo.OpenWalletBalance(ctx, m.WalletAddress); err != nil {
+				fail(fmt.Errorf("opening wallet %s: %v", m.WalletAddress, err))
+			}
+			fmt.Printf("opened %s with %d %s\n", m.WalletAddress, m.Balance, m.Currency)
+			opened++
+		}
+		if opened == 0 {
+			fmt.Println("nothing to do")
+		}
+	case "rebuild":
+		rebuilt := 0
+		for _, m := range mismatches {
+			if ledgerFix(m) != "rebuild" {
+				continue
+			}
+			if _, err := ledgerRepo.RebuildWalletBalance(ctx, m.WalletAddress); err != nil {
+				fail(fmt.Errorf("rebuilding wallet %s: %v", m.WalletAddress, err))
+			}
+			fmt.Printf("rebuilt %s from %d to %d %s\n", m.WalletAddress, m.Balance, m.PostingsBalance, m.Currency)
+			rebuilt++
+		}
+		if rebuilt == 0 {
+			fmt.Println("nothing to do")
+		}
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+// ledgerFix names the action that fixes a mismatch. A wallet without postings predates the ledger and is opened,
+// rebuilding it would wipe its balance. The float is never opened, it is the other side of the opening entries.
+func ledgerFix(m store.BalanceMismatch) string {
+	if m.PostingsCount == 0 && m.Purpose != model.OrganizationWalletFLOAT {
+		return "open"
+	}
+	return "rebuild"
+}
@@ -0,0 +1,77 @@
+package limit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dsthakur2711/wallet/model"
+)
+
+// AnyTier matches users of every tier
+const AnyTier model.UserTier = ""
+
+// Rule is the transfer limit of a currency for a user tier, amounts are minor units of the currency
+type Rule struct {
+	Currency string         `json:"currency"`
+	Tier     model.UserTier `json:"tier"`
+	model.TransferLimit
+}
+
+// Policy holds the limit rules, transfers without a matching rule are not limited
+type Policy struct {
+	rules map[string]model.TransferLimit
+}
+
+type policyFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Unlimited returns a policy without rules
+func Unlimited() *Policy {
+	return &Policy{rules: map[string]model.TransferLimit{}}
+}
+
+// NewPolicy checks the rules and builds a policy, there is at most one rule per currency and tier
+func NewPolicy(rules []Rule) (*Policy, error) {
+	p := &Policy{rules: make(map[string]model.TransferLimit, len(rules))}
+
+	for _, r := range rules {
+		if r.Currency == "" {
+			return nil, fmt.Errorf("limit rule needs a currency")
+		}
+		l := r.TransferLimit
+		if l.PerTransaction < 0 || l.DailyAmount < 0 || l.DailyCount < 0 || l.MonthlyAmount < 0 || l.MonthlyCount < 0 {
+			return nil, fmt.Errorf("limit rule %s/%s: limits can not be negative", r.Currency, r.Tier)
+		}
+
+		k := ruleKey(r.Currency, r.Tier)
+		if _, ok := p.rules[k]; ok {
+			return nil, fmt.Errorf("duplicate limit rule for %s/%s", r.Currency, r.Tier)
+		}
+		p.rules[k] = l
+	}
+
+	return p, nil
+}
+
+// LoadPolicy reads a policy file: {"rules": [{"currency": "INR", "daily_amount": 5000000}]}
+func LoadPolicy(r io.Reader) (*Policy, error) {
+	var file policyFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not decode transfer limits: %w", err)
+	}
+	return NewPolicy(file.Rules)
+}
+
+// Lookup returns the limit of the tier in the currency, the rule of the tier wins over the rule for any tier
+func (p *Policy) Lookup(currency string, tier model.UserTier) model.TransferLimit {
+	if l, ok := p.rules[ruleKey(currency, tier)]; ok {
+		return l
+	}
+	return p.rules[ruleKey(currency, AnyTier)]
+}
+
+func ruleKey(currency string, tier model.UserTier) string {
+	return currency + "/" + string(tier)
+}
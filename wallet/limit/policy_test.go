@@ -0,0 +1,53 @@
+package limit
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	f, err := os.Open("testdata/limits.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	p, err := LoadPolicy(f)
+	require.NoError(t, err)
+
+	standard := p.Lookup("INR", model.UserTierSTANDARD)
+	require.Equal(t, int64(10000000), standard.PerTransaction)
+	require.Equal(t, int64(20), standard.DailyCount)
+
+	// the tier rule replaces the rule for any tier as a whole
+	premium := p.Lookup("INR", model.UserTierPREMIUM)
+	require.Equal(t, int64(50000000), premium.PerTransaction)
+	require.Equal(t, int64(0), premium.DailyCount)
+
+	usd := p.Lookup("USD", model.UserTierPREMIUM)
+	require.Equal(t, int64(5), usd.DailyCount)
+
+	gbp := p.Lookup("GBP", model.UserTierSTANDARD)
+	require.True(t, gbp.IsUnlimited())
+}
+
+func TestLoadPolicyRejectsInvalidRules(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{`},
+		{"no currency", `{"rules": [{"daily_count": 1}]}`},
+		{"negative", `{"rules": [{"currency": "INR", "daily_amount": -1}]}`},
+		{"duplicate", `{"rules": [{"currency": "INR"}, {"currency": "INR"}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadPolicy(strings.NewReader(tc.data))
+			require.Error(t, err)
+		})
+	}
+}
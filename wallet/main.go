@@ -1,40 +1,81 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"github.com/dsthakur2711/wallet/config"
 	"github.com/dsthakur2711/wallet/server"
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/jinzhu/gorm"
 	logs "github.com/sirupsen/logrus"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 )
 
-func dbConn() (db *gorm.DB) {
-	/*dbDriver := "mysql"
-	dbUser := "root"
-	dbPass := "password"
-	dbName := "walletDB"*/
-	//db, err := sql.Open("mysql", "root:password@tcp(127.0.0.1:3306)/walletDB")
-	db, err := gorm.Open("mysql", "root:password@tcp(127.0.0.1:3306)/walletDB?parseTime=true")
-	if err != nil {
-		panic(err.Error())
+const usage = `usage: wallet [serve] [--demo] [flags]
+       wallet migrate [flags] up [version] | down [steps] | status
+       wallet seed [--users n] [flags] [fixture.yaml|fixture.json ...]
+       wallet ledger [flags] verify | open | rebuild
+
+Run "wallet <command> -h" for the flags.`
+
+func main() {
+
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		serve(args)
+	case "migrate":
+		migrateCommand(args)
+	case "seed":
+		seedCommand(args)
+	case "ledger":
+		ledgerCommand(args)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
 	}
-	return db
 }
 
-func main() {
+func serve(args []string) {
 
-	// SetFormatter sets the standard logger formatter.
-	logs.SetFormatter(&logs.TextFormatter{})
-	logs.Println("starting wallet service")
+	fs := flag.NewFlagSet("wallet serve", flag.ExitOnError)
+	fs.Bool("demo", false, "serve from memory, seeded with demo users, wallets and transfers")
+	cfg := loadConfig(fs, args)
 
-	//db := dbConn()
-	//defer db.Close()
+	logs.Println("starting wallet service")
 
-	server.Start()
+	server.Start(cfg)
 
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
 	<-interrupt
-}
\ No newline at end of file
+}
+
+// loadConfig loads the config from args with the flags of fs and sets up the logging, it exits on an invalid config
+func loadConfig(fs *flag.FlagSet, args []string) config.Config {
+	cfg, err := config.Load(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	setupLogging(cfg.Log)
+	return cfg
+}
+
+// setupLogging sets the level and format of the standard logger, the config is already validated
+func setupLogging(cfg config.LogConfig) {
+	level, _ := logs.ParseLevel(cfg.Level)
+	logs.SetLevel(level)
+
+	if cfg.Format == config.LogFormatJSON {
+		logs.SetFormatter(&logs.JSONFormatter{})
+	} else {
+		logs.SetFormatter(&logs.TextFormatter{})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/dsthakur2711/wallet/database"
+	"github.com/dsthakur2711/wallet/migrate"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// migrateCommand runs `wallet migrate up [version] | down [steps] | status`
+func migrateCommand(args []string) {
+
+	fs := flag.NewFlagSet("wallet migrate", flag.ExitOnError)
+	cfg := loadConfig(fs, args)
+	ctx := context.Background()
+
+	action, arg := "up", ""
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+	if fs.NArg() > 1 {
+		arg = fs.Arg(1)
+	}
+	number := func(defaultValue int64) int64 {
+		if arg == "" {
+			return defaultValue
+		}
+		n, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || n < 0 {
+			fail(fmt.Errorf("%q is not a number", arg))
+		}
+		return n
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		fail(err)
+	}
+
+	switch action {
+	case "up":
+		done, err := migrator.Up(ctx, number(0))
+		report("applied", done)
+		if err != nil {
+			fail(err)
+		}
+	case "down":
+		done, err := migrator.Down(ctx, int(number(1)))
+		report("reverted", done)
+		if err != nil {
+			fail(err)
+		}
+	case "status":
+		list, err := migrator.Status(ctx)
+		if err != nil {
+			fail(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range list {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func report(verb string, done []migrate.Migration) {
+	if len(done) == 0 {
+		fmt.Println("nothing to do")
+	}
+	for _, m := range done {
+		fmt.Printf("%s %d_%s\n", verb, m.Version, m.Name)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/dsthakur2711/wallet/db/migrations"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/require"
+)
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_widgets.up.sql":   {Data: []byte("-- widgets\nCREATE TABLE migrate_test_widgets\n(\n    id bigint NOT NULL PRIMARY KEY\n);\n")},
+		"0001_create_widgets.down.sql": {Data: []byte("DROP TABLE migrate_test_widgets;\n")},
+		"0002_create_gadgets.up.sql":   {Data: []byte("CREATE TABLE migrate_test_gadgets (id bigint NOT NULL PRIMARY KEY, name varchar(255));\nCREATE INDEX idx_migrate_test_gadgets_name ON migrate_test_gadgets (name);\n")},
+		"0002_create_gadgets.down.sql": {Data: []byte("DROP TABLE migrate_test_gadgets;\n")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	list, err := Load(testMigrations())
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, int64(1), list[0].Version)
+	require.Equal(t, "create_widgets", list[0].Name)
+	require.Equal(t, int64(2), list[1].Version)
+	require.Len(t, list[1].Checksum, 64)
+	require.Equal(t, []string{
+		"CREATE TABLE migrate_test_gadgets (id bigint NOT NULL PRIMARY KEY, name varchar(255))",
+		"CREATE INDEX idx_migrate_test_gadgets_name ON migrate_test_gadgets (name)",
+	}, statements(list[1].Up))
+
+	missingDown := testMigrations()
+	delete(missingDown, "0002_create_gadgets.down.sql")
+	_, err = Load(missingDown)
+	require.Error(t, err)
+
+	badName := testMigrations()
+	badName["create_gadgets.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = Load(badName)
+	require.Error(t, err)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range migrations.Dialects {
+		list, err := ForDialect(dialect)
+		require.NoError(t, err)
+		require.NotEmpty(t, list)
+		for _, m := range list {
+			require.NotEmpty(t, statements(m.Up))
+			require.NotEmpty(t, statements(m.Down))
+		}
+	}
+
+	_, err := ForDialect("oracle")
+	require.Error(t, err)
+}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db")+"?_foreign_keys=1")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrator(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+
+	list, err := Load(testMigrations())
+	require.NoError(t, err)
+	migrator := New(db, list)
+
+	require.ErrorIs(t, migrator.Verify(ctx), local_errors.ErrMigrationsPending)
+
+	done, err := migrator.Up(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	require.True(t, db.HasTable("migrate_test_widgets"))
+	require.False(t, db.HasTable("migrate_test_gadgets"))
+
+	done, err = migrator.Up(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	require.True(t, db.HasTable("migrate_test_gadgets"))
+	require.NoError(t, migrator.Verify(ctx))
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.True(t, status[0].Applied && status[1].Applied)
+
+	// an applied migration can not change
+	changed := testMigrations()
+	changed["0001_create_widgets.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE migrate_test_widgets (id bigint);\n")}
+	changedList, err := Load(changed)
+	require.NoError(t, err)
+	require.ErrorIs(t, New(db, changedList).Verify(ctx), local_errors.ErrMigrationChecksumMismatch)
+
+	// nor disappear from the build
+	require.ErrorIs(t, New(db, list[:1]).Verify(ctx), local_errors.ErrUnknownMigration)
+
+	done, err = migrator.Down(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, done, 2)
+	require.False(t, db.HasTable("migrate_test_widgets"))
+	require.False(t, db.HasTable("migrate_test_gadgets"))
+}
+
+func TestEmbeddedSQLiteMigrations(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+
+	list, err := ForDialect("sqlite3")
+	require.NoError(t, err)
+	migrator := New(db, list)
+
+	_, err = migrator.Up(ctx, 0)
+	require.NoError(t, err)
+	require.True(t, db.HasTable("wallets"))
+
+	_, err = migrator.Down(ctx, len(list))
+	require.NoError(t, err)
+	require.False(t, db.HasTable("wallets"))
+}
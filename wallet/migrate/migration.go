@@ -0,0 +1,126 @@
+// Package migrate applies and reverts the versioned schema migrations and records them in the
+// schema_migrations table, with a checksum so that an applied migration can not change unnoticed.
+package migrate
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dsthakur2711/wallet/db/migrations"
+)
+
+// Migration is one schema change, read from the files <version>_<name>.up.sql and <version>_<name>.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the sha256 of Up, recorded when the migration is applied
+	Checksum string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations at the root of fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %s has an invalid version", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		m.Checksum = checksum(m.Up)
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+
+	return list, nil
+}
+
+// ForDialect returns the embedded migrations of a SQL dialect
+func ForDialect(dialect string) ([]Migration, error) {
+	fsys, err := migrations.For(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return Load(fsys)
+}
+
+func checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+// statements splits a migration into its statements, a statement ends with a semicolon at the end of a line
+func statements(sql string) []string {
+	var list []string
+	var current strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(sql))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if current.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			list = append(list, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		list = append(list, rest)
+	}
+
+	return list
+}
@@ -0,0 +1,191 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+)
+
+// table records the applied migrations
+const table = "schema_migrations"
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    bigint       NOT NULL PRIMARY KEY,
+    name       varchar(255) NOT NULL,
+    checksum   varchar(64)  NOT NULL,
+    applied_at timestamp    NOT NULL
+)`
+
+// record is a row of schema_migrations
+type record struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationStatus tells whether and when a migration was applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator runs the migrations against one database. Only one migrator should run at a time,
+// the server only verifies the schema.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// applied returns the recorded migrations by version, after checking that they are the ones of this build
+func (m *Migrator) applied(ctx context.Context) (map[int64]record, error) {
+	if err := m.db.Exec(createTable).Error; err != nil {
+		return nil, err
+	}
+
+	var records []record
+	if err := m.db.Table(table).Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	known := map[int64]Migration{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	applied := map[int64]record{}
+	for _, r := range records {
+		migration, ok := known[r.Version]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d_%s", local_errors.ErrUnknownMigration, r.Version, r.Name)
+		}
+		if migration.Checksum != r.Checksum {
+			return nil, fmt.Errorf("%w: version %d_%s", local_errors.ErrMigrationChecksumMismatch, r.Version, r.Name)
+		}
+		applied[r.Version] = r
+	}
+
+	return applied, nil
+}
+
+// Status lists every migration, applied or not
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		r, ok := applied[migration.Version]
+		list = append(list, MigrationStatus{Migration: migration, Applied: ok, AppliedAt: r.AppliedAt})
+	}
+	return list, nil
+}
+
+// Verify checks that every migration is applied, unchanged
+func (m *Migrator) Verify(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := len(m.migrations) - len(applied)
+	if pending > 0 {
+		return fmt.Errorf("%w: %d not applied", local_errors.ErrMigrationsPending, pending)
+	}
+	return nil
+}
+
+// Up applies the pending migrations up to version target, or all of them when target is 0
+func (m *Migrator) Up(ctx context.Context, target int64) ([]Migration, error) {
+	logrus.Println("log Up in migrate/migrator/Up ")
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest int64
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		// the history is linear, a migration added below the applied ones needs a new version
+		if migration.Version < latest {
+			return done, fmt.Errorf("migration %d_%s is older than the applied migration %d", migration.Version, migration.Name, latest)
+		}
+
+		if err := m.run(migration, migration.Up, func(tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+				migration.Version, migration.Name, migration.Checksum, time.Now().UTC()).Error
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	logrus.Println("log Down in migrate/migrator/Down ")
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if err := m.run(migration, migration.Down, func(tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version).Error
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// run executes the statements of one direction of a migration and records it, in a transaction.
+// MySQL commits every DDL statement on its own, a failed migration there may be left half done.
+func (m *Migrator) run(migration Migration, sql string, record func(tx *gorm.DB) error) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(sql) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+			}
+		}
+		return record(tx)
+	})
+}
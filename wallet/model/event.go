@@ -0,0 +1,138 @@
+package model
+
+import "time"
+
+type EventType string
+
+const (
+	EventTypeWalletCreated  EventType = "WalletCreated"
+	EventTypeWalletCredited EventType = "WalletCredited"
+	// EventTypeWalletFrozen is emitted when a wallet becomes INACTIVE, EventTypeWalletUnfrozen when it is ACTIVE again
+	EventTypeWalletFrozen      EventType = "WalletFrozen"
+	EventTypeWalletUnfrozen    EventType = "WalletUnfrozen"
+	EventTypeTransferCompleted EventType = "TransferCompleted"
+	EventTypeTransferRefunded  EventType = "TransferRefunded"
+	EventTypeTransferReversed  EventType = "TransferReversed"
+)
+
+// EventTypes lists every event type, webhooks subscribe to a subset of them
+var EventTypes = []EventType{
+	EventTypeWalletCreated,
+	EventTypeWalletCredited,
+	EventTypeWalletFrozen,
+	EventTypeWalletUnfrozen,
+	EventTypeTransferCompleted,
+	EventTypeTransferRefunded,
+	EventTypeTransferReversed,
+}
+
+func IsEventType(t EventType) bool {
+	for _, eventType := range EventTypes {
+		if eventType == t {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	AggregateTypeWALLET = "wallet"
+	AggregateTypeTRANS  = "trans"
+)
+
+// OutboxEvent is a domain event written in the transaction of the change it describes, the relay
+// publishes it once the transaction committed. Payload is the JSON of a WalletEvent or a TransferEvent.
+type OutboxEvent struct {
+	ID int64 `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	// EventID is unique per event, consumers use it to drop the events that are delivered again
+	EventID       string    `gorm:"unique_index" json:"event_id"`
+	Type          EventType `json:"type"`
+	AggregateType string    `json:"aggregate_type"`
+	AggregateID   string    `json:"aggregate_id"`
+	// WalletAddress and ToWalletAddress are the wallets the event is about, ToWalletAddress is only set on transfers
+	WalletAddress   string    `gorm:"index" json:"wallet_address"`
+	ToWalletAddress string    `gorm:"index" json:"to_wallet_address"`
+	Payload         string    `gorm:"type:text" json:"payload"`
+	OccurredAt      time.Time `json:"occurred_at"`
+	// Published is set once every sink accepted the event
+	Published   bool      `gorm:"index" json:"published"`
+	PublishedAt time.Time `json:"published_at"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	// Dead is set once the relay gave up on the event, it is never published
+	Dead bool `json:"dead"`
+}
+
+// WalletEvent is the payload of the wallet events, Amount is only set on credits
+type WalletEvent struct {
+	WalletAddress string       `json:"wallet_address"`
+	Username      string       `json:"username"`
+	Currency      string       `json:"currency"`
+	Status        WalletStatus `json:"status"`
+	Balance       int64        `json:"balance"`
+	Amount        int64        `json:"amount,omitempty"`
+}
+
+func NewWalletEvent(w Wallet, amount int64) WalletEvent {
+	return WalletEvent{
+		WalletAddress: w.WalletAddress,
+		Username:      w.Username,
+		Currency:      w.Currency,
+		Status:        w.Status,
+		Balance:       w.Balance,
+		Amount:        amount,
+	}
+}
+
+// TransferEvent is the payload of the transfer events
+type TransferEvent struct {
+	TransID           int64     `json:"trans_id"`
+	Kind              TransKind `json:"kind"`
+	FromWalletAddress string    `json:"from_wallet_address"`
+	ToWalletAddress   string    `json:"to_wallet_address"`
+	Amount            int64     `json:"amount"`
+	Fee               int64     `json:"fee"`
+	NetAmount         int64     `json:"net_amount"`
+	Currency          string    `json:"currency"`
+	// ToAmount and ToCurrency are only set on cross-currency transfers
+	ToAmount        int64  `json:"to_amount,omitempty"`
+	ToCurrency      string `json:"to_currency,omitempty"`
+	OriginalTransID int64  `json:"original_trans_id,omitempty"`
+	// the balances of both wallets right after the transfer, and what the transfer moved in or out of them
+	FromBalance int64     `json:"from_balance"`
+	FromChange  int64     `json:"from_change"`
+	ToBalance   int64     `json:"to_balance"`
+	ToChange    int64     `json:"to_change"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewTransferEvent describes the transfer t, whose ledger entry made the postings and left the wallets as they are
+func NewTransferEvent(t Trans, postings []Posting, wallets map[string]Wallet) TransferEvent {
+	e := TransferEvent{
+		TransID:           t.ID,
+		Kind:              t.Kind,
+		FromWalletAddress: t.FromWalletAdd,
+		ToWalletAddress:   t.ToWalletAdd,
+		Amount:            t.Amount,
+		Fee:               t.Fee,
+		NetAmount:         t.NetAmount,
+		Currency:          t.Currency,
+		ToAmount:          t.ToAmount,
+		ToCurrency:        t.ToCurrency,
+		OriginalTransID:   t.OriginalTransID,
+		FromBalance:       wallets[t.FromWalletAdd].Balance,
+		ToBalance:         wallets[t.ToWalletAdd].Balance,
+		CreatedAt:         t.CreatedAt,
+	}
+
+	for _, p := range postings {
+		switch p.WalletAddress {
+		case t.FromWalletAdd:
+			e.FromChange += p.Amount
+		case t.ToWalletAdd:
+			e.ToChange += p.Amount
+		}
+	}
+
+	return e
+}
@@ -0,0 +1,11 @@
+package model
+
+// FeeBearer tells who pays the fee of a transfer
+type FeeBearer string
+
+const (
+	// FeeBearerPAYER adds the fee to the amount debited from the payer
+	FeeBearerPAYER FeeBearer = "PAYER"
+	// FeeBearerPAYEE deducts the fee from the amount credited to the payee
+	FeeBearerPAYEE FeeBearer = "PAYEE"
+)
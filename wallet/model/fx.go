@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// FXQuote locks an exchange rate for a transfer between wallets of different currencies until ExpiresAt
+type FXQuote struct {
+	ID            string `gorm:"primary_key" json:"id"`
+	Username      string `gorm:"index" json:"username"`
+	FromWalletAdd string `json:"from_wallet_address"`
+	ToWalletAdd   string `json:"to_wallet_address"`
+	FromCurrency  string `json:"from_currency"`
+	ToCurrency    string `json:"to_currency"`
+	// Rate is the price of one unit of FromCurrency in ToCurrency, as a decimal string
+	Rate       string `json:"rate"`
+	FromAmount int64  `json:"from_amount"`
+	ToAmount   int64  `json:"to_amount"`
+	// TransID is set once the quote was used for a transfer, a quote can be used only once
+	TransID   int64     `json:"trans_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *FXQuote) IsUsed() bool {
+	return q.TransID != 0
+}
+
+func (q *FXQuote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
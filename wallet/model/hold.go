@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type HoldStatus string
+
+const (
+	HoldStatusACTIVE   HoldStatus = "ACTIVE"
+	HoldStatusCAPTURED HoldStatus = "CAPTURED"
+	HoldStatusVOIDED   HoldStatus = "VOIDED"
+	// HoldStatusEXPIRED is set by the sweeper on holds that were neither captured nor voided in time
+	HoldStatusEXPIRED HoldStatus = "EXPIRED"
+)
+
+// Hold reserves Amount of a wallet for a later payment to ToWalletAdd, the reserved money
+// stays in the wallet balance but is no longer available until the hold is released
+type Hold struct {
+	ID            int64      `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	WalletAddress string     `gorm:"index" json:"wallet_address"`
+	ToWalletAdd   string     `gorm:"index" json:"to_wallet_address"`
+	Username      string     `gorm:"index" json:"username"`
+	Amount        int64      `json:"amount"`
+	Currency      string     `json:"currency"`
+	Description   string     `json:"description"`
+	Status        HoldStatus `gorm:"index" json:"status"`
+	// CapturedAmount and TransID are set on capture, the rest of the hold is released
+	CapturedAmount int64     `json:"captured_amount"`
+	TransID        int64     `json:"trans_id"`
+	ExpiresAt      time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (h *Hold) IsExpired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
+
+// IsActive is true while the hold can be captured or voided
+func (h *Hold) IsActive(now time.Time) bool {
+	return h.Status == HoldStatusACTIVE && !h.IsExpired(now)
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldIsActive(t *testing.T) {
+	now := time.Now()
+	h := Hold{Status: HoldStatusACTIVE, ExpiresAt: now.Add(time.Minute)}
+
+	require.True(t, h.IsActive(now))
+	require.False(t, h.IsActive(now.Add(time.Minute)))
+
+	h.Status = HoldStatusVOIDED
+	require.False(t, h.IsActive(now))
+}
+
+func TestAvailableBalance(t *testing.T) {
+	w := Wallet{Kind: WalletKindUSER, Balance: 1000, HeldBalance: 600}
+
+	require.Equal(t, int64(400), w.AvailableBalance())
+	require.True(t, w.IsBalanceSufficient(400))
+	require.False(t, w.IsBalanceSufficient(401))
+
+	// the float may go negative whatever is held
+	float := Wallet{Kind: WalletKindORGANIZATION, Purpose: OrganizationWalletFLOAT, HeldBalance: 10}
+	require.True(t, float.IsBalanceSufficient(1000))
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// IdempotencyKey remembers the response of a request sent with an Idempotency-Key header
+type IdempotencyKey struct {
+	ID          int64  `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	Username    string `gorm:"unique_index:idx_idempotency_keys_scope" json:"username"`
+	Scope       string `gorm:"unique_index:idx_idempotency_keys_scope" json:"scope"`
+	Key         string `gorm:"column:idempotency_key;unique_index:idx_idempotency_keys_scope" json:"key"`
+	Fingerprint string `json:"fingerprint"`
+	// StatusCode stays 0 while the first request is still being processed
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `gorm:"type:text" json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `gorm:"index" json:"expires_at"`
+	// LockedUntil is the lease of the request processing the key, a retry may recover the key once it passed
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.StatusCode != 0
+}
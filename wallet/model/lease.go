@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Lease is held by one server instance at a time, the holder must renew it before ExpiresAt
+type Lease struct {
+	Name      string    `gorm:"primary_key" json:"name"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
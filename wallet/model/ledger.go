@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type JournalEntryKind string
+
+const (
+	JournalEntryKindTRANSFER JournalEntryKind = "TRANSFER"
+	JournalEntryKindCREDIT   JournalEntryKind = "CREDIT"
+	// JournalEntryKindFXTRANSFER moves money between wallets of different currencies
+	JournalEntryKindFXTRANSFER JournalEntryKind = "FX_TRANSFER"
+	JournalEntryKindREFUND     JournalEntryKind = "REFUND"
+	// JournalEntryKindREVERSAL negates the postings of the entry of a transfer
+	JournalEntryKindREVERSAL JournalEntryKind = "REVERSAL"
+	// JournalEntryKindOPENING books the balance a wallet had before the ledger against the float
+	JournalEntryKindOPENING JournalEntryKind = "OPENING"
+)
+
+// JournalEntry groups the postings of one money movement, its postings always sum to zero per currency
+type JournalEntry struct {
+	ID          int64            `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	Kind        JournalEntryKind `json:"kind"`
+	TransID     int64            `json:"trans_id"`
+	Description string           `json:"description"`
+	// IdempotencyKeyID is the key of the request that posted the entry, 0 without one
+	IdempotencyKeyID int64     `gorm:"index" json:"idempotency_key_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Posting is one leg of a journal entry, a negative amount debits the wallet and a positive amount credits it
+type Posting struct {
+	ID             int64     `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	JournalEntryID int64     `gorm:"index" json:"journal_entry_id"`
+	WalletAddress  string    `gorm:"index" json:"wallet_address"`
+	Currency       string    `json:"currency"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"time"
+)
+
+// TransferLimit caps the outgoing transfers of a wallet, a zero field means no limit.
+// Days and months are calendar days and months in UTC.
+type TransferLimit struct {
+	PerTransaction int64 `json:"per_transaction"`
+	DailyAmount    int64 `json:"daily_amount"`
+	DailyCount     int64 `json:"daily_count"`
+	MonthlyAmount  int64 `json:"monthly_amount"`
+	MonthlyCount   int64 `json:"monthly_count"`
+}
+
+// TransferUsage sums the outgoing transfers of a wallet in a window
+type TransferUsage struct {
+	Count  int64 `json:"count"`
+	Amount int64 `json:"amount"`
+}
+
+func (l *TransferLimit) IsUnlimited() bool {
+	return *l == TransferLimit{}
+}
+
+// Check returns the limit a new transfer of amount would break on top of the daily and monthly usage
+func (l *TransferLimit) Check(amount int64, daily TransferUsage, monthly TransferUsage) error {
+	if l.PerTransaction > 0 && amount > l.PerTransaction {
+		return local_errors.ErrPerTransactionLimitExceeded
+	}
+	if (l.DailyAmount > 0 && daily.Amount+amount > l.DailyAmount) || (l.DailyCount > 0 && daily.Count+1 > l.DailyCount) {
+		return local_errors.ErrDailyLimitExceeded
+	}
+	if (l.MonthlyAmount > 0 && monthly.Amount+amount > l.MonthlyAmount) || (l.MonthlyCount > 0 && monthly.Count+1 > l.MonthlyCount) {
+		return local_errors.ErrMonthlyLimitExceeded
+	}
+	return nil
+}
+
+// DayStart is the start of the UTC day of t
+func DayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MonthStart is the start of the UTC month of t
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferLimitCheck(t *testing.T) {
+	l := TransferLimit{PerTransaction: 1000, DailyAmount: 2000, DailyCount: 3, MonthlyAmount: 5000, MonthlyCount: 10}
+
+	testCases := []struct {
+		name    string
+		amount  int64
+		daily   TransferUsage
+		monthly TransferUsage
+		want    error
+	}{
+		{"within limits", 1000, TransferUsage{Count: 1, Amount: 1000}, TransferUsage{Count: 1, Amount: 1000}, nil},
+		{"per transaction", 1001, TransferUsage{}, TransferUsage{}, local_errors.ErrPerTransactionLimitExceeded},
+		{"daily amount", 500, TransferUsage{Count: 1, Amount: 1600}, TransferUsage{Count: 1, Amount: 1600}, local_errors.ErrDailyLimitExceeded},
+		{"daily count", 1, TransferUsage{Count: 3, Amount: 3}, TransferUsage{Count: 3, Amount: 3}, local_errors.ErrDailyLimitExceeded},
+		{"monthly amount", 500, TransferUsage{}, TransferUsage{Count: 5, Amount: 4600}, local_errors.ErrMonthlyLimitExceeded},
+		{"monthly count", 1, TransferUsage{}, TransferUsage{Count: 10, Amount: 10}, local_errors.ErrMonthlyLimitExceeded},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, l.Check(tc.amount, tc.daily, tc.monthly))
+		})
+	}
+
+	unlimited := TransferLimit{}
+	require.True(t, unlimited.IsUnlimited())
+	require.NoError(t, unlimited.Check(1<<40, TransferUsage{Count: 1 << 20, Amount: 1 << 50}, TransferUsage{}))
+}
+
+func TestWindowStarts(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	// 03:00 IST on the 1st of March is still the 29th of February in UTC
+	ts := time.Date(2024, time.March, 1, 3, 0, 0, 0, ist)
+
+	require.Equal(t, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), DayStart(ts))
+	require.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), MonthStart(ts))
+}
+
+func TestWalletTransferUsage(t *testing.T) {
+	var w Wallet
+	day1 := time.Date(2024, time.January, 31, 10, 0, 0, 0, time.UTC)
+
+	w.AddTransferUsage(day1, 100)
+	w.AddTransferUsage(day1.Add(time.Hour), 50)
+
+	daily, monthly := w.TransferUsage(day1)
+	require.Equal(t, TransferUsage{Count: 2, Amount: 150}, daily)
+	require.Equal(t, TransferUsage{Count: 2, Amount: 150}, monthly)
+
+	// a new day and a new month start from zero
+	day2 := day1.Add(24 * time.Hour)
+	daily, monthly = w.TransferUsage(day2)
+	require.Equal(t, TransferUsage{}, daily)
+	require.Equal(t, TransferUsage{}, monthly)
+
+	w.AddTransferUsage(day2, 10)
+	daily, monthly = w.TransferUsage(day2)
+	require.Equal(t, TransferUsage{Count: 1, Amount: 10}, daily)
+	require.Equal(t, TransferUsage{Count: 1, Amount: 10}, monthly)
+}
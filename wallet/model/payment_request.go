@@ -0,0 +1,39 @@
+package model
+
+import "github.com/dsthakur2711/wallet/pkg/local_errors"
+
+// paymentRequestTransitions lists the statuses reachable from each status,
+// statuses missing from the map are final
+var paymentRequestTransitions = map[PaymentRequestStatus][]PaymentRequestStatus{
+	PaymentRequestStatusWAITINGAPPROVAL: {
+		PaymentRequestStatusAPPROVED,
+		PaymentRequestStatusREFUSED,
+		PaymentRequestStatusCANCELLED,
+	},
+	PaymentRequestStatusAPPROVED: {
+		PaymentRequestStatusPAYMENTSUCCESS,
+		PaymentRequestStatusPAYMENTFAILED,
+	},
+}
+
+func (s PaymentRequestStatus) CanTransitionTo(next PaymentRequestStatus) bool {
+	for _, allowed := range paymentRequestTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s PaymentRequestStatus) IsFinal() bool {
+	return len(paymentRequestTransitions[s]) == 0
+}
+
+// TransitionTo moves the request to the next status if the state machine allows it
+func (p *PaymentRequest) TransitionTo(next PaymentRequestStatus) error {
+	if !p.Status.CanTransitionTo(next) {
+		return local_errors.ErrInvalidPaymentRequestTransition
+	}
+	p.Status = next
+	return nil
+}
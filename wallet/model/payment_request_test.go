@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+var allPaymentRequestStatuses = []PaymentRequestStatus{
+	PaymentRequestStatusWAITINGAPPROVAL,
+	PaymentRequestStatusAPPROVED,
+	PaymentRequestStatusREFUSED,
+	PaymentRequestStatusPAYMENTSUCCESS,
+	PaymentRequestStatusPAYMENTFAILED,
+	PaymentRequestStatusCANCELLED,
+}
+
+func TestPaymentRequestTransitions(t *testing.T) {
+	legal := map[PaymentRequestStatus]map[PaymentRequestStatus]bool{
+		PaymentRequestStatusWAITINGAPPROVAL: {
+			PaymentRequestStatusAPPROVED:  true,
+			PaymentRequestStatusREFUSED:   true,
+			PaymentRequestStatusCANCELLED: true,
+		},
+		PaymentRequestStatusAPPROVED: {
+			PaymentRequestStatusPAYMENTSUCCESS: true,
+			PaymentRequestStatusPAYMENTFAILED:  true,
+		},
+	}
+
+	// every pair of statuses, including staying in the same status
+	for _, from := range allPaymentRequestStatuses {
+		for _, to := range allPaymentRequestStatuses {
+			from, to := from, to
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				p := PaymentRequest{Status: from}
+				err := p.TransitionTo(to)
+
+				if legal[from][to] {
+					require.NoError(t, err)
+					require.Equal(t, to, p.Status)
+					return
+				}
+
+				require.ErrorIs(t, err, local_errors.ErrInvalidPaymentRequestTransition)
+				require.Equal(t, from, p.Status)
+			})
+		}
+	}
+}
+
+func TestPaymentRequestFinalStatuses(t *testing.T) {
+	final := map[PaymentRequestStatus]bool{
+		PaymentRequestStatusREFUSED:        true,
+		PaymentRequestStatusPAYMENTSUCCESS: true,
+		PaymentRequestStatusPAYMENTFAILED:  true,
+		PaymentRequestStatusCANCELLED:      true,
+	}
+
+	for _, status := range allPaymentRequestStatuses {
+		require.Equal(t, final[status], status.IsFinal(), status)
+	}
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"time"
+
+	"github.com/dsthakur2711/wallet/recurrence"
+)
+
+type ScheduledPaymentStatus string
+
+const (
+	ScheduledPaymentStatusACTIVE ScheduledPaymentStatus = "ACTIVE"
+	// ScheduledPaymentStatusCOMPLETED is set once there is no occurrence left
+	ScheduledPaymentStatusCOMPLETED ScheduledPaymentStatus = "COMPLETED"
+	ScheduledPaymentStatusCANCELLED ScheduledPaymentStatus = "CANCELLED"
+	// ScheduledPaymentStatusFAILED is set when the single payment of a one-off schedule failed
+	ScheduledPaymentStatusFAILED ScheduledPaymentStatus = "FAILED"
+)
+
+// ScheduledPayment pays Amount from FromWalletAdd on every occurrence of its recurrence rule.
+// The recipient is ToWalletAdd, or the wallet of ToUsername in the payer's currency at the time of payment.
+type ScheduledPayment struct {
+	ID            int64                `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	Username      string               `gorm:"index" json:"username"`
+	FromWalletAdd string               `json:"from_wallet_address"`
+	ToWalletAdd   string               `json:"to_wallet_address"`
+	ToUsername    string               `json:"to_username"`
+	Amount        int64                `json:"amount"`
+	Currency      string               `json:"currency"`
+	Note          string               `json:"note"`
+	Frequency     recurrence.Frequency `json:"frequency"`
+	Interval      int                  `json:"interval"`
+	Cron          string               `json:"cron"`
+	StartAt       time.Time            `json:"start_at"`
+	// EndAt and MaxRuns end the schedule, their zero values mean no end
+	EndAt   time.Time `json:"end_at"`
+	MaxRuns int64     `json:"max_runs"`
+	// RunCount counts the occurrences that were executed, whatever their outcome
+	RunCount int64                  `json:"run_count"`
+	Status   ScheduledPaymentStatus `gorm:"index" json:"status"`
+	// OccurrenceAt is the occurrence to pay next, NextRunAt when it is tried, which is later on retries
+	OccurrenceAt time.Time `json:"occurrence_at"`
+	NextRunAt    time.Time `gorm:"index" json:"next_run_at"`
+	// Attempts counts the failed attempts of the current occurrence
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ScheduledPaymentRunStatus string
+
+const (
+	// ScheduledPaymentRunStatusPENDING is the status while the payment is made
+	ScheduledPaymentRunStatusPENDING   ScheduledPaymentRunStatus = "PENDING"
+	ScheduledPaymentRunStatusSUCCEEDED ScheduledPaymentRunStatus = "SUCCEEDED"
+	// ScheduledPaymentRunStatusRETRYING marks a failed attempt that is tried again later
+	ScheduledPaymentRunStatusRETRYING ScheduledPaymentRunStatus = "RETRYING"
+	ScheduledPaymentRunStatusFAILED   ScheduledPaymentRunStatus = "FAILED"
+)
+
+// ScheduledPaymentRun is one attempt to pay an occurrence of a scheduled payment
+type ScheduledPaymentRun struct {
+	ID                 int64                     `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	ScheduledPaymentID int64                     `gorm:"index" json:"scheduled_payment_id"`
+	ScheduledFor       time.Time                 `json:"scheduled_for"`
+	Attempt            int                       `json:"attempt"`
+	Status             ScheduledPaymentRunStatus `json:"status"`
+	// TransID links the transfer made by a successful run
+	TransID   int64     `json:"trans_id"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *ScheduledPayment) Rule() recurrence.Rule {
+	return recurrence.Rule{
+		Frequency: s.Frequency,
+		Interval:  s.Interval,
+		Cron:      s.Cron,
+		Start:     s.StartAt,
+	}
+}
+
+func (s *ScheduledPayment) IsDue(now time.Time) bool {
+	return s.Status == ScheduledPaymentStatusACTIVE && !now.Before(s.NextRunAt)
+}
+
+// Claim starts an attempt to pay the current occurrence. The schedule moves on to its next occurrence
+// as if the attempt succeeds, so a crash during the payment never pays an occurrence twice.
+func (s *ScheduledPayment) Claim(now time.Time) ScheduledPaymentRun {
+	run := ScheduledPaymentRun{
+		ScheduledPaymentID: s.ID,
+		ScheduledFor:       s.OccurrenceAt,
+		Attempt:            s.Attempts + 1,
+		Status:             ScheduledPaymentRunStatusPENDING,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	s.RunCount++
+	s.Attempts = 0
+
+	next, ok := s.Rule().NextAfter(s.OccurrenceAt, now)
+	if !ok || (s.MaxRuns > 0 && s.RunCount >= s.MaxRuns) || (!s.EndAt.IsZero() && next.After(s.EndAt)) {
+		s.Status = ScheduledPaymentStatusCOMPLETED
+		return run
+	}
+
+	s.OccurrenceAt = next
+	s.NextRunAt = next
+	return run
+}
+
+// Retry puts back the occurrence of a failed run, to be tried again at
+func (s *ScheduledPayment) Retry(run ScheduledPaymentRun, at time.Time) {
+	if s.Status == ScheduledPaymentStatusCANCELLED {
+		return
+	}
+
+	s.Status = ScheduledPaymentStatusACTIVE
+	s.RunCount--
+	s.OccurrenceAt = run.ScheduledFor
+	s.NextRunAt = at
+	s.Attempts = run.Attempt
+}
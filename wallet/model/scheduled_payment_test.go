@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/recurrence"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledPaymentClaim(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := ScheduledPayment{
+		ID:           1,
+		Frequency:    recurrence.FrequencyDAILY,
+		StartAt:      start,
+		MaxRuns:      3,
+		Status:       ScheduledPaymentStatusACTIVE,
+		OccurrenceAt: start,
+		NextRunAt:    start,
+	}
+
+	require.False(t, s.IsDue(start.Add(-time.Second)))
+	require.True(t, s.IsDue(start))
+
+	run := s.Claim(start)
+	require.Equal(t, start, run.ScheduledFor)
+	require.Equal(t, 1, run.Attempt)
+	require.Equal(t, start.AddDate(0, 0, 1), s.NextRunAt)
+	require.Equal(t, int64(1), s.RunCount)
+
+	// a retry puts the claimed occurrence back
+	retryAt := start.Add(time.Hour)
+	s.Retry(run, retryAt)
+	require.Equal(t, start, s.OccurrenceAt)
+	require.Equal(t, retryAt, s.NextRunAt)
+	require.Equal(t, int64(0), s.RunCount)
+
+	run = s.Claim(retryAt)
+	require.Equal(t, 2, run.Attempt)
+	require.Equal(t, 0, s.Attempts)
+
+	// occurrences missed while the scheduler was down are skipped
+	run = s.Claim(start.AddDate(0, 0, 5))
+	require.Equal(t, start.AddDate(0, 0, 1), run.ScheduledFor)
+	require.Equal(t, start.AddDate(0, 0, 6), s.NextRunAt)
+
+	s.Claim(s.NextRunAt)
+	require.Equal(t, ScheduledPaymentStatusCOMPLETED, s.Status)
+	require.Equal(t, int64(3), s.RunCount)
+}
+
+func TestScheduledPaymentEndAt(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	s := ScheduledPayment{
+		Frequency:    recurrence.FrequencyWEEKLY,
+		StartAt:      start,
+		EndAt:        start.AddDate(0, 0, 10),
+		Status:       ScheduledPaymentStatusACTIVE,
+		OccurrenceAt: start,
+		NextRunAt:    start,
+	}
+
+	s.Claim(start)
+	require.Equal(t, ScheduledPaymentStatusACTIVE, s.Status)
+	s.Claim(s.NextRunAt)
+	require.Equal(t, ScheduledPaymentStatusCOMPLETED, s.Status)
+
+	// a cancelled schedule stays cancelled when its last run is retried
+	s.Status = ScheduledPaymentStatusCANCELLED
+	s.Retry(ScheduledPaymentRun{ScheduledFor: start, Attempt: 1}, start)
+	require.Equal(t, ScheduledPaymentStatusCANCELLED, s.Status)
+}
@@ -2,13 +2,51 @@ package model
 
 import "time"
 
+type TransKind string
+
+const (
+	TransKindTRANSFER TransKind = "TRANSFER"
+	// TransKindREFUND sends back part or all of a transfer from the payee to the payer
+	TransKindREFUND TransKind = "REFUND"
+	// TransKindREVERSAL undoes a whole transfer, it is made by an admin
+	TransKindREVERSAL TransKind = "REVERSAL"
+)
 
 type Trans struct {
 	ID           int64     		`gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
-	FromWalletAdd   string      `json:"from_wallet_address"`
-	ToWalletAdd     string      `json:"to_wallet_address"`
+	FromWalletAdd   string      `gorm:"index" json:"from_wallet_address"`
+	ToWalletAdd     string      `gorm:"index" json:"to_wallet_address"`
+	// Amount is the gross amount debited from the payer, NetAmount is credited to the payee
+	// and the difference Fee goes to the fee income wallet of the currency
 	Amount       int64     		`json:"amount"`
-	CreatedAt    time.Time 		`json:"created_at"`
+	Fee          int64     		`json:"fee"`
+	NetAmount    int64     		`json:"net_amount"`
+	Currency     string    		`json:"currency"`
+	// Rate, ToAmount and ToCurrency are only set on cross-currency transfers,
+	// Amount is then debited in Currency and ToAmount credited in ToCurrency
+	Rate         string    		`json:"rate"`
+	ToAmount     int64     		`json:"to_amount"`
+	ToCurrency   string    		`json:"to_currency"`
+	Kind         TransKind 		`json:"kind"`
+	// OriginalTransID links refunds and reversals to the transfer they undo
+	OriginalTransID int64       `gorm:"index" json:"original_trans_id"`
+	// RefundedAmount sums the refunds of a transfer, ReversalTransID is set once it is reversed
+	RefundedAmount  int64       `json:"refunded_amount"`
+	ReversalTransID int64       `json:"reversal_trans_id"`
+	// Shortfall is the part of a reversal the payee could not cover, it is booked to the suspense wallet
+	Shortfall       int64       `json:"shortfall"`
+	CreatedAt    time.Time 		`gorm:"index" json:"created_at"`
+}
+
+// IsRefundable is true for same currency transfers that are not reversed
+func (t *Trans) IsRefundable() bool {
+	return t.Kind == TransKindTRANSFER && t.ToCurrency == "" && t.ReversalTransID == 0
+}
+
+// RefundableAmount is what is left to refund of the transfer. Refunds come from the payee, so they are
+// capped at the net amount it received, the fee stays with the fee income wallet.
+func (t *Trans) RefundableAmount() int64 {
+	return t.NetAmount - t.RefundedAmount
 }
 
 type PaymentRequestStatus string
@@ -19,13 +57,22 @@ const (
 	PaymentRequestStatusREFUSED         PaymentRequestStatus = "REFUSED"
 	PaymentRequestStatusPAYMENTSUCCESS  PaymentRequestStatus = "PAYMENT_SUCCESS"
 	PaymentRequestStatusPAYMENTFAILED   PaymentRequestStatus = "PAYMENT_FAILED"
+	PaymentRequestStatusCANCELLED       PaymentRequestStatus = "CANCELLED"
 )
 
+// PaymentRequest is raised by the payee (ToWalletAdd) and paid by the payer (FromWalletAdd) on approval
 type PaymentRequest struct {
-	ID           int64                `json:"id"`
+	ID           int64                `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
 	FromWalletAdd string                `json:"from_wallet_add"`
 	ToWalletAdd   string               `json:"to_wallet_add"`
+	PayerUsername string               `gorm:"index" json:"payer_username"`
+	PayeeUsername string               `gorm:"index" json:"payee_username"`
 	Amount       int64                `json:"amount"`
+	Currency      string               `json:"currency"`
+	Note          string               `json:"note"`
 	Status       PaymentRequestStatus `json:"status"`
+	TransID       int64                `json:"trans_id"`
+	FailureReason string               `json:"failure_reason"`
 	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
 }
\ No newline at end of file
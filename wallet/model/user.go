@@ -10,12 +10,29 @@ const (
 	UserStatusACTIVE  UserStatus = "ACTIVE"
 	UserStatusBLOCKED UserStatus = "BLOCKED"
 )
+
+// UserTier selects the fee schedule and limits that apply to a user
+type UserTier string
+
+const (
+	UserTierSTANDARD UserTier = "STANDARD"
+	UserTierPREMIUM  UserTier = "PREMIUM"
+)
+// UserRole grants access to the admin operations, like reversing transfers
+type UserRole string
+
+const (
+	UserRoleUSER  UserRole = "USER"
+	UserRoleADMIN UserRole = "ADMIN"
+)
 type User struct {
 
 	ID                int64      `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
-	Username          string     `json:"username;unique"`
+	Username          string     `gorm:"unique_index" json:"username"`
 	HashedPassword    string     `json:"hashed_password"`
 	Status            UserStatus `json:"status"`
+	Tier              UserTier   `json:"tier"`
+	Role              UserRole   `json:"role"`
 	Email             string     `json:"email"`
 	Address 		  string     `json:"address"`
 	Nationality		  string	 `json:"nationality"`
@@ -25,6 +42,9 @@ type User struct {
 	UpdatedAt         time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 }
 
+func (u *User) IsAdmin() bool {
+	return u.Role == UserRoleADMIN
+}
 
 ////// util me password.go me jakr
 //// HashPassword return bcrypt hash of the password
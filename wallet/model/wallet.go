@@ -9,18 +9,94 @@ const (
 	WalletStatusINACTIVE WalletStatus = "INACTIVE"
 )
 
+type WalletKind string
+
+const (
+	WalletKindUSER         WalletKind = "USER"
+	WalletKindORGANIZATION WalletKind = "ORGANIZATION"
+)
+
+// OrganizationWalletPurpose tells apart the organization wallets of one currency
+type OrganizationWalletPurpose string
+
+const (
+	// OrganizationWalletFEEINCOME collects the fees charged to users
+	OrganizationWalletFEEINCOME OrganizationWalletPurpose = "FEE_INCOME"
+	// OrganizationWalletFLOAT is the counterparty of money entering or leaving the system, its balance is negative
+	OrganizationWalletFLOAT OrganizationWalletPurpose = "FLOAT"
+	// OrganizationWalletSUSPENSE parks money that can not be booked to its final wallet yet
+	OrganizationWalletSUSPENSE OrganizationWalletPurpose = "SUSPENSE"
+)
+
+var OrganizationWalletPurposes = []OrganizationWalletPurpose{
+	OrganizationWalletFEEINCOME,
+	OrganizationWalletFLOAT,
+	OrganizationWalletSUSPENSE,
+}
+
 type Wallet struct {
 	ID                   int64        `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
-	Username          	 string       `json:"username;unique"`
-	WalletAddress 		 string       `json:"wallet_address;unique"`
+	Username          	 string       `gorm:"index" json:"username"`
+	WalletAddress 		 string       `gorm:"unique_index" json:"wallet_address"`
 	Status               WalletStatus `json:"status"`
-	//UserID               int64        `json:"user_id"`
+	// organization wallets have no user, they are told apart by Purpose
+	Kind                 WalletKind   `json:"kind"`
+	Purpose              OrganizationWalletPurpose `gorm:"unique_index:idx_wallets_user_currency" json:"purpose,omitempty"`
+	UserID               int64        `gorm:"unique_index:idx_wallets_user_currency" json:"user_id"`
+	// IsPrimary marks the wallet used when a user is paid by username
+	IsPrimary            bool         `json:"is_primary"`
+	// Balance is the ledger balance, HeldBalance the part of it reserved by active holds
 	Balance              int64        `json:"balance"`
-	Currency             string       `json:"currency"`
+	HeldBalance          int64        `json:"held_balance"`
+	Currency             string       `gorm:"unique_index:idx_wallets_user_currency" json:"currency"`
+	// usage of the current transfer limit windows, kept up to date under the wallet lock by the payment path
+	LimitDay             time.Time    `json:"-"`
+	LimitDayCount        int64        `json:"-"`
+	LimitDayAmount       int64        `json:"-"`
+	LimitMonth           time.Time    `json:"-"`
+	LimitMonthCount      int64        `json:"-"`
+	LimitMonthAmount     int64        `json:"-"`
 	CreatedAt            time.Time    `json:"created_at"`
 	UpdatedAt            time.Time    `json:"updated_at"`
 }
 
 func (e *Wallet) IsBalanceSufficient(expectedAmount int64) bool {
-	return e.Balance >= expectedAmount
-}
\ No newline at end of file
+	return e.AllowsNegativeBalance() || e.AvailableBalance() >= expectedAmount
+}
+
+// AvailableBalance is what can be spent, the ledger balance without the held funds
+func (e *Wallet) AvailableBalance() int64 {
+	return e.Balance - e.HeldBalance
+}
+
+func (e *Wallet) IsOrganization() bool {
+	return e.Kind == WalletKindORGANIZATION
+}
+
+// AllowsNegativeBalance is true for the float and suspense wallets, which mirror money held outside the ledger
+func (e *Wallet) AllowsNegativeBalance() bool {
+	return e.IsOrganization() && (e.Purpose == OrganizationWalletFLOAT || e.Purpose == OrganizationWalletSUSPENSE)
+}
+
+// TransferUsage returns the outgoing transfers of the wallet in the day and the month of now
+func (e *Wallet) TransferUsage(now time.Time) (daily TransferUsage, monthly TransferUsage) {
+	if e.LimitDay.Equal(DayStart(now)) {
+		daily = TransferUsage{Count: e.LimitDayCount, Amount: e.LimitDayAmount}
+	}
+	if e.LimitMonth.Equal(MonthStart(now)) {
+		monthly = TransferUsage{Count: e.LimitMonthCount, Amount: e.LimitMonthAmount}
+	}
+	return daily, monthly
+}
+
+// AddTransferUsage counts an outgoing transfer of amount made at now, starting new windows when needed
+func (e *Wallet) AddTransferUsage(now time.Time, amount int64) {
+	daily, monthly := e.TransferUsage(now)
+
+	e.LimitDay = DayStart(now)
+	e.LimitDayCount = daily.Count + 1
+	e.LimitDayAmount = daily.Amount + amount
+	e.LimitMonth = MonthStart(now)
+	e.LimitMonthCount = monthly.Count + 1
+	e.LimitMonthAmount = monthly.Amount + amount
+}
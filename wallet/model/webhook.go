@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// WebhookSubscription posts the events concerning Username's wallets to URL.
+// EventTypes is a comma separated filter, an empty filter matches every event.
+type WebhookSubscription struct {
+	ID         int64  `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	Username   string `gorm:"index" json:"username"`
+	URL        string `json:"url"`
+	EventTypes string `json:"event_types"`
+	// Secret signs the deliveries, it is only shown when the subscription is created
+	Secret    string    `json:"-"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *WebhookSubscription) Filter() []EventType {
+	if s.EventTypes == "" {
+		return nil
+	}
+	var filter []EventType
+	for _, t := range strings.Split(s.EventTypes, ",") {
+		filter = append(filter, EventType(t))
+	}
+	return filter
+}
+
+func (s *WebhookSubscription) Matches(t EventType) bool {
+	filter := s.Filter()
+	if len(filter) == 0 {
+		return true
+	}
+	for _, eventType := range filter {
+		if eventType == t {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPENDING   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSUCCEEDED WebhookDeliveryStatus = "SUCCEEDED"
+	// WebhookDeliveryStatusDEAD is set once every attempt failed, only a replay sends it again
+	WebhookDeliveryStatusDEAD WebhookDeliveryStatus = "DEAD"
+)
+
+// WebhookDelivery is one event to post to one subscription, Payload is the JSON body that is posted
+type WebhookDelivery struct {
+	ID             int64                 `gorm:"primary_key;AUTO_INCREMENT;not_null" json:"id"`
+	SubscriptionID int64                 `gorm:"unique_index:idx_webhook_delivery_event" json:"subscription_id"`
+	EventID        string                `gorm:"unique_index:idx_webhook_delivery_event" json:"event_id"`
+	EventType      EventType             `json:"event_type"`
+	Payload        string                `gorm:"type:text" json:"payload"`
+	Status         WebhookDeliveryStatus `gorm:"index" json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `gorm:"index" json:"next_attempt_at"`
+	// LastStatusCode is the HTTP status of the last attempt, zero when the endpoint could not be reached
+	LastStatusCode int       `json:"last_status_code"`
+	LastError      string    `json:"last_error"`
+	DeliveredAt    time.Time `json:"delivered_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package outbox
+
+import "context"
+
+// ChannelSink hands the events to a consumer in the same process
+type ChannelSink struct {
+	ch chan Message
+}
+
+// NewChannelSink creates a sink with a channel buffering size events
+func NewChannelSink(size int) *ChannelSink {
+	return &ChannelSink{ch: make(chan Message, size)}
+}
+
+// C is the channel the events are received from
+func (s *ChannelSink) C() <-chan Message {
+	return s.ch
+}
+
+// Publish waits for room in the channel, the event is not published if ctx is done first
+func (s *ChannelSink) Publish(ctx context.Context, msg Message) error {
+	select {
+	case s.ch <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
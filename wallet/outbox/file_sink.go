@@ -0,0 +1,43 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileSink appends every event to a file as a line of JSON
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink opens path for appending, creating it if needed
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// the event is marked published next, it must not be lost on a crash
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
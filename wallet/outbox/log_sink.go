@@ -0,0 +1,24 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogSink writes every event to the log
+type LogSink struct{}
+
+func NewLogSink() LogSink {
+	return LogSink{}
+}
+
+func (LogSink) Publish(ctx context.Context, msg Message) error {
+	logrus.WithFields(logrus.Fields{
+		"event_id":       msg.EventID,
+		"type":           msg.Type,
+		"aggregate_type": msg.AggregateType,
+		"aggregate_id":   msg.AggregateID,
+	}).Info(string(msg.Payload))
+	return nil
+}
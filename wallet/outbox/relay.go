@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// relayLease is the lease held by the instance that publishes the events
+	relayLease = "outbox-relay"
+	// relayBatch is the number of events published per run
+	relayBatch = 100
+)
+
+// Relay publishes the events of the outbox to a sink, in the order they were written
+type Relay struct {
+	outboxRepo store.OutboxRepo
+	leaseRepo  store.LeaseRepo
+	sink       Sink
+	leaseTTL   time.Duration
+	// maxAttempts is how many runs try an event before it is dead
+	maxAttempts int
+}
+
+func NewRelay(outboxRepo store.OutboxRepo, leaseRepo store.LeaseRepo, sink Sink, leaseTTL time.Duration, maxAttempts int) *Relay {
+	return &Relay{
+		outboxRepo:  outboxRepo,
+		leaseRepo:   leaseRepo,
+		sink:        sink,
+		leaseTTL:    leaseTTL,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run publishes the pending events if holder is the relay leader, it returns the number of events published.
+// It stops at the first event the sink refuses, which is tried again on the next run, unless that was the
+// last attempt of the event: it is dead then and the relay goes on with the events after it.
+func (r *Relay) Run(ctx context.Context, holder string) (int, error) {
+
+	leader, err := r.leaseRepo.AcquireLease(ctx, relayLease, holder, r.leaseTTL)
+	if err != nil || !leader {
+		return 0, err
+	}
+
+	events, err := r.outboxRepo.ListPendingEvents(ctx, relayBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, e := range events {
+		if err := r.sink.Publish(ctx, NewMessage(e)); err != nil {
+			logrus.Printf("failed to publish event %d: %v", e.ID, err)
+			dead := e.Attempts+1 >= r.maxAttempts
+			if markErr := r.outboxRepo.MarkEventFailed(ctx, e.ID, err.Error(), dead); markErr != nil {
+				return published, markErr
+			}
+			if !dead {
+				return published, err
+			}
+			logrus.Errorf("event %d is dead after %d attempts", e.ID, e.Attempts+1)
+			continue
+		}
+
+		if err := r.outboxRepo.MarkEventPublished(ctx, e.ID, time.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
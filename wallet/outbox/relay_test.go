@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/store/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSink refuses the event with id failID
+type failingSink struct {
+	failID    int64
+	published []int64
+}
+
+func (s *failingSink) Publish(ctx context.Context, msg Message) error {
+	if msg.ID == s.failID {
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, msg.ID)
+	return nil
+}
+
+func TestRelayRun(t *testing.T) {
+	ctx := context.Background()
+
+	events := []model.OutboxEvent{
+		{ID: 1, EventID: "a", Type: model.EventTypeWalletCreated, Payload: `{}`},
+		{ID: 2, EventID: "b", Type: model.EventTypeWalletCredited, Payload: `{}`},
+		{ID: 3, EventID: "c", Type: model.EventTypeTransferCompleted, Payload: `{}`},
+	}
+
+	outboxRepo := &mocks.OutboxRepo{}
+	leaseRepo := &mocks.LeaseRepo{}
+	leaseRepo.On("AcquireLease", ctx, relayLease, "leader", time.Minute).Return(true, nil)
+	outboxRepo.On("ListPendingEvents", ctx, relayBatch).Return(events, nil)
+	outboxRepo.On("MarkEventPublished", ctx, int64(1), mock.Anything).Return(nil)
+	outboxRepo.On("MarkEventFailed", ctx, int64(2), "sink unavailable", false).Return(nil)
+
+	// the relay stops at the refused event so that the events stay in order
+	sink := &failingSink{failID: 2}
+	n, err := NewRelay(outboxRepo, leaseRepo, sink, time.Minute, 3).Run(ctx, "leader")
+	require.Error(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, []int64{1}, sink.published)
+	outboxRepo.AssertExpectations(t)
+	outboxRepo.AssertNotCalled(t, "MarkEventPublished", ctx, int64(3), mock.Anything)
+}
+
+func TestRelayRunDeadEvent(t *testing.T) {
+	ctx := context.Background()
+
+	events := []model.OutboxEvent{
+		{ID: 1, EventID: "a", Type: model.EventTypeWalletCreated, Payload: `{}`},
+		{ID: 2, EventID: "b", Type: model.EventTypeWalletCredited, Payload: `{}`, Attempts: 2},
+		{ID: 3, EventID: "c", Type: model.EventTypeTransferCompleted, Payload: `{}`},
+	}
+
+	outboxRepo := &mocks.OutboxRepo{}
+	leaseRepo := &mocks.LeaseRepo{}
+	leaseRepo.On("AcquireLease", ctx, relayLease, "leader", time.Minute).Return(true, nil)
+	outboxRepo.On("ListPendingEvents", ctx, relayBatch).Return(events, nil)
+	outboxRepo.On("MarkEventPublished", ctx, int64(1), mock.Anything).Return(nil)
+	outboxRepo.On("MarkEventFailed", ctx, int64(2), "sink unavailable", true).Return(nil)
+	outboxRepo.On("MarkEventPublished", ctx, int64(3), mock.Anything).Return(nil)
+
+	// the last attempt of the refused event kills it, the events after it are published
+	sink := &failingSink{failID: 2}
+	n, err := NewRelay(outboxRepo, leaseRepo, sink, time.Minute, 3).Run(ctx, "leader")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []int64{1, 3}, sink.published)
+	outboxRepo.AssertExpectations(t)
+}
+
+func TestRelayRunNotLeader(t *testing.T) {
+	ctx := context.Background()
+
+	outboxRepo := &mocks.OutboxRepo{}
+	leaseRepo := &mocks.LeaseRepo{}
+	leaseRepo.On("AcquireLease", ctx, relayLease, "follower", time.Minute).Return(false, nil)
+
+	n, err := NewRelay(outboxRepo, leaseRepo, NewLogSink(), time.Minute, 3).Run(ctx, "follower")
+	require.NoError(t, err)
+	require.Zero(t, n)
+	outboxRepo.AssertNotCalled(t, "ListPendingEvents", mock.Anything, mock.Anything)
+}
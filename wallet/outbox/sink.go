@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+)
+
+// Message is the form in which an outbox event is handed to the sinks
+type Message struct {
+	// ID is the outbox id of the event, it grows with every event
+	ID            int64           `json:"id"`
+	EventID       string          `json:"event_id"`
+	Type          model.EventType `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func NewMessage(e model.OutboxEvent) Message {
+	return Message{
+		ID:            e.ID,
+		EventID:       e.EventID,
+		Type:          e.Type,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		OccurredAt:    e.OccurredAt,
+		Payload:       json.RawMessage(e.Payload),
+	}
+}
+
+// Sink is an interface for the systems the relay publishes events to. Delivery is at least once,
+// a sink may see a message again after an error, so consumers drop the event ids they already saw.
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Fanout publishes to every sink in turn and stops at the first error
+func Fanout(sinks ...Sink) Sink {
+	return fanout(sinks)
+}
+
+type fanout []Sink
+
+func (f fanout) Publish(ctx context.Context, msg Message) error {
+	for _, sink := range f {
+		if err := sink.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage(id int64) Message {
+	return NewMessage(model.OutboxEvent{
+		ID:            id,
+		EventID:       fmt.Sprintf("event-%d", id),
+		Type:          model.EventTypeWalletCredited,
+		AggregateType: model.AggregateTypeWALLET,
+		AggregateID:   "wallet",
+		Payload:       `{"wallet_address":"wallet","amount":100}`,
+		OccurredAt:    time.Now(),
+	})
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Publish(context.Background(), testMessage(1)))
+	require.NoError(t, sink.Publish(context.Background(), testMessage(2)))
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		require.JSONEq(t, `{"wallet_address":"wallet","amount":100}`, string(msg.Payload))
+		ids = append(ids, msg.ID)
+	}
+	require.Equal(t, []int64{1, 2}, ids)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received Message
+	status := http.StatusOK
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, string(model.EventTypeWalletCredited), r.Header.Get("X-Event-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer stub.Close()
+
+	sink, err := NewWebhookSink(stub.URL, nil)
+	require.NoError(t, err)
+
+	msg := testMessage(1)
+	require.NoError(t, sink.Publish(context.Background(), msg))
+	require.Equal(t, msg.EventID, received.EventID)
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, sink.Publish(context.Background(), msg))
+
+	_, err = NewWebhookSink("not a url", nil)
+	require.Error(t, err)
+}
+
+func TestChannelSink(t *testing.T) {
+	sink := NewChannelSink(1)
+
+	require.NoError(t, sink.Publish(context.Background(), testMessage(1)))
+	require.Equal(t, int64(1), (<-sink.C()).ID)
+
+	// a full channel does not block past the context
+	require.NoError(t, sink.Publish(context.Background(), testMessage(2)))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, sink.Publish(ctx, testMessage(3)), context.DeadlineExceeded)
+}
+
+func TestFanout(t *testing.T) {
+	first, second := NewChannelSink(1), &failingSink{failID: 1}
+
+	require.Error(t, Fanout(first, second).Publish(context.Background(), testMessage(1)))
+	require.Equal(t, int64(1), (<-first.C()).ID)
+
+	require.NoError(t, Fanout(first, second).Publish(context.Background(), testMessage(2)))
+	require.Equal(t, []int64{2}, second.published)
+}
@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookSink posts every event as JSON to a URL, any answer but a 2xx is a failed delivery
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink for the endpoint at rawURL
+func NewWebhookSink(rawURL string, client *http.Client) (*WebhookSink, error) {
+	if _, err := url.ParseRequestURI(rawURL); err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookSink{url: rawURL, client: client}, nil
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", msg.EventID)
+	req.Header.Set("X-Event-Type", string(msg.Type))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
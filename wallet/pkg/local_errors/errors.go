@@ -15,15 +15,64 @@ var (
 	ErrCurrencyNotFound           = errors.New("currency not found")
 	ErrSomethingWrong             = errors.New("something went wrong")
 	ErrCurrencyMismatch           = errors.New("currency mismatch")
+	ErrCurrencyDisabled           = errors.New("currency is not enabled")
 	ErrWalletNotFound             = errors.New("wallet not found")
 	ErrMissingAuthHeader          = errors.New("missing authorization header")
 	ErrInvalidAuthHeaderFormat    = errors.New("invalid auth header format")
 	ErrUnsupportedAuth            = errors.New("auth type not supported")
 	ErrUnauthorized               = errors.New("unauthorized user")
+	ErrInvalidToken               = errors.New("token is invalid")
+	ErrExpiredToken               = errors.New("token has expired")
+	ErrWrongTokenType             = errors.New("token is not of the expected type")
 	ErrOrganizationWalletNotFound = errors.New("organization wallet with the currency doesn't exist")
 	ErrInsufficientBalance        = errors.New("insufficient balance")
 	ErrWalletInactive             = errors.New("wallet is inactive")
 	ErrPaymentRequestNotFound     = errors.New("payment request not found")
+	ErrUserBlocked                = errors.New("user is blocked")
+	ErrSessionNotFound            = errors.New("session not found")
+	ErrSessionBlocked             = errors.New("session is blocked")
+	ErrSessionExpired             = errors.New("session has expired")
+	ErrSessionMismatch            = errors.New("session does not match the token")
+	ErrIdempotencyKeyMismatch     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress   = errors.New("a request with this idempotency key is still being processed")
+	ErrInvalidCursor              = errors.New("invalid pagination cursor")
+	ErrWalletCurrencyExists       = errors.New("user already has a wallet in this currency")
+	ErrFXRateUnavailable          = errors.New("exchange rate is not available")
+	ErrFXQuoteNotFound            = errors.New("fx quote not found")
+	ErrFXQuoteExpired             = errors.New("fx quote has expired")
+	ErrFXQuoteUsed                = errors.New("fx quote was already used")
+	ErrRecipientRequired          = errors.New("exactly one of to_wallet_address and to_username is required")
+	ErrPayAmountNotPositive       = errors.New("amount to pay should be positive")
+	ErrPayToSameWallet            = errors.New("can not pay to the same wallet")
+	ErrPayToOrganizationWallet    = errors.New("can not pay to an organization wallet")
+	ErrTransNotFound              = errors.New("transaction not found")
+	ErrAdminRequired              = errors.New("only an admin can do this")
+	ErrTransNotRefundable         = errors.New("transaction can not be refunded")
+	ErrRefundExceedsAmount        = errors.New("refunds can not exceed the amount the payee received")
+	ErrTransAlreadyReversed       = errors.New("transaction was already reversed")
+	ErrTransRefunded              = errors.New("a refunded transaction can not be reversed")
+	ErrWalletHasPostings          = errors.New("wallet already has ledger postings")
+	ErrHoldNotFound               = errors.New("hold not found")
+	ErrHoldNotActive              = errors.New("hold was already captured, voided or released")
+	ErrHoldExpired                = errors.New("hold has expired")
+	ErrCaptureExceedsHold         = errors.New("capture exceeds the held amount")
+	ErrCaptureBelowFee            = errors.New("capture does not cover the fee")
+	ErrScheduledPaymentNotFound   = errors.New("scheduled payment not found")
+	ErrScheduledPaymentNotActive  = errors.New("scheduled payment is not active")
+	ErrScheduledPaymentNotDue     = errors.New("scheduled payment is not due")
+	ErrWebhookNotFound            = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound    = errors.New("webhook delivery not found")
+	ErrInvalidWebhookSignature    = errors.New("invalid webhook signature")
+	ErrWebhookURLNotPublic        = errors.New("webhook url must resolve to a public address")
+	ErrStreamBehind               = errors.New("stream fell behind, reconnect with the last event id")
+	ErrMigrationsPending          = errors.New("the database schema is behind, run the pending migrations")
+	ErrMigrationChecksumMismatch  = errors.New("an applied migration was changed")
+	ErrUnknownMigration           = errors.New("the database has a migration this build does not know")
+
+	ErrInvalidPaymentRequestTransition = errors.New("payment request can not move to this status")
+	ErrPerTransactionLimitExceeded     = errors.New("amount exceeds the per transaction limit")
+	ErrDailyLimitExceeded              = errors.New("transfer exceeds the daily limit")
+	ErrMonthlyLimitExceeded            = errors.New("transfer exceeds the monthly limit")
 )
 
 // Error renderer type for handling all sorts of errors.
@@ -41,14 +90,28 @@ func (e *Error) Render(w http.ResponseWriter, r *http.Request) error {
 
 func Status(err error) int {
 	switch err {
-	case ErrUserNotFound, ErrWalletNotFound, ErrCurrencyNotFound, ErrPaymentRequestNotFound:
+	case ErrUserNotFound, ErrWalletNotFound, ErrCurrencyNotFound, ErrPaymentRequestNotFound, ErrSessionNotFound,
+		ErrFXQuoteNotFound, ErrTransNotFound, ErrHoldNotFound,
+		ErrScheduledPaymentNotFound, ErrWebhookNotFound, ErrWebhookDeliveryNotFound:
 		return http.StatusNotFound
-	case ErrUsernameAlreadyTaken, ErrUserAlreadyExist, ErrOrganizationWalletNotFound, ErrInsufficientBalance, ErrWalletInactive:
+	case ErrUsernameAlreadyTaken, ErrUserAlreadyExist, ErrOrganizationWalletNotFound, ErrInsufficientBalance, ErrWalletInactive,
+		ErrUserBlocked, ErrCurrencyDisabled, ErrPerTransactionLimitExceeded, ErrDailyLimitExceeded, ErrMonthlyLimitExceeded,
+		ErrAdminRequired:
 		return http.StatusForbidden
-	case ErrCurrencyMismatch:
+	case ErrCurrencyMismatch, ErrIdempotencyKeyInProgress, ErrInvalidPaymentRequestTransition, ErrWalletCurrencyExists,
+		ErrFXQuoteExpired, ErrFXQuoteUsed, ErrTransNotRefundable, ErrRefundExceedsAmount, ErrTransAlreadyReversed, ErrTransRefunded,
+		ErrWalletHasPostings, ErrHoldNotActive, ErrHoldExpired, ErrCaptureExceedsHold, ErrCaptureBelowFee, ErrScheduledPaymentNotActive,
+		ErrScheduledPaymentNotDue, ErrPayToOrganizationWallet:
 		return http.StatusConflict
-	case ErrMissingAuthHeader, ErrInvalidAuthHeaderFormat, ErrUnsupportedAuth, ErrUnauthorized, ErrIncorrectPassword:
+	case ErrIdempotencyKeyMismatch:
+		return http.StatusUnprocessableEntity
+	case ErrMissingAuthHeader, ErrInvalidAuthHeaderFormat, ErrUnsupportedAuth, ErrUnauthorized, ErrIncorrectPassword,
+		ErrInvalidToken, ErrExpiredToken, ErrWrongTokenType, ErrSessionBlocked, ErrSessionExpired, ErrSessionMismatch, ErrInvalidWebhookSignature:
 		return http.StatusUnauthorized
+	case ErrInvalidCursor, ErrWebhookURLNotPublic, ErrRecipientRequired, ErrPayAmountNotPositive, ErrPayToSameWallet:
+		return http.StatusBadRequest
+	case ErrFXRateUnavailable:
+		return http.StatusServiceUnavailable
 	case ErrSomethingWrong:
 		return http.StatusInternalServerError
 	default:
@@ -0,0 +1,161 @@
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five field cron expression: minute hour day-of-month month day-of-week.
+// Fields take *, numbers, ranges a-b, steps */n or a-b/n and comma separated lists of these.
+// Like cron, when both day fields are restricted a time matching either of them matches.
+type Cron struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// anyDay and anyWeekday are set when the day fields are *
+	anyDay     bool
+	anyWeekday bool
+}
+
+// maxCronSearch bounds the search for the next match, expressions like "0 0 30 2 *" never match
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+type cronField struct {
+	name string
+	min  int
+	max  int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron parses a cron expression like "30 9 * * 1-5"
+func ParseCron(expr string) (Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("cron expression needs %d fields, got %d", len(cronFields), len(fields))
+	}
+
+	var bits [5]uint64
+	for i, f := range cronFields {
+		b, err := parseCronField(fields[i], f)
+		if err != nil {
+			return Cron{}, err
+		}
+		bits[i] = b
+	}
+
+	// 7 is another name for sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+		bits[4] &^= 1 << 7
+	}
+
+	return Cron{
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(value string, f cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			rangePart = part[:i]
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in cron %s field %q", f.name, part)
+			}
+			step = s
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range in cron %s field %q", f.name, part)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in cron %s field %q", f.name, part)
+			}
+			lo, hi = n, n
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("cron %s field %q is out of range %d-%d", f.name, part, f.min, f.max)
+		}
+
+		for n := lo; n <= hi; n += step {
+			bits |= 1 << uint(n)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first time strictly after t that matches the expression, in the location of t
+func (c Cron) Next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+
+	for t.Before(limit) {
+		if c.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+func (c Cron) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
@@ -0,0 +1,60 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "30 9 * * 1-5", "*/15 0-6/2 1,15 * 7", "0 0 29 2 *"} {
+		_, err := ParseCron(expr)
+		require.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		require.NoError(t, err)
+		return v
+	}
+
+	testCases := []struct {
+		name string
+		expr string
+		from string
+		want string
+	}{
+		{name: "every minute", expr: "* * * * *", from: "2024-03-10 10:00", want: "2024-03-10 10:01"},
+		{name: "weekdays at 9:30", expr: "30 9 * * 1-5", from: "2024-03-08 09:30", want: "2024-03-11 09:30"},
+		{name: "sunday as 7", expr: "0 8 * * 7", from: "2024-03-10 08:00", want: "2024-03-17 08:00"},
+		{name: "every quarter hour", expr: "*/15 * * * *", from: "2024-03-10 10:07", want: "2024-03-10 10:15"},
+		{name: "month rollover", expr: "0 0 1 * *", from: "2024-12-15 00:00", want: "2025-01-01 00:00"},
+		{name: "leap day", expr: "0 12 29 2 *", from: "2024-03-01 00:00", want: "2028-02-29 12:00"},
+		// with both day fields restricted either one matches
+		{name: "day or weekday", expr: "0 0 15 * 1", from: "2024-03-12 00:00", want: "2024-03-15 00:00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+
+			next, ok := c.Next(at(tc.from))
+			require.True(t, ok)
+			require.Equal(t, at(tc.want), next)
+		})
+	}
+
+	c, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	_, ok := c.Next(at("2024-01-01 00:00"))
+	require.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package recurrence
+
+import (
+	"fmt"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyONCE    Frequency = "ONCE"
+	FrequencyDAILY   Frequency = "DAILY"
+	FrequencyWEEKLY  Frequency = "WEEKLY"
+	FrequencyMONTHLY Frequency = "MONTHLY"
+	// FrequencyCRON follows a cron expression, evaluated in UTC
+	FrequencyCRON Frequency = "CRON"
+)
+
+// Rule describes when a recurring event happens, starting at Start
+type Rule struct {
+	Frequency Frequency `json:"frequency"`
+	// Interval repeats every Interval days, weeks or months, zero means one
+	Interval int       `json:"interval"`
+	Cron     string    `json:"cron"`
+	Start    time.Time `json:"start"`
+}
+
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case FrequencyONCE, FrequencyDAILY, FrequencyWEEKLY, FrequencyMONTHLY:
+		if r.Cron != "" {
+			return fmt.Errorf("cron is only used with the %s frequency", FrequencyCRON)
+		}
+	case FrequencyCRON:
+		if _, err := ParseCron(r.Cron); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown frequency %q", r.Frequency)
+	}
+
+	if r.Interval < 0 {
+		return fmt.Errorf("interval can not be negative")
+	}
+	if r.Start.IsZero() {
+		return fmt.Errorf("rule needs a start")
+	}
+	return nil
+}
+
+// First is the first occurrence, for cron rules the first match at or after Start
+func (r Rule) First() (time.Time, bool) {
+	if r.Frequency != FrequencyCRON {
+		return r.Start, true
+	}
+	return r.Next(r.Start.Add(-time.Minute))
+}
+
+// Next returns the occurrence after prev, which is itself an occurrence of the rule.
+// Cron rules take any time for prev. There is no next occurrence for a rule happening once.
+func (r Rule) Next(prev time.Time) (time.Time, bool) {
+	interval := r.Interval
+	if interval == 0 {
+		interval = 1
+	}
+
+	switch r.Frequency {
+	case FrequencyDAILY:
+		return prev.AddDate(0, 0, interval), true
+	case FrequencyWEEKLY:
+		return prev.AddDate(0, 0, 7*interval), true
+	case FrequencyMONTHLY:
+		// months are counted from Start so a start on the 31st stays at the end of shorter months
+		months := monthsBetween(r.Start, prev) + interval
+		return addMonths(r.Start, months), true
+	case FrequencyCRON:
+		c, err := ParseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return c.Next(prev.UTC())
+	default:
+		return time.Time{}, false
+	}
+}
+
+// NextAfter returns the first occurrence following prev that is after t, missed occurrences are skipped
+func (r Rule) NextAfter(prev time.Time, t time.Time) (time.Time, bool) {
+	next, ok := r.Next(prev)
+	for ok && !next.After(t) {
+		next, ok = r.Next(next)
+	}
+	return next, ok
+}
+
+func monthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+}
+
+// addMonths moves t by n months, keeping its day unless the target month is shorter
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
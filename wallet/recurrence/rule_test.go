@@ -0,0 +1,61 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleNext(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+
+	monthly := Rule{Frequency: FrequencyMONTHLY, Start: start}
+	var got []time.Time
+	for next, ok := monthly.First(); ok && len(got) < 4; next, ok = monthly.Next(next) {
+		got = append(got, next)
+	}
+	// the day is clamped in short months and comes back afterwards
+	require.Equal(t, []time.Time{
+		start,
+		time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC),
+	}, got)
+
+	weekly := Rule{Frequency: FrequencyWEEKLY, Interval: 2, Start: start}
+	next, ok := weekly.Next(start)
+	require.True(t, ok)
+	require.Equal(t, start.AddDate(0, 0, 14), next)
+
+	_, ok = Rule{Frequency: FrequencyONCE, Start: start}.Next(start)
+	require.False(t, ok)
+
+	cron := Rule{Frequency: FrequencyCRON, Cron: "0 9 * * *", Start: start.Add(time.Minute)}
+	first, ok := cron.First()
+	require.True(t, ok)
+	require.Equal(t, start.AddDate(0, 0, 1), first)
+}
+
+func TestRuleNextAfter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	daily := Rule{Frequency: FrequencyDAILY, Start: start}
+
+	// occurrences missed while nothing ran are skipped
+	next, ok := daily.NextAfter(start, start.AddDate(0, 0, 3).Add(time.Hour))
+	require.True(t, ok)
+	require.Equal(t, start.AddDate(0, 0, 4), next)
+}
+
+func TestRuleValidate(t *testing.T) {
+	start := time.Now()
+
+	require.NoError(t, Rule{Frequency: FrequencyDAILY, Start: start}.Validate())
+	require.NoError(t, Rule{Frequency: FrequencyCRON, Cron: "0 9 * * 1", Start: start}.Validate())
+
+	require.Error(t, Rule{Frequency: "HOURLY", Start: start}.Validate())
+	require.Error(t, Rule{Frequency: FrequencyCRON, Cron: "0 9 * *", Start: start}.Validate())
+	require.Error(t, Rule{Frequency: FrequencyDAILY, Cron: "0 9 * * 1", Start: start}.Validate())
+	require.Error(t, Rule{Frequency: FrequencyDAILY, Interval: -1, Start: start}.Validate())
+	require.Error(t, Rule{Frequency: FrequencyDAILY}.Validate())
+}
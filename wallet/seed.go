@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dsthakur2711/wallet/api/seed"
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/database"
+	"math/rand"
+	"time"
+)
+
+// seedCommand runs `wallet seed [flags] [fixture...]`, it loads the bundled fixture when given neither files nor --users
+func seedCommand(args []string) {
+
+	fs := flag.NewFlagSet("wallet seed", flag.ExitOnError)
+	users := fs.Int("users", 0, "also generate this many synthetic users with a random history")
+	password := fs.String("password", "password", "password of the synthetic users")
+	randSeed := fs.Int64("rand-seed", 0, "seed of the synthetic history, random when 0")
+	cfg := loadConfig(fs, args)
+	ctx := context.Background()
+
+	if cfg.Database.Driver == config.DriverMemory {
+		fail(errors.New("the memory driver keeps nothing to seed, run `wallet serve --demo` instead"))
+	}
+	if *users < 0 {
+		fail(fmt.Errorf("--users must not be negative"))
+	}
+
+	type source struct {
+		name    string
+		fixture seed.Fixture
+	}
+	var sources []source
+	for _, path := range fs.Args() {
+		f, err := seed.ReadFixture(path)
+		if err != nil {
+			fail(err)
+		}
+		sources = append(sources, source{path, f})
+	}
+	if *users > 0 {
+		if *randSeed == 0 {
+			*randSeed = time.Now().UnixNano()
+		}
+		name := fmt.Sprintf("%d synthetic users (--rand-seed %d)", *users, *randSeed)
+		sources = append(sources, source{name, seed.Generate(*users, *password, rand.New(rand.NewSource(*randSeed)))})
+	}
+	if len(sources) == 0 {
+		sources = append(sources, source{"bundled fixture", seed.DefaultFixture()})
+	}
+
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		fail(err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		fail(err)
+	}
+	if err := migrator.Verify(ctx); err != nil {
+		fail(fmt.Errorf("the database schema is not the one of this build, run `wallet migrate up`: %v", err))
+	}
+
+	for _, s := range sources {
+		report, err := seed.Load(ctx, db, s.fixture)
+		if err != nil {
+			// the fixture was rolled back, the ones before it stay
+			fail(fmt.Errorf("%s: %v", s.name, err))
+		}
+		fmt.Printf("%s: %s\n", s.name, report)
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"github.com/dsthakur2711/wallet/dto"
+	logs "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// demoPassword is the password of every demo user
+const demoPassword = "demo1234"
+
+// demoUser is a user of the demo mode, its wallets are opened in order so the first one is its primary wallet
+type demoUser struct {
+	username string
+	email    string
+	wallets  []demoWallet
+}
+
+// demoWallet is credited with credit, in minor units
+type demoWallet struct {
+	currency string
+	credit   int64
+}
+
+var demoUsers = []demoUser{
+	{username: "alice", email: "alice@example.com", wallets: []demoWallet{{"INR", 1000000}, {"USD", 50000}}},
+	{username: "bob", email: "bob@example.com", wallets: []demoWallet{{"INR", 500000}}},
+	{username: "carol", email: "carol@example.com", wallets: []demoWallet{{"INR", 250000}, {"EUR", 20000}}},
+}
+
+// demoTransfers are paid from the INR wallet of from to the INR wallet of to
+var demoTransfers = []struct {
+	from, to string
+	amount   int64
+}{
+	{"alice", "bob", 25000},
+	{"bob", "carol", 10000},
+	{"carol", "alice", 5000},
+	{"alice", "carol", 12500},
+}
+
+// seedDemo fills the store of `wallet serve --demo` through the services, like the users would through the api
+func seedDemo(ctx context.Context, svc *services) error {
+
+	primary := map[string]string{}
+
+	for _, u := range demoUsers {
+		if _, err := svc.userSvc.CreateUser(ctx, dto.CreateUserDto{
+			Username: u.username,
+			Password: demoPassword,
+			Email:    u.email,
+		}); err != nil {
+			return err
+		}
+
+		for _, dw := range u.wallets {
+			w, err := svc.walletSvc.AddWallet(ctx, u.username, dto.CreateWalletDto{Username: u.username, Currency: dw.currency})
+			if err != nil {
+				return err
+			}
+			if w.IsPrimary {
+				primary[u.username] = w.WalletAddress
+			}
+
+			if _, err := svc.walletSvc.Credit(ctx, u.username, dto.CreditDto{WalletAddress: w.WalletAddress, Amount: dw.credit}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, t := range demoTransfers {
+		if _, err := svc.walletSvc.Pay(ctx, t.from, dto.TransferMoneyDto{
+			FromWalletAddress: primary[t.from],
+			ToUsername:        t.to,
+			Amount:            t.amount,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range demoUsers {
+		logs.Infof("demo user %s, password %s", u.username, demoPassword)
+	}
+	return nil
+}
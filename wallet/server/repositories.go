@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/database"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/jinzhu/gorm"
+	logs "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// repositories are the stores the services are built on
+type repositories struct {
+	user             store.UserRepo
+	session          store.SessionRepo
+	trans            store.TransRepo
+	ledger           store.LedgerRepo
+	fxQuote          store.FXQuoteRepo
+	wallet           store.WalletRepo
+	idempotency      store.IdempotencyRepo
+	paymentRequest   store.PaymentRequestRepo
+	hold             store.HoldRepo
+	scheduledPayment store.ScheduledPaymentRepo
+	lease            store.LeaseRepo
+	webhook          store.WebhookRepo
+	outbox           store.OutboxRepo
+}
+
+func newSQLRepositories(db *gorm.DB) repositories {
+	transRepo := store.NewTransRepo(db)
+	ledgerRepo := store.NewLedgerRepo(db)
+	fxQuoteRepo := store.NewFXQuoteRepo(db)
+
+	return repositories{
+		user:             store.NewUserRepo(db),
+		session:          store.NewSessionRepo(db),
+		trans:            transRepo,
+		ledger:           ledgerRepo,
+		fxQuote:          fxQuoteRepo,
+		wallet:           store.NewWalletRepo(db, transRepo, ledgerRepo, fxQuoteRepo),
+		idempotency:      store.NewIdempotencyRepo(db),
+		paymentRequest:   store.NewPaymentRequestRepo(db),
+		hold:             store.NewHoldRepo(db),
+		scheduledPayment: store.NewScheduledPaymentRepo(db),
+		lease:            store.NewLeaseRepo(db),
+		webhook:          store.NewWebhookRepo(db),
+		outbox:           store.NewOutboxRepo(db),
+	}
+}
+
+func newMemoryRepositories(m *store.MemoryStore) repositories {
+	return repositories{
+		user:             store.NewMemoryUserRepo(m),
+		session:          store.NewMemorySessionRepo(m),
+		trans:            store.NewMemoryTransRepo(m),
+		ledger:           store.NewMemoryLedgerRepo(m),
+		fxQuote:          store.NewMemoryFXQuoteRepo(m),
+		wallet:           store.NewMemoryWalletRepo(m),
+		idempotency:      store.NewMemoryIdempotencyRepo(m),
+		paymentRequest:   store.NewMemoryPaymentRequestRepo(m),
+		hold:             store.NewMemoryHoldRepo(m),
+		scheduledPayment: store.NewMemoryScheduledPaymentRepo(m),
+		lease:            store.NewMemoryLeaseRepo(m),
+		webhook:          store.NewMemoryWebhookRepo(m),
+		outbox:           store.NewMemoryOutboxRepo(m),
+	}
+}
+
+// openRepositories opens the configured database, close releases it once the server stopped.
+// The memory driver keeps everything in the process, nothing is left after it exits.
+func openRepositories(cfg config.DatabaseConfig) (repos repositories, close func(), err error) {
+
+	if cfg.Driver == config.DriverMemory {
+		logs.Warn("database.driver is memory, the data is lost when the server stops")
+		return newMemoryRepositories(store.NewMemoryStore()), func() {}, nil
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return repos, nil, err
+	}
+
+	// the schema is only changed by `wallet migrate`, the server refuses to run on another one
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return repos, nil, err
+	}
+	if err := migrator.Verify(context.Background()); err != nil {
+		db.Close()
+		return repos, nil, fmt.Errorf("the database schema is not the one of this build, run `wallet migrate up`: %v", err)
+	}
+
+	return newSQLRepositories(db), func() { db.Close() }, nil
+}
@@ -1,17 +1,27 @@
 package server
 
 import (
+	"fmt"
 	"github.com/dsthakur2711/wallet/api"
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/constant"
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/fx"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/outbox"
 	"github.com/dsthakur2711/wallet/service"
-	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/stream"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/dsthakur2711/wallet/webhook"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/httprate"
 	"github.com/go-chi/render"
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/jinzhu/gorm"
 	logs "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -20,56 +30,262 @@ import (
 )
 
 const (
-	serverAddress = "127.0.0.1:8000"
+	// how often expired idempotency keys are deleted
+	idempotencyPurgeInterval = time.Hour
+	// how often expired holds are released
+	holdSweepInterval = time.Minute
+	// how often the scheduled payments are run, the scheduler lease outlives two runs
+	schedulerInterval = 30 * time.Second
+	schedulerLeaseTTL = 2 * time.Minute
+	// how often the outbox events are published
+	relayInterval = time.Second
+	relayLeaseTTL = 30 * time.Second
+	// how often the due webhook deliveries are sent
+	webhookInterval = 5 * time.Second
+	webhookLeaseTTL = 30 * time.Second
+	// how often the wallet streams look for new outbox events
+	streamPollInterval = 500 * time.Millisecond
 )
 
 // Start starts the external server
-func Start() {
+func Start(cfg config.Config) {
 
-	go StartServer()
+	go StartServer(cfg)
 }
 
-
-func dbConn() (db *gorm.DB) {
-	/*dbDriver := "mysql"
-	dbUser := "root"
-	dbPass := "password"
-	dbName := "walletDB"*/
-	//db, err := sql.Open("mysql", "root:password@tcp(127.0.0.1:3306)/walletDB")
-	db, err := gorm.Open("mysql", "root:password@tcp(127.0.0.1:3306)/walletDB?parseTime=true")
-	logs.Print("db connection opened")
+func newTokenMaker(cfg config.TokenConfig) token.Maker {
+	tokenMaker, err := token.NewMaker(cfg.Type, cfg.SymmetricKey)
 	if err != nil {
 		panic(err.Error())
 	}
-	return db
+	return tokenMaker
 }
 
-func createRouter() *chi.Mux {
-	r := chi.NewRouter()
+// newRateProvider asks the rate service at fx.rates_url, or serves the rates file at
+// fx.rates_file, falling back to the bundled static rates
+func newRateProvider(cfg config.FXConfig) (fx.FXRateProvider, error) {
+	if cfg.RatesURL != "" {
+		return fx.NewHTTPRateProvider(cfg.RatesURL, nil)
+	}
+
+	if cfg.RatesFile != "" {
+		f, err := os.Open(cfg.RatesFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
 
-	r.Use(httprate.LimitByIP(100, 1*time.Minute))
+		return fx.LoadStaticRateProvider(f)
+	}
 
-	return r
+	logs.Warn("fx.rates_url and fx.rates_file not set, using the bundled exchange rates")
+	return fx.DefaultStaticRateProvider()
+}
+
+// newFeeSchedule loads the fee schedule at path, without it transfers are free
+func newFeeSchedule(path string) (*fee.Schedule, error) {
+	if path == "" {
+		logs.Warn("payments.fee_schedule_file not set, transfers are free")
+		return fee.Free(), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return fee.LoadSchedule(f)
+}
+
+// newTransferLimits loads the transfer limits at path, without it transfers are not limited
+func newTransferLimits(path string) (*limit.Policy, error) {
+	if path == "" {
+		logs.Warn("payments.transfer_limits_file not set, transfers are not limited")
+		return limit.Unlimited(), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return limit.LoadPolicy(f)
+}
+
+// newEventSink publishes the events to the log, and to the file at events.file and the
+// endpoint at events.webhook_url when they are set
+func newEventSink(cfg config.EventsConfig) (outbox.Sink, error) {
+	sinks := []outbox.Sink{outbox.NewLogSink()}
+
+	if cfg.File != "" {
+		sink, err := outbox.NewFileSink(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if cfg.WebhookURL != "" {
+		sink, err := outbox.NewWebhookSink(cfg.WebhookURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return outbox.Fanout(sinks...), nil
+}
+
+// services holds everything the routes and the background workers need
+type services struct {
+	tokenMaker          token.Maker
+	userSvc             service.UserSvc
+	walletSvc           service.WalletSvc
+	idempotencySvc      service.IdempotencySvc
+	paymentRequestSvc   service.PaymentRequestSvc
+	transSvc            service.TransSvc
+	fxSvc               service.FXSvc
+	organizationSvc     service.OrganizationSvc
+	holdSvc             service.HoldSvc
+	scheduledPaymentSvc service.ScheduledPaymentSvc
+	webhookSvc          service.WebhookSvc
+	walletStreamSvc     service.WalletStreamSvc
+	relay               *outbox.Relay
+	broker              *stream.Broker
+}
+
+// newServices builds the services on repos, the files the config names are read here
+func newServices(cfg config.Config, repos repositories) (*services, error) {
+
+	fees, err := newFeeSchedule(cfg.Payments.FeeScheduleFile)
+	if err != nil {
+		return nil, fmt.Errorf("payments.fee_schedule_file: %v", err)
+	}
+	limits, err := newTransferLimits(cfg.Payments.TransferLimitsFile)
+	if err != nil {
+		return nil, fmt.Errorf("payments.transfer_limits_file: %v", err)
+	}
+	rates, err := newRateProvider(cfg.FX)
+	if err != nil {
+		return nil, fmt.Errorf("fx: %v", err)
+	}
+	sink, err := newEventSink(cfg.Events)
+	if err != nil {
+		return nil, fmt.Errorf("events: %v", err)
+	}
+
+	tokenMaker := newTokenMaker(cfg.Token)
+	walletSvc := service.NewWalletService(repos.wallet, repos.user, currency.Default(), fees, limits)
+	webhookSvc := service.NewWebhookService(repos.webhook, repos.lease, repos.wallet, webhook.NewSender(nil),
+		service.RetryPolicy{
+			MaxAttempts: constant.WebhookMaxAttempts,
+			Backoff:     cfg.Events.WebhookRetryBackoff,
+		}, webhookLeaseTTL)
+	broker := stream.NewBroker(repos.outbox, streamPollInterval)
+
+	return &services{
+		tokenMaker:        tokenMaker,
+		userSvc:           service.NewUserService(repos.user, repos.session, tokenMaker),
+		walletSvc:         walletSvc,
+		idempotencySvc:    service.NewIdempotencyService(repos.idempotency, cfg.Payments.IdempotencyKeyTTL),
+		paymentRequestSvc: service.NewPaymentRequestService(repos.paymentRequest, repos.wallet, repos.user, fees, limits),
+		transSvc:          service.NewTransService(repos.trans, repos.wallet, repos.user),
+		organizationSvc:   service.NewOrganizationService(repos.wallet, repos.ledger, currency.Default()),
+		fxSvc: service.NewFXService(repos.fxQuote, repos.wallet, repos.user, rates, currency.Default(), limits,
+			cfg.FX.QuoteTTL),
+		holdSvc: service.NewHoldService(repos.hold, repos.wallet, repos.user, fees, limits,
+			cfg.Payments.HoldTTL),
+		scheduledPaymentSvc: service.NewScheduledPaymentService(repos.scheduledPayment, repos.lease, repos.wallet, walletSvc,
+			service.RetryPolicy{
+				MaxAttempts: constant.ScheduledPaymentMaxAttempts,
+				Backoff:     cfg.Payments.ScheduledRetryBackoff,
+			}, schedulerLeaseTTL),
+		webhookSvc:      webhookSvc,
+		walletStreamSvc: service.NewWalletStreamService(repos.outbox, repos.wallet, broker),
+		// the webhook deliveries are queued by the relay like any other sink
+		relay:  outbox.NewRelay(repos.outbox, repos.lease, outbox.Fanout(sink, webhookSvc), relayLeaseTTL, constant.OutboxMaxAttempts),
+		broker: broker,
+	}, nil
 }
-func initRoutes(db *gorm.DB, r *chi.Mux) *chi.Mux {
 
-	userRepo := store.NewUserRepo(db)
-	userSvc := service.NewUserService(userRepo)
-	userApi := api.NewUserResource(userSvc)
+func createRouter(cfg config.RateLimitConfig) *chi.Mux {
+	r := chi.NewRouter()
+
+	if cfg.Requests > 0 {
+		r.Use(httprate.LimitByIP(cfg.Requests, cfg.Window))
+	}
 
-	transRepo := store.NewTransRepo(db)
-	walletRepo := store.NewWalletRepo(db, transRepo)
-	walletSvc := service.NewWalletService(walletRepo)
-	walletApi := api.NewWalletResource(walletSvc)
+	return r
+}
+func initRoutes(svc *services, r *chi.Mux) *chi.Mux {
+
+	userApi := api.NewUserResource(svc.userSvc)
+	walletApi := api.NewWalletResource(svc.walletSvc)
+	paymentRequestApi := api.NewPaymentRequestResource(svc.paymentRequestSvc)
+	transApi := api.NewTransResource(svc.transSvc)
+	fxApi := api.NewFXResource(svc.fxSvc)
+	holdApi := api.NewHoldResource(svc.holdSvc)
+	scheduledPaymentApi := api.NewScheduledPaymentResource(svc.scheduledPaymentSvc)
+	webhookApi := api.NewWebhookResource(svc.webhookSvc)
+	walletStreamApi := api.NewWalletStreamResource(svc.walletStreamSvc, svc.userSvc)
 	//Routes
 	//public
-	 //userApi.RegisterRoutes(r.With(httprate.LimitByIP(10, 1*time.Minute)))
+	//userApi.RegisterRoutes(r.With(httprate.LimitByIP(10, 1*time.Minute)))
 	r.Post("/users", userApi.Create)
 	r.Get("/users/login", userApi.Login)
-
-	r.Post("/wallet/addWallet", walletApi.AddWallet)
-	r.Post("/wallet/pay", walletApi.Pay)
-	r.Put("/wallet/credit", walletApi.Credit)
+	r.Post("/tokens/renew", userApi.RenewAccessToken)
+
+	//private
+	r.Group(func(r chi.Router) {
+		r.Use(api.AuthMiddleware(svc.tokenMaker, svc.userSvc))
+
+		r.Post("/users/logout", userApi.Logout)
+		r.Put("/users/{username}/status", userApi.UpdateStatus)
+
+		r.Post("/wallet/addWallet", walletApi.AddWallet)
+		r.With(api.IdempotencyMiddleware(svc.idempotencySvc, "pay")).Post("/wallet/pay", walletApi.Pay)
+		r.With(api.IdempotencyMiddleware(svc.idempotencySvc, "credit")).Put("/wallet/credit", walletApi.Credit)
+
+		r.Get("/fees/quote", walletApi.QuoteFee)
+
+		r.Get("/wallets/{address}", walletApi.Get)
+		r.Put("/wallets/{address}/primary", walletApi.SetPrimary)
+		r.Get("/wallets/{address}/limits", walletApi.Limits)
+		r.Get("/wallets/{address}/stream", walletStreamApi.Stream)
+		r.Get("/users/{username}/wallets", walletApi.ListByUsername)
+		r.Get("/wallets/{address}/transactions", transApi.ListByWallet)
+		r.With(api.IdempotencyMiddleware(svc.idempotencySvc, "refund")).Post("/transactions/{id}/refund", transApi.Refund)
+		r.With(api.IdempotencyMiddleware(svc.idempotencySvc, "reverse")).Post("/transactions/{id}/reverse", transApi.Reverse)
+
+		r.Post("/fx/quotes", fxApi.CreateQuote)
+		r.Get("/fx/quotes/{id}", fxApi.GetQuote)
+		r.With(api.IdempotencyMiddleware(svc.idempotencySvc, "pay_fx")).Post("/wallet/pay/fx", fxApi.Pay)
+
+		r.Post("/holds", holdApi.Create)
+		r.Get("/holds/{id}", holdApi.Get)
+		r.With(api.IdempotencyMiddleware(svc.idempotencySvc, "capture")).Post("/holds/{id}/capture", holdApi.Capture)
+		r.Post("/holds/{id}/void", holdApi.Void)
+
+		r.Post("/scheduled-payments", scheduledPaymentApi.Create)
+		r.Get("/scheduled-payments", scheduledPaymentApi.List)
+		r.Get("/scheduled-payments/{id}", scheduledPaymentApi.Get)
+		r.Post("/scheduled-payments/{id}/cancel", scheduledPaymentApi.Cancel)
+		r.Get("/scheduled-payments/{id}/runs", scheduledPaymentApi.ListRuns)
+
+		r.Post("/webhooks", webhookApi.Create)
+		r.Get("/webhooks", webhookApi.List)
+		r.Delete("/webhooks/{id}", webhookApi.Delete)
+		r.Get("/webhooks/{id}/deliveries", webhookApi.ListDeliveries)
+		r.Post("/webhooks/{id}/deliveries/{delivery_id}/replay", webhookApi.ReplayDelivery)
+
+		r.Post("/payment-requests", paymentRequestApi.Create)
+		r.Get("/payment-requests/pending", paymentRequestApi.ListPending)
+		r.Post("/payment-requests/{id}/approve", paymentRequestApi.Approve)
+		r.Post("/payment-requests/{id}/refuse", paymentRequestApi.Refuse)
+		r.Post("/payment-requests/{id}/cancel", paymentRequestApi.Cancel)
+	})
 
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		render.JSON(w, r, "deepak")
@@ -78,20 +294,44 @@ func initRoutes(db *gorm.DB, r *chi.Mux) *chi.Mux {
 	return r
 }
 
-
 // Start starts the internal server
-func StartServer() {
+func StartServer(cfg config.Config) {
 	log.Print("Starting server")
 
-	db := dbConn()
-	defer db.Close()
+	repos, closeRepos, err := openRepositories(cfg.Database)
+	if err != nil {
+		log.Fatal("failed to open the database ", err)
+	}
+	defer closeRepos()
+
+	svc, err := newServices(cfg, repos)
+	if err != nil {
+		log.Fatal("failed to set up the services ", err)
+	}
+
+	if err := svc.organizationSvc.Bootstrap(context.Background()); err != nil {
+		log.Fatal("failed to create the organization wallets ", err)
+	}
+
+	if cfg.Server.Demo {
+		if err := seedDemo(context.Background(), svc); err != nil {
+			log.Fatal("failed to seed the demo data ", err)
+		}
+	}
 
-	r := createRouter()
-	r = initRoutes(db, r)
+	r := createRouter(cfg.RateLimit)
+	r = initRoutes(svc, r)
 
-	server := &http.Server{Addr: serverAddress, Handler: r}
+	// the wallet streams never go idle, they are ended as soon as the server shuts down
+	requestCtx, stopRequests := context.WithCancel(context.Background())
+	server := &http.Server{Addr: cfg.Server.Addr, Handler: r, BaseContext: func(net.Listener) context.Context {
+		return requestCtx
+	}}
+	server.RegisterOnShutdown(stopRequests)
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
+	startWorkers(serverCtx, svc)
+
 	// Listen for syscall signals for process to interrupt/quit
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
@@ -117,7 +357,11 @@ func StartServer() {
 	}()
 
 	// Run the server
-	err := server.ListenAndServe()
+	if cfg.Server.TLS() {
+		err = server.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
 	if err != nil && err != http.ErrServerClosed {
 		log.Fatal("failed to start server", err)
 		os.Exit(1)
@@ -127,10 +371,3 @@ func StartServer() {
 	<-serverCtx.Done()
 
 }
-
-
-
-
-
-
-
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/config"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer serves the routes of the server on the memory repositories
+func newTestServer(t *testing.T) (*httptest.Server, *services) {
+	svc, err := newServices(config.Default(), newMemoryRepositories(store.NewMemoryStore()))
+	require.NoError(t, err)
+	require.NoError(t, svc.organizationSvc.Bootstrap(context.Background()))
+
+	srv := httptest.NewServer(initRoutes(svc, createRouter(config.RateLimitConfig{})))
+	t.Cleanup(srv.Close)
+	return srv, svc
+}
+
+type testClient struct {
+	t   *testing.T
+	srv *httptest.Server
+	// accessToken is sent as the bearer token when it is set
+	accessToken string
+	sessionID   string
+}
+
+// do sends body as JSON and decodes the response into out when it is not nil, it returns the status code
+func (c *testClient) do(method string, path string, header http.Header, body interface{}, out interface{}) int {
+	var reader bytes.Buffer
+	if body != nil {
+		require.NoError(c.t, json.NewEncoder(&reader).Encode(body))
+	}
+
+	req, err := http.NewRequest(method, c.srv.URL+path, &reader)
+	require.NoError(c.t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+
+	res, err := c.srv.Client().Do(req)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+
+	if out != nil && res.StatusCode == http.StatusOK {
+		require.NoError(c.t, json.NewDecoder(res.Body).Decode(out))
+	}
+	return res.StatusCode
+}
+
+// login signs the user in, the client then sends its access token
+func (c *testClient) login(username string, password string) {
+	var loggedIn dto.LoggedInUserDto
+	status := c.do(http.MethodGet, "/users/login", nil, dto.LoginCredentialsDto{Username: username, Password: password}, &loggedIn)
+	require.Equal(c.t, http.StatusOK, status)
+	c.accessToken = loggedIn.AccessToken
+	c.sessionID = loggedIn.SessionID
+}
+
+func TestServer(t *testing.T) {
+	srv, _ := newTestServer(t)
+	alice := &testClient{t: t, srv: srv}
+	bob := &testClient{t: t, srv: srv}
+
+	for _, username := range []string{"alice", "bob"} {
+		status := alice.do(http.MethodPost, "/users", nil, dto.CreateUserDto{Username: username, Password: "secret1", Email: username + "@example.com"}, nil)
+		require.Equal(t, http.StatusOK, status)
+	}
+	status := alice.do(http.MethodPost, "/users", nil, dto.CreateUserDto{Username: "alice", Password: "secret1", Email: "alice@example.com"}, nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	status = alice.do(http.MethodGet, "/users/login", nil, dto.LoginCredentialsDto{Username: "alice", Password: "wrong-password"}, nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	// the private routes need an access token
+	status = alice.do(http.MethodPost, "/wallet/addWallet", nil, dto.CreateWalletDto{Username: "alice", Currency: "INR"}, nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	alice.login("alice", "secret1")
+	bob.login("bob", "secret1")
+
+	var aliceWallet, bobWallet dto.WalletDto
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/wallet/addWallet", nil, dto.CreateWalletDto{Username: "alice", Currency: "INR"}, &aliceWallet))
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, "/wallet/addWallet", nil, dto.CreateWalletDto{Username: "bob", Currency: "INR"}, &bobWallet))
+	require.True(t, aliceWallet.IsPrimary)
+
+	var credited dto.UpdatedWalletBalanceDto
+	status = alice.do(http.MethodPut, "/wallet/credit", nil, dto.CreditDto{WalletAddress: aliceWallet.WalletAddress, Amount: 1000}, &credited)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, int64(1000), credited.UpdatedBalance)
+
+	// a payment sent twice with one idempotency key is made once
+	pay := dto.TransferMoneyDto{FromWalletAddress: aliceWallet.WalletAddress, ToUsername: "bob", Amount: 300}
+	header := http.Header{"Idempotency-Key": {"pay-1"}}
+	var first, replayed dto.TransResultDto
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/wallet/pay", header, pay, &first))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/wallet/pay", header, pay, &replayed))
+	require.Equal(t, first.ID, replayed.ID)
+
+	pay.Amount = 701
+	require.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, "/wallet/pay", nil, pay, nil))
+
+	// bob can not spend from the wallet of alice
+	require.Equal(t, http.StatusUnauthorized, bob.do(http.MethodPost, "/wallet/pay", nil, pay, nil))
+
+	var wallet dto.WalletDto
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/wallets/"+aliceWallet.WalletAddress, nil, nil, &wallet))
+	require.Equal(t, int64(700), wallet.Balance)
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/wallets/"+bobWallet.WalletAddress, nil, nil, &wallet))
+	require.Equal(t, int64(300), wallet.Balance)
+
+	// the wallets of alice do not exist for bob
+	require.Equal(t, http.StatusNotFound, bob.do(http.MethodGet, "/wallets/"+aliceWallet.WalletAddress, nil, nil, nil))
+	require.Equal(t, http.StatusNotFound, bob.do(http.MethodGet, "/users/alice/wallets", nil, nil, nil))
+
+	var page dto.TransactionPageDto
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/wallets/"+bobWallet.WalletAddress+"/transactions", nil, nil, &page))
+	require.Len(t, page.Transactions, 1)
+	require.Equal(t, first.ID, page.Transactions[0].ID)
+
+	// a refund sends the money back
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, "/transactions/"+strconv.FormatInt(first.ID, 10)+"/refund", nil, nil, nil))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/wallets/"+aliceWallet.WalletAddress, nil, nil, &wallet))
+	require.Equal(t, int64(1000), wallet.Balance)
+
+	// only admins block users
+	status = alice.do(http.MethodPut, "/users/bob/status", nil, dto.UpdateUserStatusDto{Status: model.UserStatusBLOCKED}, nil)
+	require.Equal(t, http.StatusForbidden, status)
+
+	require.Equal(t, http.StatusNoContent, alice.do(http.MethodPost, "/users/logout", nil, dto.LogoutDto{SessionID: alice.sessionID}, nil))
+}
+
+func TestSeedDemo(t *testing.T) {
+	srv, svc := newTestServer(t)
+	require.NoError(t, seedDemo(context.Background(), svc))
+
+	alice := &testClient{t: t, srv: srv}
+	alice.login("alice", demoPassword)
+
+	var wallets []dto.WalletDto
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/users/alice/wallets", nil, nil, &wallets))
+	require.Len(t, wallets, 2)
+	require.Equal(t, "INR", wallets[0].Currency)
+	require.Equal(t, int64(1000000-25000+5000-12500), wallets[0].Balance)
+
+	var page dto.TransactionPageDto
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/wallets/"+wallets[0].WalletAddress+"/transactions", nil, nil, &page))
+	require.Len(t, page.Transactions, 3)
+}
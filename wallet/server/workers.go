@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	uuid "github.com/nu7hatch/gouuid"
+
+	logs "github.com/sirupsen/logrus"
+)
+
+// startWorkers starts the background jobs, they stop when ctx is cancelled
+func startWorkers(ctx context.Context, svc *services) {
+	instanceID := newInstanceID()
+
+	// every instance follows the outbox for the wallet streams of its own clients
+	go svc.broker.Run(ctx)
+
+	go runEvery(ctx, idempotencyPurgeInterval, func(ctx context.Context) {
+		n, err := svc.idempotencySvc.PurgeExpired(ctx)
+		if err != nil {
+			logs.Errorf("failed to purge idempotency keys: %v", err)
+			return
+		}
+		if n > 0 {
+			logs.Printf("purged %d expired idempotency keys", n)
+		}
+	})
+
+	go runEvery(ctx, holdSweepInterval, func(ctx context.Context) {
+		n, err := svc.holdSvc.ReleaseExpired(ctx)
+		if err != nil {
+			logs.Errorf("failed to release expired holds: %v", err)
+			return
+		}
+		if n > 0 {
+			logs.Printf("released %d expired holds", n)
+		}
+	})
+
+	// every instance runs the scheduler, the one holding the scheduler lease pays the scheduled payments
+	go runEvery(ctx, schedulerInterval, func(ctx context.Context) {
+		n, err := svc.scheduledPaymentSvc.RunDue(ctx, instanceID)
+		if err != nil {
+			logs.Errorf("failed to run the scheduled payments: %v", err)
+			return
+		}
+		if n > 0 {
+			logs.Printf("made %d scheduled payments", n)
+		}
+	})
+
+	// likewise only the holder of the relay lease publishes the outbox events
+	go runEvery(ctx, relayInterval, func(ctx context.Context) {
+		if _, err := svc.relay.Run(ctx, instanceID); err != nil {
+			logs.Errorf("failed to publish the outbox events: %v", err)
+		}
+	})
+
+	go runEvery(ctx, webhookInterval, func(ctx context.Context) {
+		n, err := svc.webhookSvc.Dispatch(ctx, instanceID)
+		if err != nil {
+			logs.Errorf("failed to send the webhook deliveries: %v", err)
+			return
+		}
+		if n > 0 {
+			logs.Printf("sent %d webhook deliveries", n)
+		}
+	})
+}
+
+// newInstanceID names this server process in the leases it holds
+func newInstanceID() string {
+	hostname, _ := os.Hostname()
+	id, err := uuid.NewV4()
+	if err != nil {
+		return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), id)
+}
+
+// runEvery calls job every interval until ctx is done
+func runEvery(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job(ctx)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fx"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type FXSvc interface {
+	// CreateQuote locks the current rate for a transfer between wallets of different currencies
+	CreateQuote(ctx context.Context, username string, createDto dto.CreateFXQuoteDto) (dto.FXQuoteDto, error)
+	GetQuote(ctx context.Context, username string, id string) (dto.FXQuoteDto, error)
+	// Pay makes the transfer described by an unexpired quote at its locked rate
+	Pay(ctx context.Context, username string, payDto dto.PayFXDto) (dto.TransResultDto, error)
+}
+
+type fxService struct {
+	fxQuoteRepo  store.FXQuoteRepo
+	walletRepo   store.WalletRepo
+	userRepo     store.UserRepo
+	rateProvider fx.FXRateProvider
+	currencies   *currency.Registry
+	limits       *limit.Policy
+	quoteTTL     time.Duration
+}
+
+func NewFXService(fxQuoteRepo store.FXQuoteRepo, walletRepo store.WalletRepo, userRepo store.UserRepo, rateProvider fx.FXRateProvider, currencies *currency.Registry, limits *limit.Policy, quoteTTL time.Duration) FXSvc {
+	return &fxService{
+		fxQuoteRepo:  fxQuoteRepo,
+		walletRepo:   walletRepo,
+		userRepo:     userRepo,
+		rateProvider: rateProvider,
+		currencies:   currencies,
+		limits:       limits,
+		quoteTTL:     quoteTTL,
+	}
+}
+
+func (f *fxService) CreateQuote(ctx context.Context, username string, createDto dto.CreateFXQuoteDto) (dto.FXQuoteDto, error) {
+	logrus.Println("log CreateQuote in service/fx/CreateQuote ")
+
+	var quoteDto dto.FXQuoteDto
+
+	if createDto.Amount <= 0 {
+		return quoteDto, local_errors.ErrPayAmountNotPositive
+	}
+
+	fromWallet, toWallet, err := transferWallets(ctx, f.walletRepo, username, createDto.FromWalletAddress, createDto.ToWalletAddress)
+	if err != nil {
+		return quoteDto, err
+	}
+
+	if fromWallet.Currency == toWallet.Currency {
+		return quoteDto, fmt.Errorf("both wallets hold %s, use a regular payment", fromWallet.Currency)
+	}
+
+	fromCurrency, err := f.currencies.Lookup(fromWallet.Currency)
+	if err != nil {
+		return quoteDto, err
+	}
+	toCurrency, err := f.currencies.Lookup(toWallet.Currency)
+	if err != nil {
+		return quoteDto, err
+	}
+
+	rate, err := f.rateProvider.GetRate(ctx, fromCurrency.Code, toCurrency.Code)
+	if err != nil {
+		return quoteDto, err
+	}
+
+	// the rounded rate is shown on the quote and used for the conversion
+	rounded := fx.RoundRate(rate.Value)
+
+	toAmount, err := fx.Convert(createDto.Amount, rounded, fromCurrency.MinorUnits, toCurrency.MinorUnits)
+	if err != nil {
+		return quoteDto, err
+	}
+	if toAmount <= 0 {
+		return quoteDto, fmt.Errorf("amount is too small to convert")
+	}
+
+	quote, err := f.fxQuoteRepo.CreateFXQuote(ctx, store.CreateFXQuoteParams{
+		Username:          username,
+		FromWalletAddress: fromWallet.WalletAddress,
+		ToWalletAddress:   toWallet.WalletAddress,
+		FromCurrency:      fromCurrency.Code,
+		ToCurrency:        toCurrency.Code,
+		Rate:              fx.FormatRate(rounded),
+		FromAmount:        createDto.Amount,
+		ToAmount:          toAmount,
+		ExpiresAt:         time.Now().Add(f.quoteTTL),
+	})
+	if err != nil {
+		return quoteDto, err
+	}
+
+	quoteDto = dto.NewFXQuoteDto(quote)
+	return quoteDto, nil
+}
+
+func (f *fxService) GetQuote(ctx context.Context, username string, id string) (dto.FXQuoteDto, error) {
+	logrus.Println("log GetQuote in service/fx/GetQuote ")
+
+	var quoteDto dto.FXQuoteDto
+
+	quote, err := f.fxQuoteRepo.GetFXQuote(ctx, id)
+	if err != nil {
+		return quoteDto, err
+	}
+
+	if quote.Username != username {
+		return quoteDto, local_errors.ErrUnauthorized
+	}
+
+	quoteDto = dto.NewFXQuoteDto(quote)
+	return quoteDto, nil
+}
+
+func (f *fxService) Pay(ctx context.Context, username string, payDto dto.PayFXDto) (dto.TransResultDto, error) {
+	logrus.Println("log Pay in service/fx/Pay ")
+
+	var txnResDto dto.TransResultDto
+
+	quote, err := f.fxQuoteRepo.GetFXQuote(ctx, payDto.QuoteID)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	if quote.Username != username {
+		return txnResDto, local_errors.ErrUnauthorized
+	}
+	if quote.IsUsed() {
+		return txnResDto, local_errors.ErrFXQuoteUsed
+	}
+	if quote.IsExpired(time.Now()) {
+		return txnResDto, local_errors.ErrFXQuoteExpired
+	}
+
+	// the wallets may have changed since the quote was made
+	if _, _, err := transferWallets(ctx, f.walletRepo, username, quote.FromWalletAdd, quote.ToWalletAdd); err != nil {
+		return txnResDto, err
+	}
+
+	// the limits of the source currency apply to the amount leaving the payer's wallet
+	tier, err := userTier(ctx, f.userRepo, username)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	res, err := f.walletRepo.SendMoneyFX(ctx, store.SendMoneyFXParams{
+		QuoteID: quote.ID,
+		Limit:   f.limits.Lookup(quote.FromCurrency, tier),
+	})
+	if err != nil {
+		return txnResDto, err
+	}
+
+	txnResDto = dto.NewTransResultDto(res.Trans)
+	return txnResDto, nil
+}
+
+// transferWallets loads the wallets of a transfer, the source must belong to username and both must be active
+func transferWallets(ctx context.Context, walletRepo store.WalletRepo, username string, fromAddress string, toAddress string) (model.Wallet, model.Wallet, error) {
+
+	if fromAddress == toAddress {
+		return model.Wallet{}, model.Wallet{}, local_errors.ErrPayToSameWallet
+	}
+
+	fromWallet, err := walletRepo.GetWalletByAddress(ctx, fromAddress)
+	if err != nil {
+		return fromWallet, model.Wallet{}, fmt.Errorf("from_wallet_address does not exists")
+	}
+
+	if fromWallet.Username != username {
+		return fromWallet, model.Wallet{}, local_errors.ErrUnauthorized
+	}
+
+	if fromWallet.Status != model.WalletStatusACTIVE {
+		return fromWallet, model.Wallet{}, fmt.Errorf("inactive from_wallet")
+	}
+
+	toWallet, err := walletRepo.GetWalletByAddress(ctx, toAddress)
+	if err != nil {
+		return fromWallet, toWallet, fmt.Errorf("to_wallet_address does not exists")
+	}
+
+	if toWallet.IsOrganization() {
+		return fromWallet, toWallet, local_errors.ErrPayToOrganizationWallet
+	}
+
+	if toWallet.Status != model.WalletStatusACTIVE {
+		return fromWallet, toWallet, fmt.Errorf("inactive to_wallet")
+	}
+
+	return fromWallet, toWallet, nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/fx"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFXQuoteAndPay(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	fxQuoteRepo := store.NewFXQuoteRepo(db)
+	ledgerRepo := store.NewLedgerRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), ledgerRepo, fxQuoteRepo)
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	rates, err := fx.NewStaticRateProvider("USD", map[string]string{"INR": "80"})
+	require.NoError(t, err)
+	fxSvc := NewFXService(fxQuoteRepo, walletRepo, store.NewUserRepo(db), rates, currency.Default(), limit.Unlimited(), time.Minute)
+
+	payer := createTestWallet(t, db, walletRepo, 100000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+	usd, err := walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "USD"})
+	require.NoError(t, err)
+
+	// 999.99 INR at 0.0125 is 12.4998750 USD, the payee gets 12.49
+	quote, err := fxSvc.CreateQuote(ctx, payer.Username, dto.CreateFXQuoteDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   usd.WalletAddress,
+		Amount:            99999,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0.01250000", quote.Rate)
+	require.Equal(t, int64(1249), quote.ToAmount)
+	require.Equal(t, "12.49", quote.ToAmountFormatted)
+
+	// only the payer can use the quote
+	_, err = fxSvc.Pay(ctx, payee.Username, dto.PayFXDto{QuoteID: quote.ID})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	res, err := fxSvc.Pay(ctx, payer.Username, dto.PayFXDto{QuoteID: quote.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(99999), res.Amount)
+	require.Equal(t, "INR", res.Currency)
+	require.Equal(t, int64(1249), res.ToAmount)
+	require.Equal(t, "USD", res.ToCurrency)
+	require.Equal(t, "0.01250000", res.Rate)
+
+	_, err = fxSvc.Pay(ctx, payer.Username, dto.PayFXDto{QuoteID: quote.ID})
+	require.ErrorIs(t, err, local_errors.ErrFXQuoteUsed)
+
+	from, err := walletRepo.GetWalletByAddress(ctx, payer.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), from.Balance)
+
+	to, err := walletRepo.GetWalletByAddress(ctx, usd.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(1249), to.Balance)
+
+	for _, w := range []string{from.WalletAddress, to.WalletAddress} {
+		balance, err := ledgerRepo.GetPostingsBalance(ctx, w)
+		require.NoError(t, err)
+		wallet, err := walletRepo.GetWalletByAddress(ctx, w)
+		require.NoError(t, err)
+		require.Equal(t, wallet.Balance, balance)
+	}
+
+	// wallets of the same currency use the regular payment
+	_, err = fxSvc.CreateQuote(ctx, payer.Username, dto.CreateFXQuoteDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		Amount:            1,
+	})
+	require.Error(t, err)
+}
+
+func TestFXPayRejectsExpiredQuote(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	fxQuoteRepo := store.NewFXQuoteRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), fxQuoteRepo)
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	rates, err := fx.NewStaticRateProvider("USD", map[string]string{"INR": "80"})
+	require.NoError(t, err)
+	// quotes expire as soon as they are made
+	fxSvc := NewFXService(fxQuoteRepo, walletRepo, store.NewUserRepo(db), rates, currency.Default(), limit.Unlimited(), 0)
+
+	payer := createTestWallet(t, db, walletRepo, 10000)
+	usd, err := walletSvc.AddWallet(ctx, payer.Username, dto.CreateWalletDto{Username: payer.Username, Currency: "USD"})
+	require.NoError(t, err)
+
+	quote, err := fxSvc.CreateQuote(ctx, payer.Username, dto.CreateFXQuoteDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   usd.WalletAddress,
+		Amount:            8000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), quote.ToAmount)
+
+	_, err = fxSvc.Pay(ctx, payer.Username, dto.PayFXDto{QuoteID: quote.ID})
+	require.ErrorIs(t, err, local_errors.ErrFXQuoteExpired)
+
+	// the expired quote did not move any money
+	wallet, err := walletRepo.GetWalletByAddress(ctx, payer.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(10000), wallet.Balance)
+}
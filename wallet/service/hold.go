@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type HoldSvc interface {
+	// Create reserves an amount of the payer's wallet for a later payment to the target wallet
+	Create(ctx context.Context, username string, createDto dto.CreateHoldDto) (dto.HoldDto, error)
+	Get(ctx context.Context, username string, id int64) (dto.HoldDto, error)
+	// Capture pays part or all of the hold to the target wallet, the rest is released
+	Capture(ctx context.Context, username string, id int64, captureDto dto.CaptureHoldDto) (dto.TransResultDto, error)
+	Void(ctx context.Context, username string, id int64) (dto.HoldDto, error)
+	// ReleaseExpired releases the holds past their expiry, it is run by the sweeper
+	ReleaseExpired(ctx context.Context) (int64, error)
+}
+
+type holdService struct {
+	holdRepo   store.HoldRepo
+	walletRepo store.WalletRepo
+	userRepo   store.UserRepo
+	fees       *fee.Schedule
+	limits     *limit.Policy
+	holdTTL    time.Duration
+}
+
+func NewHoldService(holdRepo store.HoldRepo, walletRepo store.WalletRepo, userRepo store.UserRepo, fees *fee.Schedule, limits *limit.Policy, holdTTL time.Duration) HoldSvc {
+	return &holdService{
+		holdRepo:   holdRepo,
+		walletRepo: walletRepo,
+		userRepo:   userRepo,
+		fees:       fees,
+		limits:     limits,
+		holdTTL:    holdTTL,
+	}
+}
+
+func (h *holdService) Create(ctx context.Context, username string, createDto dto.CreateHoldDto) (dto.HoldDto, error) {
+	logrus.Println("log Create in service/hold/Create ")
+
+	var holdDto dto.HoldDto
+
+	if createDto.Amount <= 0 {
+		return holdDto, fmt.Errorf("amount to hold should be positive")
+	}
+
+	fromWallet, toWallet, err := transferWallets(ctx, h.walletRepo, username, createDto.WalletAddress, createDto.ToWalletAddress)
+	if err != nil {
+		return holdDto, err
+	}
+
+	if fromWallet.Currency != toWallet.Currency {
+		return holdDto, local_errors.ErrCurrencyMismatch
+	}
+
+	ttl := h.holdTTL
+	if createDto.ExpiresIn > 0 {
+		ttl = time.Duration(createDto.ExpiresIn) * time.Second
+	}
+
+	hold, err := h.holdRepo.CreateHold(ctx, store.CreateHoldParams{
+		WalletAddress:   fromWallet.WalletAddress,
+		ToWalletAddress: toWallet.WalletAddress,
+		Username:        username,
+		Amount:          createDto.Amount,
+		Description:     createDto.Description,
+		ExpiresAt:       time.Now().Add(ttl),
+	})
+	if err != nil {
+		return holdDto, err
+	}
+
+	holdDto = dto.NewHoldDto(hold)
+	return holdDto, nil
+}
+
+func (h *holdService) Get(ctx context.Context, username string, id int64) (dto.HoldDto, error) {
+	logrus.Println("log Get in service/hold/Get ")
+
+	var holdDto dto.HoldDto
+
+	hold, err := h.partyHold(ctx, username, id)
+	if err != nil {
+		return holdDto, err
+	}
+
+	holdDto = dto.NewHoldDto(hold)
+	return holdDto, nil
+}
+
+func (h *holdService) Capture(ctx context.Context, username string, id int64, captureDto dto.CaptureHoldDto) (dto.TransResultDto, error) {
+	logrus.Println("log Capture in service/hold/Capture ")
+
+	var txnResDto dto.TransResultDto
+
+	if captureDto.Amount < 0 {
+		return txnResDto, fmt.Errorf("amount to capture should be positive")
+	}
+
+	hold, err := h.partyHold(ctx, username, id)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	if !hold.IsActive(time.Now()) {
+		if hold.Status == model.HoldStatusACTIVE {
+			return txnResDto, local_errors.ErrHoldExpired
+		}
+		return txnResDto, local_errors.ErrHoldNotActive
+	}
+
+	amount := captureDto.Amount
+	if amount == 0 {
+		amount = hold.Amount
+	}
+
+	tier, err := userTier(ctx, h.userRepo, hold.Username)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	// a capture debits exactly the captured amount, whoever bears the fee it is taken from what the target receives
+	quote, err := h.fees.Quote(hold.Currency, tier, amount)
+	if err != nil {
+		return txnResDto, err
+	}
+	if quote.Fee >= amount {
+		return txnResDto, local_errors.ErrCaptureBelowFee
+	}
+
+	res, err := h.walletRepo.CaptureHold(ctx, store.CaptureHoldParams{
+		HoldID: hold.ID,
+		Amount: amount,
+		Fee:    quote.Fee,
+		Limit:  h.limits.Lookup(hold.Currency, tier),
+	})
+	if err != nil {
+		return txnResDto, err
+	}
+
+	txnResDto = dto.NewTransResultDto(res.Trans)
+	return txnResDto, nil
+}
+
+func (h *holdService) Void(ctx context.Context, username string, id int64) (dto.HoldDto, error) {
+	logrus.Println("log Void in service/hold/Void ")
+
+	var holdDto dto.HoldDto
+
+	if _, err := h.partyHold(ctx, username, id); err != nil {
+		return holdDto, err
+	}
+
+	hold, err := h.holdRepo.VoidHold(ctx, id)
+	if err != nil {
+		return holdDto, err
+	}
+
+	holdDto = dto.NewHoldDto(hold)
+	return holdDto, nil
+}
+
+func (h *holdService) ReleaseExpired(ctx context.Context) (int64, error) {
+	logrus.Println("log ReleaseExpired in service/hold/ReleaseExpired ")
+
+	return h.holdRepo.ReleaseExpiredHolds(ctx, time.Now())
+}
+
+// partyHold loads a hold that username placed or is the target of, only they can see, capture or void it
+func (h *holdService) partyHold(ctx context.Context, username string, id int64) (model.Hold, error) {
+
+	hold, err := h.holdRepo.GetHold(ctx, id)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Username == username {
+		return hold, nil
+	}
+
+	toWallet, err := h.walletRepo.GetWalletByAddress(ctx, hold.ToWalletAdd)
+	if err != nil {
+		return hold, err
+	}
+	if toWallet.Username != username {
+		return hold, local_errors.ErrUnauthorized
+	}
+
+	return hold, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHolds(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	userRepo := store.NewUserRepo(db)
+	holdRepo := store.NewHoldRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+
+	fees, err := fee.NewSchedule([]fee.Rule{{Currency: "INR", Kind: fee.KindFLAT, Flat: 10}})
+	require.NoError(t, err)
+	walletSvc := NewWalletService(walletRepo, userRepo, currency.Default(), fees, limit.Unlimited())
+	holdSvc := NewHoldService(holdRepo, walletRepo, userRepo, fees, limit.Unlimited(), time.Hour)
+
+	payer := createTestWallet(t, db, walletRepo, 1000)
+	merchant := createTestWallet(t, db, walletRepo, 0)
+	stranger := createTestWallet(t, db, walletRepo, 0)
+
+	wallet := func(address string) model.Wallet {
+		w, err := walletRepo.GetWalletByAddress(ctx, address)
+		require.NoError(t, err)
+		return w
+	}
+	available := func(address string) int64 {
+		w := wallet(address)
+		return w.AvailableBalance()
+	}
+
+	hold, err := holdSvc.Create(ctx, payer.Username, dto.CreateHoldDto{WalletAddress: payer.WalletAddress, ToWalletAddress: merchant.WalletAddress, Amount: 600})
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusACTIVE, hold.Status)
+
+	// held funds can not be spent
+	w := wallet(payer.WalletAddress)
+	require.Equal(t, int64(1000), w.Balance)
+	require.Equal(t, int64(400), w.AvailableBalance())
+
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: merchant.WalletAddress, Amount: 400})
+	require.Error(t, err)
+	_, err = holdSvc.Create(ctx, payer.Username, dto.CreateHoldDto{WalletAddress: payer.WalletAddress, ToWalletAddress: merchant.WalletAddress, Amount: 401})
+	require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+
+	_, err = holdSvc.Capture(ctx, stranger.Username, hold.ID, dto.CaptureHoldDto{})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+	_, err = holdSvc.Capture(ctx, merchant.Username, hold.ID, dto.CaptureHoldDto{Amount: 601})
+	require.ErrorIs(t, err, local_errors.ErrCaptureExceedsHold)
+	// the fee of 10 would leave nothing for the merchant
+	_, err = holdSvc.Capture(ctx, merchant.Username, hold.ID, dto.CaptureHoldDto{Amount: 10})
+	require.ErrorIs(t, err, local_errors.ErrCaptureBelowFee)
+	require.Equal(t, http.StatusConflict, local_errors.Status(err))
+
+	// a partial capture releases the rest of the hold
+	res, err := holdSvc.Capture(ctx, merchant.Username, hold.ID, dto.CaptureHoldDto{Amount: 400})
+	require.NoError(t, err)
+	require.Equal(t, int64(400), res.Amount)
+	require.Equal(t, int64(390), res.NetAmount)
+
+	w = wallet(payer.WalletAddress)
+	require.Equal(t, int64(600), w.Balance)
+	require.Equal(t, int64(0), w.HeldBalance)
+	require.Equal(t, int64(390), wallet(merchant.WalletAddress).Balance)
+
+	captured, err := holdSvc.Get(ctx, payer.Username, hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusCAPTURED, captured.Status)
+	require.Equal(t, int64(400), captured.CapturedAmount)
+	require.Equal(t, res.ID, captured.TransID)
+
+	_, err = holdSvc.Capture(ctx, merchant.Username, hold.ID, dto.CaptureHoldDto{})
+	require.ErrorIs(t, err, local_errors.ErrHoldNotActive)
+
+	// a voided hold gives the funds back
+	voided, err := holdSvc.Create(ctx, payer.Username, dto.CreateHoldDto{WalletAddress: payer.WalletAddress, ToWalletAddress: merchant.WalletAddress, Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, int64(500), available(payer.WalletAddress))
+
+	voided, err = holdSvc.Void(ctx, merchant.Username, voided.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusVOIDED, voided.Status)
+	require.Equal(t, int64(600), available(payer.WalletAddress))
+
+	_, err = holdSvc.Void(ctx, payer.Username, voided.ID)
+	require.ErrorIs(t, err, local_errors.ErrHoldNotActive)
+
+	// the sweeper releases holds past their expiry
+	expiring, err := holdSvc.Create(ctx, payer.Username, dto.CreateHoldDto{WalletAddress: payer.WalletAddress, ToWalletAddress: merchant.WalletAddress, Amount: 200, ExpiresIn: 60})
+	require.NoError(t, err)
+
+	_, err = holdRepo.ReleaseExpiredHolds(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, int64(400), available(payer.WalletAddress))
+
+	n, err := holdRepo.ReleaseExpiredHolds(ctx, expiring.ExpiresAt)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, int64(1))
+
+	expired, err := holdSvc.Get(ctx, payer.Username, expiring.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.HoldStatusEXPIRED, expired.Status)
+	require.Equal(t, int64(600), available(payer.WalletAddress))
+}
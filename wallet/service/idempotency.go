@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dsthakur2711/wallet/constant"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+const maxIdempotencyKeyLength = 255
+
+type IdempotencySvc interface {
+	// Begin reserves the key for a new request, replay is true when a stored response must be sent back instead.
+	// A key still in progress after its lease is recovered from the journal entry linked to it, or handed to the retry when there is none.
+	Begin(ctx context.Context, username string, scope string, key string, fingerprint string) (idempotencyKey model.IdempotencyKey, replay bool, err error)
+	Complete(ctx context.Context, id int64, statusCode int, responseBody []byte) error
+	Release(ctx context.Context, id int64) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyService struct {
+	idempotencyRepo store.IdempotencyRepo
+	ttl             time.Duration
+	lease           time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo store.IdempotencyRepo, ttl time.Duration) IdempotencySvc {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		lease:           constant.IdempotencyKeyLease,
+	}
+}
+
+func (i *idempotencyService) Begin(ctx context.Context, username string, scope string, key string, fingerprint string) (model.IdempotencyKey, bool, error) {
+	logrus.Println("log Begin in service/idempotency/Begin ")
+
+	if len(key) == 0 || len(key) > maxIdempotencyKeyLength {
+		return model.IdempotencyKey{}, false, fmt.Errorf("idempotency key must be 1 to %d characters", maxIdempotencyKeyLength)
+	}
+
+	k, created, err := i.idempotencyRepo.ReserveIdempotencyKey(ctx, store.ReserveIdempotencyKeyParams{
+		Username:    username,
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(i.ttl),
+		LockedUntil: time.Now().Add(i.lease),
+	})
+	if err != nil {
+		return k, false, err
+	}
+
+	if created {
+		return k, false, nil
+	}
+
+	if k.Fingerprint != fingerprint {
+		return k, false, local_errors.ErrIdempotencyKeyMismatch
+	}
+
+	if !k.IsCompleted() {
+		if time.Now().Before(k.LockedUntil) {
+			return k, false, local_errors.ErrIdempotencyKeyInProgress
+		}
+		return i.recover(ctx, k)
+	}
+
+	return k, true, nil
+}
+
+// recover resolves a key whose request died or failed to store its response before its lease passed,
+// the journal entry linked to the key tells whether the request moved the money
+func (i *idempotencyService) recover(ctx context.Context, k model.IdempotencyKey) (model.IdempotencyKey, bool, error) {
+
+	entry, found, err := i.idempotencyRepo.GetIdempotencyKeyEntry(ctx, k.ID)
+	if err != nil {
+		return k, false, err
+	}
+
+	if found {
+		body, err := json.Marshal(dto.RecoveredResponseDto{JournalEntryID: entry.ID, TransID: entry.TransID})
+		if err != nil {
+			return k, false, err
+		}
+		if err := i.Complete(ctx, k.ID, http.StatusOK, body); err != nil {
+			return k, false, err
+		}
+		k.StatusCode = http.StatusOK
+		k.ResponseBody = string(body)
+		return k, true, nil
+	}
+
+	// nothing was posted, the retry runs the request again unless a concurrent retry took the key first
+	lockedUntil := time.Now().Add(i.lease)
+	taken, err := i.idempotencyRepo.TakeOverIdempotencyKey(ctx, k.ID, lockedUntil)
+	if err != nil {
+		return k, false, err
+	}
+	if !taken {
+		return k, false, local_errors.ErrIdempotencyKeyInProgress
+	}
+
+	k.LockedUntil = lockedUntil
+	return k, false, nil
+}
+
+func (i *idempotencyService) Complete(ctx context.Context, id int64, statusCode int, responseBody []byte) error {
+	logrus.Println("log Complete in service/idempotency/Complete ")
+
+	return i.idempotencyRepo.SaveIdempotencyResponse(ctx, store.SaveIdempotencyResponseParams{
+		ID:           id,
+		StatusCode:   statusCode,
+		ResponseBody: string(responseBody),
+	})
+}
+
+// Release forgets the key so that the client can retry a request that failed on our side
+func (i *idempotencyService) Release(ctx context.Context, id int64) error {
+	logrus.Println("log Release in service/idempotency/Release ")
+
+	return i.idempotencyRepo.DeleteIdempotencyKey(ctx, id)
+}
+
+func (i *idempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	logrus.Println("log PurgeExpired in service/idempotency/PurgeExpired ")
+
+	return i.idempotencyRepo.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+}
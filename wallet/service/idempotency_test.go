@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRecoversCrashedRequest(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	svc := &idempotencyService{idempotencyRepo: store.NewIdempotencyRepo(db), ttl: time.Hour, lease: 50 * time.Millisecond}
+
+	payer := createTestWallet(t, db, walletRepo, 100)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	// the request moves the money and dies before it stores its response
+	k, replay, err := svc.Begin(ctx, payer.Username, "pay", "crashed", "fingerprint")
+	require.NoError(t, err)
+	require.False(t, replay)
+	sent, err := walletRepo.SendMoney(store.WithIdempotencyKey(ctx, k.ID), store.SendMoneyParams{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		Amount:            30,
+		Currency:          "INR",
+	})
+	require.NoError(t, err)
+
+	_, _, err = svc.Begin(ctx, payer.Username, "pay", "crashed", "fingerprint")
+	require.ErrorIs(t, err, local_errors.ErrIdempotencyKeyInProgress)
+
+	// once the lease passed the retry gets the transfer back instead of a second one
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		recovered, replay, err := svc.Begin(ctx, payer.Username, "pay", "crashed", "fingerprint")
+		require.NoError(t, err)
+		require.True(t, replay)
+		require.Equal(t, k.ID, recovered.ID)
+		require.Equal(t, http.StatusOK, recovered.StatusCode)
+
+		var body dto.RecoveredResponseDto
+		require.NoError(t, json.Unmarshal([]byte(recovered.ResponseBody), &body))
+		require.Equal(t, sent.Trans.ID, body.TransID)
+		require.NotZero(t, body.JournalEntryID)
+	}
+
+	// the request dies before it moves any money
+	k, _, err = svc.Begin(ctx, payer.Username, "pay", "lost", "fingerprint")
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	retried, replay, err := svc.Begin(ctx, payer.Username, "pay", "lost", "fingerprint")
+	require.NoError(t, err)
+	require.False(t, replay)
+	require.Equal(t, k.ID, retried.ID)
+
+	// the retry holds the key now
+	_, _, err = svc.Begin(ctx, payer.Username, "pay", "lost", "fingerprint")
+	require.ErrorIs(t, err, local_errors.ErrIdempotencyKeyInProgress)
+}
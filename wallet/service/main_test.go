@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/database/databasetest"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestDB opens a migrated database, see databasetest.Open, with the organization wallets
+func openTestDB(t *testing.T) *gorm.DB {
+	db := databasetest.Open(t)
+
+	// credits and fx transfers need the float wallets
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	err := NewOrganizationService(walletRepo, store.NewLedgerRepo(db), currency.Default()).Bootstrap(context.Background())
+	require.NoError(t, err)
+
+	return db
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+)
+
+type OrganizationSvc interface {
+	// Bootstrap creates the missing organization wallets of every enabled currency
+	Bootstrap(ctx context.Context) error
+}
+
+type organizationService struct {
+	walletRepo store.WalletRepo
+	ledgerRepo store.LedgerRepo
+	currencies *currency.Registry
+}
+
+func NewOrganizationService(walletRepo store.WalletRepo, ledgerRepo store.LedgerRepo, currencies *currency.Registry) OrganizationSvc {
+	return &organizationService{
+		walletRepo: walletRepo,
+		ledgerRepo: ledgerRepo,
+		currencies: currencies,
+	}
+}
+
+func (o *organizationService) Bootstrap(ctx context.Context) error {
+	logrus.Println("log Bootstrap in service/organization/Bootstrap ")
+
+	for _, c := range o.currencies.List() {
+		if !c.Enabled {
+			continue
+		}
+
+		for _, purpose := range model.OrganizationWalletPurposes {
+			_, err := o.walletRepo.EnsureOrganizationWallet(ctx, store.OrganizationWalletParams{
+				Currency: c.Code,
+				Purpose:  purpose,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// every movement has a counterparty wallet, so the balances of a currency add up to zero
+	totals, err := o.ledgerRepo.GetBalanceTotals(ctx)
+	if err != nil {
+		return err
+	}
+	for code, total := range totals {
+		if total != 0 {
+			logrus.Warnf("wallet balances in %s add up to %d instead of 0", code, total)
+		}
+	}
+
+	return nil
+}
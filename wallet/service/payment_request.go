@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentRequestSvc interface {
+	Create(ctx context.Context, username string, createDto dto.CreatePaymentRequestDto) (dto.PaymentRequestDto, error)
+	ListPending(ctx context.Context, username string) ([]dto.PaymentRequestDto, error)
+	Approve(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error)
+	Refuse(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error)
+	Cancel(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error)
+}
+
+type paymentRequestService struct {
+	paymentRequestRepo store.PaymentRequestRepo
+	walletRepo         store.WalletRepo
+	userRepo           store.UserRepo
+	fees               *fee.Schedule
+	limits             *limit.Policy
+}
+
+func NewPaymentRequestService(paymentRequestRepo store.PaymentRequestRepo, walletRepo store.WalletRepo, userRepo store.UserRepo, fees *fee.Schedule, limits *limit.Policy) PaymentRequestSvc {
+	return &paymentRequestService{
+		paymentRequestRepo: paymentRequestRepo,
+		walletRepo:         walletRepo,
+		userRepo:           userRepo,
+		fees:               fees,
+		limits:             limits,
+	}
+}
+
+func (p *paymentRequestService) Create(ctx context.Context, username string, createDto dto.CreatePaymentRequestDto) (dto.PaymentRequestDto, error) {
+	logrus.Println("log Create in service/payment_request/Create ")
+
+	var requestDto dto.PaymentRequestDto
+
+	if createDto.Amount <= 0 {
+		return requestDto, fmt.Errorf("amount to request should be positive")
+	}
+
+	if createDto.FromWalletAddress == createDto.ToWalletAddress {
+		return requestDto, fmt.Errorf("can not request money from the same wallet")
+	}
+
+	payeeWallet, err := p.walletRepo.GetWalletByAddress(ctx, createDto.ToWalletAddress)
+	if err != nil {
+		return requestDto, err
+	}
+
+	// money can only be requested into one of your own wallets
+	if payeeWallet.Username != username {
+		return requestDto, local_errors.ErrUnauthorized
+	}
+
+	payerWallet, err := p.walletRepo.GetWalletByAddress(ctx, createDto.FromWalletAddress)
+	if err != nil {
+		return requestDto, err
+	}
+
+	if payerWallet.Currency != payeeWallet.Currency {
+		return requestDto, local_errors.ErrCurrencyMismatch
+	}
+
+	request, err := p.paymentRequestRepo.CreatePaymentRequest(ctx, store.CreatePaymentRequestParams{
+		FromWalletAddress: payerWallet.WalletAddress,
+		ToWalletAddress:   payeeWallet.WalletAddress,
+		PayerUsername:     payerWallet.Username,
+		PayeeUsername:     payeeWallet.Username,
+		Amount:            createDto.Amount,
+		Currency:          payeeWallet.Currency,
+		Note:              createDto.Note,
+	})
+	if err != nil {
+		return requestDto, err
+	}
+
+	requestDto = dto.NewPaymentRequestDto(request)
+	return requestDto, nil
+}
+
+func (p *paymentRequestService) ListPending(ctx context.Context, username string) ([]dto.PaymentRequestDto, error) {
+	logrus.Println("log ListPending in service/payment_request/ListPending ")
+
+	requests, err := p.paymentRequestRepo.ListPaymentRequestsByPayer(ctx, username, model.PaymentRequestStatusWAITINGAPPROVAL)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewPaymentRequestDtos(requests), nil
+}
+
+// Approve accepts the request and pays it, the request ends in PAYMENT_SUCCESS or PAYMENT_FAILED.
+// The approval and the transfer are one repository transaction, like the capture of a hold. An error on our
+// side is returned and the request keeps WAITING_APPROVAL, so that the payer can approve it again.
+func (p *paymentRequestService) Approve(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error) {
+	logrus.Println("log Approve in service/payment_request/Approve ")
+
+	var requestDto dto.PaymentRequestDto
+
+	request, err := p.getAsPayer(ctx, username, id)
+	if err != nil {
+		return requestDto, err
+	}
+
+	if !request.Status.CanTransitionTo(model.PaymentRequestStatusAPPROVED) {
+		return requestDto, local_errors.ErrInvalidPaymentRequestTransition
+	}
+
+	payErr := p.pay(ctx, request)
+
+	if payErr == local_errors.ErrInvalidPaymentRequestTransition || payErr == local_errors.ErrPaymentRequestNotFound {
+		// another approval, refusal or cancellation got there first
+		return requestDto, payErr
+	}
+	if payErr != nil && !isPaymentFailure(payErr) {
+		return requestDto, payErr
+	}
+	if payErr != nil {
+		// the failed transfer moved nothing, the request goes straight to PAYMENT_FAILED
+		logrus.Printf("payment of request %d failed: %v", request.ID, payErr)
+		request, err = p.fail(ctx, request, payErr)
+	} else {
+		request, err = p.paymentRequestRepo.GetPaymentRequest(ctx, request.ID)
+	}
+	if err != nil {
+		return requestDto, err
+	}
+
+	requestDto = dto.NewPaymentRequestDto(request)
+	return requestDto, nil
+}
+
+// pay approves and pays the request, the fee and the limits are the payer's like on any transfer
+func (p *paymentRequestService) pay(ctx context.Context, request model.PaymentRequest) error {
+	tier, err := userTier(ctx, p.userRepo, request.PayerUsername)
+	if err != nil {
+		return err
+	}
+
+	quote, err := p.fees.Quote(request.Currency, tier, request.Amount)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.walletRepo.PayPaymentRequest(ctx, store.PayPaymentRequestParams{
+		RequestID: request.ID,
+		Amount:    quote.Gross,
+		Fee:       quote.Fee,
+		Limit:     p.limits.Lookup(request.Currency, tier),
+	})
+	return err
+}
+
+// isPaymentFailure tells whether the payment was refused for the payer's wallets, such a request can not be paid as it is
+func isPaymentFailure(err error) bool {
+	for _, failure := range []error{
+		local_errors.ErrInsufficientBalance,
+		local_errors.ErrPerTransactionLimitExceeded,
+		local_errors.ErrDailyLimitExceeded,
+		local_errors.ErrMonthlyLimitExceeded,
+		local_errors.ErrWalletInactive,
+		local_errors.ErrCurrencyMismatch,
+	} {
+		if errors.Is(err, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail records why the payment of a waiting request failed, the request passes APPROVED in a single update
+func (p *paymentRequestService) fail(ctx context.Context, request model.PaymentRequest, payErr error) (model.PaymentRequest, error) {
+	current := request.Status
+	if err := request.TransitionTo(model.PaymentRequestStatusAPPROVED); err != nil {
+		return request, err
+	}
+	if err := request.TransitionTo(model.PaymentRequestStatusPAYMENTFAILED); err != nil {
+		return request, err
+	}
+
+	return p.paymentRequestRepo.UpdatePaymentRequestStatus(ctx, store.UpdatePaymentRequestStatusParams{
+		ID:            request.ID,
+		FromStatus:    current,
+		ToStatus:      request.Status,
+		FailureReason: payErr.Error(),
+	})
+}
+
+func (p *paymentRequestService) Refuse(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error) {
+	logrus.Println("log Refuse in service/payment_request/Refuse ")
+
+	var requestDto dto.PaymentRequestDto
+
+	request, err := p.getAsPayer(ctx, username, id)
+	if err != nil {
+		return requestDto, err
+	}
+
+	request, err = p.transition(ctx, request, model.PaymentRequestStatusREFUSED, 0, "")
+	if err != nil {
+		return requestDto, err
+	}
+
+	requestDto = dto.NewPaymentRequestDto(request)
+	return requestDto, nil
+}
+
+func (p *paymentRequestService) Cancel(ctx context.Context, username string, id int64) (dto.PaymentRequestDto, error) {
+	logrus.Println("log Cancel in service/payment_request/Cancel ")
+
+	var requestDto dto.PaymentRequestDto
+
+	request, err := p.paymentRequestRepo.GetPaymentRequest(ctx, id)
+	if err != nil {
+		return requestDto, err
+	}
+
+	// only the payee who raised the request can cancel it
+	if request.PayeeUsername != username {
+		return requestDto, local_errors.ErrUnauthorized
+	}
+
+	request, err = p.transition(ctx, request, model.PaymentRequestStatusCANCELLED, 0, "")
+	if err != nil {
+		return requestDto, err
+	}
+
+	requestDto = dto.NewPaymentRequestDto(request)
+	return requestDto, nil
+}
+
+func (p *paymentRequestService) getAsPayer(ctx context.Context, username string, id int64) (model.PaymentRequest, error) {
+	request, err := p.paymentRequestRepo.GetPaymentRequest(ctx, id)
+	if err != nil {
+		return request, err
+	}
+
+	if request.PayerUsername != username {
+		return request, local_errors.ErrUnauthorized
+	}
+
+	return request, nil
+}
+
+// transition checks the move against the state machine and stores it
+func (p *paymentRequestService) transition(ctx context.Context, request model.PaymentRequest, next model.PaymentRequestStatus, transID int64, failureReason string) (model.PaymentRequest, error) {
+	current := request.Status
+	if err := request.TransitionTo(next); err != nil {
+		return request, err
+	}
+
+	return p.paymentRequestRepo.UpdatePaymentRequestStatus(ctx, store.UpdatePaymentRequestStatusParams{
+		ID:            request.ID,
+		FromStatus:    current,
+		ToStatus:      next,
+		TransID:       transID,
+		FailureReason: failureReason,
+	})
+}
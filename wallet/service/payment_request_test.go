@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovePaymentRequest(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	transRepo := store.NewTransRepo(db)
+	walletRepo := store.NewWalletRepo(db, transRepo, store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+
+	fees, err := fee.NewSchedule([]fee.Rule{{Currency: "INR", Kind: fee.KindFLAT, Flat: 10}})
+	require.NoError(t, err)
+	requestSvc := NewPaymentRequestService(store.NewPaymentRequestRepo(db), walletRepo, store.NewUserRepo(db), fees, limit.Unlimited())
+
+	payer := createTestWallet(t, db, walletRepo, 1000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	newRequest := func(amount int64) dto.PaymentRequestDto {
+		request, err := requestSvc.Create(ctx, payee.Username, dto.CreatePaymentRequestDto{
+			FromWalletAddress: payer.WalletAddress,
+			ToWalletAddress:   payee.WalletAddress,
+			Amount:            amount,
+		})
+		require.NoError(t, err)
+		return request
+	}
+
+	request := newRequest(300)
+	_, err = requestSvc.Approve(ctx, payee.Username, request.ID)
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	// the request is paid with its transfer linked
+	approved, err := requestSvc.Approve(ctx, payer.Username, request.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.PaymentRequestStatusPAYMENTSUCCESS, approved.Status)
+
+	trans, err := transRepo.GetTransfer(ctx, approved.TransID)
+	require.NoError(t, err)
+	require.Equal(t, payer.WalletAddress, trans.FromWalletAdd)
+	require.Equal(t, int64(10), trans.Fee)
+
+	_, err = requestSvc.Approve(ctx, payer.Username, request.ID)
+	require.ErrorIs(t, err, local_errors.ErrInvalidPaymentRequestTransition)
+
+	// a payment that fails moves no money and ends the request
+	failed, err := requestSvc.Approve(ctx, payer.Username, newRequest(5000).ID)
+	require.NoError(t, err)
+	require.Equal(t, model.PaymentRequestStatusPAYMENTFAILED, failed.Status)
+	require.Equal(t, local_errors.ErrInsufficientBalance.Error(), failed.FailureReason)
+	require.Zero(t, failed.TransID)
+
+	transfers, err := transRepo.ListTransfers(ctx, store.ListTransfersParams{WalletAddress: payer.WalletAddress, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+}
+
+// failingWalletRepo fails the payments of the requests like a database that went away
+type failingWalletRepo struct {
+	store.WalletRepo
+}
+
+func (r failingWalletRepo) PayPaymentRequest(ctx context.Context, arg store.PayPaymentRequestParams) (store.WalletTransferResult, error) {
+	return store.WalletTransferResult{}, errors.New("connection reset")
+}
+
+func TestApprovePaymentRequestRepoError(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	requestRepo := store.NewPaymentRequestRepo(db)
+
+	payer := createTestWallet(t, db, walletRepo, 1000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	requestSvc := NewPaymentRequestService(requestRepo, failingWalletRepo{walletRepo}, store.NewUserRepo(db), fee.Free(), limit.Unlimited())
+	request, err := requestSvc.Create(ctx, payee.Username, dto.CreatePaymentRequestDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		Amount:            300,
+	})
+	require.NoError(t, err)
+
+	// an error on our side is not the payer's, the request can be approved again
+	_, err = requestSvc.Approve(ctx, payer.Username, request.ID)
+	require.EqualError(t, err, "connection reset")
+
+	waiting, err := requestRepo.GetPaymentRequest(ctx, request.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.PaymentRequestStatusWAITINGAPPROVAL, waiting.Status)
+	require.Empty(t, waiting.FailureReason)
+
+	requestSvc = NewPaymentRequestService(requestRepo, walletRepo, store.NewUserRepo(db), fee.Free(), limit.Unlimited())
+	approved, err := requestSvc.Approve(ctx, payer.Username, request.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.PaymentRequestStatusPAYMENTSUCCESS, approved.Status)
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/recurrence"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+const (
+	// schedulerLease is the lease held by the instance that runs the scheduled payments
+	schedulerLease = "scheduler"
+	// scheduledPaymentBatch is the number of due schedules paid per run
+	scheduledPaymentBatch = 100
+)
+
+// RetryPolicy retries the payments that failed on an insufficient balance, up to MaxAttempts attempts
+// in total with a delay that starts at Backoff and doubles on every attempt
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// delay returns when the attempt after a failed attempt is made, false when there is none left
+func (p RetryPolicy) delay(attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts {
+		return 0, false
+	}
+	return p.Backoff << uint(attempt-1), true
+}
+
+type ScheduledPaymentSvc interface {
+	Create(ctx context.Context, username string, createDto dto.CreateScheduledPaymentDto) (dto.ScheduledPaymentDto, error)
+	List(ctx context.Context, username string) ([]dto.ScheduledPaymentDto, error)
+	Get(ctx context.Context, username string, id int64) (dto.ScheduledPaymentDto, error)
+	Cancel(ctx context.Context, username string, id int64) (dto.ScheduledPaymentDto, error)
+	ListRuns(ctx context.Context, username string, id int64) ([]dto.ScheduledPaymentRunDto, error)
+	// RunDue pays the due scheduled payments if holder is the scheduler leader, it returns the number of payments made
+	RunDue(ctx context.Context, holder string) (int, error)
+}
+
+type scheduledPaymentService struct {
+	scheduledPaymentRepo store.ScheduledPaymentRepo
+	leaseRepo            store.LeaseRepo
+	walletRepo           store.WalletRepo
+	walletSvc            WalletSvc
+	retry                RetryPolicy
+	leaseTTL             time.Duration
+}
+
+func NewScheduledPaymentService(scheduledPaymentRepo store.ScheduledPaymentRepo, leaseRepo store.LeaseRepo, walletRepo store.WalletRepo, walletSvc WalletSvc, retry RetryPolicy, leaseTTL time.Duration) ScheduledPaymentSvc {
+	return &scheduledPaymentService{
+		scheduledPaymentRepo: scheduledPaymentRepo,
+		leaseRepo:            leaseRepo,
+		walletRepo:           walletRepo,
+		walletSvc:            walletSvc,
+		retry:                retry,
+		leaseTTL:             leaseTTL,
+	}
+}
+
+func (s *scheduledPaymentService) Create(ctx context.Context, username string, createDto dto.CreateScheduledPaymentDto) (dto.ScheduledPaymentDto, error) {
+	logrus.Println("log Create in service/scheduled_payment/Create ")
+
+	var scheduledPaymentDto dto.ScheduledPaymentDto
+
+	if createDto.Amount <= 0 {
+		return scheduledPaymentDto, local_errors.ErrPayAmountNotPositive
+	}
+
+	if (createDto.ToWalletAddress == "") == (createDto.ToUsername == "") {
+		return scheduledPaymentDto, local_errors.ErrRecipientRequired
+	}
+
+	fromWallet, err := s.walletRepo.GetWalletByAddress(ctx, createDto.FromWalletAddress)
+	if err != nil {
+		return scheduledPaymentDto, fmt.Errorf("from_wallet_address does not exists")
+	}
+
+	// the wallet of a recipient given by username is looked up again on every payment
+	toAddress := createDto.ToWalletAddress
+	if createDto.ToUsername != "" {
+		toWallet, err := s.walletRepo.GetWalletByUsernameAndCurrency(ctx, createDto.ToUsername, fromWallet.Currency)
+		if err != nil {
+			return scheduledPaymentDto, err
+		}
+		toAddress = toWallet.WalletAddress
+	}
+
+	fromWallet, toWallet, err := transferWallets(ctx, s.walletRepo, username, fromWallet.WalletAddress, toAddress)
+	if err != nil {
+		return scheduledPaymentDto, err
+	}
+
+	if fromWallet.Currency != toWallet.Currency {
+		return scheduledPaymentDto, local_errors.ErrCurrencyMismatch
+	}
+
+	now := time.Now()
+
+	startAt := createDto.StartAt
+	if startAt.IsZero() {
+		startAt = now
+	} else if startAt.Before(now.Add(-time.Minute)) {
+		return scheduledPaymentDto, fmt.Errorf("start_at is in the past")
+	}
+
+	frequency := recurrence.Frequency(createDto.Frequency)
+	if frequency == "" {
+		frequency = recurrence.FrequencyONCE
+	}
+
+	rule := recurrence.Rule{
+		Frequency: frequency,
+		Interval:  createDto.Interval,
+		Cron:      createDto.Cron,
+		Start:     startAt,
+	}
+	if err := rule.Validate(); err != nil {
+		return scheduledPaymentDto, err
+	}
+
+	firstRunAt, ok := rule.First()
+	if !ok {
+		return scheduledPaymentDto, fmt.Errorf("the schedule has no occurrence")
+	}
+
+	if !createDto.EndAt.IsZero() && createDto.EndAt.Before(firstRunAt) {
+		return scheduledPaymentDto, fmt.Errorf("end_at is before the first payment")
+	}
+
+	scheduledPayment, err := s.scheduledPaymentRepo.CreateScheduledPayment(ctx, store.CreateScheduledPaymentParams{
+		Username:          username,
+		FromWalletAddress: fromWallet.WalletAddress,
+		ToWalletAddress:   createDto.ToWalletAddress,
+		ToUsername:        createDto.ToUsername,
+		Amount:            createDto.Amount,
+		Currency:          fromWallet.Currency,
+		Note:              createDto.Note,
+		Frequency:         frequency,
+		Interval:          createDto.Interval,
+		Cron:              createDto.Cron,
+		StartAt:           startAt,
+		EndAt:             createDto.EndAt,
+		MaxRuns:           createDto.MaxRuns,
+		FirstRunAt:        firstRunAt,
+	})
+	if err != nil {
+		return scheduledPaymentDto, err
+	}
+
+	scheduledPaymentDto = dto.NewScheduledPaymentDto(scheduledPayment)
+	return scheduledPaymentDto, nil
+}
+
+func (s *scheduledPaymentService) List(ctx context.Context, username string) ([]dto.ScheduledPaymentDto, error) {
+	logrus.Println("log List in service/scheduled_payment/List ")
+
+	scheduledPayments, err := s.scheduledPaymentRepo.ListScheduledPayments(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewScheduledPaymentDtos(scheduledPayments), nil
+}
+
+func (s *scheduledPaymentService) Get(ctx context.Context, username string, id int64) (dto.ScheduledPaymentDto, error) {
+	logrus.Println("log Get in service/scheduled_payment/Get ")
+
+	var scheduledPaymentDto dto.ScheduledPaymentDto
+
+	scheduledPayment, err := s.ownScheduledPayment(ctx, username, id)
+	if err != nil {
+		return scheduledPaymentDto, err
+	}
+
+	scheduledPaymentDto = dto.NewScheduledPaymentDto(scheduledPayment)
+	return scheduledPaymentDto, nil
+}
+
+func (s *scheduledPaymentService) Cancel(ctx context.Context, username string, id int64) (dto.ScheduledPaymentDto, error) {
+	logrus.Println("log Cancel in service/scheduled_payment/Cancel ")
+
+	var scheduledPaymentDto dto.ScheduledPaymentDto
+
+	if _, err := s.ownScheduledPayment(ctx, username, id); err != nil {
+		return scheduledPaymentDto, err
+	}
+
+	scheduledPayment, err := s.scheduledPaymentRepo.CancelScheduledPayment(ctx, id)
+	if err != nil {
+		return scheduledPaymentDto, err
+	}
+
+	scheduledPaymentDto = dto.NewScheduledPaymentDto(scheduledPayment)
+	return scheduledPaymentDto, nil
+}
+
+func (s *scheduledPaymentService) ListRuns(ctx context.Context, username string, id int64) ([]dto.ScheduledPaymentRunDto, error) {
+	logrus.Println("log ListRuns in service/scheduled_payment/ListRuns ")
+
+	if _, err := s.ownScheduledPayment(ctx, username, id); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.scheduledPaymentRepo.ListScheduledPaymentRuns(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewScheduledPaymentRunDtos(runs), nil
+}
+
+func (s *scheduledPaymentService) RunDue(ctx context.Context, holder string) (int, error) {
+	logrus.Println("log RunDue in service/scheduled_payment/RunDue ")
+
+	leader, err := s.leaseRepo.AcquireLease(ctx, schedulerLease, holder, s.leaseTTL)
+	if err != nil || !leader {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	ids, err := s.scheduledPaymentRepo.ListDueScheduledPayments(ctx, now, scheduledPaymentBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	for _, id := range ids {
+		ok, err := s.runScheduledPayment(ctx, id, now)
+		if err != nil {
+			return paid, err
+		}
+		if ok {
+			paid++
+		}
+	}
+
+	return paid, nil
+}
+
+// runScheduledPayment pays the current occurrence of a schedule and records the run, it returns
+// whether the payment was made. Failed payments are not errors, they are recorded on the run.
+func (s *scheduledPaymentService) runScheduledPayment(ctx context.Context, id int64, now time.Time) (bool, error) {
+
+	run, err := s.scheduledPaymentRepo.ClaimScheduledPayment(ctx, id, now)
+	if errors.Is(err, local_errors.ErrScheduledPaymentNotDue) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	scheduledPayment, err := s.scheduledPaymentRepo.GetScheduledPayment(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	// a recipient named by username is paid in the payer's currency, not necessarily in its primary wallet
+	res, payErr := s.walletSvc.Pay(ctx, scheduledPayment.Username, dto.TransferMoneyDto{
+		FromWalletAddress: scheduledPayment.FromWalletAdd,
+		ToWalletAddress:   scheduledPayment.ToWalletAdd,
+		ToUsername:        scheduledPayment.ToUsername,
+		Currency:          scheduledPayment.Currency,
+		Amount:            scheduledPayment.Amount,
+	})
+
+	arg := store.FinishScheduledPaymentRunParams{RunID: run.ID, TransID: res.ID}
+	if payErr != nil {
+		logrus.Printf("scheduled payment %d failed on attempt %d: %v", id, run.Attempt, payErr)
+		arg.Error = payErr.Error()
+
+		if errors.Is(payErr, local_errors.ErrInsufficientBalance) {
+			if delay, ok := s.retry.delay(run.Attempt); ok {
+				arg.RetryAt = now.Add(delay)
+			}
+		}
+	}
+
+	if _, err := s.scheduledPaymentRepo.FinishScheduledPaymentRun(ctx, arg); err != nil {
+		return false, err
+	}
+
+	return payErr == nil, nil
+}
+
+// ownScheduledPayment returns the scheduled payment if it belongs to username
+func (s *scheduledPaymentService) ownScheduledPayment(ctx context.Context, username string, id int64) (model.ScheduledPayment, error) {
+
+	scheduledPayment, err := s.scheduledPaymentRepo.GetScheduledPayment(ctx, id)
+	if err != nil {
+		return scheduledPayment, err
+	}
+
+	if scheduledPayment.Username != username {
+		return model.ScheduledPayment{}, local_errors.ErrUnauthorized
+	}
+
+	return scheduledPayment, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduledPayments(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	userRepo := store.NewUserRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, userRepo, currency.Default(), fee.Free(), limit.Unlimited())
+	// retries are due at once so that the test can run them
+	scheduledPaymentSvc := NewScheduledPaymentService(store.NewScheduledPaymentRepo(db), store.NewLeaseRepo(db), walletRepo, walletSvc,
+		RetryPolicy{MaxAttempts: 2}, time.Minute)
+
+	const leader, follower = "scheduler-test-leader", "scheduler-test-follower"
+
+	payer := createTestWallet(t, db, walletRepo, 100)
+	payee := createTestWallet(t, db, walletRepo, 0)
+	stranger := createTestWallet(t, db, walletRepo, 0)
+
+	balance := func(address string) int64 {
+		w, err := walletRepo.GetWalletByAddress(ctx, address)
+		require.NoError(t, err)
+		return w.Balance
+	}
+	schedule := func(createDto dto.CreateScheduledPaymentDto) dto.ScheduledPaymentDto {
+		s, err := scheduledPaymentSvc.Create(ctx, payer.Username, createDto)
+		require.NoError(t, err)
+		require.Equal(t, model.ScheduledPaymentStatusACTIVE, s.Status)
+		return s
+	}
+
+	_, err := scheduledPaymentSvc.Create(ctx, payer.Username, dto.CreateScheduledPaymentDto{
+		TransferMoneyDto: dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 10},
+		StartAt:          time.Now().Add(-time.Hour),
+	})
+	require.Error(t, err)
+	_, err = scheduledPaymentSvc.Create(ctx, stranger.Username, dto.CreateScheduledPaymentDto{
+		TransferMoneyDto: dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 10},
+	})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	// a one-off payment is made once and linked to its transfer
+	once := schedule(dto.CreateScheduledPaymentDto{
+		TransferMoneyDto: dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToUsername: payee.Username, Amount: 60},
+	})
+
+	_, err = scheduledPaymentSvc.RunDue(ctx, leader)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), balance(payer.WalletAddress))
+	require.Equal(t, int64(60), balance(payee.WalletAddress))
+
+	done, err := scheduledPaymentSvc.Get(ctx, payer.Username, once.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.ScheduledPaymentStatusCOMPLETED, done.Status)
+	require.Equal(t, int64(1), done.RunCount)
+	require.Nil(t, done.NextRunAt)
+
+	runs, err := scheduledPaymentSvc.ListRuns(ctx, payer.Username, once.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, model.ScheduledPaymentRunStatusSUCCEEDED, runs[0].Status)
+	require.NotZero(t, runs[0].TransID)
+
+	_, err = scheduledPaymentSvc.RunDue(ctx, leader)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), balance(payer.WalletAddress))
+
+	// only the holder of the scheduler lease pays
+	daily := schedule(dto.CreateScheduledPaymentDto{
+		TransferMoneyDto: dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 70},
+		Frequency:        "DAILY",
+		MaxRuns:          2,
+	})
+
+	n, err := scheduledPaymentSvc.RunDue(ctx, follower)
+	require.NoError(t, err)
+	require.Zero(t, n)
+	runs, err = scheduledPaymentSvc.ListRuns(ctx, payer.Username, daily.ID)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+
+	// an insufficient balance is retried, the occurrence is paid once the wallet is credited
+	_, err = scheduledPaymentSvc.RunDue(ctx, leader)
+	require.NoError(t, err)
+
+	retrying, err := scheduledPaymentSvc.Get(ctx, payer.Username, daily.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.ScheduledPaymentStatusACTIVE, retrying.Status)
+	require.Equal(t, int64(0), retrying.RunCount)
+	require.Equal(t, 1, retrying.Attempts)
+	require.NotEmpty(t, retrying.LastError)
+
+	_, err = walletRepo.AddWalletBalance(ctx, store.AddWalletBalanceParams{WalletAddress: payer.WalletAddress, Amount: 100})
+	require.NoError(t, err)
+
+	_, err = scheduledPaymentSvc.RunDue(ctx, leader)
+	require.NoError(t, err)
+	require.Equal(t, int64(70), balance(payer.WalletAddress))
+
+	paid, err := scheduledPaymentSvc.Get(ctx, payer.Username, daily.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), paid.RunCount)
+	require.Zero(t, paid.Attempts)
+	require.True(t, paid.NextRunAt.After(time.Now().Add(23*time.Hour)))
+
+	runs, err = scheduledPaymentSvc.ListRuns(ctx, payer.Username, daily.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, model.ScheduledPaymentRunStatusSUCCEEDED, runs[0].Status)
+	require.Equal(t, 2, runs[0].Attempt)
+	require.Equal(t, model.ScheduledPaymentRunStatusRETRYING, runs[1].Status)
+	require.Equal(t, runs[0].ScheduledFor.Unix(), runs[1].ScheduledFor.Unix())
+
+	// only the owner cancels, and only once
+	_, err = scheduledPaymentSvc.Cancel(ctx, stranger.Username, daily.ID)
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	cancelled, err := scheduledPaymentSvc.Cancel(ctx, payer.Username, daily.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.ScheduledPaymentStatusCANCELLED, cancelled.Status)
+
+	_, err = scheduledPaymentSvc.Cancel(ctx, payer.Username, daily.ID)
+	require.ErrorIs(t, err, local_errors.ErrScheduledPaymentNotActive)
+
+	list, err := scheduledPaymentSvc.List(ctx, payer.Username)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/stream"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// streamBacklogPage is the number of missed events read at once when a stream resumes
+	streamBacklogPage = 500
+	// streamBuffer is how many live events a stream may fall behind before it is closed
+	streamBuffer = 256
+)
+
+type WalletStreamSvc interface {
+	// Open streams the events of a wallet of username, starting after lastEventID when it is set. A resumed
+	// stream sends the events of the commit window before lastEventID again, clients drop the ids they have.
+	Open(ctx context.Context, username string, address string, lastEventID int64) (WalletStream, error)
+}
+
+// WalletStream yields the events of one wallet, the missed ones first
+type WalletStream interface {
+	// Next waits for the next event, it returns ErrStreamBehind once the stream can not keep up
+	Next(ctx context.Context) (dto.WalletStreamEventDto, error)
+	Close()
+}
+
+type walletStreamService struct {
+	outboxRepo store.OutboxRepo
+	walletRepo store.WalletRepo
+	broker     *stream.Broker
+}
+
+func NewWalletStreamService(outboxRepo store.OutboxRepo, walletRepo store.WalletRepo, broker *stream.Broker) WalletStreamSvc {
+	return &walletStreamService{
+		outboxRepo: outboxRepo,
+		walletRepo: walletRepo,
+		broker:     broker,
+	}
+}
+
+func (s *walletStreamService) Open(ctx context.Context, username string, address string, lastEventID int64) (WalletStream, error) {
+	logrus.Println("log Open in service/stream/Open ")
+
+	wallet, err := s.walletRepo.GetWalletByAddress(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if wallet.Username != username {
+		return nil, local_errors.ErrUnauthorized
+	}
+
+	// subscribe before reading the backlog so that nothing is written in between unseen
+	ws := &walletStream{
+		outboxRepo:  s.outboxRepo,
+		address:     address,
+		sub:         s.broker.Subscribe(address, streamBuffer),
+		resumeID:    lastEventID,
+		rescanSince: time.Now().Add(-stream.CommitWindow),
+		rescanning:  lastEventID > 0,
+		cursor:      lastEventID,
+		replaying:   lastEventID > 0,
+		// the client has lastEventID
+		replayed: map[int64]bool{lastEventID: true},
+	}
+	return ws, nil
+}
+
+type walletStream struct {
+	outboxRepo store.OutboxRepo
+	address    string
+	sub        *stream.Subscription
+
+	// ids are taken before the events commit, like the broker a resumed stream rescans the commit window
+	// for the events below resumeID that became visible after the client saw it, then reads the backlog
+	// page by page after cursor. replayed holds the ids handed out so that the live events are not repeated.
+	backlog      []model.OutboxEvent
+	resumeID     int64
+	rescanSince  time.Time
+	rescanCursor int64
+	rescanning   bool
+	cursor       int64
+	replaying    bool
+	replayed     map[int64]bool
+}
+
+func (ws *walletStream) Next(ctx context.Context) (dto.WalletStreamEventDto, error) {
+
+	for ws.rescanning && len(ws.backlog) == 0 {
+		events, err := ws.outboxRepo.ListEvents(ctx, store.ListEventsParams{
+			WalletAddress: ws.address,
+			AfterID:       ws.rescanCursor,
+			Since:         ws.rescanSince,
+			Limit:         streamBacklogPage,
+		})
+		if err != nil {
+			return dto.WalletStreamEventDto{}, err
+		}
+		if len(events) < streamBacklogPage {
+			ws.rescanning = false
+		}
+		for _, e := range events {
+			// the client has resumeID, the backlog covers what follows
+			if e.ID >= ws.resumeID {
+				ws.rescanning = false
+				break
+			}
+			ws.backlog = append(ws.backlog, e)
+		}
+		if len(events) > 0 {
+			ws.rescanCursor = events[len(events)-1].ID
+		}
+	}
+
+	for ws.replaying && len(ws.backlog) == 0 {
+		events, err := ws.outboxRepo.ListEvents(ctx, store.ListEventsParams{
+			WalletAddress: ws.address,
+			AfterID:       ws.cursor,
+			Limit:         streamBacklogPage,
+		})
+		if err != nil {
+			return dto.WalletStreamEventDto{}, err
+		}
+		if len(events) < streamBacklogPage {
+			ws.replaying = false
+		}
+		if len(events) > 0 {
+			ws.cursor = events[len(events)-1].ID
+		}
+		ws.backlog = events
+		if len(events) == 0 {
+			break
+		}
+	}
+
+	if len(ws.backlog) > 0 {
+		e := ws.backlog[0]
+		ws.backlog = ws.backlog[1:]
+		ws.replayed[e.ID] = true
+		return dto.NewWalletStreamEventDto(e, ws.address)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return dto.WalletStreamEventDto{}, ctx.Err()
+		case e, ok := <-ws.sub.C:
+			if !ok {
+				return dto.WalletStreamEventDto{}, local_errors.ErrStreamBehind
+			}
+			if ws.replayed[e.ID] {
+				continue
+			}
+			return dto.NewWalletStreamEventDto(e, ws.address)
+		}
+	}
+}
+
+func (ws *walletStream) Close() {
+	ws.sub.Close()
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/stream"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletStreamResume(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	outboxRepo := store.NewOutboxRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+	broker := stream.NewBroker(outboxRepo, time.Second)
+	streamSvc := NewWalletStreamService(outboxRepo, walletRepo, broker)
+
+	payer := createTestWallet(t, db, walletRepo, 100)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	_, err := streamSvc.Open(ctx, payee.Username, payer.WalletAddress, 0)
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	// the client saw everything up to the credit of the payer, then the transfer happened while it was away
+	lastEventID, err := outboxRepo.LastEventID(ctx)
+	require.NoError(t, err)
+	// as on start, the broker only hands out what it sees from now on
+	require.NoError(t, broker.Poll(ctx, time.Now()))
+
+	res, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 30})
+	require.NoError(t, err)
+
+	payerStream, err := streamSvc.Open(ctx, payer.Username, payer.WalletAddress, lastEventID)
+	require.NoError(t, err)
+	defer payerStream.Close()
+	payeeStream, err := streamSvc.Open(ctx, payee.Username, payee.WalletAddress, 0)
+	require.NoError(t, err)
+	defer payeeStream.Close()
+
+	next := func(ws WalletStream) dto.WalletStreamEventDto {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		e, err := ws.Next(ctx)
+		require.NoError(t, err)
+		return e
+	}
+
+	// the commit window before lastEventID comes again, the client drops the ids it has
+	missed := next(payerStream)
+	for missed.ID <= lastEventID {
+		missed = next(payerStream)
+	}
+	require.Equal(t, model.EventTypeTransferCompleted, missed.Type)
+	require.Equal(t, res.ID, missed.TransID)
+	require.Equal(t, int64(70), missed.UpdatedBalance)
+	require.Equal(t, int64(-30), missed.Change)
+	require.Equal(t, payee.WalletAddress, missed.CounterpartyWalletAddress)
+
+	_, err = walletSvc.Credit(ctx, payer.Username, dto.CreditDto{WalletAddress: payer.WalletAddress, Amount: 50})
+	require.NoError(t, err)
+	require.NoError(t, broker.Poll(ctx, time.Now()))
+
+	// the replayed transfer is not handed out twice when the broker sees it too
+	live := next(payerStream)
+	require.Equal(t, model.EventTypeWalletCredited, live.Type)
+	require.Equal(t, int64(120), live.UpdatedBalance)
+	require.Equal(t, int64(50), live.Change)
+	require.Greater(t, live.ID, missed.ID)
+
+	received := next(payeeStream)
+	require.Equal(t, model.EventTypeTransferCompleted, received.Type)
+	require.Equal(t, int64(30), received.UpdatedBalance)
+	require.Equal(t, int64(30), received.Change)
+	require.Equal(t, payer.WalletAddress, received.CounterpartyWalletAddress)
+
+	// nothing else happened to the payer
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = payerStream.Next(waitCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWalletStreamResumeRescansCommitWindow(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	outboxRepo := store.NewOutboxRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+	broker := stream.NewBroker(outboxRepo, time.Second)
+	streamSvc := NewWalletStreamService(outboxRepo, walletRepo, broker)
+
+	payer := createTestWallet(t, db, walletRepo, 100)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	late, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 10})
+	require.NoError(t, err)
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 20})
+	require.NoError(t, err)
+
+	// the client saw the second transfer, the first one committed after it
+	events, err := outboxRepo.ListEvents(ctx, store.ListEventsParams{WalletAddress: payer.WalletAddress, Limit: 10})
+	require.NoError(t, err)
+	lastEventID := events[len(events)-1].ID
+
+	payerStream, err := streamSvc.Open(ctx, payer.Username, payer.WalletAddress, lastEventID)
+	require.NoError(t, err)
+	defer payerStream.Close()
+	require.NoError(t, broker.Poll(ctx, time.Now()))
+
+	var resent []dto.WalletStreamEventDto
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		e, err := payerStream.Next(waitCtx)
+		cancel()
+		if err != nil {
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			break
+		}
+		resent = append(resent, e)
+	}
+
+	// every event before the one the client has comes once, the late transfer among them, and nothing after
+	require.Len(t, resent, len(events)-1)
+	found := false
+	for i, e := range resent {
+		require.Equal(t, events[i].ID, e.ID)
+		if e.TransID == late.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+	"strconv"
+)
+
+const (
+	defaultTransactionPageSize = 20
+	maxTransactionPageSize     = 100
+)
+
+type TransSvc interface {
+	ListTransactions(ctx context.Context, username string, address string, listDto dto.ListTransactionsDto) (dto.TransactionPageDto, error)
+	// Refund is made by the payee of a transfer, refunds of a transfer never sum to more than its net amount
+	Refund(ctx context.Context, username string, id int64, refundDto dto.RefundDto) (dto.TransResultDto, error)
+	// Reverse undoes a whole transfer, only admins can reverse
+	Reverse(ctx context.Context, username string, id int64, reverseDto dto.ReverseDto) (dto.TransResultDto, error)
+}
+
+type transService struct {
+	transRepo  store.TransRepo
+	walletRepo store.WalletRepo
+	userRepo   store.UserRepo
+}
+
+func NewTransService(transRepo store.TransRepo, walletRepo store.WalletRepo, userRepo store.UserRepo) TransSvc {
+	return &transService{
+		transRepo:  transRepo,
+		walletRepo: walletRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (t *transService) ListTransactions(ctx context.Context, username string, address string, listDto dto.ListTransactionsDto) (dto.TransactionPageDto, error) {
+	logrus.Println("log ListTransactions in service/trans/ListTransactions ")
+
+	var pageDto dto.TransactionPageDto
+
+	wallet, err := t.walletRepo.GetWalletByAddress(ctx, address)
+	if err != nil {
+		return pageDto, err
+	}
+
+	if wallet.Username != username {
+		return pageDto, local_errors.ErrUnauthorized
+	}
+
+	beforeID, err := decodeCursor(listDto.Cursor)
+	if err != nil {
+		return pageDto, err
+	}
+
+	limit := listDto.Limit
+	if limit <= 0 {
+		limit = defaultTransactionPageSize
+	}
+	if limit > maxTransactionPageSize {
+		limit = maxTransactionPageSize
+	}
+
+	// one extra row tells whether there is a next page
+	transfers, err := t.transRepo.ListTransfers(ctx, store.ListTransfersParams{
+		WalletAddress: address,
+		Direction:     store.TransDirection(listDto.Direction),
+		CreatedFrom:   listDto.CreatedFrom,
+		CreatedTo:     listDto.CreatedTo,
+		MinAmount:     listDto.MinAmount,
+		MaxAmount:     listDto.MaxAmount,
+		BeforeID:      beforeID,
+		Limit:         limit + 1,
+	})
+	if err != nil {
+		return pageDto, err
+	}
+
+	if len(transfers) > limit {
+		transfers = transfers[:limit]
+		pageDto.NextCursor = encodeCursor(transfers[limit-1].ID)
+	}
+
+	pageDto.Transactions = dto.NewTransResultDtos(transfers)
+	return pageDto, nil
+}
+
+func (t *transService) Refund(ctx context.Context, username string, id int64, refundDto dto.RefundDto) (dto.TransResultDto, error) {
+	logrus.Println("log Refund in service/trans/Refund ")
+
+	var txnResDto dto.TransResultDto
+
+	if refundDto.Amount < 0 {
+		return txnResDto, fmt.Errorf("amount to refund should be positive")
+	}
+
+	trans, err := t.transRepo.GetTransfer(ctx, id)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	payee, err := t.walletRepo.GetWalletByAddress(ctx, trans.ToWalletAdd)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	if payee.Username != username {
+		return txnResDto, local_errors.ErrUnauthorized
+	}
+
+	if !trans.IsRefundable() {
+		if trans.ReversalTransID != 0 {
+			return txnResDto, local_errors.ErrTransAlreadyReversed
+		}
+		return txnResDto, local_errors.ErrTransNotRefundable
+	}
+
+	amount := refundDto.Amount
+	if amount == 0 {
+		amount = trans.RefundableAmount()
+	}
+
+	// the store checks the refundable amount again under the lock of the transfer
+	res, err := t.walletRepo.RefundTransfer(ctx, store.RefundTransferParams{TransID: id, Amount: amount})
+	if err != nil {
+		return txnResDto, err
+	}
+
+	txnResDto = dto.NewTransResultDto(res.Trans)
+	return txnResDto, nil
+}
+
+func (t *transService) Reverse(ctx context.Context, username string, id int64, reverseDto dto.ReverseDto) (dto.TransResultDto, error) {
+	logrus.Println("log Reverse in service/trans/Reverse ")
+
+	var txnResDto dto.TransResultDto
+
+	user, err := t.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	if !user.IsAdmin() {
+		return txnResDto, local_errors.ErrAdminRequired
+	}
+
+	res, err := t.walletRepo.ReverseTransfer(ctx, store.ReverseTransferParams{TransID: id, Reason: reverseDto.Reason})
+	if err != nil {
+		return txnResDto, err
+	}
+
+	if res.Trans.Shortfall > 0 {
+		logrus.Warnf("reversal %d of transaction %d is short of %d, booked to the suspense wallet", res.Trans.ID, id, res.Trans.Shortfall)
+	}
+
+	txnResDto = dto.NewTransResultDto(res.Trans)
+	return txnResDto, nil
+}
+
+// encodeCursor hides the id behind an opaque token so clients do not build cursors themselves
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, local_errors.ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, local_errors.ErrInvalidCursor
+	}
+
+	return id, nil
+}
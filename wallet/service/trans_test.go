@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id, err := decodeCursor(encodeCursor(42))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), id)
+
+	id, err = decodeCursor("")
+	require.NoError(t, err)
+	require.Zero(t, id)
+
+	for _, cursor := range []string{"%%%", encodeCursor(-1), "YWJj"} {
+		_, err = decodeCursor(cursor)
+		require.ErrorIs(t, err, local_errors.ErrInvalidCursor, cursor)
+	}
+}
+
+func TestListTransactions(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	transRepo := store.NewTransRepo(db)
+	walletRepo := store.NewWalletRepo(db, transRepo, store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+	transSvc := NewTransService(transRepo, walletRepo, store.NewUserRepo(db))
+
+	walletA := createTestWallet(t, db, walletRepo, 1000)
+	walletB := createTestWallet(t, db, walletRepo, 1000)
+
+	pay := func(from, to string, username string, amount int64) {
+		_, err := walletSvc.Pay(ctx, username, dto.TransferMoneyDto{FromWalletAddress: from, ToWalletAddress: to, Amount: amount})
+		require.NoError(t, err)
+	}
+	for i := int64(1); i <= 5; i++ {
+		pay(walletA.WalletAddress, walletB.WalletAddress, walletA.Username, i*10)
+	}
+	for i := int64(1); i <= 3; i++ {
+		pay(walletB.WalletAddress, walletA.WalletAddress, walletB.Username, i)
+	}
+
+	// walk the outgoing transfers two at a time
+	var amounts []int64
+	cursor := ""
+	for page := 0; ; page++ {
+		require.Less(t, page, 5)
+		res, err := transSvc.ListTransactions(ctx, walletA.Username, walletA.WalletAddress, dto.ListTransactionsDto{
+			Direction: "outgoing",
+			Cursor:    cursor,
+			Limit:     2,
+		})
+		require.NoError(t, err)
+		for _, trans := range res.Transactions {
+			amounts = append(amounts, trans.Amount)
+		}
+		if res.NextCursor == "" {
+			break
+		}
+		cursor = res.NextCursor
+	}
+	require.Equal(t, []int64{50, 40, 30, 20, 10}, amounts)
+
+	res, err := transSvc.ListTransactions(ctx, walletA.Username, walletA.WalletAddress, dto.ListTransactionsDto{Direction: "incoming"})
+	require.NoError(t, err)
+	require.Len(t, res.Transactions, 3)
+	require.Empty(t, res.NextCursor)
+
+	res, err = transSvc.ListTransactions(ctx, walletA.Username, walletA.WalletAddress, dto.ListTransactionsDto{MinAmount: 3, MaxAmount: 30})
+	require.NoError(t, err)
+	require.Len(t, res.Transactions, 4)
+
+	_, err = transSvc.ListTransactions(ctx, walletB.Username, walletA.WalletAddress, dto.ListTransactionsDto{})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+}
+
+func TestRefundAndReverse(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	userRepo := store.NewUserRepo(db)
+	transRepo := store.NewTransRepo(db)
+	ledgerRepo := store.NewLedgerRepo(db)
+	walletRepo := store.NewWalletRepo(db, transRepo, ledgerRepo, store.NewFXQuoteRepo(db))
+
+	fees, err := fee.NewSchedule([]fee.Rule{{Currency: "INR", Kind: fee.KindFLAT, Flat: 10}})
+	require.NoError(t, err)
+	walletSvc := NewWalletService(walletRepo, userRepo, currency.Default(), fees, limit.Unlimited())
+	transSvc := NewTransService(transRepo, walletRepo, userRepo)
+
+	admin := fmt.Sprintf("admin%d", time.Now().UnixNano())
+	_, err = userRepo.CreateUser(ctx, store.CreateUserParams{Username: admin, HashedPassword: "secret", Status: model.UserStatusACTIVE, Role: model.UserRoleADMIN})
+	require.NoError(t, err)
+
+	walletA := createTestWallet(t, db, walletRepo, 1000)
+	walletB := createTestWallet(t, db, walletRepo, 0)
+	walletC := createTestWallet(t, db, walletRepo, 0)
+
+	totals, err := ledgerRepo.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+
+	balance := func(address string) int64 {
+		w, err := walletRepo.GetWalletByAddress(ctx, address)
+		require.NoError(t, err)
+		return w.Balance
+	}
+	pay := func(from model.Wallet, to model.Wallet, amount int64) dto.TransResultDto {
+		res, err := walletSvc.Pay(ctx, from.Username, dto.TransferMoneyDto{FromWalletAddress: from.WalletAddress, ToWalletAddress: to.WalletAddress, Amount: amount})
+		require.NoError(t, err)
+		return res
+	}
+
+	// partial refunds never go past what the payee received, the payer bore the fee
+	first := pay(walletA, walletB, 500)
+	require.Equal(t, int64(510), first.Amount)
+	require.Equal(t, int64(500), first.NetAmount)
+
+	_, err = transSvc.Refund(ctx, walletA.Username, first.ID, dto.RefundDto{Amount: 100})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	refund, err := transSvc.Refund(ctx, walletB.Username, first.ID, dto.RefundDto{Amount: 200})
+	require.NoError(t, err)
+	require.Equal(t, model.TransKindREFUND, refund.Kind)
+	require.Equal(t, first.ID, refund.OriginalTransID)
+	require.Equal(t, int64(690), balance(walletA.WalletAddress))
+	require.Equal(t, int64(300), balance(walletB.WalletAddress))
+
+	_, err = transSvc.Refund(ctx, walletB.Username, first.ID, dto.RefundDto{Amount: 301})
+	require.ErrorIs(t, err, local_errors.ErrRefundExceedsAmount)
+
+	_, err = transSvc.Refund(ctx, walletB.Username, refund.ID, dto.RefundDto{})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	_, err = transSvc.Reverse(ctx, admin, first.ID, dto.ReverseDto{})
+	require.ErrorIs(t, err, local_errors.ErrTransRefunded)
+
+	// the payee spent most of the second transfer before it is reversed
+	second := pay(walletA, walletB, 300)
+	pay(walletB, walletC, 500)
+	require.Equal(t, int64(90), balance(walletB.WalletAddress))
+
+	_, err = transSvc.Reverse(ctx, walletA.Username, second.ID, dto.ReverseDto{})
+	require.ErrorIs(t, err, local_errors.ErrAdminRequired)
+
+	reversal, err := transSvc.Reverse(ctx, admin, second.ID, dto.ReverseDto{Reason: "disputed"})
+	require.NoError(t, err)
+	require.Equal(t, model.TransKindREVERSAL, reversal.Kind)
+	require.Equal(t, second.ID, reversal.OriginalTransID)
+	require.Equal(t, int64(210), reversal.Shortfall)
+
+	require.Equal(t, int64(690), balance(walletA.WalletAddress))
+	require.Equal(t, int64(0), balance(walletB.WalletAddress))
+
+	_, err = transSvc.Reverse(ctx, admin, second.ID, dto.ReverseDto{})
+	require.ErrorIs(t, err, local_errors.ErrTransAlreadyReversed)
+	_, err = transSvc.Refund(ctx, walletB.Username, second.ID, dto.RefundDto{})
+	require.ErrorIs(t, err, local_errors.ErrTransAlreadyReversed)
+
+	// a full refund gives back what the payee received, the fee is not paid twice
+	feeIncome, err := walletRepo.GetOrganizationWallet(ctx, store.OrganizationWalletParams{Currency: "INR", Purpose: model.OrganizationWalletFEEINCOME})
+	require.NoError(t, err)
+	walletD := createTestWallet(t, db, walletRepo, 110)
+	walletE := createTestWallet(t, db, walletRepo, 0)
+	third := pay(walletD, walletE, 100)
+
+	full, err := transSvc.Refund(ctx, walletE.Username, third.ID, dto.RefundDto{})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), full.Amount)
+	require.Equal(t, int64(100), balance(walletD.WalletAddress))
+	require.Equal(t, int64(0), balance(walletE.WalletAddress))
+	require.Equal(t, feeIncome.Balance+10, balance(feeIncome.WalletAddress))
+
+	_, err = transSvc.Refund(ctx, walletE.Username, third.ID, dto.RefundDto{Amount: 1})
+	require.ErrorIs(t, err, local_errors.ErrRefundExceedsAmount)
+
+	// money only moved between wallets, the suspense wallet holds what the payee owes
+	after, err := ledgerRepo.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+	require.Equal(t, totals, after)
+}
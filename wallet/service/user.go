@@ -2,26 +2,39 @@ package service
 
 import (
 	"context"
+	"github.com/dsthakur2711/wallet/constant"
 	"github.com/dsthakur2711/wallet/dto"
 	"github.com/dsthakur2711/wallet/model"
 	"github.com/dsthakur2711/wallet/pkg/local_errors"
 	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/token"
 	"github.com/dsthakur2711/wallet/util"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 type UserSvc interface {
 	CreateUser(ctx context.Context, createUserDto dto.CreateUserDto) (dto.UserDto, error)
 	LoginUser(ctx context.Context, loginCredsDto dto.LoginCredentialsDto) (dto.LoggedInUserDto, error)
+	RenewAccessToken(ctx context.Context, renewDto dto.RenewAccessTokenDto) (dto.RenewAccessTokenResultDto, error)
+	Logout(ctx context.Context, username string, logoutDto dto.LogoutDto) error
+	// Authorize checks that the session of an access token is still open and that its user is not blocked
+	Authorize(ctx context.Context, payload *token.Payload) error
+	// UpdateUserStatus is made by an admin, blocking a user revokes all of his sessions
+	UpdateUserStatus(ctx context.Context, adminUsername string, username string, status model.UserStatus) (dto.UserDto, error)
 }
 
 type userService struct {
-	userRepo store.UserRepo
+	userRepo    store.UserRepo
+	sessionRepo store.SessionRepo
+	tokenMaker  token.Maker
 }
 
-func NewUserService(userRepo store.UserRepo) UserSvc {
+func NewUserService(userRepo store.UserRepo, sessionRepo store.SessionRepo, tokenMaker token.Maker) UserSvc {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		tokenMaker:  tokenMaker,
 	}
 }
 
@@ -70,15 +83,180 @@ func (u *userService) LoginUser(ctx context.Context, loginCredentialsDto dto.Log
 		return loggedInDto, local_errors.ErrIncorrectPassword
 	}
 
-	//accessToken, err := u.tokenMaker.CreateToken(user.ID, constant.AccessTokenDuration)
-	//if err != nil {
-	//	return loggedInDto, err
-	//}
+	if user.Status == model.UserStatusBLOCKED {
+		return loggedInDto, local_errors.ErrUserBlocked
+	}
+
+	// the refresh token id doubles as the session id
+	refreshToken, refreshPayload, err := u.tokenMaker.CreateToken(user.Username, "", token.TokenTypeRefresh, constant.RefreshTokenDuration)
+	if err != nil {
+		return loggedInDto, err
+	}
+
+	accessToken, accessPayload, err := u.tokenMaker.CreateToken(user.Username, refreshPayload.ID, token.TokenTypeAccess, constant.AccessTokenDuration)
+	if err != nil {
+		return loggedInDto, err
+	}
+
+	session, err := u.sessionRepo.CreateSession(ctx, store.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    loginCredentialsDto.UserAgent,
+		ClientIp:     loginCredentialsDto.ClientIp,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return loggedInDto, err
+	}
 
 	loggedInDto = dto.LoggedInUserDto{
-		//AccessToken: accessToken,
-		User:        dto.NewUserDto(user),
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  dto.NewUserDto(user),
 	}
 
 	return loggedInDto, nil
 }
+
+func (u *userService) RenewAccessToken(ctx context.Context, renewDto dto.RenewAccessTokenDto) (dto.RenewAccessTokenResultDto, error) {
+	logrus.Println("log  RenewAccessToken in service/user/RenewAccessToken ")
+
+	var resultDto dto.RenewAccessTokenResultDto
+
+	refreshPayload, err := u.tokenMaker.VerifyToken(renewDto.RefreshToken)
+	if err != nil {
+		return resultDto, err
+	}
+
+	if err := refreshPayload.Expect(token.TokenTypeRefresh); err != nil {
+		return resultDto, err
+	}
+
+	session, err := u.sessionRepo.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		return resultDto, err
+	}
+
+	if session.IsBlocked {
+		return resultDto, local_errors.ErrSessionBlocked
+	}
+
+	if session.Username != refreshPayload.Username || session.RefreshToken != renewDto.RefreshToken {
+		return resultDto, local_errors.ErrSessionMismatch
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return resultDto, local_errors.ErrSessionExpired
+	}
+
+	// a user blocked after login must not be able to keep the session alive
+	user, err := u.userRepo.GetUserByUsername(ctx, session.Username)
+	if err != nil {
+		return resultDto, err
+	}
+
+	if user.Status == model.UserStatusBLOCKED {
+		if err := u.sessionRepo.BlockUserSessions(ctx, user.Username); err != nil {
+			return resultDto, err
+		}
+		return resultDto, local_errors.ErrUserBlocked
+	}
+
+	accessToken, accessPayload, err := u.tokenMaker.CreateToken(session.Username, session.ID, token.TokenTypeAccess, constant.AccessTokenDuration)
+	if err != nil {
+		return resultDto, err
+	}
+
+	resultDto = dto.RenewAccessTokenResultDto{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+
+	return resultDto, nil
+}
+
+func (u *userService) Logout(ctx context.Context, username string, logoutDto dto.LogoutDto) error {
+	logrus.Println("log  Logout in service/user/Logout ")
+
+	session, err := u.sessionRepo.GetSession(ctx, logoutDto.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.Username != username {
+		return local_errors.ErrUnauthorized
+	}
+
+	return u.sessionRepo.BlockSession(ctx, session.ID)
+}
+
+func (u *userService) Authorize(ctx context.Context, payload *token.Payload) error {
+
+	if payload.SessionID == "" {
+		return local_errors.ErrInvalidToken
+	}
+
+	session, err := u.sessionRepo.GetSession(ctx, payload.SessionID)
+	if err == local_errors.ErrSessionNotFound {
+		return local_errors.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if session.IsBlocked {
+		return local_errors.ErrSessionBlocked
+	}
+
+	if session.Username != payload.Username {
+		return local_errors.ErrSessionMismatch
+	}
+
+	user, err := u.userRepo.GetUserByUsername(ctx, payload.Username)
+	if err != nil {
+		return err
+	}
+
+	if user.Status == model.UserStatusBLOCKED {
+		return local_errors.ErrUserBlocked
+	}
+
+	return nil
+}
+
+// UpdateUserStatus changes the user status, blocking a user revokes all of his sessions
+func (u *userService) UpdateUserStatus(ctx context.Context, adminUsername string, username string, status model.UserStatus) (dto.UserDto, error) {
+	logrus.Println("log  UpdateUserStatus in service/user/UpdateUserStatus ")
+
+	var userDto dto.UserDto
+
+	admin, err := u.userRepo.GetUserByUsername(ctx, adminUsername)
+	if err != nil {
+		return userDto, err
+	}
+
+	if !admin.IsAdmin() {
+		return userDto, local_errors.ErrAdminRequired
+	}
+
+	user, err := u.userRepo.UpdateUserStatus(ctx, store.UpdateUserStatusParams{
+		Username: username,
+		Status:   status,
+	})
+	if err != nil {
+		return userDto, err
+	}
+
+	if status == model.UserStatusBLOCKED {
+		if err := u.sessionRepo.BlockUserSessions(ctx, username); err != nil {
+			return userDto, err
+		}
+	}
+
+	userDto = dto.NewUserDto(user)
+	return userDto, nil
+}
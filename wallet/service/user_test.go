@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/token"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUserService(t *testing.T) (UserSvc, store.UserRepo) {
+	db := openTestDB(t)
+
+	tokenMaker, err := token.NewPasetoMaker("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	userRepo := store.NewUserRepo(db)
+	return NewUserService(userRepo, store.NewSessionRepo(db), tokenMaker), userRepo
+}
+
+// createAndLogin creates a user with the password secret1 and logs it in
+func createAndLogin(t *testing.T, userSvc UserSvc) dto.LoggedInUserDto {
+	ctx := context.Background()
+	username := fmt.Sprintf("user%d", time.Now().UnixNano())
+
+	_, err := userSvc.CreateUser(ctx, dto.CreateUserDto{Username: username, Password: "secret1", Email: username + "@example.com"})
+	require.NoError(t, err)
+	loggedIn, err := userSvc.LoginUser(ctx, dto.LoginCredentialsDto{Username: username, Password: "secret1"})
+	require.NoError(t, err)
+	return loggedIn
+}
+
+func TestLoginUser(t *testing.T) {
+	ctx := context.Background()
+	userSvc, _ := newTestUserService(t)
+	loggedIn := createAndLogin(t, userSvc)
+
+	require.NotEmpty(t, loggedIn.AccessToken)
+	require.NotEmpty(t, loggedIn.SessionID)
+	require.True(t, loggedIn.RefreshTokenExpiresAt.After(loggedIn.AccessTokenExpiresAt))
+
+	_, err := userSvc.LoginUser(ctx, dto.LoginCredentialsDto{Username: loggedIn.User.Username, Password: "wrong-password"})
+	require.ErrorIs(t, err, local_errors.ErrIncorrectPassword)
+}
+
+func TestRenewAccessToken(t *testing.T) {
+	ctx := context.Background()
+	userSvc, _ := newTestUserService(t)
+	loggedIn := createAndLogin(t, userSvc)
+
+	renewed, err := userSvc.RenewAccessToken(ctx, dto.RenewAccessTokenDto{RefreshToken: loggedIn.RefreshToken})
+	require.NoError(t, err)
+	require.NotEmpty(t, renewed.AccessToken)
+
+	// an access token can not renew itself
+	_, err = userSvc.RenewAccessToken(ctx, dto.RenewAccessTokenDto{RefreshToken: loggedIn.AccessToken})
+	require.ErrorIs(t, err, local_errors.ErrWrongTokenType)
+}
+
+func TestLogout(t *testing.T) {
+	ctx := context.Background()
+	userSvc, _ := newTestUserService(t)
+	loggedIn := createAndLogin(t, userSvc)
+	other := createAndLogin(t, userSvc)
+
+	// a user can only end its own sessions
+	err := userSvc.Logout(ctx, other.User.Username, dto.LogoutDto{SessionID: loggedIn.SessionID})
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+
+	require.NoError(t, userSvc.Logout(ctx, loggedIn.User.Username, dto.LogoutDto{SessionID: loggedIn.SessionID}))
+
+	_, err = userSvc.RenewAccessToken(ctx, dto.RenewAccessTokenDto{RefreshToken: loggedIn.RefreshToken})
+	require.ErrorIs(t, err, local_errors.ErrSessionBlocked)
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	ctx := context.Background()
+	userSvc, userRepo := newTestUserService(t)
+
+	admin := fmt.Sprintf("admin%d", time.Now().UnixNano())
+	_, err := userRepo.CreateUser(ctx, store.CreateUserParams{Username: admin, HashedPassword: "secret", Status: model.UserStatusACTIVE, Role: model.UserRoleADMIN})
+	require.NoError(t, err)
+
+	loggedIn := createAndLogin(t, userSvc)
+	other := createAndLogin(t, userSvc)
+	username := loggedIn.User.Username
+
+	_, err = userSvc.UpdateUserStatus(ctx, other.User.Username, username, model.UserStatusBLOCKED)
+	require.ErrorIs(t, err, local_errors.ErrAdminRequired)
+
+	blocked, err := userSvc.UpdateUserStatus(ctx, admin, username, model.UserStatusBLOCKED)
+	require.NoError(t, err)
+	require.Equal(t, string(model.UserStatusBLOCKED), blocked.Status)
+
+	// the sessions of a blocked user are revoked, and it can not open new ones
+	_, err = userSvc.RenewAccessToken(ctx, dto.RenewAccessTokenDto{RefreshToken: loggedIn.RefreshToken})
+	require.ErrorIs(t, err, local_errors.ErrSessionBlocked)
+	_, err = userSvc.LoginUser(ctx, dto.LoginCredentialsDto{Username: username, Password: "secret1"})
+	require.ErrorIs(t, err, local_errors.ErrUserBlocked)
+
+	// unblocking lets it log in again, the revoked sessions stay revoked
+	_, err = userSvc.UpdateUserStatus(ctx, admin, username, model.UserStatusACTIVE)
+	require.NoError(t, err)
+	_, err = userSvc.LoginUser(ctx, dto.LoginCredentialsDto{Username: username, Password: "secret1"})
+	require.NoError(t, err)
+	_, err = userSvc.RenewAccessToken(ctx, dto.RenewAccessTokenDto{RefreshToken: loggedIn.RefreshToken})
+	require.ErrorIs(t, err, local_errors.ErrSessionBlocked)
+}
@@ -2,42 +2,71 @@ package service
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"github.com/dsthakur2711/wallet/currency"
 	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
 	"github.com/dsthakur2711/wallet/model"
 	"github.com/dsthakur2711/wallet/pkg/local_errors"
 	"github.com/dsthakur2711/wallet/store"
 	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
 )
 
 type WalletSvc interface {
-	Pay(ctx context.Context, transferMoneyDto dto.TransferMoneyDto) (dto.TransResultDto, error)
-	Credit(ctx context.Context, creditDto dto.CreditDto) (dto.UpdatedWalletBalanceDto,error)
-	AddWallet(ctx context.Context,createWalletDto dto.CreateWalletDto) (dto.WalletDto,error)
+	Pay(ctx context.Context, username string, transferMoneyDto dto.TransferMoneyDto) (dto.TransResultDto, error)
+	Credit(ctx context.Context, username string, creditDto dto.CreditDto) (dto.UpdatedWalletBalanceDto,error)
+	AddWallet(ctx context.Context, username string, createWalletDto dto.CreateWalletDto) (dto.WalletDto,error)
 	GetWalletByUsername(ctx context.Context, username string) (dto.WalletDto, error)
-	GetWalletByAddress(ctx context.Context, address string) (dto.WalletDto, error)
+	GetWalletByAddress(ctx context.Context, username string, address string) (dto.WalletDto, error)
+	ListWalletsByUsername(ctx context.Context, username string, owner string) ([]dto.WalletDto, error)
+	SetPrimaryWallet(ctx context.Context, username string, address string) (dto.WalletDto, error)
+	// QuoteFee shows the fee, gross and net amounts of a payment before it is made
+	QuoteFee(ctx context.Context, username string, quoteDto dto.FeeQuoteRequestDto) (dto.FeeQuoteDto, error)
+	// GetTransferLimits shows the limits of the wallet owner's tier and what is left of them
+	GetTransferLimits(ctx context.Context, username string, address string) (dto.TransferLimitsDto, error)
 }
 
 type walletService struct {
 	walletRepo store.WalletRepo
+	userRepo   store.UserRepo
+	currencies *currency.Registry
+	fees       *fee.Schedule
+	limits     *limit.Policy
 }
 
-func NewWalletService(walletRepo store.WalletRepo) WalletSvc {
+func NewWalletService(walletRepo store.WalletRepo, userRepo store.UserRepo, currencies *currency.Registry, fees *fee.Schedule, limits *limit.Policy) WalletSvc {
 	return &walletService{
 		walletRepo: walletRepo,
+		userRepo:   userRepo,
+		currencies: currencies,
+		fees:       fees,
+		limits:     limits,
 	}
 }
 
 
-func (w *walletService) AddWallet(ctx context.Context,createWalletDto dto.CreateWalletDto) (dto.WalletDto,error){
+func (w *walletService) AddWallet(ctx context.Context, username string, createWalletDto dto.CreateWalletDto) (dto.WalletDto,error){
 	logrus.Println("log AddWallet in service/wallet/AddWallet ")
 
 	var walletDto dto.WalletDto
 
+	// a user can only open wallets for himself
+	if createWalletDto.Username != username {
+		return walletDto, local_errors.ErrUnauthorized
+	}
+
+	// wallets can only be opened in enabled currencies of the registry
+	c, err := w.currencies.Validate(strings.ToUpper(createWalletDto.Currency))
+	if err != nil {
+		return walletDto, err
+	}
+
 	arg := store.CreateWalletParams{
 		Username:	createWalletDto.Username,
-		Currency: 	createWalletDto.Currency,
+		Currency: 	c.Code,
 	}
 
 	wallet, err := w.walletRepo.CreateWallet(ctx,arg)
@@ -57,10 +86,6 @@ func (w *walletService) GetWalletByUsername(ctx context.Context, username string
 
 	wallet, err := w.walletRepo.GetWalletByUsername(ctx, username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return walletDto, local_errors.ErrWalletNotFound
-		}
-
 		return walletDto, err
 	}
 
@@ -68,7 +93,8 @@ func (w *walletService) GetWalletByUsername(ctx context.Context, username string
 	return walletDto, nil
 }
 
-func (w *walletService) GetWalletByAddress(ctx context.Context, address string) (dto.WalletDto, error) {
+// GetWalletByAddress returns the wallet if it belongs to username
+func (w *walletService) GetWalletByAddress(ctx context.Context, username string, address string) (dto.WalletDto, error) {
 
 	logrus.Println("log GetWalletByAddress in service/wallet/GetWalletByAddress ")
 
@@ -76,10 +102,53 @@ func (w *walletService) GetWalletByAddress(ctx context.Context, address string)
 
 	wallet, err := w.walletRepo.GetWalletByAddress(ctx, address)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return walletDto, local_errors.ErrWalletNotFound
-		}
+		return walletDto, err
+	}
+
+	// the wallets of other users are not found, so that their addresses can not be probed
+	if wallet.Username != username {
+		return walletDto, local_errors.ErrWalletNotFound
+	}
+
+	walletDto = dto.NewWalletDto(wallet)
+	return walletDto, nil
+}
+
+// ListWalletsByUsername lists the wallets of owner, users can only list their own wallets
+func (w *walletService) ListWalletsByUsername(ctx context.Context, username string, owner string) ([]dto.WalletDto, error) {
+
+	logrus.Println("log ListWalletsByUsername in service/wallet/ListWalletsByUsername ")
+
+	if owner != username {
+		return nil, local_errors.ErrWalletNotFound
+	}
+
+	wallets, err := w.walletRepo.ListWalletsByUsername(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewWalletDtos(wallets), nil
+}
+
+// SetPrimaryWallet makes the wallet the primary wallet of username
+func (w *walletService) SetPrimaryWallet(ctx context.Context, username string, address string) (dto.WalletDto, error) {
 
+	logrus.Println("log SetPrimaryWallet in service/wallet/SetPrimaryWallet ")
+
+	var walletDto dto.WalletDto
+
+	wallet, err := w.walletRepo.GetWalletByAddress(ctx, address)
+	if err != nil {
+		return walletDto, err
+	}
+
+	if wallet.Username != username {
+		return walletDto, local_errors.ErrUnauthorized
+	}
+
+	wallet, err = w.walletRepo.SetPrimaryWallet(ctx, username, address)
+	if err != nil {
 		return walletDto, err
 	}
 
@@ -87,7 +156,7 @@ func (w *walletService) GetWalletByAddress(ctx context.Context, address string)
 	return walletDto, nil
 }
 
-func (w *walletService) Pay(ctx context.Context, transferMoneyDto dto.TransferMoneyDto) (dto.TransResultDto, error) {
+func (w *walletService) Pay(ctx context.Context, username string, transferMoneyDto dto.TransferMoneyDto) (dto.TransResultDto, error) {
 	logrus.Println("log Pay in service/wallet/Pay ")
 
 	var txnResDto dto.TransResultDto
@@ -104,16 +173,49 @@ func (w *walletService) Pay(ctx context.Context, transferMoneyDto dto.TransferMo
 		return txnResDto, fmt.Errorf("from_wallet_address does not exists")
 	}
 
+	if fromWallet.Username != username {
+		logrus.Println("log  fromWallet does not belong to the logged in user !! ")
+		return txnResDto, local_errors.ErrUnauthorized
+	}
+
 	if fromWallet.Status != model.WalletStatusACTIVE {
 		logrus.Println("log  fromWallet.Status is not ACTIVE !! ")
 		return txnResDto, fmt.Errorf("inactive from_wallet")
 	}
 
 
-	toWallet, err := w.walletRepo.GetWalletByAddress(ctx,arg.ToWalletAddress)
+	if (arg.ToWalletAddress == "") == (transferMoneyDto.ToUsername == "") {
+		return txnResDto, local_errors.ErrRecipientRequired
+	}
 
-	if err != nil {
-		return txnResDto, fmt.Errorf("to_wallet_address does not exists")
+	if transferMoneyDto.Currency != "" && strings.ToUpper(transferMoneyDto.Currency) != fromWallet.Currency {
+		return txnResDto, local_errors.ErrCurrencyMismatch
+	}
+
+	var toWallet model.Wallet
+	if arg.ToWalletAddress != "" {
+		toWallet, err = w.walletRepo.GetWalletByAddress(ctx,arg.ToWalletAddress)
+
+		if err != nil {
+			return txnResDto, fmt.Errorf("to_wallet_address does not exists")
+		}
+	} else {
+		if transferMoneyDto.Currency != "" {
+			// the payer fixed the currency, the recipient's wallet in it is paid
+			toWallet, err = w.walletRepo.GetWalletByUsernameAndCurrency(ctx, transferMoneyDto.ToUsername, fromWallet.Currency)
+		} else {
+			// otherwise the recipient's primary wallet is paid, it has to hold the payer's currency
+			toWallet, err = w.walletRepo.GetWalletByUsername(ctx, transferMoneyDto.ToUsername)
+		}
+
+		if err != nil {
+			return txnResDto, err
+		}
+		arg.ToWalletAddress = toWallet.WalletAddress
+	}
+
+	if toWallet.IsOrganization() {
+		return txnResDto, local_errors.ErrPayToOrganizationWallet
 	}
 
 	if toWallet.Status != model.WalletStatusACTIVE{
@@ -121,12 +223,34 @@ func (w *walletService) Pay(ctx context.Context, transferMoneyDto dto.TransferMo
 		return txnResDto, fmt.Errorf("inactive to_wallet")
 	}
 
+	if fromWallet.Currency != toWallet.Currency {
+		return txnResDto, local_errors.ErrCurrencyMismatch
+	}
+	arg.Currency = fromWallet.Currency
+
 	if arg.Amount <= 0 {
-		return txnResDto, fmt.Errorf("amount to pay should be positive")
+		return txnResDto, local_errors.ErrPayAmountNotPositive
 	}
 
+	if arg.FromWalletAddress == arg.ToWalletAddress {
+		return txnResDto, local_errors.ErrPayToSameWallet
+	}
+
+	tier, err := userTier(ctx, w.userRepo, fromWallet.Username)
+	if err != nil {
+		return txnResDto, err
+	}
+
+	quote, err := w.fees.Quote(fromWallet.Currency, tier, arg.Amount)
+	if err != nil {
+		return txnResDto, err
+	}
+	arg.Amount = quote.Gross
+	arg.Fee = quote.Fee
+	arg.Limit = w.limits.Lookup(fromWallet.Currency, tier)
+
 	if !fromWallet.IsBalanceSufficient(arg.Amount) {
-		return txnResDto, fmt.Errorf("insufficient wallet balance")
+		return txnResDto, local_errors.ErrInsufficientBalance
 	}
 
 	res, err := w.walletRepo.SendMoney(ctx, arg)
@@ -138,7 +262,76 @@ func (w *walletService) Pay(ctx context.Context, transferMoneyDto dto.TransferMo
 	return txnResDto, nil
 }
 
-func (w *walletService) Credit(ctx context.Context, creditDto dto.CreditDto) (dto.UpdatedWalletBalanceDto,error){
+func (w *walletService) QuoteFee(ctx context.Context, username string, quoteDto dto.FeeQuoteRequestDto) (dto.FeeQuoteDto, error) {
+	logrus.Println("log QuoteFee in service/wallet/QuoteFee ")
+
+	var feeQuoteDto dto.FeeQuoteDto
+
+	if quoteDto.Amount <= 0 {
+		return feeQuoteDto, local_errors.ErrPayAmountNotPositive
+	}
+
+	fromWallet, err := w.walletRepo.GetWalletByAddress(ctx, quoteDto.FromWalletAddress)
+	if err != nil {
+		return feeQuoteDto, err
+	}
+
+	if fromWallet.Username != username {
+		return feeQuoteDto, local_errors.ErrUnauthorized
+	}
+
+	tier, err := userTier(ctx, w.userRepo, fromWallet.Username)
+	if err != nil {
+		return feeQuoteDto, err
+	}
+
+	quote, err := w.fees.Quote(fromWallet.Currency, tier, quoteDto.Amount)
+	if err != nil {
+		return feeQuoteDto, err
+	}
+
+	feeQuoteDto = dto.NewFeeQuoteDto(fromWallet.Currency, quote)
+	return feeQuoteDto, nil
+}
+
+func (w *walletService) GetTransferLimits(ctx context.Context, username string, address string) (dto.TransferLimitsDto, error) {
+	logrus.Println("log GetTransferLimits in service/wallet/GetTransferLimits ")
+
+	var limitsDto dto.TransferLimitsDto
+
+	wallet, err := w.walletRepo.GetWalletByAddress(ctx, address)
+	if err != nil {
+		return limitsDto, err
+	}
+
+	if wallet.Username != username {
+		return limitsDto, local_errors.ErrUnauthorized
+	}
+
+	tier, err := userTier(ctx, w.userRepo, wallet.Username)
+	if err != nil {
+		return limitsDto, err
+	}
+
+	limitsDto = dto.NewTransferLimitsDto(wallet, tier, w.limits.Lookup(wallet.Currency, tier), time.Now())
+	return limitsDto, nil
+}
+
+// userTier returns the tier the fees and limits of a user are looked up with
+func userTier(ctx context.Context, userRepo store.UserRepo, username string) (model.UserTier, error) {
+
+	user, err := userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return "", err
+	}
+
+	if user.Tier == "" {
+		return model.UserTierSTANDARD, nil
+	}
+	return user.Tier, nil
+}
+
+func (w *walletService) Credit(ctx context.Context, username string, creditDto dto.CreditDto) (dto.UpdatedWalletBalanceDto,error){
 	logrus.Println("log Credit in service/wallet/Credit ")
 
 	var updatedWalletBalanceDto dto.UpdatedWalletBalanceDto
@@ -152,7 +345,17 @@ func (w *walletService) Credit(ctx context.Context, creditDto dto.CreditDto) (dt
 		return updatedWalletBalanceDto, fmt.Errorf("amount to credit should be positive")
 	}
 
-	wallet, err := w.walletRepo.AddWalletBalance(ctx,arg)
+	wallet, err := w.walletRepo.GetWalletByAddress(ctx, arg.WalletAddress)
+	if err != nil {
+		return updatedWalletBalanceDto, err
+	}
+
+	if wallet.Username != username {
+		logrus.Println("log  wallet does not belong to the logged in user !! ")
+		return updatedWalletBalanceDto, local_errors.ErrUnauthorized
+	}
+
+	wallet, err = w.walletRepo.AddWalletBalance(ctx,arg)
 	if err != nil{
 		return updatedWalletBalanceDto, err
 	}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/constant"
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/outbox"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestWallet(t *testing.T, db *gorm.DB, walletRepo store.WalletRepo, balance int64) model.Wallet {
+	ctx := context.Background()
+
+	username := fmt.Sprintf("user%d", time.Now().UnixNano())
+	_, err := store.NewUserRepo(db).CreateUser(ctx, store.CreateUserParams{
+		Username:       username,
+		HashedPassword: "secret",
+		Status:         model.UserStatusACTIVE,
+		Email:          username + "@example.com",
+	})
+	require.NoError(t, err)
+
+	wallet, err := walletRepo.CreateWallet(ctx, store.CreateWalletParams{
+		Username: username,
+		Currency: "INR",
+	})
+	require.NoError(t, err)
+
+	if balance == 0 {
+		return wallet
+	}
+
+	wallet, err = walletRepo.AddWalletBalance(ctx, store.AddWalletBalanceParams{
+		WalletAddress: wallet.WalletAddress,
+		Amount:        balance,
+	})
+	require.NoError(t, err)
+	require.Equal(t, balance, wallet.Balance)
+
+	return wallet
+}
+
+func TestConcurrentPayConservesMoney(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	ledgerRepo := store.NewLedgerRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), ledgerRepo, store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	const initialBalance = 10000
+	walletA := createTestWallet(t, db, walletRepo, initialBalance)
+	walletB := createTestWallet(t, db, walletRepo, initialBalance)
+
+	// transfers in both directions at the same time, so that a wrong lock
+	// order shows up as a deadlock and a lost update as a wrong total
+	const n = 300
+	var sentByA, sentByB int64
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			from, to := walletA, walletB
+			if i%2 == 1 {
+				from, to = walletB, walletA
+			}
+			amount := int64(i%50 + 1)
+
+			_, err := walletSvc.Pay(ctx, from.Username, dto.TransferMoneyDto{
+				FromWalletAddress: from.WalletAddress,
+				ToWalletAddress:   to.WalletAddress,
+				Amount:            amount,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if from.ID == walletA.ID {
+				atomic.AddInt64(&sentByA, amount)
+			} else {
+				atomic.AddInt64(&sentByB, amount)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	updatedA, err := walletRepo.GetWalletByAddress(ctx, walletA.WalletAddress)
+	require.NoError(t, err)
+	updatedB, err := walletRepo.GetWalletByAddress(ctx, walletB.WalletAddress)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(2*initialBalance), updatedA.Balance+updatedB.Balance)
+	require.Equal(t, initialBalance-sentByA+sentByB, updatedA.Balance)
+	require.Equal(t, initialBalance-sentByB+sentByA, updatedB.Balance)
+
+	// the cached balances must agree with the ledger
+	for _, w := range []model.Wallet{updatedA, updatedB} {
+		balance, err := ledgerRepo.GetPostingsBalance(ctx, w.WalletAddress)
+		require.NoError(t, err)
+		require.Equal(t, w.Balance, balance)
+	}
+}
+
+func TestMultipleWalletsPerUser(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	payer := createTestWallet(t, db, walletRepo, 1000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+	require.True(t, payee.IsPrimary)
+
+	usd, err := walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "USD"})
+	require.NoError(t, err)
+	require.False(t, usd.IsPrimary)
+	require.Equal(t, payee.UserID, usd.UserID)
+
+	// a second wallet in the same currency is rejected
+	_, err = walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "INR"})
+	require.ErrorIs(t, err, local_errors.ErrWalletCurrencyExists)
+
+	primary, err := walletSvc.SetPrimaryWallet(ctx, payee.Username, usd.WalletAddress)
+	require.NoError(t, err)
+	require.True(t, primary.IsPrimary)
+
+	wallet, err := walletRepo.GetWalletByUsername(ctx, payee.Username)
+	require.NoError(t, err)
+	require.Equal(t, usd.WalletAddress, wallet.WalletAddress)
+
+	wallets, err := walletSvc.ListWalletsByUsername(ctx, payee.Username, payee.Username)
+	require.NoError(t, err)
+	require.Len(t, wallets, 2)
+
+	// the wallets of another user are not found
+	_, err = walletSvc.ListWalletsByUsername(ctx, payer.Username, payee.Username)
+	require.ErrorIs(t, err, local_errors.ErrWalletNotFound)
+	_, err = walletSvc.GetWalletByAddress(ctx, payer.Username, usd.WalletAddress)
+	require.ErrorIs(t, err, local_errors.ErrWalletNotFound)
+
+	// paying by username pays the primary wallet, which is not in the payer's currency
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToUsername:        payee.Username,
+		Amount:            300,
+	})
+	require.ErrorIs(t, err, local_errors.ErrCurrencyMismatch)
+
+	// unless the payer fixes the currency
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToUsername:        payee.Username,
+		Currency:          "inr",
+		Amount:            300,
+	})
+	require.NoError(t, err)
+
+	wallet, err = walletRepo.GetWalletByAddress(ctx, payee.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(300), wallet.Balance)
+
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToUsername:        payee.Username,
+		Currency:          "USD",
+		Amount:            1,
+	})
+	require.ErrorIs(t, err, local_errors.ErrCurrencyMismatch)
+
+	// with the INR wallet primary again, the username alone pays it
+	_, err = walletSvc.SetPrimaryWallet(ctx, payee.Username, payee.WalletAddress)
+	require.NoError(t, err)
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToUsername:        payee.Username,
+		Amount:            100,
+	})
+	require.NoError(t, err)
+
+	wallet, err = walletRepo.GetWalletByAddress(ctx, payee.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), wallet.Balance)
+
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		ToUsername:        payee.Username,
+		Amount:            1,
+	})
+	require.Error(t, err)
+}
+
+func TestAddWalletValidatesCurrencyAndPayRejectsMismatch(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	payer := createTestWallet(t, db, walletRepo, 1000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	_, err := walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "XYZ"})
+	require.ErrorIs(t, err, local_errors.ErrCurrencyNotFound)
+
+	_, err = walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "BDT"})
+	require.ErrorIs(t, err, local_errors.ErrCurrencyDisabled)
+
+	// codes are normalised to upper case
+	usd, err := walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "usd"})
+	require.NoError(t, err)
+	require.Equal(t, "USD", usd.Currency)
+	require.Equal(t, "0.00", usd.BalanceFormatted)
+
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   usd.WalletAddress,
+		Amount:            100,
+	})
+	require.ErrorIs(t, err, local_errors.ErrCurrencyMismatch)
+
+	res, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		Amount:            125,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "INR", res.Currency)
+	require.Equal(t, "1.25", res.AmountFormatted)
+}
+
+func TestPayRejectsInvalidRecipients(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	payer := createTestWallet(t, db, walletRepo, 1000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+	float, err := walletRepo.GetOrganizationWallet(ctx, store.OrganizationWalletParams{Currency: "INR", Purpose: model.OrganizationWalletFLOAT})
+	require.NoError(t, err)
+
+	pay := func(transferMoneyDto dto.TransferMoneyDto) error {
+		transferMoneyDto.FromWalletAddress = payer.WalletAddress
+		_, err := walletSvc.Pay(ctx, payer.Username, transferMoneyDto)
+		return err
+	}
+
+	// the client sent a request it has to fix, none of these is a server error
+	err = pay(dto.TransferMoneyDto{Amount: 100})
+	require.ErrorIs(t, err, local_errors.ErrRecipientRequired)
+	require.Equal(t, http.StatusBadRequest, local_errors.Status(err))
+
+	err = pay(dto.TransferMoneyDto{ToWalletAddress: payee.WalletAddress, ToUsername: payee.Username, Amount: 100})
+	require.ErrorIs(t, err, local_errors.ErrRecipientRequired)
+
+	err = pay(dto.TransferMoneyDto{ToWalletAddress: payee.WalletAddress})
+	require.ErrorIs(t, err, local_errors.ErrPayAmountNotPositive)
+	require.Equal(t, http.StatusBadRequest, local_errors.Status(err))
+
+	err = pay(dto.TransferMoneyDto{ToWalletAddress: payer.WalletAddress, Amount: 100})
+	require.ErrorIs(t, err, local_errors.ErrPayToSameWallet)
+	require.Equal(t, http.StatusBadRequest, local_errors.Status(err))
+
+	err = pay(dto.TransferMoneyDto{ToWalletAddress: float.WalletAddress, Amount: 100})
+	require.ErrorIs(t, err, local_errors.ErrPayToOrganizationWallet)
+	require.Equal(t, http.StatusConflict, local_errors.Status(err))
+}
+
+func TestCreditDrawsFromFloat(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	ledgerRepo := store.NewLedgerRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), ledgerRepo, store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limit.Unlimited())
+
+	float, err := walletRepo.GetOrganizationWallet(ctx, store.OrganizationWalletParams{Currency: "INR", Purpose: model.OrganizationWalletFLOAT})
+	require.NoError(t, err)
+
+	totals, err := ledgerRepo.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+
+	wallet := createTestWallet(t, db, walletRepo, 0)
+
+	updated, err := walletSvc.Credit(ctx, wallet.Username, dto.CreditDto{WalletAddress: wallet.WalletAddress, Amount: 2500})
+	require.NoError(t, err)
+	require.Equal(t, int64(2500), updated.UpdatedBalance)
+
+	updatedFloat, err := walletRepo.GetWalletByAddress(ctx, float.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, float.Balance-2500, updatedFloat.Balance)
+
+	// the credit moved money between wallets, the totals per currency did not change
+	after, err := ledgerRepo.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+	require.Equal(t, totals, after)
+
+	// organization wallets can not be paid into by users
+	_, err = walletSvc.Pay(ctx, wallet.Username, dto.TransferMoneyDto{
+		FromWalletAddress: wallet.WalletAddress,
+		ToWalletAddress:   float.WalletAddress,
+		Amount:            100,
+	})
+	require.Error(t, err)
+}
+
+func TestPayChargesFees(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+
+	fees, err := fee.NewSchedule([]fee.Rule{
+		{Currency: "INR", Kind: fee.KindPERCENTAGE, Percentage: "2", Min: 10},
+		{Currency: "USD", Kind: fee.KindFLAT, Flat: 30, Bearer: model.FeeBearerPAYEE},
+	})
+	require.NoError(t, err)
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fees, limit.Unlimited())
+
+	feeWallet, err := walletRepo.GetOrganizationWallet(ctx, store.OrganizationWalletParams{Currency: "INR", Purpose: model.OrganizationWalletFEEINCOME})
+	require.NoError(t, err)
+
+	payer := createTestWallet(t, db, walletRepo, 10000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	quote, err := walletSvc.QuoteFee(ctx, payer.Username, dto.FeeQuoteRequestDto{FromWalletAddress: payer.WalletAddress, Amount: 5000})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), quote.Fee)
+	require.Equal(t, int64(5100), quote.Gross)
+	require.Equal(t, int64(5000), quote.Net)
+	require.Equal(t, "51.00", quote.GrossFormatted)
+
+	// the payer bears the fee
+	res, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		Amount:            5000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5100), res.Amount)
+	require.Equal(t, int64(100), res.Fee)
+	require.Equal(t, int64(5000), res.NetAmount)
+
+	from, err := walletRepo.GetWalletByAddress(ctx, payer.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(4900), from.Balance)
+
+	to, err := walletRepo.GetWalletByAddress(ctx, payee.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(5000), to.Balance)
+
+	updatedFeeWallet, err := walletRepo.GetWalletByAddress(ctx, feeWallet.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, feeWallet.Balance+100, updatedFeeWallet.Balance)
+
+	// the gross amount must be covered by the balance
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: payer.WalletAddress,
+		ToWalletAddress:   payee.WalletAddress,
+		Amount:            4900,
+	})
+	require.Error(t, err)
+
+	// the payee bears the fee
+	usdPayer, err := walletSvc.AddWallet(ctx, payer.Username, dto.CreateWalletDto{Username: payer.Username, Currency: "USD"})
+	require.NoError(t, err)
+	_, err = walletSvc.Credit(ctx, payer.Username, dto.CreditDto{WalletAddress: usdPayer.WalletAddress, Amount: 1000})
+	require.NoError(t, err)
+	usdPayee, err := walletSvc.AddWallet(ctx, payee.Username, dto.CreateWalletDto{Username: payee.Username, Currency: "USD"})
+	require.NoError(t, err)
+
+	res, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+		FromWalletAddress: usdPayer.WalletAddress,
+		ToWalletAddress:   usdPayee.WalletAddress,
+		Amount:            1000,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), res.Amount)
+	require.Equal(t, int64(30), res.Fee)
+	require.Equal(t, int64(970), res.NetAmount)
+	require.Equal(t, "9.70", res.NetAmountFormatted)
+}
+
+func TestPayEnforcesTransferLimits(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+
+	limits, err := limit.NewPolicy([]limit.Rule{
+		{Currency: "INR", TransferLimit: model.TransferLimit{PerTransaction: 500, DailyAmount: 800, DailyCount: 3}},
+	})
+	require.NoError(t, err)
+	walletSvc := NewWalletService(walletRepo, store.NewUserRepo(db), currency.Default(), fee.Free(), limits)
+
+	payer := createTestWallet(t, db, walletRepo, 10000)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	pay := func(amount int64) error {
+		_, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{
+			FromWalletAddress: payer.WalletAddress,
+			ToWalletAddress:   payee.WalletAddress,
+			Amount:            amount,
+		})
+		return err
+	}
+
+	require.ErrorIs(t, pay(501), local_errors.ErrPerTransactionLimitExceeded)
+	require.NoError(t, pay(500))
+	require.NoError(t, pay(200))
+	require.ErrorIs(t, pay(101), local_errors.ErrDailyLimitExceeded)
+
+	limitsDto, err := walletSvc.GetTransferLimits(ctx, payer.Username, payer.WalletAddress)
+	require.NoError(t, err)
+	require.Equal(t, int64(500), *limitsDto.PerTransaction)
+	require.Equal(t, int64(700), limitsDto.Daily.AmountUsed)
+	require.Equal(t, int64(100), *limitsDto.Daily.AmountRemaining)
+	require.Equal(t, int64(1), *limitsDto.Daily.CountRemaining)
+	require.Nil(t, limitsDto.Monthly.AmountLimit)
+	require.Equal(t, int64(2), limitsDto.Monthly.CountUsed)
+
+	require.NoError(t, pay(100))
+	require.ErrorIs(t, pay(1), local_errors.ErrDailyLimitExceeded)
+
+	// only the owner sees the limits of a wallet
+	_, err = walletSvc.GetTransferLimits(ctx, payee.Username, payer.WalletAddress)
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+}
+
+func TestWalletChangesEmitEvents(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	userRepo := store.NewUserRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	walletSvc := NewWalletService(walletRepo, userRepo, currency.Default(), fee.Free(), limit.Unlimited())
+
+	payer := createTestWallet(t, db, walletRepo, 100)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	events := func(aggregateType string, aggregateID string) []model.EventType {
+		var rows []model.OutboxEvent
+		require.NoError(t, db.Where("aggregate_type = ? AND aggregate_id = ?", aggregateType, aggregateID).Order("id").Find(&rows).Error)
+		types := make([]model.EventType, 0, len(rows))
+		for _, e := range rows {
+			require.NotEmpty(t, e.EventID)
+			require.False(t, e.Published)
+			types = append(types, e.Type)
+		}
+		return types
+	}
+
+	require.Equal(t, []model.EventType{model.EventTypeWalletCreated, model.EventTypeWalletCredited},
+		events(model.AggregateTypeWALLET, payer.WalletAddress))
+
+	res, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 30})
+	require.NoError(t, err)
+	require.Equal(t, []model.EventType{model.EventTypeTransferCompleted},
+		events(model.AggregateTypeTRANS, fmt.Sprint(res.ID)))
+
+	var payload model.TransferEvent
+	var row model.OutboxEvent
+	require.NoError(t, db.Where("aggregate_type = ? AND aggregate_id = ?", model.AggregateTypeTRANS, fmt.Sprint(res.ID)).Take(&row).Error)
+	require.NoError(t, json.Unmarshal([]byte(row.Payload), &payload))
+	require.Equal(t, payer.WalletAddress, payload.FromWalletAddress)
+	require.Equal(t, int64(30), payload.Amount)
+
+	// a rolled back transfer leaves no event behind
+	var before int
+	require.NoError(t, db.Model(&model.OutboxEvent{}).Where("type = ?", model.EventTypeTransferCompleted).Count(&before).Error)
+	_, err = walletRepo.SendMoney(ctx, store.SendMoneyParams{FromWalletAddress: payee.WalletAddress, ToWalletAddress: payer.WalletAddress, Amount: 31, Currency: "INR"})
+	require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+	var after int
+	require.NoError(t, db.Model(&model.OutboxEvent{}).Where("type = ?", model.EventTypeTransferCompleted).Count(&after).Error)
+	require.Equal(t, before, after)
+
+	_, err = walletRepo.UpdateWalletStatus(ctx, store.UpdateWalletStatusParams{ID: payee.ID, Status: model.WalletStatusINACTIVE})
+	require.NoError(t, err)
+	_, err = walletRepo.UpdateWalletStatus(ctx, store.UpdateWalletStatusParams{ID: payee.ID, Status: model.WalletStatusINACTIVE})
+	require.NoError(t, err)
+	require.Equal(t, []model.EventType{model.EventTypeWalletCreated, model.EventTypeWalletFrozen},
+		events(model.AggregateTypeWALLET, payee.WalletAddress))
+
+	// the relay publishes everything that is pending, in order, and only once
+	sink := &recordingSink{}
+	relay := outbox.NewRelay(store.NewOutboxRepo(db), store.NewLeaseRepo(db), sink, time.Minute, constant.OutboxMaxAttempts)
+	for {
+		n, err := relay.Run(ctx, "relay-test")
+		require.NoError(t, err)
+		if n == 0 {
+			break
+		}
+	}
+
+	require.GreaterOrEqual(t, len(sink.ids), 5)
+	for i := 1; i < len(sink.ids); i++ {
+		require.Greater(t, sink.ids[i], sink.ids[i-1])
+	}
+
+	var pending int
+	require.NoError(t, db.Model(&model.OutboxEvent{}).Where("published = ?", false).Count(&pending).Error)
+	require.Zero(t, pending)
+}
+
+// recordingSink records the ids of the events it is given
+type recordingSink struct {
+	ids []int64
+}
+
+func (s *recordingSink) Publish(ctx context.Context, msg outbox.Message) error {
+	s.ids = append(s.ids, msg.ID)
+	return nil
+}
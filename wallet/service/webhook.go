@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/outbox"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/webhook"
+	"github.com/sirupsen/logrus"
+	"net/url"
+	"time"
+)
+
+const (
+	// webhookLease is the lease held by the instance that sends the webhook deliveries
+	webhookLease = "webhook-dispatcher"
+	// webhookBatch is the number of deliveries sent per run, and listed per request
+	webhookBatch = 100
+)
+
+type WebhookSvc interface {
+	CreateSubscription(ctx context.Context, username string, createDto dto.CreateWebhookDto) (dto.WebhookSubscriptionDto, error)
+	ListSubscriptions(ctx context.Context, username string) ([]dto.WebhookSubscriptionDto, error)
+	// DeleteSubscription deactivates the subscription, its pending deliveries are dropped
+	DeleteSubscription(ctx context.Context, username string, id int64) (dto.WebhookSubscriptionDto, error)
+	ListDeliveries(ctx context.Context, username string, subscriptionID int64) ([]dto.WebhookDeliveryDto, error)
+	// ReplayDelivery sends a delivery again, with a fresh set of attempts
+	ReplayDelivery(ctx context.Context, username string, subscriptionID int64, deliveryID int64) (dto.WebhookDeliveryDto, error)
+	// Publish makes the service an outbox sink, it queues a delivery of the event for every matching subscription
+	Publish(ctx context.Context, msg outbox.Message) error
+	// Dispatch sends the due deliveries if holder is the dispatcher leader, it returns the number delivered
+	Dispatch(ctx context.Context, holder string) (int, error)
+}
+
+type webhookService struct {
+	webhookRepo store.WebhookRepo
+	leaseRepo   store.LeaseRepo
+	walletRepo  store.WalletRepo
+	sender      *webhook.Sender
+	retry       RetryPolicy
+	leaseTTL    time.Duration
+}
+
+func NewWebhookService(webhookRepo store.WebhookRepo, leaseRepo store.LeaseRepo, walletRepo store.WalletRepo, sender *webhook.Sender, retry RetryPolicy, leaseTTL time.Duration) WebhookSvc {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		leaseRepo:   leaseRepo,
+		walletRepo:  walletRepo,
+		sender:      sender,
+		retry:       retry,
+		leaseTTL:    leaseTTL,
+	}
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, username string, createDto dto.CreateWebhookDto) (dto.WebhookSubscriptionDto, error) {
+	logrus.Println("log CreateSubscription in service/webhook/CreateSubscription ")
+
+	var subscriptionDto dto.WebhookSubscriptionDto
+
+	u, err := url.Parse(createDto.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return subscriptionDto, fmt.Errorf("url must be an http or https url")
+	}
+	if err := s.sender.CheckURL(ctx, createDto.URL); err != nil {
+		return subscriptionDto, err
+	}
+
+	eventTypes := make([]model.EventType, 0, len(createDto.EventTypes))
+	for _, t := range createDto.EventTypes {
+		if !model.IsEventType(model.EventType(t)) {
+			return subscriptionDto, fmt.Errorf("unknown event type %q", t)
+		}
+		eventTypes = append(eventTypes, model.EventType(t))
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return subscriptionDto, err
+	}
+
+	subscription, err := s.webhookRepo.CreateWebhookSubscription(ctx, store.CreateWebhookSubscriptionParams{
+		Username:   username,
+		URL:        createDto.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	})
+	if err != nil {
+		return subscriptionDto, err
+	}
+
+	subscriptionDto = dto.NewWebhookSubscriptionDto(subscription)
+	subscriptionDto.Secret = subscription.Secret
+	return subscriptionDto, nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context, username string) ([]dto.WebhookSubscriptionDto, error) {
+	logrus.Println("log ListSubscriptions in service/webhook/ListSubscriptions ")
+
+	subscriptions, err := s.webhookRepo.ListWebhookSubscriptions(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewWebhookSubscriptionDtos(subscriptions), nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, username string, id int64) (dto.WebhookSubscriptionDto, error) {
+	logrus.Println("log DeleteSubscription in service/webhook/DeleteSubscription ")
+
+	var subscriptionDto dto.WebhookSubscriptionDto
+
+	if _, err := s.ownSubscription(ctx, username, id); err != nil {
+		return subscriptionDto, err
+	}
+
+	subscription, err := s.webhookRepo.DeactivateWebhookSubscription(ctx, id)
+	if err != nil {
+		return subscriptionDto, err
+	}
+
+	subscriptionDto = dto.NewWebhookSubscriptionDto(subscription)
+	return subscriptionDto, nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, username string, subscriptionID int64) ([]dto.WebhookDeliveryDto, error) {
+	logrus.Println("log ListDeliveries in service/webhook/ListDeliveries ")
+
+	if _, err := s.ownSubscription(ctx, username, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.webhookRepo.ListWebhookDeliveries(ctx, subscriptionID, webhookBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewWebhookDeliveryDtos(deliveries), nil
+}
+
+func (s *webhookService) ReplayDelivery(ctx context.Context, username string, subscriptionID int64, deliveryID int64) (dto.WebhookDeliveryDto, error) {
+	logrus.Println("log ReplayDelivery in service/webhook/ReplayDelivery ")
+
+	var deliveryDto dto.WebhookDeliveryDto
+
+	subscription, err := s.ownSubscription(ctx, username, subscriptionID)
+	if err != nil {
+		return deliveryDto, err
+	}
+
+	if !subscription.Active {
+		return deliveryDto, fmt.Errorf("webhook subscription is deleted")
+	}
+
+	delivery, err := s.webhookRepo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return deliveryDto, err
+	}
+
+	if delivery.SubscriptionID != subscription.ID {
+		return deliveryDto, local_errors.ErrWebhookDeliveryNotFound
+	}
+
+	delivery, err = s.webhookRepo.ReplayWebhookDelivery(ctx, deliveryID, time.Now())
+	if err != nil {
+		return deliveryDto, err
+	}
+
+	deliveryDto = dto.NewWebhookDeliveryDto(delivery)
+	return deliveryDto, nil
+}
+
+func (s *webhookService) Publish(ctx context.Context, msg outbox.Message) error {
+	logrus.Println("log Publish in service/webhook/Publish ")
+
+	usernames, err := s.eventUsers(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, err := s.webhookRepo.ListActiveWebhookSubscriptions(ctx, usernames)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []model.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Matches(msg.Type) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        msg.EventID,
+			EventType:      msg.Type,
+			Payload:        string(payload),
+			Status:         model.WebhookDeliveryStatusPENDING,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return s.webhookRepo.CreateWebhookDeliveries(ctx, deliveries)
+}
+
+func (s *webhookService) Dispatch(ctx context.Context, holder string) (int, error) {
+	logrus.Println("log Dispatch in service/webhook/Dispatch ")
+
+	leader, err := s.leaseRepo.AcquireLease(ctx, webhookLease, holder, s.leaseTTL)
+	if err != nil || !leader {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	deliveries, err := s.webhookRepo.ListDueWebhookDeliveries(ctx, now, webhookBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	subscriptions := map[int64]model.WebhookSubscription{}
+	delivered := 0
+	for _, d := range deliveries {
+		subscription, ok := subscriptions[d.SubscriptionID]
+		if !ok {
+			subscription, err = s.webhookRepo.GetWebhookSubscription(ctx, d.SubscriptionID)
+			if err != nil {
+				return delivered, err
+			}
+			subscriptions[d.SubscriptionID] = subscription
+		}
+
+		arg := store.RecordWebhookDeliveryAttemptParams{ID: d.ID}
+		if !subscription.Active {
+			arg.Error = "webhook subscription is deleted"
+		} else {
+			statusCode, sendErr := s.sender.Send(ctx, subscription, d)
+			arg.StatusCode = statusCode
+			if sendErr != nil {
+				logrus.Printf("webhook delivery %d failed on attempt %d: %v", d.ID, d.Attempts+1, sendErr)
+				arg.Error = sendErr.Error()
+				if delay, ok := s.retry.delay(d.Attempts + 1); ok {
+					arg.NextAttemptAt = now.Add(delay)
+				}
+			}
+		}
+
+		if _, err := s.webhookRepo.RecordWebhookDeliveryAttempt(ctx, arg); err != nil {
+			return delivered, err
+		}
+		if arg.Error == "" {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// eventUsers returns the owners of the wallets an event is about
+func (s *webhookService) eventUsers(ctx context.Context, msg outbox.Message) ([]string, error) {
+
+	switch msg.AggregateType {
+	case model.AggregateTypeWALLET:
+		var e model.WalletEvent
+		if err := json.Unmarshal(msg.Payload, &e); err != nil {
+			return nil, err
+		}
+		return []string{e.Username}, nil
+
+	case model.AggregateTypeTRANS:
+		var e model.TransferEvent
+		if err := json.Unmarshal(msg.Payload, &e); err != nil {
+			return nil, err
+		}
+
+		var usernames []string
+		for _, address := range []string{e.FromWalletAddress, e.ToWalletAddress} {
+			w, err := s.walletRepo.GetWalletByAddress(ctx, address)
+			if err != nil {
+				return nil, err
+			}
+			if w.IsOrganization() || (len(usernames) > 0 && usernames[0] == w.Username) {
+				continue
+			}
+			usernames = append(usernames, w.Username)
+		}
+		return usernames, nil
+	}
+
+	return nil, nil
+}
+
+// ownSubscription returns the subscription if it belongs to username
+func (s *webhookService) ownSubscription(ctx context.Context, username string, id int64) (model.WebhookSubscription, error) {
+
+	subscription, err := s.webhookRepo.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		return subscription, err
+	}
+
+	if subscription.Username != username {
+		return model.WebhookSubscription{}, local_errors.ErrUnauthorized
+	}
+
+	return subscription, nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/constant"
+	"github.com/dsthakur2711/wallet/currency"
+	"github.com/dsthakur2711/wallet/dto"
+	"github.com/dsthakur2711/wallet/fee"
+	"github.com/dsthakur2711/wallet/limit"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/outbox"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// testReceiver is a webhook endpoint that checks the signature of what it receives
+type testReceiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []outbox.Message
+}
+
+func (rc *testReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if err := webhook.Verify(rc.secret, r.Header.Get(webhook.SignatureHeader), body, time.Now(), time.Minute); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if rc.status != http.StatusOK {
+		w.WriteHeader(rc.status)
+		return
+	}
+
+	var msg outbox.Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rc.received = append(rc.received, msg)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	userRepo := store.NewUserRepo(db)
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	webhookRepo := store.NewWebhookRepo(db)
+	walletSvc := NewWalletService(walletRepo, userRepo, currency.Default(), fee.Free(), limit.Unlimited())
+	// failed deliveries are due again at once so that the test can send them
+	webhookSvc := NewWebhookService(webhookRepo, store.NewLeaseRepo(db), walletRepo, webhook.NewUnrestrictedSender(nil), RetryPolicy{MaxAttempts: 2}, time.Minute)
+	relay := outbox.NewRelay(store.NewOutboxRepo(db), store.NewLeaseRepo(db), webhookSvc, time.Minute, constant.OutboxMaxAttempts)
+
+	const dispatcher = "webhook-test"
+
+	payer := createTestWallet(t, db, walletRepo, 100)
+	payee := createTestWallet(t, db, walletRepo, 0)
+
+	receiver := &testReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	_, err := webhookSvc.CreateSubscription(ctx, payee.Username, dto.CreateWebhookDto{URL: server.URL, EventTypes: []string{"MoneyArrived"}})
+	require.Error(t, err)
+	_, err = webhookSvc.CreateSubscription(ctx, payee.Username, dto.CreateWebhookDto{URL: "ftp://example.com"})
+	require.Error(t, err)
+
+	subscription, err := webhookSvc.CreateSubscription(ctx, payee.Username, dto.CreateWebhookDto{
+		URL:        server.URL,
+		EventTypes: []string{string(model.EventTypeTransferCompleted)},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, subscription.Secret)
+	receiver.secret = subscription.Secret
+
+	// the payer only wants to hear about frozen wallets
+	payerSubscription, err := webhookSvc.CreateSubscription(ctx, payer.Username, dto.CreateWebhookDto{
+		URL:        server.URL,
+		EventTypes: []string{string(model.EventTypeWalletFrozen)},
+	})
+	require.NoError(t, err)
+
+	relayAll := func() {
+		for {
+			n, err := relay.Run(ctx, "relay-test")
+			require.NoError(t, err)
+			if n == 0 {
+				return
+			}
+		}
+	}
+	deliveries := func(username string, id int64) []dto.WebhookDeliveryDto {
+		list, err := webhookSvc.ListDeliveries(ctx, username, id)
+		require.NoError(t, err)
+		return list
+	}
+
+	res, err := walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 40})
+	require.NoError(t, err)
+	relayAll()
+
+	pending := deliveries(payee.Username, subscription.ID)
+	require.Len(t, pending, 1)
+	require.Equal(t, model.WebhookDeliveryStatusPENDING, pending[0].Status)
+	require.Empty(t, deliveries(payer.Username, payerSubscription.ID))
+
+	// an event the relay publishes again is not delivered twice
+	var msg outbox.Message
+	require.NoError(t, json.Unmarshal(pending[0].Payload, &msg))
+	require.NoError(t, webhookSvc.Publish(ctx, msg))
+	require.Len(t, deliveries(payee.Username, subscription.ID), 1)
+
+	_, err = webhookSvc.Dispatch(ctx, dispatcher)
+	require.NoError(t, err)
+
+	require.Len(t, receiver.received, 1)
+	require.Equal(t, model.EventTypeTransferCompleted, receiver.received[0].Type)
+	var transfer model.TransferEvent
+	require.NoError(t, json.Unmarshal(receiver.received[0].Payload, &transfer))
+	require.Equal(t, res.ID, transfer.TransID)
+	require.Equal(t, payee.WalletAddress, transfer.ToWalletAddress)
+
+	delivered := deliveries(payee.Username, subscription.ID)[0]
+	require.Equal(t, model.WebhookDeliveryStatusSUCCEEDED, delivered.Status)
+	require.Equal(t, http.StatusOK, delivered.LastStatusCode)
+	require.NotNil(t, delivered.DeliveredAt)
+
+	// a failing endpoint is retried, then the delivery is dead
+	receiver.status = http.StatusInternalServerError
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 10})
+	require.NoError(t, err)
+	relayAll()
+
+	_, err = webhookSvc.Dispatch(ctx, dispatcher)
+	require.NoError(t, err)
+	failed := deliveries(payee.Username, subscription.ID)[0]
+	require.Equal(t, model.WebhookDeliveryStatusPENDING, failed.Status)
+	require.Equal(t, 1, failed.Attempts)
+	require.Equal(t, http.StatusInternalServerError, failed.LastStatusCode)
+
+	_, err = webhookSvc.Dispatch(ctx, dispatcher)
+	require.NoError(t, err)
+	dead := deliveries(payee.Username, subscription.ID)[0]
+	require.Equal(t, model.WebhookDeliveryStatusDEAD, dead.Status)
+	require.Equal(t, 2, dead.Attempts)
+
+	// a replay sends the dead delivery again
+	_, err = webhookSvc.ReplayDelivery(ctx, payer.Username, subscription.ID, dead.ID)
+	require.ErrorIs(t, err, local_errors.ErrUnauthorized)
+	_, err = webhookSvc.ReplayDelivery(ctx, payer.Username, payerSubscription.ID, dead.ID)
+	require.ErrorIs(t, err, local_errors.ErrWebhookDeliveryNotFound)
+
+	replayed, err := webhookSvc.ReplayDelivery(ctx, payee.Username, subscription.ID, dead.ID)
+	require.NoError(t, err)
+	require.Equal(t, model.WebhookDeliveryStatusPENDING, replayed.Status)
+	require.Zero(t, replayed.Attempts)
+
+	receiver.status = http.StatusOK
+	_, err = webhookSvc.Dispatch(ctx, dispatcher)
+	require.NoError(t, err)
+	require.Len(t, receiver.received, 2)
+	require.Equal(t, model.WebhookDeliveryStatusSUCCEEDED, deliveries(payee.Username, subscription.ID)[0].Status)
+
+	// a deleted subscription gets no more deliveries
+	deleted, err := webhookSvc.DeleteSubscription(ctx, payee.Username, subscription.ID)
+	require.NoError(t, err)
+	require.False(t, deleted.Active)
+
+	_, err = walletSvc.Pay(ctx, payer.Username, dto.TransferMoneyDto{FromWalletAddress: payer.WalletAddress, ToWalletAddress: payee.WalletAddress, Amount: 10})
+	require.NoError(t, err)
+	relayAll()
+	require.Len(t, deliveries(payee.Username, subscription.ID), 2)
+}
+
+func TestCreateSubscriptionRefusesNonPublicURLs(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	walletRepo := store.NewWalletRepo(db, store.NewTransRepo(db), store.NewLedgerRepo(db), store.NewFXQuoteRepo(db))
+	webhookSvc := NewWebhookService(store.NewWebhookRepo(db), store.NewLeaseRepo(db), walletRepo, webhook.NewSender(nil), RetryPolicy{MaxAttempts: 2}, time.Minute)
+
+	owner := createTestWallet(t, db, walletRepo, 0)
+
+	for _, rawURL := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5/hook", "http://localhost/hook"} {
+		_, err := webhookSvc.CreateSubscription(ctx, owner.Username, dto.CreateWebhookDto{
+			URL:        rawURL,
+			EventTypes: []string{string(model.EventTypeTransferCompleted)},
+		})
+		require.ErrorIs(t, err, local_errors.ErrWebhookURLNotPublic, rawURL)
+	}
+
+	subscriptions, err := webhookSvc.ListSubscriptions(ctx, owner.Username)
+	require.NoError(t, err)
+	require.Empty(t, subscriptions)
+}
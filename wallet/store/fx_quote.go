@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/nu7hatch/gouuid"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type FXQuoteRepo interface {
+	CreateFXQuote(ctx context.Context, arg CreateFXQuoteParams) (model.FXQuote, error)
+	GetFXQuote(ctx context.Context, id string) (model.FXQuote, error)
+	// UseFXQuote links an unused, unexpired quote to the transfer made with it
+	UseFXQuote(ctx context.Context, id string, transID int64, now time.Time) error
+	// WithTx returns a FXQuoteRepo bound to the given transaction
+	WithTx(tx *gorm.DB) FXQuoteRepo
+}
+
+type fxQuoteRepository struct {
+	db *gorm.DB
+}
+
+func NewFXQuoteRepo(client *gorm.DB) FXQuoteRepo {
+	return &fxQuoteRepository{
+		db: client,
+	}
+}
+
+func (q *fxQuoteRepository) WithTx(tx *gorm.DB) FXQuoteRepo {
+	return &fxQuoteRepository{
+		db: tx,
+	}
+}
+
+type CreateFXQuoteParams struct {
+	Username          string    `json:"username"`
+	FromWalletAddress string    `json:"from_wallet_address"`
+	ToWalletAddress   string    `json:"to_wallet_address"`
+	FromCurrency      string    `json:"from_currency"`
+	ToCurrency        string    `json:"to_currency"`
+	Rate              string    `json:"rate"`
+	FromAmount        int64     `json:"from_amount"`
+	ToAmount          int64     `json:"to_amount"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+func (q *fxQuoteRepository) CreateFXQuote(ctx context.Context, arg CreateFXQuoteParams) (model.FXQuote, error) {
+
+	logrus.Println("log  CreateFXQuote in store/fx_quote/CreateFXQuote ")
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return model.FXQuote{}, err
+	}
+
+	quote := model.FXQuote{
+		ID:            id.String(),
+		Username:      arg.Username,
+		FromWalletAdd: arg.FromWalletAddress,
+		ToWalletAdd:   arg.ToWalletAddress,
+		FromCurrency:  arg.FromCurrency,
+		ToCurrency:    arg.ToCurrency,
+		Rate:          arg.Rate,
+		FromAmount:    arg.FromAmount,
+		ToAmount:      arg.ToAmount,
+		ExpiresAt:     arg.ExpiresAt,
+		CreatedAt:     time.Now(),
+	}
+	res := q.db.Create(&quote)
+
+	return quote, res.Error
+}
+
+func (q *fxQuoteRepository) GetFXQuote(ctx context.Context, id string) (model.FXQuote, error) {
+
+	logrus.Println("log  GetFXQuote in store/fx_quote/GetFXQuote ")
+
+	var quote model.FXQuote
+	res := q.db.Where("id = ?", id).Take(&quote)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return quote, local_errors.ErrFXQuoteNotFound
+	}
+
+	return quote, res.Error
+}
+
+func (q *fxQuoteRepository) UseFXQuote(ctx context.Context, id string, transID int64, now time.Time) error {
+
+	logrus.Println("log  UseFXQuote in store/fx_quote/UseFXQuote ")
+
+	// the conditions make concurrent uses of one quote race for a single row update
+	res := q.db.Model(&model.FXQuote{}).
+		Where("id = ? AND trans_id = ? AND expires_at > ?", id, 0, now).
+		Update("trans_id", transID)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		quote, err := q.GetFXQuote(ctx, id)
+		if err != nil {
+			return err
+		}
+		if quote.IsUsed() {
+			return local_errors.ErrFXQuoteUsed
+		}
+		return local_errors.ErrFXQuoteExpired
+	}
+
+	return nil
+}
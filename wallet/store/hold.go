@@ -0,0 +1,207 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type HoldRepo interface {
+	// CreateHold reserves an amount of the available balance of a wallet
+	CreateHold(ctx context.Context, arg CreateHoldParams) (model.Hold, error)
+	GetHold(ctx context.Context, id int64) (model.Hold, error)
+	// VoidHold releases an active hold without moving money
+	VoidHold(ctx context.Context, id int64) (model.Hold, error)
+	// ReleaseExpiredHolds releases the active holds past their expiry and returns how many were released
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error)
+}
+
+type holdRepository struct {
+	db *gorm.DB
+}
+
+func NewHoldRepo(client *gorm.DB) HoldRepo {
+	return &holdRepository{
+		db: client,
+	}
+}
+
+type CreateHoldParams struct {
+	WalletAddress   string    `json:"wallet_address"`
+	ToWalletAddress string    `json:"to_wallet_address"`
+	Username        string    `json:"username"`
+	Amount          int64     `json:"amount"`
+	Description     string    `json:"description"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+func (q *holdRepository) CreateHold(ctx context.Context, arg CreateHoldParams) (model.Hold, error) {
+
+	logrus.Println("log  CreateHold in store/hold/CreateHold ")
+
+	var h model.Hold
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		wallets, err := lockWallets(tx, arg.WalletAddress)
+		if err != nil {
+			return err
+		}
+		w := wallets[arg.WalletAddress]
+
+		if w.Status != model.WalletStatusACTIVE {
+			return local_errors.ErrWalletInactive
+		}
+		if !w.IsBalanceSufficient(arg.Amount) {
+			return local_errors.ErrInsufficientBalance
+		}
+
+		now := time.Now()
+		h = model.Hold{
+			WalletAddress: arg.WalletAddress,
+			ToWalletAdd:   arg.ToWalletAddress,
+			Username:      arg.Username,
+			Amount:        arg.Amount,
+			Currency:      w.Currency,
+			Description:   arg.Description,
+			Status:        model.HoldStatusACTIVE,
+			ExpiresAt:     arg.ExpiresAt,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := tx.Create(&h).Error; err != nil {
+			return err
+		}
+
+		return addHeldBalance(tx, w, arg.Amount)
+	})
+
+	return h, err
+}
+
+func (q *holdRepository) GetHold(ctx context.Context, id int64) (model.Hold, error) {
+
+	logrus.Println("log  GetHold in store/hold/GetHold ")
+
+	var h model.Hold
+	res := q.db.Where("id = ?", id).Take(&h)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return h, local_errors.ErrHoldNotFound
+	}
+
+	return h, res.Error
+}
+
+func (q *holdRepository) VoidHold(ctx context.Context, id int64) (model.Hold, error) {
+
+	logrus.Println("log  VoidHold in store/hold/VoidHold ")
+
+	var h model.Hold
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		h, err = lockHold(tx, id)
+		if err != nil {
+			return err
+		}
+		if h.Status != model.HoldStatusACTIVE {
+			return local_errors.ErrHoldNotActive
+		}
+
+		h, err = releaseHold(tx, h, model.HoldStatusVOIDED)
+		return err
+	})
+
+	return h, err
+}
+
+func (q *holdRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error) {
+
+	logrus.Println("log  ReleaseExpiredHolds in store/hold/ReleaseExpiredHolds ")
+
+	var ids []int64
+	res := q.db.Model(&model.Hold{}).
+		Where("status = ? AND expires_at <= ?", model.HoldStatusACTIVE, now).
+		Pluck("id", &ids)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	// each hold is released on its own, a capture racing the sweeper wins or loses as a whole
+	var released int64
+	for _, id := range ids {
+		err := q.db.Transaction(func(tx *gorm.DB) error {
+
+			h, err := lockHold(tx, id)
+			if err != nil {
+				return err
+			}
+			if h.Status != model.HoldStatusACTIVE {
+				return nil
+			}
+
+			if _, err := releaseHold(tx, h, model.HoldStatusEXPIRED); err != nil {
+				return err
+			}
+			released++
+			return nil
+		})
+		if err != nil {
+			return released, err
+		}
+	}
+
+	return released, nil
+}
+
+// lockHold loads a hold with SELECT ... FOR UPDATE, the hold is locked before its wallets
+func lockHold(tx *gorm.DB, id int64) (model.Hold, error) {
+
+	var h model.Hold
+	res := forUpdate(tx).Where("id = ?", id).Take(&h)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return h, local_errors.ErrHoldNotFound
+	}
+
+	return h, res.Error
+}
+
+// releaseHold gives the held amount back to the available balance of the wallet and closes the hold with status
+func releaseHold(tx *gorm.DB, h model.Hold, status model.HoldStatus) (model.Hold, error) {
+
+	wallets, err := lockWallets(tx, h.WalletAddress)
+	if err != nil {
+		return h, err
+	}
+
+	if err := addHeldBalance(tx, wallets[h.WalletAddress], -h.Amount); err != nil {
+		return h, err
+	}
+
+	h.Status = status
+	h.UpdatedAt = time.Now()
+	res := tx.Model(&model.Hold{}).Where("id = ?", h.ID).Updates(map[string]interface{}{
+		"status":     h.Status,
+		"updated_at": h.UpdatedAt,
+	})
+
+	return h, res.Error
+}
+
+// addHeldBalance adds amount to the held funds of a wallet locked by the caller
+func addHeldBalance(tx *gorm.DB, w model.Wallet, amount int64) error {
+
+	res := tx.Model(&model.Wallet{}).Where("id = ?", w.ID).Updates(map[string]interface{}{
+		"held_balance": gorm.Expr("held_balance + ?", amount),
+		"updated_at":   time.Now(),
+	})
+
+	return res.Error
+}
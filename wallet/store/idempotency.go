@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type IdempotencyRepo interface {
+	// ReserveIdempotencyKey stores a new key, or returns the live one already stored with created false
+	ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (model.IdempotencyKey, bool, error)
+	// TakeOverIdempotencyKey extends the lease of a key that is still in progress and whose lease passed, false when another request got it first
+	TakeOverIdempotencyKey(ctx context.Context, id int64, lockedUntil time.Time) (bool, error)
+	// GetIdempotencyKeyEntry returns the journal entry posted by the request holding the key, false when it posted none
+	GetIdempotencyKeyEntry(ctx context.Context, id int64) (model.JournalEntry, bool, error)
+	SaveIdempotencyResponse(ctx context.Context, arg SaveIdempotencyResponseParams) error
+	DeleteIdempotencyKey(ctx context.Context, id int64) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context for processing the request holding the key, the journal entry posted with it is linked to the key
+func WithIdempotencyKey(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, id)
+}
+
+// idempotencyKeyOf returns the key set by WithIdempotencyKey, 0 without one
+func idempotencyKeyOf(ctx context.Context) int64 {
+	id, _ := ctx.Value(idempotencyKeyContextKey{}).(int64)
+	return id
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepo(client *gorm.DB) IdempotencyRepo {
+	return &idempotencyRepository{
+		db: client,
+	}
+}
+
+type ReserveIdempotencyKeyParams struct {
+	Username    string    `json:"username"`
+	Scope       string    `json:"scope"`
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (q *idempotencyRepository) ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (model.IdempotencyKey, bool, error) {
+
+	logrus.Println("log  ReserveIdempotencyKey in store/idempotency/ReserveIdempotencyKey ")
+
+	now := time.Now()
+
+	// an expired key can be used again
+	res := q.db.Where("username = ? AND scope = ? AND idempotency_key = ? AND expires_at <= ?", arg.Username, arg.Scope, arg.Key, now).
+		Delete(&model.IdempotencyKey{})
+	if res.Error != nil {
+		return model.IdempotencyKey{}, false, res.Error
+	}
+
+	k := model.IdempotencyKey{
+		Username:    arg.Username,
+		Scope:       arg.Scope,
+		Key:         arg.Key,
+		Fingerprint: arg.Fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   arg.ExpiresAt,
+		LockedUntil: arg.LockedUntil,
+	}
+	createErr := q.db.Create(&k).Error
+	if createErr == nil {
+		return k, true, nil
+	}
+
+	// the unique index rejected the insert, a concurrent or earlier request owns the key
+	var existing model.IdempotencyKey
+	res = q.db.Where("username = ? AND scope = ? AND idempotency_key = ?", arg.Username, arg.Scope, arg.Key).Take(&existing)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return existing, false, createErr
+	}
+	if res.Error != nil {
+		return existing, false, res.Error
+	}
+
+	return existing, false, nil
+}
+
+func (q *idempotencyRepository) TakeOverIdempotencyKey(ctx context.Context, id int64, lockedUntil time.Time) (bool, error) {
+
+	logrus.Println("log  TakeOverIdempotencyKey in store/idempotency/TakeOverIdempotencyKey ")
+
+	res := q.db.Model(&model.IdempotencyKey{}).
+		Where("id = ? AND status_code = 0 AND locked_until <= ?", id, time.Now()).
+		Update("locked_until", lockedUntil)
+
+	return res.RowsAffected == 1, res.Error
+}
+
+func (q *idempotencyRepository) GetIdempotencyKeyEntry(ctx context.Context, id int64) (model.JournalEntry, bool, error) {
+
+	logrus.Println("log  GetIdempotencyKeyEntry in store/idempotency/GetIdempotencyKeyEntry ")
+
+	var entry model.JournalEntry
+	res := q.db.Where("idempotency_key_id = ?", id).Take(&entry)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return entry, false, nil
+	}
+
+	return entry, res.Error == nil, res.Error
+}
+
+type SaveIdempotencyResponseParams struct {
+	ID           int64  `json:"id"`
+	StatusCode   int    `json:"status_code"`
+	ResponseBody string `json:"response_body"`
+}
+
+func (q *idempotencyRepository) SaveIdempotencyResponse(ctx context.Context, arg SaveIdempotencyResponseParams) error {
+
+	logrus.Println("log  SaveIdempotencyResponse in store/idempotency/SaveIdempotencyResponse ")
+
+	res := q.db.Model(&model.IdempotencyKey{}).Where("id = ?", arg.ID).Updates(map[string]interface{}{
+		"status_code":   arg.StatusCode,
+		"response_body": arg.ResponseBody,
+	})
+
+	return res.Error
+}
+
+func (q *idempotencyRepository) DeleteIdempotencyKey(ctx context.Context, id int64) error {
+
+	logrus.Println("log  DeleteIdempotencyKey in store/idempotency/DeleteIdempotencyKey ")
+
+	return q.db.Where("id = ?", id).Delete(&model.IdempotencyKey{}).Error
+}
+
+func (q *idempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+
+	logrus.Println("log  DeleteExpiredIdempotencyKeys in store/idempotency/DeleteExpiredIdempotencyKeys ")
+
+	res := q.db.Where("expires_at <= ?", now).Delete(&model.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// errLeaseTaken rolls back the transaction of a failed insert, the lease is not acquired
+var errLeaseTaken = errors.New("lease is taken")
+
+type LeaseRepo interface {
+	// AcquireLease takes or renews the named lease for holder, it returns false while another holder has it
+	AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease gives up the lease if holder has it
+	ReleaseLease(ctx context.Context, name string, holder string) error
+}
+
+type leaseRepository struct {
+	db *gorm.DB
+}
+
+func NewLeaseRepo(client *gorm.DB) LeaseRepo {
+	return &leaseRepository{
+		db: client,
+	}
+}
+
+func (q *leaseRepository) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+
+	logrus.Println("log  AcquireLease in store/lease/AcquireLease ")
+
+	acquired := false
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		now := time.Now()
+
+		var l model.Lease
+		res := forUpdate(tx).Where("name = ?", name).Take(&l)
+
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			l = model.Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl), UpdatedAt: now}
+			if err := tx.Create(&l).Error; err != nil {
+				// another instance created the lease first, it is its holder
+				logrus.Printf("lease %s was created by another instance: %v", name, err)
+				return errLeaseTaken
+			}
+			acquired = true
+			return nil
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if l.Holder != holder && now.Before(l.ExpiresAt) {
+			return nil
+		}
+
+		upd := tx.Model(&model.Lease{}).Where("name = ?", name).Updates(map[string]interface{}{
+			"holder":     holder,
+			"expires_at": now.Add(ttl),
+			"updated_at": now,
+		})
+		if upd.Error != nil {
+			return upd.Error
+		}
+		acquired = true
+		return nil
+	})
+
+	if err == errLeaseTaken {
+		return false, nil
+	}
+	return acquired, err
+}
+
+func (q *leaseRepository) ReleaseLease(ctx context.Context, name string, holder string) error {
+
+	logrus.Println("log  ReleaseLease in store/lease/ReleaseLease ")
+
+	res := q.db.Model(&model.Lease{}).
+		Where("name = ? AND holder = ?", name, holder).
+		Update("expires_at", time.Now())
+
+	return res.Error
+}
@@ -0,0 +1,379 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type LedgerRepo interface {
+	// PostEntry records a balanced journal entry and applies its postings to the wallet balances
+	PostEntry(ctx context.Context, arg PostEntryParams) (PostEntryResult, error)
+	// GetPostingsBalance sums all postings of a wallet
+	GetPostingsBalance(ctx context.Context, address string) (int64, error)
+	// RebuildWalletBalance recomputes the cached wallet balance from its postings
+	RebuildWalletBalance(ctx context.Context, address string) (model.Wallet, error)
+	// GetBalanceTotals sums the balances of all wallets per currency, every total is zero on a consistent ledger
+	GetBalanceTotals(ctx context.Context) (map[string]int64, error)
+	// ListBalanceMismatches returns the wallets whose cached balance is not the sum of their postings
+	ListBalanceMismatches(ctx context.Context) ([]BalanceMismatch, error)
+	// OpenWalletBalance books the cached balance of a wallet without postings, one that predates the ledger,
+	// as an opening entry against the float of its currency
+	OpenWalletBalance(ctx context.Context, address string) (PostEntryResult, error)
+	// WithTx returns a LedgerRepo bound to the given transaction
+	WithTx(tx *gorm.DB) LedgerRepo
+}
+
+type ledgerRepository struct {
+	db   *gorm.DB
+	inTx bool
+}
+
+func NewLedgerRepo(client *gorm.DB) LedgerRepo {
+	return &ledgerRepository{
+		db: client,
+	}
+}
+
+func (q *ledgerRepository) WithTx(tx *gorm.DB) LedgerRepo {
+	return &ledgerRepository{
+		db:   tx,
+		inTx: true,
+	}
+}
+
+// transaction runs fn in the bound transaction, or in a new one
+func (q *ledgerRepository) transaction(fn func(tx *gorm.DB) error) error {
+	if q.inTx {
+		return fn(q.db)
+	}
+	return q.db.Transaction(fn)
+}
+
+type PostingParams struct {
+	WalletAddress string `json:"wallet_address"`
+	// Currency defaults to the wallet currency
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+type PostEntryParams struct {
+	Kind        model.JournalEntryKind `json:"kind"`
+	TransID     int64                  `json:"trans_id"`
+	Description string                 `json:"description"`
+	Postings    []PostingParams        `json:"postings"`
+	// AllowInactive posts to wallets that are not ACTIVE, a reversal must undo the transfers of frozen wallets
+	AllowInactive bool `json:"allow_inactive"`
+}
+
+type PostEntryResult struct {
+	Entry    model.JournalEntry      `json:"entry"`
+	Postings []model.Posting         `json:"postings"`
+	Wallets  map[string]model.Wallet `json:"wallets"`
+}
+
+func (q *ledgerRepository) PostEntry(ctx context.Context, arg PostEntryParams) (PostEntryResult, error) {
+
+	logrus.Println("log  PostEntry in store/ledger/PostEntry ")
+
+	var res PostEntryResult
+
+	if len(arg.Postings) < 2 {
+		return res, fmt.Errorf("journal entry needs at least two postings")
+	}
+
+	err := q.transaction(func(tx *gorm.DB) error {
+
+		var addresses []string
+		for _, p := range arg.Postings {
+			addresses = append(addresses, p.WalletAddress)
+		}
+
+		wallets, err := lockWallets(tx, addresses...)
+		if err != nil {
+			return err
+		}
+
+		postings, err := resolvePostings(arg.Postings, wallets, arg.AllowInactive)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		entry := model.JournalEntry{
+			Kind:             arg.Kind,
+			TransID:          arg.TransID,
+			Description:      arg.Description,
+			IdempotencyKeyID: idempotencyKeyOf(ctx),
+			CreatedAt:        now,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		for i := range postings {
+			postings[i].JournalEntryID = entry.ID
+			postings[i].CreatedAt = now
+			if err := tx.Create(&postings[i]).Error; err != nil {
+				return err
+			}
+
+			w, err := addWalletBalance(tx, wallets[postings[i].WalletAddress], postings[i].Amount)
+			if err != nil {
+				return err
+			}
+			wallets[w.WalletAddress] = w
+		}
+
+		res = PostEntryResult{
+			Entry:    entry,
+			Postings: postings,
+			Wallets:  wallets,
+		}
+		return nil
+	})
+
+	return res, err
+}
+
+// resolvePostings validates the postings against the locked wallets and checks that they balance,
+// the wallets must be ACTIVE unless allowInactive is set
+func resolvePostings(params []PostingParams, wallets map[string]model.Wallet, allowInactive bool) ([]model.Posting, error) {
+
+	postings := make([]model.Posting, 0, len(params))
+	sums := make(map[string]int64)
+	// the net effect of the entry on each wallet, a wallet may appear in several postings
+	net := make(map[string]int64)
+
+	for _, p := range params {
+		if p.Amount == 0 {
+			return nil, fmt.Errorf("posting amount can not be zero")
+		}
+
+		w, ok := wallets[p.WalletAddress]
+		if !ok {
+			return nil, local_errors.ErrWalletNotFound
+		}
+		if w.Status != model.WalletStatusACTIVE && !allowInactive {
+			return nil, local_errors.ErrWalletInactive
+		}
+
+		currency := p.Currency
+		if currency == "" {
+			currency = w.Currency
+		}
+		if currency != w.Currency {
+			return nil, local_errors.ErrCurrencyMismatch
+		}
+		net[p.WalletAddress] += p.Amount
+
+		sums[currency] += p.Amount
+		postings = append(postings, model.Posting{
+			WalletAddress: p.WalletAddress,
+			Currency:      currency,
+			Amount:        p.Amount,
+		})
+	}
+
+	for currency, sum := range sums {
+		if sum != 0 {
+			return nil, fmt.Errorf("journal entry is not balanced for %s: %d", currency, sum)
+		}
+	}
+
+	for address, amount := range net {
+		w := wallets[address]
+		if amount < 0 && !w.IsBalanceSufficient(-amount) {
+			return nil, local_errors.ErrInsufficientBalance
+		}
+	}
+
+	return postings, nil
+}
+
+func (q *ledgerRepository) GetPostingsBalance(ctx context.Context, address string) (int64, error) {
+
+	logrus.Println("log  GetPostingsBalance in store/ledger/GetPostingsBalance ")
+
+	var row struct {
+		Balance int64
+	}
+	res := q.db.Model(&model.Posting{}).
+		Select("COALESCE(SUM(amount), 0) AS balance").
+		Where("wallet_address = ?", address).
+		Scan(&row)
+
+	return row.Balance, res.Error
+}
+
+func (q *ledgerRepository) GetBalanceTotals(ctx context.Context) (map[string]int64, error) {
+
+	logrus.Println("log  GetBalanceTotals in store/ledger/GetBalanceTotals ")
+
+	var rows []struct {
+		Currency string
+		Total    int64
+	}
+	res := q.db.Model(&model.Wallet{}).
+		Select("currency, COALESCE(SUM(balance), 0) AS total").
+		Group("currency").
+		Scan(&rows)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	totals := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		totals[row.Currency] = row.Total
+	}
+	return totals, nil
+}
+
+func (q *ledgerRepository) RebuildWalletBalance(ctx context.Context, address string) (model.Wallet, error) {
+
+	logrus.Println("log  RebuildWalletBalance in store/ledger/RebuildWalletBalance ")
+
+	var w model.Wallet
+
+	err := q.transaction(func(tx *gorm.DB) error {
+
+		wallets, err := lockWallets(tx, address)
+		if err != nil {
+			return err
+		}
+		w = wallets[address]
+
+		balance, err := q.WithTx(tx).GetPostingsBalance(ctx, address)
+		if err != nil {
+			return err
+		}
+
+		if balance != w.Balance {
+			logrus.Printf("rebuilding balance of wallet %s: cached %d, postings %d", address, w.Balance, balance)
+		}
+
+		w, err = addWalletBalance(tx, w, balance-w.Balance)
+		return err
+	})
+
+	return w, err
+}
+
+type BalanceMismatch struct {
+	WalletAddress   string                          `json:"wallet_address"`
+	Currency        string                          `json:"currency"`
+	Purpose         model.OrganizationWalletPurpose `json:"purpose,omitempty"`
+	Balance         int64                           `json:"balance"`
+	PostingsBalance int64                           `json:"postings_balance"`
+	// PostingsCount is zero for the wallets that predate the ledger
+	PostingsCount int64 `json:"postings_count"`
+}
+
+func (q *ledgerRepository) ListBalanceMismatches(ctx context.Context) ([]BalanceMismatch, error) {
+
+	logrus.Println("log  ListBalanceMismatches in store/ledger/ListBalanceMismatches ")
+
+	var rows []BalanceMismatch
+	res := q.db.Table("wallets").
+		Select("wallets.wallet_address, wallets.currency, wallets.purpose, wallets.balance, " +
+			"COALESCE(SUM(postings.amount), 0) AS postings_balance, COUNT(postings.id) AS postings_count").
+		Joins("LEFT JOIN postings ON postings.wallet_address = wallets.wallet_address").
+		Group("wallets.wallet_address, wallets.currency, wallets.purpose, wallets.balance").
+		Having("wallets.balance <> COALESCE(SUM(postings.amount), 0)").
+		Order("wallets.wallet_address").
+		Scan(&rows)
+
+	return rows, res.Error
+}
+
+func (q *ledgerRepository) OpenWalletBalance(ctx context.Context, address string) (PostEntryResult, error) {
+
+	logrus.Println("log  OpenWalletBalance in store/ledger/OpenWalletBalance ")
+
+	var res PostEntryResult
+
+	err := q.transaction(func(tx *gorm.DB) error {
+
+		var w model.Wallet
+		if err := tx.Where("wallet_address = ?", address).Take(&w).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return local_errors.ErrWalletNotFound
+			}
+			return err
+		}
+		if w.IsOrganization() && w.Purpose == model.OrganizationWalletFLOAT {
+			return fmt.Errorf("the float of %s takes the other side of the opening entries, it has none of its own", w.Currency)
+		}
+
+		float, err := organizationWallet(tx, w.Currency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+		wallets, err := lockWallets(tx, address, float.WalletAddress)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.Posting{}).Where("wallet_address = ?", address).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return local_errors.ErrWalletHasPostings
+		}
+
+		w = wallets[address]
+		res.Wallets = wallets
+		if w.Balance == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		res.Entry = model.JournalEntry{
+			Kind:        model.JournalEntryKindOPENING,
+			Description: fmt.Sprintf("opening balance of wallet %s", address),
+			CreatedAt:   now,
+		}
+		if err := tx.Create(&res.Entry).Error; err != nil {
+			return err
+		}
+
+		res.Postings = []model.Posting{
+			{JournalEntryID: res.Entry.ID, WalletAddress: address, Currency: w.Currency, Amount: w.Balance, CreatedAt: now},
+			{JournalEntryID: res.Entry.ID, WalletAddress: float.WalletAddress, Currency: w.Currency, Amount: -w.Balance, CreatedAt: now},
+		}
+		for i := range res.Postings {
+			if err := tx.Create(&res.Postings[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		// the wallet already holds its balance, only the float gives it out
+		res.Wallets[float.WalletAddress], err = addWalletBalance(tx, wallets[float.WalletAddress], -w.Balance)
+		return err
+	})
+
+	return res, err
+}
+
+// addWalletBalance atomically adds amount to the balance of a wallet locked by the caller
+func addWalletBalance(tx *gorm.DB, w model.Wallet, amount int64) (model.Wallet, error) {
+
+	now := time.Now()
+	res := tx.Model(&model.Wallet{}).Where("id = ?", w.ID).Updates(map[string]interface{}{
+		"balance":    gorm.Expr("balance + ?", amount),
+		"updated_at": now,
+	})
+	if res.Error != nil {
+		return w, res.Error
+	}
+
+	w.Balance += amount
+	w.UpdatedAt = now
+	return w, nil
+}
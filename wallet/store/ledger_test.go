@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolvePostings(t *testing.T) {
+	wallets := map[string]model.Wallet{
+		"a": {WalletAddress: "a", Status: model.WalletStatusACTIVE, Currency: "INR", Balance: 100},
+		"b": {WalletAddress: "b", Status: model.WalletStatusACTIVE, Currency: "INR"},
+		"c": {WalletAddress: "c", Status: model.WalletStatusINACTIVE, Currency: "INR"},
+		"d": {WalletAddress: "d", Status: model.WalletStatusACTIVE, Currency: "USD"},
+		"float": {
+			WalletAddress: "float", Status: model.WalletStatusACTIVE, Currency: "INR",
+			Kind: model.WalletKindORGANIZATION, Purpose: model.OrganizationWalletFLOAT,
+		},
+		"fees": {
+			WalletAddress: "fees", Status: model.WalletStatusACTIVE, Currency: "INR",
+			Kind: model.WalletKindORGANIZATION, Purpose: model.OrganizationWalletFEEINCOME,
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		postings []PostingParams
+		checkErr func(t *testing.T, err error)
+	}{
+		{
+			name:     "balanced transfer",
+			postings: []PostingParams{{WalletAddress: "a", Amount: -100}, {WalletAddress: "b", Amount: 100}},
+			checkErr: func(t *testing.T, err error) { require.NoError(t, err) },
+		},
+		{
+			name:     "credit from the float",
+			postings: []PostingParams{{WalletAddress: "float", Amount: -50}, {WalletAddress: "b", Amount: 50}},
+			checkErr: func(t *testing.T, err error) { require.NoError(t, err) },
+		},
+		{
+			name:     "fee income can not go negative",
+			postings: []PostingParams{{WalletAddress: "fees", Amount: -50}, {WalletAddress: "b", Amount: 50}},
+			checkErr: func(t *testing.T, err error) { require.ErrorIs(t, err, local_errors.ErrInsufficientBalance) },
+		},
+		{
+			name:     "unbalanced",
+			postings: []PostingParams{{WalletAddress: "a", Amount: -100}, {WalletAddress: "b", Amount: 90}},
+			checkErr: func(t *testing.T, err error) { require.Error(t, err) },
+		},
+		{
+			name:     "insufficient balance",
+			postings: []PostingParams{{WalletAddress: "a", Amount: -101}, {WalletAddress: "b", Amount: 101}},
+			checkErr: func(t *testing.T, err error) { require.ErrorIs(t, err, local_errors.ErrInsufficientBalance) },
+		},
+		{
+			name:     "inactive wallet",
+			postings: []PostingParams{{WalletAddress: "a", Amount: -10}, {WalletAddress: "c", Amount: 10}},
+			checkErr: func(t *testing.T, err error) { require.ErrorIs(t, err, local_errors.ErrWalletInactive) },
+		},
+		{
+			name:     "currency mismatch",
+			postings: []PostingParams{{WalletAddress: "a", Amount: -10}, {WalletAddress: "d", Currency: "INR", Amount: 10}},
+			checkErr: func(t *testing.T, err error) { require.ErrorIs(t, err, local_errors.ErrCurrencyMismatch) },
+		},
+		{
+			name:     "unknown wallet",
+			postings: []PostingParams{{WalletAddress: "a", Amount: -10}, {WalletAddress: "x", Amount: 10}},
+			checkErr: func(t *testing.T, err error) { require.ErrorIs(t, err, local_errors.ErrWalletNotFound) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := resolvePostings(tc.postings, wallets, false)
+			tc.checkErr(t, err)
+		})
+	}
+}
+
+// mismatchOf returns the mismatch of the wallet, mismatches of wallets of other tests on a shared database are ignored
+func mismatchOf(t *testing.T, repos testRepos, address string) (BalanceMismatch, bool) {
+	mismatches, err := repos.ledger.ListBalanceMismatches(context.Background())
+	require.NoError(t, err)
+	for _, m := range mismatches {
+		if m.WalletAddress == address {
+			return m, true
+		}
+	}
+	return BalanceMismatch{}, false
+}
+
+func TestOpenWalletBalance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		ledgerRepo := repos.ledger
+
+		float, err := repos.wallet.GetOrganizationWallet(ctx, OrganizationWalletParams{Currency: "INR", Purpose: model.OrganizationWalletFLOAT})
+		require.NoError(t, err)
+
+		// a wallet from before the ledger has a balance and no postings
+		w := createTestWallet(t, repos, "INR", 0)
+		repos.setBalance(t, w.WalletAddress, 500)
+
+		m, ok := mismatchOf(t, repos, w.WalletAddress)
+		require.True(t, ok)
+		require.Equal(t, BalanceMismatch{WalletAddress: w.WalletAddress, Currency: "INR", Balance: 500}, m)
+
+		res, err := ledgerRepo.OpenWalletBalance(ctx, w.WalletAddress)
+		require.NoError(t, err)
+		require.Equal(t, model.JournalEntryKindOPENING, res.Entry.Kind)
+		require.Len(t, res.Postings, 2)
+		require.Equal(t, int64(500), walletOf(t, repos, w.WalletAddress).Balance)
+		require.Equal(t, float.Balance-500, walletOf(t, repos, float.WalletAddress).Balance)
+
+		_, ok = mismatchOf(t, repos, w.WalletAddress)
+		require.False(t, ok)
+		requireConsistentLedger(t, repos, w, float)
+
+		_, err = ledgerRepo.OpenWalletBalance(ctx, w.WalletAddress)
+		require.ErrorIs(t, err, local_errors.ErrWalletHasPostings)
+
+		// a drifted cache is rebuilt from the postings instead
+		repos.setBalance(t, w.WalletAddress, 600)
+		m, ok = mismatchOf(t, repos, w.WalletAddress)
+		require.True(t, ok)
+		require.Equal(t, int64(500), m.PostingsBalance)
+		require.Equal(t, int64(1), m.PostingsCount)
+
+		rebuilt, err := ledgerRepo.RebuildWalletBalance(ctx, w.WalletAddress)
+		require.NoError(t, err)
+		require.Equal(t, int64(500), rebuilt.Balance)
+		requireConsistentLedger(t, repos, w, float)
+
+		// an empty wallet has nothing to open
+		empty := createTestWallet(t, repos, "INR", 0)
+		res, err = ledgerRepo.OpenWalletBalance(ctx, empty.WalletAddress)
+		require.NoError(t, err)
+		require.Zero(t, res.Entry.ID)
+
+		_, err = ledgerRepo.OpenWalletBalance(ctx, float.WalletAddress)
+		require.Error(t, err)
+		_, err = ledgerRepo.OpenWalletBalance(ctx, "nowhere")
+		require.ErrorIs(t, err, local_errors.ErrWalletNotFound)
+	})
+}
@@ -0,0 +1,13 @@
+package store
+
+import "github.com/jinzhu/gorm"
+
+// forUpdate makes the queries of tx lock the rows they read until the transaction ends, with
+// SELECT ... FOR UPDATE. SQLite has no row locks, nor FOR UPDATE: its write transactions take the
+// database lock when they begin (database.Open sets _txlock=immediate), they already run one at a time.
+func forUpdate(tx *gorm.DB) *gorm.DB {
+	if tx.Dialect().GetName() == "sqlite3" {
+		return tx
+	}
+	return tx.Set("gorm:query_option", "FOR UPDATE")
+}
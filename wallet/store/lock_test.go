@@ -0,0 +1,29 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForUpdate(t *testing.T) {
+	db := openTestDB(t)
+
+	// the SQL of the other dialects is never run, their gorm.DB only wraps the connection of db
+	for _, dialect := range []string{"mysql", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			other, err := gorm.Open(dialect, db.DB())
+			require.NoError(t, err)
+
+			option, ok := forUpdate(other).Get("gorm:query_option")
+			require.True(t, ok)
+			require.Equal(t, "FOR UPDATE", option)
+		})
+	}
+
+	if db.Dialect().GetName() == "sqlite3" {
+		_, ok := forUpdate(db).Get("gorm:query_option")
+		require.False(t, ok)
+	}
+}
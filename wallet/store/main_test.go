@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/database/databasetest"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/require"
+)
+
+// testRepos are the repositories of one backend
+type testRepos struct {
+	user           UserRepo
+	session        SessionRepo
+	wallet         WalletRepo
+	trans          TransRepo
+	ledger         LedgerRepo
+	fxQuote        FXQuoteRepo
+	hold           HoldRepo
+	paymentRequest PaymentRequestRepo
+	outbox         OutboxRepo
+	// setBalance overwrites the cached balance of a wallet behind the ledger's back, like the wallets
+	// that predate it
+	setBalance func(t *testing.T, address string, balance int64)
+}
+
+// testBackends are the implementations of the repositories, every repository test runs on each of them
+// so that they keep behaving alike
+var testBackends = []struct {
+	name string
+	open func(t *testing.T) testRepos
+}{
+	{"sql", func(t *testing.T) testRepos { return newSQLTestRepos(openTestDB(t)) }},
+	{"memory", func(t *testing.T) testRepos { return newMemoryTestRepos(NewMemoryStore()) }},
+}
+
+// forEachBackend runs test on the repositories of every backend, with the organization wallets of INR and USD
+func forEachBackend(t *testing.T, test func(t *testing.T, repos testRepos)) {
+	for _, backend := range testBackends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			repos := backend.open(t)
+
+			for _, code := range []string{"INR", "USD"} {
+				for _, purpose := range model.OrganizationWalletPurposes {
+					_, err := repos.wallet.EnsureOrganizationWallet(context.Background(), OrganizationWalletParams{Currency: code, Purpose: purpose})
+					require.NoError(t, err)
+				}
+			}
+
+			test(t, repos)
+		})
+	}
+}
+
+// openTestDB opens a migrated database, see databasetest.Open
+func openTestDB(t *testing.T) *gorm.DB {
+	return databasetest.Open(t)
+}
+
+func newSQLTestRepos(db *gorm.DB) testRepos {
+	transRepo := NewTransRepo(db)
+	ledgerRepo := NewLedgerRepo(db)
+	fxQuoteRepo := NewFXQuoteRepo(db)
+
+	return testRepos{
+		user:           NewUserRepo(db),
+		session:        NewSessionRepo(db),
+		wallet:         NewWalletRepo(db, transRepo, ledgerRepo, fxQuoteRepo),
+		trans:          transRepo,
+		ledger:         ledgerRepo,
+		fxQuote:        fxQuoteRepo,
+		hold:           NewHoldRepo(db),
+		paymentRequest: NewPaymentRequestRepo(db),
+		outbox:         NewOutboxRepo(db),
+		setBalance: func(t *testing.T, address string, balance int64) {
+			require.NoError(t, db.Model(&model.Wallet{}).Where("wallet_address = ?", address).Update("balance", balance).Error)
+		},
+	}
+}
+
+func newMemoryTestRepos(m *MemoryStore) testRepos {
+	return testRepos{
+		user:           NewMemoryUserRepo(m),
+		session:        NewMemorySessionRepo(m),
+		wallet:         NewMemoryWalletRepo(m),
+		trans:          NewMemoryTransRepo(m),
+		ledger:         NewMemoryLedgerRepo(m),
+		fxQuote:        NewMemoryFXQuoteRepo(m),
+		hold:           NewMemoryHoldRepo(m),
+		paymentRequest: NewMemoryPaymentRequestRepo(m),
+		outbox:         NewMemoryOutboxRepo(m),
+		setBalance: func(t *testing.T, address string, balance int64) {
+			require.NoError(t, m.update(func(tx *memoryTx) error {
+				w := tx.wallets[address]
+				w.Balance = balance
+				tx.put(tx.wallets, address, w)
+				return nil
+			}))
+		},
+	}
+}
+
+var testUserSeq int64
+
+func createTestUser(t *testing.T, repos testRepos) model.User {
+	username := fmt.Sprintf("user%d_%d", time.Now().UnixNano(), atomic.AddInt64(&testUserSeq, 1))
+	user, err := repos.user.CreateUser(context.Background(), CreateUserParams{
+		Username:       username,
+		HashedPassword: "secret",
+		Status:         model.UserStatusACTIVE,
+		Email:          username + "@example.com",
+	})
+	require.NoError(t, err)
+	return user
+}
+
+// createTestWallet creates a wallet for a new user, credited with balance from the float
+func createTestWallet(t *testing.T, repos testRepos, currency string, balance int64) model.Wallet {
+	ctx := context.Background()
+
+	wallet, err := repos.wallet.CreateWallet(ctx, CreateWalletParams{Username: createTestUser(t, repos).Username, Currency: currency})
+	require.NoError(t, err)
+
+	if balance > 0 {
+		wallet, err = repos.wallet.AddWalletBalance(ctx, AddWalletBalanceParams{WalletAddress: wallet.WalletAddress, Amount: balance})
+		require.NoError(t, err)
+	}
+	return wallet
+}
+
+// walletOf reads the wallet again
+func walletOf(t *testing.T, repos testRepos, address string) model.Wallet {
+	w, err := repos.wallet.GetWalletByAddress(context.Background(), address)
+	require.NoError(t, err)
+	return w
+}
+
+// requireConsistentLedger checks that the cached balances of the wallets match their postings and that
+// every currency adds up to zero
+func requireConsistentLedger(t *testing.T, repos testRepos, wallets ...model.Wallet) {
+	ctx := context.Background()
+
+	for _, w := range wallets {
+		balance, err := repos.ledger.GetPostingsBalance(ctx, w.WalletAddress)
+		require.NoError(t, err)
+		require.Equal(t, walletOf(t, repos, w.WalletAddress).Balance, balance, w.WalletAddress)
+	}
+
+	totals, err := repos.ledger.GetBalanceTotals(ctx)
+	require.NoError(t, err)
+	for code, total := range totals {
+		require.Zero(t, total, code)
+	}
+}
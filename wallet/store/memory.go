@@ -0,0 +1,375 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/nu7hatch/gouuid"
+)
+
+// MemoryStore keeps the data of the memory repositories, for the tests and the demo mode that run
+// without a database. Nothing is persisted. One lock guards all of it: a change runs alone and is
+// undone when it fails, so SendMoney and the other changes of several rows are applied whole or not at all.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	users           map[string]model.User
+	sessions        map[string]model.Session
+	wallets         map[string]model.Wallet
+	trans           map[int64]model.Trans
+	entries         map[int64]model.JournalEntry
+	postings        map[int64]model.Posting
+	fxQuotes        map[string]model.FXQuote
+	holds           map[int64]model.Hold
+	idempotencyKeys map[int64]model.IdempotencyKey
+	paymentRequests map[int64]model.PaymentRequest
+	schedules       map[int64]model.ScheduledPayment
+	scheduleRuns    map[int64]model.ScheduledPaymentRun
+	leases          map[string]model.Lease
+	events          map[int64]model.OutboxEvent
+	subscriptions   map[int64]model.WebhookSubscription
+	deliveries      map[int64]model.WebhookDelivery
+
+	// lastIDs are the last ids given out per table, like auto increments they are not reused after a rollback
+	lastIDs map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:           map[string]model.User{},
+		sessions:        map[string]model.Session{},
+		wallets:         map[string]model.Wallet{},
+		trans:           map[int64]model.Trans{},
+		entries:         map[int64]model.JournalEntry{},
+		postings:        map[int64]model.Posting{},
+		fxQuotes:        map[string]model.FXQuote{},
+		holds:           map[int64]model.Hold{},
+		idempotencyKeys: map[int64]model.IdempotencyKey{},
+		paymentRequests: map[int64]model.PaymentRequest{},
+		schedules:       map[int64]model.ScheduledPayment{},
+		scheduleRuns:    map[int64]model.ScheduledPaymentRun{},
+		leases:          map[string]model.Lease{},
+		events:          map[int64]model.OutboxEvent{},
+		subscriptions:   map[int64]model.WebhookSubscription{},
+		deliveries:      map[int64]model.WebhookDelivery{},
+		lastIDs:         map[string]int64{},
+	}
+}
+
+// memoryTx is a change of the store, it records how to undo what it did
+type memoryTx struct {
+	*MemoryStore
+	undo []func()
+}
+
+// view runs fn with the store locked for reading
+func (m *MemoryStore) view(fn func()) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fn()
+}
+
+// update runs fn with the store locked for writing, the changes of fn are undone if it returns an error
+func (m *MemoryStore) update(fn func(tx *memoryTx) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tx := &memoryTx{MemoryStore: m}
+	err := fn(tx)
+	if err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+	}
+	return err
+}
+
+// nextID gives out the next id of a table
+func (m *MemoryStore) nextID(table string) int64 {
+	m.lastIDs[table]++
+	return m.lastIDs[table]
+}
+
+// put sets table[key] to value, table is one of the maps of the store
+func (tx *memoryTx) put(table interface{}, key interface{}, value interface{}) {
+	t, k := reflect.ValueOf(table), reflect.ValueOf(key)
+
+	old := t.MapIndex(k)
+	tx.undo = append(tx.undo, func() {
+		// the zero Value deletes the key
+		t.SetMapIndex(k, old)
+	})
+
+	t.SetMapIndex(k, reflect.ValueOf(value))
+}
+
+// remove deletes table[key], table is one of the maps of the store
+func (tx *memoryTx) remove(table interface{}, key interface{}) {
+	t, k := reflect.ValueOf(table), reflect.ValueOf(key)
+
+	old := t.MapIndex(k)
+	if !old.IsValid() {
+		return
+	}
+	tx.undo = append(tx.undo, func() {
+		t.SetMapIndex(k, old)
+	})
+
+	t.SetMapIndex(k, reflect.Value{})
+}
+
+// limitRows keeps the first limit of n rows, a negative limit keeps them all like in gorm
+func limitRows(n int, limit int) int {
+	if limit >= 0 && limit < n {
+		return limit
+	}
+	return n
+}
+
+func (m *MemoryStore) walletByID(id int64) (model.Wallet, bool) {
+	for _, w := range m.wallets {
+		if w.ID == id {
+			return w, true
+		}
+	}
+	return model.Wallet{}, false
+}
+
+func (m *MemoryStore) organizationWallet(currency string, purpose model.OrganizationWalletPurpose) (model.Wallet, error) {
+	for _, w := range m.wallets {
+		if w.Kind == model.WalletKindORGANIZATION && w.Currency == currency && w.Purpose == purpose {
+			return w, nil
+		}
+	}
+	return model.Wallet{}, local_errors.ErrOrganizationWalletNotFound
+}
+
+// walletsByAddress is lockWallets of the memory store, the store lock already keeps the wallets
+func (m *MemoryStore) walletsByAddress(addresses ...string) (map[string]model.Wallet, error) {
+	wallets := make(map[string]model.Wallet, len(addresses))
+	for _, address := range addresses {
+		w, ok := m.wallets[address]
+		if !ok {
+			return nil, local_errors.ErrWalletNotFound
+		}
+		wallets[address] = w
+	}
+	return wallets, nil
+}
+
+func (tx *memoryTx) createWallet(w model.Wallet) model.Wallet {
+	w.ID = tx.nextID("wallets")
+	if w.UpdatedAt.IsZero() {
+		w.UpdatedAt = w.CreatedAt
+	}
+	tx.put(tx.wallets, w.WalletAddress, w)
+	return w
+}
+
+// newWalletAddress returns a wallet address that is not taken
+func newWalletAddress() (string, error) {
+	wa, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return wa.String(), nil
+}
+
+func (tx *memoryTx) createTransfer(arg SendMoneyParams) model.Trans {
+	kind := arg.Kind
+	if kind == "" {
+		kind = model.TransKindTRANSFER
+	}
+
+	t := model.Trans{
+		ID:              tx.nextID("trans"),
+		FromWalletAdd:   arg.FromWalletAddress,
+		ToWalletAdd:     arg.ToWalletAddress,
+		Amount:          arg.Amount,
+		Fee:             arg.Fee,
+		NetAmount:       arg.Amount - arg.Fee,
+		Currency:        arg.Currency,
+		Rate:            arg.Rate,
+		ToAmount:        arg.ToAmount,
+		ToCurrency:      arg.ToCurrency,
+		Kind:            kind,
+		OriginalTransID: arg.OriginalTransID,
+		CreatedAt:       time.Now(),
+	}
+	tx.put(tx.trans, t.ID, t)
+	return t
+}
+
+// postEntry is PostEntry of the memory store
+func (tx *memoryTx) postEntry(ctx context.Context, arg PostEntryParams) (PostEntryResult, error) {
+
+	var res PostEntryResult
+
+	if len(arg.Postings) < 2 {
+		return res, fmt.Errorf("journal entry needs at least two postings")
+	}
+
+	addresses := make([]string, 0, len(arg.Postings))
+	for _, p := range arg.Postings {
+		addresses = append(addresses, p.WalletAddress)
+	}
+	wallets, err := tx.walletsByAddress(addresses...)
+	if err != nil {
+		return res, err
+	}
+
+	postings, err := resolvePostings(arg.Postings, wallets, arg.AllowInactive)
+	if err != nil {
+		return res, err
+	}
+
+	now := time.Now()
+	entry := model.JournalEntry{
+		ID:               tx.nextID("journal_entries"),
+		Kind:             arg.Kind,
+		TransID:          arg.TransID,
+		Description:      arg.Description,
+		IdempotencyKeyID: idempotencyKeyOf(ctx),
+		CreatedAt:        now,
+	}
+	tx.put(tx.entries, entry.ID, entry)
+
+	for i := range postings {
+		postings[i].ID = tx.nextID("postings")
+		postings[i].JournalEntryID = entry.ID
+		postings[i].CreatedAt = now
+		tx.put(tx.postings, postings[i].ID, postings[i])
+
+		w := wallets[postings[i].WalletAddress]
+		w.Balance += postings[i].Amount
+		w.UpdatedAt = now
+		tx.put(tx.wallets, w.WalletAddress, w)
+		wallets[w.WalletAddress] = w
+	}
+
+	return PostEntryResult{
+		Entry:    entry,
+		Postings: postings,
+		Wallets:  wallets,
+	}, nil
+}
+
+// recordTransferUsage is recordTransferUsage of the memory store
+func (tx *memoryTx) recordTransferUsage(from string, amount int64, limit model.TransferLimit, postings []PostingParams) error {
+
+	addresses := make([]string, 0, len(postings))
+	for _, p := range postings {
+		addresses = append(addresses, p.WalletAddress)
+	}
+	wallets, err := tx.walletsByAddress(addresses...)
+	if err != nil {
+		return err
+	}
+
+	w := wallets[from]
+	now := time.Now()
+
+	daily, monthly := w.TransferUsage(now)
+	if err := limit.Check(amount, daily, monthly); err != nil {
+		return err
+	}
+
+	w.AddTransferUsage(now, amount)
+	tx.put(tx.wallets, w.WalletAddress, w)
+	return nil
+}
+
+// emit adds an event to the outbox, it is undone with the change that made it
+func (tx *memoryTx) emit(event model.OutboxEvent, err error) error {
+	if err != nil {
+		return err
+	}
+	event.ID = tx.nextID("outbox_events")
+	tx.put(tx.events, event.ID, event)
+	return nil
+}
+
+// sendMoney is sendMoney of the memory store
+func (tx *memoryTx) sendMoney(ctx context.Context, arg SendMoneyParams) (WalletTransferResult, error) {
+
+	var res WalletTransferResult
+
+	if arg.Fee < 0 || arg.Fee >= arg.Amount {
+		return res, fmt.Errorf("fee must be less than the amount")
+	}
+
+	trans := tx.createTransfer(arg)
+	res.Trans = trans
+
+	postings := []PostingParams{
+		{WalletAddress: arg.FromWalletAddress, Currency: arg.Currency, Amount: -arg.Amount},
+		{WalletAddress: arg.ToWalletAddress, Currency: arg.Currency, Amount: arg.Amount - arg.Fee},
+	}
+	if arg.Fee > 0 {
+		feeWallet, err := tx.organizationWallet(trans.Currency, model.OrganizationWalletFEEINCOME)
+		if err != nil {
+			return res, err
+		}
+		postings = append(postings, PostingParams{WalletAddress: feeWallet.WalletAddress, Currency: arg.Currency, Amount: arg.Fee})
+	}
+
+	if err := tx.recordTransferUsage(arg.FromWalletAddress, arg.Amount, arg.Limit, postings); err != nil {
+		return res, err
+	}
+
+	entry, err := tx.postEntry(ctx, PostEntryParams{
+		Kind:     model.JournalEntryKindTRANSFER,
+		TransID:  trans.ID,
+		Postings: postings,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	res.Wallet = entry.Wallets[arg.FromWalletAddress]
+
+	return res, tx.emit(transferEvent(model.EventTypeTransferCompleted, trans, entry))
+}
+
+// releaseHold gives the held amount back to the available balance of the wallet and closes the hold with status
+func (tx *memoryTx) releaseHold(h model.Hold, status model.HoldStatus) (model.Hold, error) {
+
+	if err := tx.addHeldBalance(h.WalletAddress, -h.Amount); err != nil {
+		return h, err
+	}
+
+	h.Status = status
+	h.UpdatedAt = time.Now()
+	tx.put(tx.holds, h.ID, h)
+	return h, nil
+}
+
+func (tx *memoryTx) addHeldBalance(address string, amount int64) error {
+	w, ok := tx.wallets[address]
+	if !ok {
+		return local_errors.ErrWalletNotFound
+	}
+
+	w.HeldBalance += amount
+	w.UpdatedAt = time.Now()
+	tx.put(tx.wallets, address, w)
+	return nil
+}
+
+// sortByID orders rows, a slice of models, by the int64 field ID, descending when desc is set
+func sortByID(rows interface{}, desc bool) {
+	v := reflect.ValueOf(rows)
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := v.Index(i).FieldByName("ID").Int(), v.Index(j).FieldByName("ID").Int()
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+}
@@ -0,0 +1,560 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/sirupsen/logrus"
+)
+
+type memoryOutboxRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryOutboxRepo(m *MemoryStore) OutboxRepo {
+	return &memoryOutboxRepository{
+		m: m,
+	}
+}
+
+func (q *memoryOutboxRepository) ListPendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+
+	logrus.Println("log  ListPendingEvents in store/memory_events/ListPendingEvents ")
+
+	return q.listEvents(limit, func(e model.OutboxEvent) bool {
+		return !e.Published && !e.Dead
+	}), nil
+}
+
+func (q *memoryOutboxRepository) MarkEventPublished(ctx context.Context, id int64, at time.Time) error {
+
+	logrus.Println("log  MarkEventPublished in store/memory_events/MarkEventPublished ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		e, ok := tx.events[id]
+		if !ok {
+			return nil
+		}
+
+		e.Published = true
+		e.PublishedAt = at
+		e.Attempts++
+		e.LastError = ""
+		tx.put(tx.events, id, e)
+		return nil
+	})
+}
+
+func (q *memoryOutboxRepository) MarkEventFailed(ctx context.Context, id int64, reason string, dead bool) error {
+
+	logrus.Println("log  MarkEventFailed in store/memory_events/MarkEventFailed ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		e, ok := tx.events[id]
+		if !ok {
+			return nil
+		}
+
+		e.Attempts++
+		e.LastError = reason
+		e.Dead = dead
+		tx.put(tx.events, id, e)
+		return nil
+	})
+}
+
+func (q *memoryOutboxRepository) ListEvents(ctx context.Context, arg ListEventsParams) ([]model.OutboxEvent, error) {
+
+	logrus.Println("log  ListEvents in store/memory_events/ListEvents ")
+
+	return q.listEvents(arg.Limit, func(e model.OutboxEvent) bool {
+		if arg.WalletAddress != "" && e.WalletAddress != arg.WalletAddress && e.ToWalletAddress != arg.WalletAddress {
+			return false
+		}
+		if !arg.Since.IsZero() && e.OccurredAt.Before(arg.Since) {
+			return false
+		}
+		return e.ID > arg.AfterID
+	}), nil
+}
+
+func (q *memoryOutboxRepository) LastEventID(ctx context.Context) (int64, error) {
+
+	logrus.Println("log  LastEventID in store/memory_events/LastEventID ")
+
+	var last int64
+	q.m.view(func() {
+		for id := range q.m.events {
+			if id > last {
+				last = id
+			}
+		}
+	})
+
+	return last, nil
+}
+
+// listEvents returns the first limit events matching the condition, in the order of their ids
+func (q *memoryOutboxRepository) listEvents(limit int, match func(e model.OutboxEvent) bool) []model.OutboxEvent {
+
+	var events []model.OutboxEvent
+	q.m.view(func() {
+		for _, e := range q.m.events {
+			if match(e) {
+				events = append(events, e)
+			}
+		}
+	})
+	sortByID(events, false)
+
+	return events[:limitRows(len(events), limit)]
+}
+
+type memoryWebhookRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryWebhookRepo(m *MemoryStore) WebhookRepo {
+	return &memoryWebhookRepository{
+		m: m,
+	}
+}
+
+func (q *memoryWebhookRepository) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (model.WebhookSubscription, error) {
+
+	logrus.Println("log  CreateWebhookSubscription in store/memory_events/CreateWebhookSubscription ")
+
+	eventTypes := make([]string, 0, len(arg.EventTypes))
+	for _, t := range arg.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+
+	var s model.WebhookSubscription
+
+	err := q.m.update(func(tx *memoryTx) error {
+		now := time.Now()
+		s = model.WebhookSubscription{
+			ID:         tx.nextID("webhook_subscriptions"),
+			Username:   arg.Username,
+			URL:        arg.URL,
+			EventTypes: strings.Join(eventTypes, ","),
+			Secret:     arg.Secret,
+			Active:     true,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		tx.put(tx.subscriptions, s.ID, s)
+		return nil
+	})
+
+	return s, err
+}
+
+func (q *memoryWebhookRepository) GetWebhookSubscription(ctx context.Context, id int64) (model.WebhookSubscription, error) {
+
+	logrus.Println("log  GetWebhookSubscription in store/memory_events/GetWebhookSubscription ")
+
+	var s model.WebhookSubscription
+	var ok bool
+	q.m.view(func() {
+		s, ok = q.m.subscriptions[id]
+	})
+
+	if !ok {
+		return s, local_errors.ErrWebhookNotFound
+	}
+	return s, nil
+}
+
+func (q *memoryWebhookRepository) ListWebhookSubscriptions(ctx context.Context, username string) ([]model.WebhookSubscription, error) {
+
+	logrus.Println("log  ListWebhookSubscriptions in store/memory_events/ListWebhookSubscriptions ")
+
+	var subscriptions []model.WebhookSubscription
+	q.m.view(func() {
+		for _, s := range q.m.subscriptions {
+			if s.Username == username {
+				subscriptions = append(subscriptions, s)
+			}
+		}
+	})
+	sortByID(subscriptions, true)
+
+	return subscriptions, nil
+}
+
+func (q *memoryWebhookRepository) ListActiveWebhookSubscriptions(ctx context.Context, usernames []string) ([]model.WebhookSubscription, error) {
+
+	logrus.Println("log  ListActiveWebhookSubscriptions in store/memory_events/ListActiveWebhookSubscriptions ")
+
+	wanted := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		wanted[username] = true
+	}
+
+	var subscriptions []model.WebhookSubscription
+	q.m.view(func() {
+		for _, s := range q.m.subscriptions {
+			if s.Active && wanted[s.Username] {
+				subscriptions = append(subscriptions, s)
+			}
+		}
+	})
+	sortByID(subscriptions, false)
+
+	return subscriptions, nil
+}
+
+func (q *memoryWebhookRepository) DeactivateWebhookSubscription(ctx context.Context, id int64) (model.WebhookSubscription, error) {
+
+	logrus.Println("log  DeactivateWebhookSubscription in store/memory_events/DeactivateWebhookSubscription ")
+
+	var s model.WebhookSubscription
+
+	err := q.m.update(func(tx *memoryTx) error {
+		var ok bool
+		s, ok = tx.subscriptions[id]
+		if !ok {
+			return local_errors.ErrWebhookNotFound
+		}
+
+		s.Active = false
+		s.UpdatedAt = time.Now()
+		tx.put(tx.subscriptions, id, s)
+		return nil
+	})
+
+	return s, err
+}
+
+func (q *memoryWebhookRepository) CreateWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+
+	logrus.Println("log  CreateWebhookDeliveries in store/memory_events/CreateWebhookDeliveries ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		for _, d := range deliveries {
+			// the relay delivers an event at least once, the same event must not be queued twice
+			queued := false
+			for _, existing := range tx.deliveries {
+				if existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID {
+					queued = true
+					break
+				}
+			}
+			if queued {
+				continue
+			}
+
+			d.ID = tx.nextID("webhook_deliveries")
+			tx.put(tx.deliveries, d.ID, d)
+		}
+		return nil
+	})
+}
+
+func (q *memoryWebhookRepository) GetWebhookDelivery(ctx context.Context, id int64) (model.WebhookDelivery, error) {
+
+	logrus.Println("log  GetWebhookDelivery in store/memory_events/GetWebhookDelivery ")
+
+	var d model.WebhookDelivery
+	var ok bool
+	q.m.view(func() {
+		d, ok = q.m.deliveries[id]
+	})
+
+	if !ok {
+		return d, local_errors.ErrWebhookDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (q *memoryWebhookRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]model.WebhookDelivery, error) {
+
+	logrus.Println("log  ListWebhookDeliveries in store/memory_events/ListWebhookDeliveries ")
+
+	var deliveries []model.WebhookDelivery
+	q.m.view(func() {
+		for _, d := range q.m.deliveries {
+			if d.SubscriptionID == subscriptionID {
+				deliveries = append(deliveries, d)
+			}
+		}
+	})
+	sortByID(deliveries, true)
+
+	return deliveries[:limitRows(len(deliveries), limit)], nil
+}
+
+func (q *memoryWebhookRepository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+
+	logrus.Println("log  ListDueWebhookDeliveries in store/memory_events/ListDueWebhookDeliveries ")
+
+	var deliveries []model.WebhookDelivery
+	q.m.view(func() {
+		for _, d := range q.m.deliveries {
+			if d.Status == model.WebhookDeliveryStatusPENDING && !d.NextAttemptAt.After(now) {
+				deliveries = append(deliveries, d)
+			}
+		}
+	})
+
+	sortByID(deliveries, false)
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt)
+	})
+
+	return deliveries[:limitRows(len(deliveries), limit)], nil
+}
+
+func (q *memoryWebhookRepository) RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (model.WebhookDelivery, error) {
+
+	logrus.Println("log  RecordWebhookDeliveryAttempt in store/memory_events/RecordWebhookDeliveryAttempt ")
+
+	var d model.WebhookDelivery
+
+	err := q.m.update(func(tx *memoryTx) error {
+		var ok bool
+		d, ok = tx.deliveries[arg.ID]
+		if !ok {
+			return local_errors.ErrWebhookDeliveryNotFound
+		}
+
+		// a replay may have queued the delivery again meanwhile, only the pending delivery is updated
+		if d.Status != model.WebhookDeliveryStatusPENDING {
+			return nil
+		}
+
+		now := time.Now()
+		d.Attempts++
+		d.LastStatusCode = arg.StatusCode
+		d.LastError = arg.Error
+		d.UpdatedAt = now
+		switch {
+		case arg.Error == "":
+			d.Status = model.WebhookDeliveryStatusSUCCEEDED
+			d.DeliveredAt = now
+		case !arg.NextAttemptAt.IsZero():
+			d.NextAttemptAt = arg.NextAttemptAt
+		default:
+			d.Status = model.WebhookDeliveryStatusDEAD
+		}
+		tx.put(tx.deliveries, d.ID, d)
+		return nil
+	})
+
+	return d, err
+}
+
+func (q *memoryWebhookRepository) ReplayWebhookDelivery(ctx context.Context, id int64, now time.Time) (model.WebhookDelivery, error) {
+
+	logrus.Println("log  ReplayWebhookDelivery in store/memory_events/ReplayWebhookDelivery ")
+
+	var d model.WebhookDelivery
+
+	err := q.m.update(func(tx *memoryTx) error {
+		var ok bool
+		d, ok = tx.deliveries[id]
+		if !ok {
+			return local_errors.ErrWebhookDeliveryNotFound
+		}
+
+		d.Status = model.WebhookDeliveryStatusPENDING
+		d.Attempts = 0
+		d.NextAttemptAt = now
+		d.UpdatedAt = now
+		tx.put(tx.deliveries, id, d)
+		return nil
+	})
+
+	return d, err
+}
+
+type memoryIdempotencyRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryIdempotencyRepo(m *MemoryStore) IdempotencyRepo {
+	return &memoryIdempotencyRepository{
+		m: m,
+	}
+}
+
+func (q *memoryIdempotencyRepository) ReserveIdempotencyKey(ctx context.Context, arg ReserveIdempotencyKeyParams) (model.IdempotencyKey, bool, error) {
+
+	logrus.Println("log  ReserveIdempotencyKey in store/memory_events/ReserveIdempotencyKey ")
+
+	var k model.IdempotencyKey
+	created := false
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		now := time.Now()
+		for id, existing := range tx.idempotencyKeys {
+			if existing.Username != arg.Username || existing.Scope != arg.Scope || existing.Key != arg.Key {
+				continue
+			}
+			// an expired key can be used again
+			if !existing.ExpiresAt.After(now) {
+				tx.remove(tx.idempotencyKeys, id)
+				break
+			}
+			k = existing
+			return nil
+		}
+
+		k = model.IdempotencyKey{
+			ID:          tx.nextID("idempotency_keys"),
+			Username:    arg.Username,
+			Scope:       arg.Scope,
+			Key:         arg.Key,
+			Fingerprint: arg.Fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   arg.ExpiresAt,
+			LockedUntil: arg.LockedUntil,
+		}
+		tx.put(tx.idempotencyKeys, k.ID, k)
+		created = true
+		return nil
+	})
+
+	return k, created, err
+}
+
+func (q *memoryIdempotencyRepository) TakeOverIdempotencyKey(ctx context.Context, id int64, lockedUntil time.Time) (bool, error) {
+
+	logrus.Println("log  TakeOverIdempotencyKey in store/memory_events/TakeOverIdempotencyKey ")
+
+	taken := false
+
+	err := q.m.update(func(tx *memoryTx) error {
+		k, ok := tx.idempotencyKeys[id]
+		if !ok || k.IsCompleted() || k.LockedUntil.After(time.Now()) {
+			return nil
+		}
+
+		k.LockedUntil = lockedUntil
+		tx.put(tx.idempotencyKeys, k.ID, k)
+		taken = true
+		return nil
+	})
+
+	return taken, err
+}
+
+func (q *memoryIdempotencyRepository) GetIdempotencyKeyEntry(ctx context.Context, id int64) (model.JournalEntry, bool, error) {
+
+	logrus.Println("log  GetIdempotencyKeyEntry in store/memory_events/GetIdempotencyKeyEntry ")
+
+	var entry model.JournalEntry
+	found := false
+
+	q.m.view(func() {
+		for _, e := range q.m.entries {
+			if e.IdempotencyKeyID == id {
+				entry, found = e, true
+				return
+			}
+		}
+	})
+
+	return entry, found, nil
+}
+
+func (q *memoryIdempotencyRepository) SaveIdempotencyResponse(ctx context.Context, arg SaveIdempotencyResponseParams) error {
+
+	logrus.Println("log  SaveIdempotencyResponse in store/memory_events/SaveIdempotencyResponse ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		k, ok := tx.idempotencyKeys[arg.ID]
+		if !ok {
+			return nil
+		}
+
+		k.StatusCode = arg.StatusCode
+		k.ResponseBody = arg.ResponseBody
+		tx.put(tx.idempotencyKeys, k.ID, k)
+		return nil
+	})
+}
+
+func (q *memoryIdempotencyRepository) DeleteIdempotencyKey(ctx context.Context, id int64) error {
+
+	logrus.Println("log  DeleteIdempotencyKey in store/memory_events/DeleteIdempotencyKey ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		tx.remove(tx.idempotencyKeys, id)
+		return nil
+	})
+}
+
+func (q *memoryIdempotencyRepository) DeleteExpiredIdempotencyKeys(ctx context.Context, now time.Time) (int64, error) {
+
+	logrus.Println("log  DeleteExpiredIdempotencyKeys in store/memory_events/DeleteExpiredIdempotencyKeys ")
+
+	var deleted int64
+
+	err := q.m.update(func(tx *memoryTx) error {
+		for id, k := range tx.idempotencyKeys {
+			if !k.ExpiresAt.After(now) {
+				tx.remove(tx.idempotencyKeys, id)
+				deleted++
+			}
+		}
+		return nil
+	})
+
+	return deleted, err
+}
+
+type memoryLeaseRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryLeaseRepo(m *MemoryStore) LeaseRepo {
+	return &memoryLeaseRepository{
+		m: m,
+	}
+}
+
+func (q *memoryLeaseRepository) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+
+	logrus.Println("log  AcquireLease in store/memory_events/AcquireLease ")
+
+	acquired := false
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		now := time.Now()
+
+		l, ok := tx.leases[name]
+		if ok && l.Holder != holder && now.Before(l.ExpiresAt) {
+			return nil
+		}
+
+		tx.put(tx.leases, name, model.Lease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl), UpdatedAt: now})
+		acquired = true
+		return nil
+	})
+
+	return acquired, err
+}
+
+func (q *memoryLeaseRepository) ReleaseLease(ctx context.Context, name string, holder string) error {
+
+	logrus.Println("log  ReleaseLease in store/memory_events/ReleaseLease ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		l, ok := tx.leases[name]
+		if !ok || l.Holder != holder {
+			return nil
+		}
+
+		l.ExpiresAt = time.Now()
+		tx.put(tx.leases, name, l)
+		return nil
+	})
+}
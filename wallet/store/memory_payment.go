@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/recurrence"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+)
+
+type memoryPaymentRequestRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryPaymentRequestRepo(m *MemoryStore) PaymentRequestRepo {
+	return &memoryPaymentRequestRepository{
+		m: m,
+	}
+}
+
+func (q *memoryPaymentRequestRepository) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (model.PaymentRequest, error) {
+
+	logrus.Println("log  CreatePaymentRequest in store/memory_payment/CreatePaymentRequest ")
+
+	var p model.PaymentRequest
+
+	err := q.m.update(func(tx *memoryTx) error {
+		now := time.Now()
+		p = model.PaymentRequest{
+			ID:            tx.nextID("payment_requests"),
+			FromWalletAdd: arg.FromWalletAddress,
+			ToWalletAdd:   arg.ToWalletAddress,
+			PayerUsername: arg.PayerUsername,
+			PayeeUsername: arg.PayeeUsername,
+			Amount:        arg.Amount,
+			Currency:      arg.Currency,
+			Note:          arg.Note,
+			Status:        model.PaymentRequestStatusWAITINGAPPROVAL,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		tx.put(tx.paymentRequests, p.ID, p)
+		return nil
+	})
+
+	return p, err
+}
+
+func (q *memoryPaymentRequestRepository) GetPaymentRequest(ctx context.Context, id int64) (model.PaymentRequest, error) {
+
+	logrus.Println("log  GetPaymentRequest in store/memory_payment/GetPaymentRequest ")
+
+	var p model.PaymentRequest
+	var ok bool
+	q.m.view(func() {
+		p, ok = q.m.paymentRequests[id]
+	})
+
+	if !ok {
+		return p, local_errors.ErrPaymentRequestNotFound
+	}
+	return p, nil
+}
+
+func (q *memoryPaymentRequestRepository) ListPaymentRequestsByPayer(ctx context.Context, payerUsername string, status model.PaymentRequestStatus) ([]model.PaymentRequest, error) {
+
+	logrus.Println("log  ListPaymentRequestsByPayer in store/memory_payment/ListPaymentRequestsByPayer ")
+
+	var requests []model.PaymentRequest
+	q.m.view(func() {
+		for _, p := range q.m.paymentRequests {
+			if p.PayerUsername == payerUsername && p.Status == status {
+				requests = append(requests, p)
+			}
+		}
+	})
+
+	sortByID(requests, true)
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.After(requests[j].CreatedAt)
+	})
+
+	return requests, nil
+}
+
+// UpdatePaymentRequestStatus only moves requests still in FromStatus, so two concurrent
+// transitions of the same request can not both succeed
+func (q *memoryPaymentRequestRepository) UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (model.PaymentRequest, error) {
+
+	logrus.Println("log  UpdatePaymentRequestStatus in store/memory_payment/UpdatePaymentRequestStatus ")
+
+	var p model.PaymentRequest
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		p, ok = tx.paymentRequests[arg.ID]
+		if !ok || p.Status != arg.FromStatus {
+			p = model.PaymentRequest{}
+			return local_errors.ErrInvalidPaymentRequestTransition
+		}
+
+		p.Status = arg.ToStatus
+		p.TransID = arg.TransID
+		p.FailureReason = arg.FailureReason
+		p.UpdatedAt = time.Now()
+		tx.put(tx.paymentRequests, p.ID, p)
+		return nil
+	})
+
+	return p, err
+}
+
+type memoryScheduledPaymentRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryScheduledPaymentRepo(m *MemoryStore) ScheduledPaymentRepo {
+	return &memoryScheduledPaymentRepository{
+		m: m,
+	}
+}
+
+func (q *memoryScheduledPaymentRepository) CreateScheduledPayment(ctx context.Context, arg CreateScheduledPaymentParams) (model.ScheduledPayment, error) {
+
+	logrus.Println("log  CreateScheduledPayment in store/memory_payment/CreateScheduledPayment ")
+
+	var s model.ScheduledPayment
+
+	err := q.m.update(func(tx *memoryTx) error {
+		now := time.Now()
+		s = model.ScheduledPayment{
+			ID:            tx.nextID("scheduled_payments"),
+			Username:      arg.Username,
+			FromWalletAdd: arg.FromWalletAddress,
+			ToWalletAdd:   arg.ToWalletAddress,
+			ToUsername:    arg.ToUsername,
+			Amount:        arg.Amount,
+			Currency:      arg.Currency,
+			Note:          arg.Note,
+			Frequency:     arg.Frequency,
+			Interval:      arg.Interval,
+			Cron:          arg.Cron,
+			StartAt:       arg.StartAt,
+			EndAt:         arg.EndAt,
+			MaxRuns:       arg.MaxRuns,
+			Status:        model.ScheduledPaymentStatusACTIVE,
+			OccurrenceAt:  arg.FirstRunAt,
+			NextRunAt:     arg.FirstRunAt,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		tx.put(tx.schedules, s.ID, s)
+		return nil
+	})
+
+	return s, err
+}
+
+func (q *memoryScheduledPaymentRepository) GetScheduledPayment(ctx context.Context, id int64) (model.ScheduledPayment, error) {
+
+	logrus.Println("log  GetScheduledPayment in store/memory_payment/GetScheduledPayment ")
+
+	var s model.ScheduledPayment
+	var ok bool
+	q.m.view(func() {
+		s, ok = q.m.schedules[id]
+	})
+
+	if !ok {
+		return s, local_errors.ErrScheduledPaymentNotFound
+	}
+	return s, nil
+}
+
+func (q *memoryScheduledPaymentRepository) ListScheduledPayments(ctx context.Context, username string) ([]model.ScheduledPayment, error) {
+
+	logrus.Println("log  ListScheduledPayments in store/memory_payment/ListScheduledPayments ")
+
+	var schedules []model.ScheduledPayment
+	q.m.view(func() {
+		for _, s := range q.m.schedules {
+			if s.Username == username {
+				schedules = append(schedules, s)
+			}
+		}
+	})
+	sortByID(schedules, true)
+
+	return schedules, nil
+}
+
+func (q *memoryScheduledPaymentRepository) CancelScheduledPayment(ctx context.Context, id int64) (model.ScheduledPayment, error) {
+
+	logrus.Println("log  CancelScheduledPayment in store/memory_payment/CancelScheduledPayment ")
+
+	var s model.ScheduledPayment
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		s, ok = tx.schedules[id]
+		if !ok {
+			return local_errors.ErrScheduledPaymentNotFound
+		}
+		if s.Status != model.ScheduledPaymentStatusACTIVE {
+			return local_errors.ErrScheduledPaymentNotActive
+		}
+
+		s.Status = model.ScheduledPaymentStatusCANCELLED
+		s.UpdatedAt = time.Now()
+		tx.put(tx.schedules, id, s)
+		return nil
+	})
+
+	return s, err
+}
+
+func (q *memoryScheduledPaymentRepository) ListDueScheduledPayments(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+
+	logrus.Println("log  ListDueScheduledPayments in store/memory_payment/ListDueScheduledPayments ")
+
+	var due []model.ScheduledPayment
+	q.m.view(func() {
+		for _, s := range q.m.schedules {
+			if s.Status == model.ScheduledPaymentStatusACTIVE && !s.NextRunAt.After(now) {
+				due = append(due, s)
+			}
+		}
+	})
+
+	sortByID(due, false)
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].NextRunAt.Before(due[j].NextRunAt)
+	})
+
+	ids := make([]int64, 0, len(due))
+	for _, s := range due[:limitRows(len(due), limit)] {
+		ids = append(ids, s.ID)
+	}
+
+	return ids, nil
+}
+
+func (q *memoryScheduledPaymentRepository) ClaimScheduledPayment(ctx context.Context, id int64, now time.Time) (model.ScheduledPaymentRun, error) {
+
+	logrus.Println("log  ClaimScheduledPayment in store/memory_payment/ClaimScheduledPayment ")
+
+	var run model.ScheduledPaymentRun
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		s, ok := tx.schedules[id]
+		if !ok {
+			return local_errors.ErrScheduledPaymentNotFound
+		}
+
+		if !s.IsDue(now) {
+			return local_errors.ErrScheduledPaymentNotDue
+		}
+
+		run = s.Claim(now)
+		run.ID = tx.nextID("scheduled_payment_runs")
+		tx.put(tx.scheduleRuns, run.ID, run)
+
+		s.UpdatedAt = now
+		tx.put(tx.schedules, id, s)
+		return nil
+	})
+
+	return run, err
+}
+
+func (q *memoryScheduledPaymentRepository) FinishScheduledPaymentRun(ctx context.Context, arg FinishScheduledPaymentRunParams) (model.ScheduledPaymentRun, error) {
+
+	logrus.Println("log  FinishScheduledPaymentRun in store/memory_payment/FinishScheduledPaymentRun ")
+
+	var run model.ScheduledPaymentRun
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		run, ok = tx.scheduleRuns[arg.RunID]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+
+		s, ok := tx.schedules[run.ScheduledPaymentID]
+		if !ok {
+			return local_errors.ErrScheduledPaymentNotFound
+		}
+
+		now := time.Now()
+		switch {
+		case arg.Error == "":
+			run.Status = model.ScheduledPaymentRunStatusSUCCEEDED
+		case !arg.RetryAt.IsZero() && s.Status != model.ScheduledPaymentStatusCANCELLED:
+			run.Status = model.ScheduledPaymentRunStatusRETRYING
+			s.Retry(run, arg.RetryAt)
+		default:
+			run.Status = model.ScheduledPaymentRunStatusFAILED
+			if s.Frequency == recurrence.FrequencyONCE && s.Status == model.ScheduledPaymentStatusCOMPLETED {
+				s.Status = model.ScheduledPaymentStatusFAILED
+			}
+		}
+		run.TransID = arg.TransID
+		run.Error = arg.Error
+		run.UpdatedAt = now
+		tx.put(tx.scheduleRuns, run.ID, run)
+
+		s.LastError = arg.Error
+		s.UpdatedAt = now
+		tx.put(tx.schedules, s.ID, s)
+		return nil
+	})
+
+	return run, err
+}
+
+func (q *memoryScheduledPaymentRepository) ListScheduledPaymentRuns(ctx context.Context, scheduledPaymentID int64) ([]model.ScheduledPaymentRun, error) {
+
+	logrus.Println("log  ListScheduledPaymentRuns in store/memory_payment/ListScheduledPaymentRuns ")
+
+	var runs []model.ScheduledPaymentRun
+	q.m.view(func() {
+		for _, r := range q.m.scheduleRuns {
+			if r.ScheduledPaymentID == scheduledPaymentID {
+				runs = append(runs, r)
+			}
+		}
+	})
+	sortByID(runs, true)
+
+	return runs, nil
+}
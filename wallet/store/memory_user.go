@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/sirupsen/logrus"
+)
+
+type memoryUserRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryUserRepo(m *MemoryStore) UserRepo {
+	return &memoryUserRepository{
+		m: m,
+	}
+}
+
+func (q *memoryUserRepository) CreateUser(ctx context.Context, arg CreateUserParams) (model.User, error) {
+
+	logrus.Println("log create user in store/memory_user/CreateUser ")
+
+	var u model.User
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		if existing, ok := tx.users[arg.Username]; ok {
+			u = existing
+			logrus.Println("username already exist !! ")
+			return local_errors.ErrUsernameAlreadyTaken
+		}
+
+		tier := arg.Tier
+		if tier == "" {
+			tier = model.UserTierSTANDARD
+		}
+		role := arg.Role
+		if role == "" {
+			role = model.UserRoleUSER
+		}
+
+		id := arg.Id
+		if id == 0 {
+			id = tx.nextID("users")
+		}
+
+		now := time.Now()
+		u = model.User{
+			ID:                id,
+			Username:          arg.Username,
+			HashedPassword:    arg.HashedPassword,
+			Status:            arg.Status,
+			Tier:              tier,
+			Role:              role,
+			Email:             arg.Email,
+			Address:           arg.Address,
+			Nationality:       arg.Nationality,
+			AadharNo:          arg.AadharNo,
+			PasswordChangedAt: now,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		tx.put(tx.users, u.Username, u)
+		return nil
+	})
+
+	return u, err
+}
+
+func (q *memoryUserRepository) GetUserByUsername(ctx context.Context, username string) (model.User, error) {
+
+	logrus.Println("log  Login user in store/memory_user/")
+
+	var u model.User
+	var ok bool
+	q.m.view(func() {
+		u, ok = q.m.users[username]
+	})
+
+	if !ok {
+		logrus.Println("username not found !! ")
+		return u, fmt.Errorf("wrong username")
+	}
+	return u, nil
+}
+
+func (q *memoryUserRepository) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (model.User, error) {
+
+	logrus.Println("log  UpdateUserStatus in store/memory_user/UpdateUserStatus ")
+
+	var u model.User
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		u, ok = tx.users[arg.Username]
+		if !ok {
+			return local_errors.ErrUserNotFound
+		}
+
+		u.Status = arg.Status
+		u.UpdatedAt = time.Now()
+		tx.put(tx.users, u.Username, u)
+		return nil
+	})
+
+	return u, err
+}
+
+type memorySessionRepository struct {
+	m *MemoryStore
+}
+
+func NewMemorySessionRepo(m *MemoryStore) SessionRepo {
+	return &memorySessionRepository{
+		m: m,
+	}
+}
+
+func (q *memorySessionRepository) CreateSession(ctx context.Context, arg CreateSessionParams) (model.Session, error) {
+
+	logrus.Println("log  CreateSession in store/memory_user/CreateSession ")
+
+	s := model.Session{
+		ID:           arg.ID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		UserAgent:    arg.UserAgent,
+		ClientIp:     arg.ClientIp,
+		IsBlocked:    false,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    time.Now(),
+	}
+
+	err := q.m.update(func(tx *memoryTx) error {
+		if _, ok := tx.sessions[s.ID]; ok {
+			return fmt.Errorf("session %s already exists", s.ID)
+		}
+		tx.put(tx.sessions, s.ID, s)
+		return nil
+	})
+
+	return s, err
+}
+
+func (q *memorySessionRepository) GetSession(ctx context.Context, id string) (model.Session, error) {
+
+	logrus.Println("log  GetSession in store/memory_user/GetSession ")
+
+	var s model.Session
+	var ok bool
+	q.m.view(func() {
+		s, ok = q.m.sessions[id]
+	})
+
+	if !ok {
+		return s, local_errors.ErrSessionNotFound
+	}
+	return s, nil
+}
+
+func (q *memorySessionRepository) BlockSession(ctx context.Context, id string) error {
+
+	logrus.Println("log  BlockSession in store/memory_user/BlockSession ")
+
+	return q.m.update(func(tx *memoryTx) error {
+
+		s, ok := tx.sessions[id]
+		if !ok {
+			return local_errors.ErrSessionNotFound
+		}
+
+		s.IsBlocked = true
+		tx.put(tx.sessions, id, s)
+		return nil
+	})
+}
+
+func (q *memorySessionRepository) BlockUserSessions(ctx context.Context, username string) error {
+
+	logrus.Println("log  BlockUserSessions in store/memory_user/BlockUserSessions ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		for id, s := range tx.sessions {
+			if s.Username == username && !s.IsBlocked {
+				s.IsBlocked = true
+				tx.put(tx.sessions, id, s)
+			}
+		}
+		return nil
+	})
+}
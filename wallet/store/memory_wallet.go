@@ -0,0 +1,1140 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+)
+
+type memoryWalletRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryWalletRepo(m *MemoryStore) WalletRepo {
+	return &memoryWalletRepository{
+		m: m,
+	}
+}
+
+func (q *memoryWalletRepository) CreateWallet(ctx context.Context, arg CreateWalletParams) (model.Wallet, error) {
+
+	logrus.Println("log  CreateWallet in store/memory_wallet/CreateWallet ")
+
+	var w model.Wallet
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		u, ok := tx.users[arg.Username]
+		if !ok {
+			logrus.Println("No user exist with this !! ")
+			return local_errors.ErrUserNotFound
+		}
+
+		// a user holds at most one wallet per currency
+		count := 0
+		for _, existing := range tx.wallets {
+			if existing.UserID != u.ID {
+				continue
+			}
+			if existing.Currency == arg.Currency {
+				return local_errors.ErrWalletCurrencyExists
+			}
+			count++
+		}
+
+		address, err := newWalletAddress()
+		if err != nil {
+			logrus.Println("error in creating new uuid for wa(wallet_address) !!")
+			return err
+		}
+
+		w = tx.createWallet(model.Wallet{
+			Username:      arg.Username,
+			UserID:        u.ID,
+			WalletAddress: address,
+			Status:        model.WalletStatusACTIVE,
+			Kind:          model.WalletKindUSER,
+			// the first wallet of a user becomes his primary wallet
+			IsPrimary: count == 0,
+			Balance:   0,
+			Currency:  arg.Currency,
+			CreatedAt: time.Now(),
+		})
+
+		return tx.emit(walletEvent(model.EventTypeWalletCreated, w, 0))
+	})
+
+	return w, err
+}
+
+// GetWalletByUsername returns the primary wallet of the user
+func (q *memoryWalletRepository) GetWalletByUsername(ctx context.Context, username string) (model.Wallet, error) {
+
+	logrus.Println("log  GetWallet in store/memory_wallet/GetWallet")
+
+	return q.findWallet(func(w model.Wallet) bool {
+		return w.Username == username && w.IsPrimary
+	})
+}
+
+func (q *memoryWalletRepository) GetWalletByAddress(ctx context.Context, address string) (model.Wallet, error) {
+
+	logrus.Println("log  GetWalletByAddress in store/memory_wallet/GetWalletByAddress")
+
+	return q.findWallet(func(w model.Wallet) bool {
+		return w.WalletAddress == address
+	})
+}
+
+func (q *memoryWalletRepository) GetWalletByUsernameAndCurrency(ctx context.Context, username string, currency string) (model.Wallet, error) {
+
+	logrus.Println("log  GetWalletByUsernameAndCurrency in store/memory_wallet/GetWalletByUsernameAndCurrency")
+
+	return q.findWallet(func(w model.Wallet) bool {
+		return w.Username == username && w.Currency == currency
+	})
+}
+
+// findWallet returns a wallet matching the condition, or ErrWalletNotFound
+func (q *memoryWalletRepository) findWallet(match func(w model.Wallet) bool) (model.Wallet, error) {
+
+	var found model.Wallet
+	err := local_errors.ErrWalletNotFound
+
+	q.m.view(func() {
+		for _, w := range q.m.wallets {
+			if match(w) {
+				found, err = w, nil
+				return
+			}
+		}
+	})
+
+	return found, err
+}
+
+// SetPrimaryWallet makes the wallet the only primary wallet of its owner
+func (q *memoryWalletRepository) SetPrimaryWallet(ctx context.Context, username string, address string) (model.Wallet, error) {
+
+	logrus.Println("log  SetPrimaryWallet in store/memory_wallet/SetPrimaryWallet")
+
+	var i model.Wallet
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		i, ok = tx.wallets[address]
+		if !ok || i.Username != username {
+			return local_errors.ErrWalletNotFound
+		}
+
+		now := time.Now()
+		for _, w := range tx.wallets {
+			if w.Username == username && w.IsPrimary && w.ID != i.ID {
+				w.IsPrimary = false
+				w.UpdatedAt = now
+				tx.put(tx.wallets, w.WalletAddress, w)
+			}
+		}
+
+		i.IsPrimary = true
+		i.UpdatedAt = now
+		tx.put(tx.wallets, i.WalletAddress, i)
+		return nil
+	})
+
+	return i, err
+}
+
+func (q *memoryWalletRepository) ListWalletsByUsername(ctx context.Context, username string) ([]model.Wallet, error) {
+
+	logrus.Println("log  ListWalletsByUsername in store/memory_wallet/ListWalletsByUsername")
+
+	var wallets []model.Wallet
+	q.m.view(func() {
+		for _, w := range q.m.wallets {
+			if w.Username == username {
+				wallets = append(wallets, w)
+			}
+		}
+	})
+	sortByID(wallets, false)
+
+	return wallets, nil
+}
+
+func (q *memoryWalletRepository) UpdateWalletStatus(ctx context.Context, arg UpdateWalletStatusParams) (model.Wallet, error) {
+
+	logrus.Println("log  UpdateWalletStatus in store/memory_wallet/UpdateWalletStatus ")
+
+	var i model.Wallet
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		i, ok = tx.walletByID(arg.ID)
+		if !ok {
+			return local_errors.ErrWalletNotFound
+		}
+
+		if i.Status == arg.Status {
+			return nil
+		}
+
+		i.Status = arg.Status
+		tx.put(tx.wallets, i.WalletAddress, i)
+
+		eventType := model.EventTypeWalletUnfrozen
+		if arg.Status == model.WalletStatusINACTIVE {
+			eventType = model.EventTypeWalletFrozen
+		}
+		return tx.emit(walletEvent(eventType, i, 0))
+	})
+
+	return i, err
+}
+
+func (q *memoryWalletRepository) SendMoney(ctx context.Context, arg SendMoneyParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  SendMoney in store/memory_wallet/SendMoney")
+
+	var res WalletTransferResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+		var err error
+		res, err = tx.sendMoney(ctx, arg)
+		return err
+	})
+
+	return res, err
+}
+
+func (q *memoryWalletRepository) SendMoneyFX(ctx context.Context, arg SendMoneyFXParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  SendMoneyFX in store/memory_wallet/SendMoneyFX")
+
+	var res WalletTransferResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		quote, ok := tx.fxQuotes[arg.QuoteID]
+		if !ok {
+			return local_errors.ErrFXQuoteNotFound
+		}
+
+		trans := tx.createTransfer(SendMoneyParams{
+			FromWalletAddress: quote.FromWalletAdd,
+			ToWalletAddress:   quote.ToWalletAdd,
+			Amount:            quote.FromAmount,
+			Currency:          quote.FromCurrency,
+			Rate:              quote.Rate,
+			ToAmount:          quote.ToAmount,
+			ToCurrency:        quote.ToCurrency,
+		})
+		res.Trans = trans
+
+		// a quote pays for a single transfer
+		if err := tx.useFXQuote(quote.ID, trans.ID, time.Now()); err != nil {
+			return err
+		}
+
+		// the float of each currency takes the other side, so each currency balances on its own
+		fromFloat, err := tx.organizationWallet(quote.FromCurrency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+		toFloat, err := tx.organizationWallet(quote.ToCurrency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+
+		postings := []PostingParams{
+			{WalletAddress: quote.FromWalletAdd, Currency: quote.FromCurrency, Amount: -quote.FromAmount},
+			{WalletAddress: fromFloat.WalletAddress, Currency: quote.FromCurrency, Amount: quote.FromAmount},
+			{WalletAddress: toFloat.WalletAddress, Currency: quote.ToCurrency, Amount: -quote.ToAmount},
+			{WalletAddress: quote.ToWalletAdd, Currency: quote.ToCurrency, Amount: quote.ToAmount},
+		}
+
+		if err := tx.recordTransferUsage(quote.FromWalletAdd, quote.FromAmount, arg.Limit, postings); err != nil {
+			return err
+		}
+
+		entry, err := tx.postEntry(ctx, PostEntryParams{
+			Kind:        model.JournalEntryKindFXTRANSFER,
+			TransID:     trans.ID,
+			Description: "fx " + quote.FromCurrency + "/" + quote.ToCurrency + " at " + quote.Rate,
+			Postings:    postings,
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Wallet = entry.Wallets[quote.FromWalletAdd]
+
+		return tx.emit(transferEvent(model.EventTypeTransferCompleted, trans, entry))
+	})
+
+	return res, err
+}
+
+func (q *memoryWalletRepository) RefundTransfer(ctx context.Context, arg RefundTransferParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  RefundTransfer in store/memory_wallet/RefundTransfer")
+
+	var res WalletTransferResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		original, ok := tx.trans[arg.TransID]
+		if !ok {
+			return local_errors.ErrTransNotFound
+		}
+
+		if original.ReversalTransID != 0 {
+			return local_errors.ErrTransAlreadyReversed
+		}
+		if !original.IsRefundable() {
+			return local_errors.ErrTransNotRefundable
+		}
+		if arg.Amount <= 0 || arg.Amount > original.RefundableAmount() {
+			return local_errors.ErrRefundExceedsAmount
+		}
+
+		trans := tx.createTransfer(SendMoneyParams{
+			FromWalletAddress: original.ToWalletAdd,
+			ToWalletAddress:   original.FromWalletAdd,
+			Amount:            arg.Amount,
+			Currency:          original.Currency,
+			Kind:              model.TransKindREFUND,
+			OriginalTransID:   original.ID,
+		})
+		res.Trans = trans
+
+		original.RefundedAmount += arg.Amount
+		tx.put(tx.trans, original.ID, original)
+
+		entry, err := tx.postEntry(ctx, PostEntryParams{
+			Kind:        model.JournalEntryKindREFUND,
+			TransID:     trans.ID,
+			Description: fmt.Sprintf("refund of transaction %d", original.ID),
+			Postings: []PostingParams{
+				{WalletAddress: original.ToWalletAdd, Currency: original.Currency, Amount: -arg.Amount},
+				{WalletAddress: original.FromWalletAdd, Currency: original.Currency, Amount: arg.Amount},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Wallet = entry.Wallets[original.ToWalletAdd]
+
+		return tx.emit(transferEvent(model.EventTypeTransferRefunded, trans, entry))
+	})
+
+	return res, err
+}
+
+func (q *memoryWalletRepository) ReverseTransfer(ctx context.Context, arg ReverseTransferParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  ReverseTransfer in store/memory_wallet/ReverseTransfer")
+
+	var res WalletTransferResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		original, ok := tx.trans[arg.TransID]
+		if !ok {
+			return local_errors.ErrTransNotFound
+		}
+
+		if original.ReversalTransID != 0 {
+			return local_errors.ErrTransAlreadyReversed
+		}
+		if original.Kind != model.TransKindTRANSFER {
+			return local_errors.ErrTransNotRefundable
+		}
+		if original.RefundedAmount > 0 {
+			return local_errors.ErrTransRefunded
+		}
+
+		var entry model.JournalEntry
+		for _, e := range tx.entries {
+			if e.TransID == original.ID && (e.Kind == model.JournalEntryKindTRANSFER || e.Kind == model.JournalEntryKindFXTRANSFER) {
+				entry = e
+				break
+			}
+		}
+		if entry.ID == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		var postings []model.Posting
+		for _, p := range tx.postings {
+			if p.JournalEntryID == entry.ID {
+				postings = append(postings, p)
+			}
+		}
+		sortByID(postings, false)
+
+		// the payee is credited in the currency it received, which differs from Currency on cross-currency transfers
+		payeeCurrency := original.Currency
+		if original.ToCurrency != "" {
+			payeeCurrency = original.ToCurrency
+		}
+		suspense, err := tx.organizationWallet(payeeCurrency, model.OrganizationWalletSUSPENSE)
+		if err != nil {
+			return err
+		}
+
+		addresses := []string{suspense.WalletAddress}
+		for _, p := range postings {
+			addresses = append(addresses, p.WalletAddress)
+		}
+		wallets, err := tx.walletsByAddress(addresses...)
+		if err != nil {
+			return err
+		}
+
+		// the payee may have spent the money, the part it can not give back is parked on the suspense wallet
+		var shortfall int64
+		reversal := make([]PostingParams, 0, len(postings)+1)
+		for _, p := range postings {
+			amount := -p.Amount
+			if p.WalletAddress == original.ToWalletAdd && amount < 0 {
+				w := wallets[p.WalletAddress]
+				balance := w.AvailableBalance()
+				if balance < 0 {
+					balance = 0
+				}
+				if -amount > balance {
+					shortfall = -amount - balance
+					amount = -balance
+				}
+			}
+			if amount != 0 {
+				reversal = append(reversal, PostingParams{WalletAddress: p.WalletAddress, Currency: p.Currency, Amount: amount})
+			}
+		}
+		if shortfall > 0 {
+			reversal = append(reversal, PostingParams{WalletAddress: suspense.WalletAddress, Currency: payeeCurrency, Amount: -shortfall})
+		}
+
+		// the reversal carries the amounts of the transfer it undoes, with the wallets swapped
+		trans := tx.createTransfer(SendMoneyParams{
+			FromWalletAddress: original.ToWalletAdd,
+			ToWalletAddress:   original.FromWalletAdd,
+			Amount:            original.Amount,
+			Fee:               original.Fee,
+			Currency:          original.Currency,
+			Rate:              original.Rate,
+			ToAmount:          original.ToAmount,
+			ToCurrency:        original.ToCurrency,
+			Kind:              model.TransKindREVERSAL,
+			OriginalTransID:   original.ID,
+		})
+
+		if shortfall > 0 {
+			trans.Shortfall = shortfall
+			tx.put(tx.trans, trans.ID, trans)
+		}
+
+		original.ReversalTransID = trans.ID
+		tx.put(tx.trans, original.ID, original)
+
+		description := fmt.Sprintf("reversal of transaction %d", original.ID)
+		if arg.Reason != "" {
+			description += ": " + arg.Reason
+		}
+
+		posted, err := tx.postEntry(ctx, PostEntryParams{
+			Kind:        model.JournalEntryKindREVERSAL,
+			TransID:     trans.ID,
+			Description: description,
+			Postings:    reversal,
+			// the transfer is undone even when a wallet was frozen since, typically for the fraud being reversed
+			AllowInactive: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Trans = trans
+		res.Wallet = posted.Wallets[original.FromWalletAdd]
+
+		return tx.emit(transferEvent(model.EventTypeTransferReversed, trans, posted))
+	})
+
+	return res, err
+}
+
+func (q *memoryWalletRepository) CaptureHold(ctx context.Context, arg CaptureHoldParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  CaptureHold in store/memory_wallet/CaptureHold")
+
+	var res WalletTransferResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		h, ok := tx.holds[arg.HoldID]
+		if !ok {
+			return local_errors.ErrHoldNotFound
+		}
+
+		if h.Status != model.HoldStatusACTIVE {
+			return local_errors.ErrHoldNotActive
+		}
+		if h.IsExpired(time.Now()) {
+			return local_errors.ErrHoldExpired
+		}
+		if arg.Amount <= 0 || arg.Amount > h.Amount {
+			return local_errors.ErrCaptureExceedsHold
+		}
+
+		// the whole hold is released, the captured part then leaves the wallet with the transfer
+		h, err := tx.releaseHold(h, model.HoldStatusCAPTURED)
+		if err != nil {
+			return err
+		}
+
+		res, err = tx.sendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: h.WalletAddress,
+			ToWalletAddress:   h.ToWalletAdd,
+			Amount:            arg.Amount,
+			Fee:               arg.Fee,
+			Currency:          h.Currency,
+			Limit:             arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		h.CapturedAmount = arg.Amount
+		h.TransID = res.Trans.ID
+		tx.put(tx.holds, h.ID, h)
+		return nil
+	})
+
+	return res, err
+}
+
+func (q *memoryWalletRepository) PayPaymentRequest(ctx context.Context, arg PayPaymentRequestParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  PayPaymentRequest in store/memory_wallet/PayPaymentRequest")
+
+	var res WalletTransferResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		p, ok := tx.paymentRequests[arg.RequestID]
+		if !ok {
+			return local_errors.ErrPaymentRequestNotFound
+		}
+
+		// the request is approved and paid at once, it is never left APPROVED without its transfer
+		if err := p.TransitionTo(model.PaymentRequestStatusAPPROVED); err != nil {
+			return err
+		}
+		if err := p.TransitionTo(model.PaymentRequestStatusPAYMENTSUCCESS); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = tx.sendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: p.FromWalletAdd,
+			ToWalletAddress:   p.ToWalletAdd,
+			Amount:            arg.Amount,
+			Fee:               arg.Fee,
+			Currency:          p.Currency,
+			Limit:             arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		p.TransID = res.Trans.ID
+		p.UpdatedAt = time.Now()
+		tx.put(tx.paymentRequests, p.ID, p)
+		return nil
+	})
+
+	return res, err
+}
+
+func (q *memoryWalletRepository) AddWalletBalance(ctx context.Context, params AddWalletBalanceParams) (model.Wallet, error) {
+
+	logrus.Println("log  AddWalletBalance in store/memory_wallet/AddWalletBalance ")
+
+	var i model.Wallet
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		w, ok := tx.wallets[params.WalletAddress]
+		if !ok {
+			return local_errors.ErrWalletNotFound
+		}
+
+		// credited money comes from the float of the currency, money is never minted
+		float, err := tx.organizationWallet(w.Currency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+
+		entry, err := tx.postEntry(ctx, PostEntryParams{
+			Kind: model.JournalEntryKindCREDIT,
+			Postings: []PostingParams{
+				{WalletAddress: float.WalletAddress, Amount: -params.Amount},
+				{WalletAddress: params.WalletAddress, Amount: params.Amount},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		i = entry.Wallets[params.WalletAddress]
+		return tx.emit(walletEvent(model.EventTypeWalletCredited, i, params.Amount))
+	})
+
+	return i, err
+}
+
+func (q *memoryWalletRepository) EnsureOrganizationWallet(ctx context.Context, arg OrganizationWalletParams) (model.Wallet, error) {
+
+	logrus.Println("log  EnsureOrganizationWallet in store/memory_wallet/EnsureOrganizationWallet ")
+
+	var w model.Wallet
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var err error
+		w, err = tx.organizationWallet(arg.Currency, arg.Purpose)
+		if err != local_errors.ErrOrganizationWalletNotFound {
+			return err
+		}
+
+		address, err := newWalletAddress()
+		if err != nil {
+			return err
+		}
+
+		w = tx.createWallet(model.Wallet{
+			WalletAddress: address,
+			Status:        model.WalletStatusACTIVE,
+			Kind:          model.WalletKindORGANIZATION,
+			Purpose:       arg.Purpose,
+			Balance:       0,
+			Currency:      arg.Currency,
+			CreatedAt:     time.Now(),
+		})
+		return nil
+	})
+
+	return w, err
+}
+
+func (q *memoryWalletRepository) GetOrganizationWallet(ctx context.Context, arg OrganizationWalletParams) (model.Wallet, error) {
+
+	logrus.Println("log  GetOrganizationWallet in store/memory_wallet/GetOrganizationWallet ")
+
+	var w model.Wallet
+	var err error
+	q.m.view(func() {
+		w, err = q.m.organizationWallet(arg.Currency, arg.Purpose)
+	})
+
+	return w, err
+}
+
+type memoryLedgerRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryLedgerRepo(m *MemoryStore) LedgerRepo {
+	return &memoryLedgerRepository{
+		m: m,
+	}
+}
+
+// WithTx returns the repository itself, the memory store has no database transactions to join
+func (q *memoryLedgerRepository) WithTx(tx *gorm.DB) LedgerRepo {
+	return q
+}
+
+func (q *memoryLedgerRepository) PostEntry(ctx context.Context, arg PostEntryParams) (PostEntryResult, error) {
+
+	logrus.Println("log  PostEntry in store/memory_wallet/PostEntry ")
+
+	var res PostEntryResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+		var err error
+		res, err = tx.postEntry(ctx, arg)
+		return err
+	})
+
+	return res, err
+}
+
+func (q *memoryLedgerRepository) GetPostingsBalance(ctx context.Context, address string) (int64, error) {
+
+	logrus.Println("log  GetPostingsBalance in store/memory_wallet/GetPostingsBalance ")
+
+	var balance int64
+	q.m.view(func() {
+		balance = q.m.postingsBalance(address)
+	})
+
+	return balance, nil
+}
+
+func (q *memoryLedgerRepository) GetBalanceTotals(ctx context.Context) (map[string]int64, error) {
+
+	logrus.Println("log  GetBalanceTotals in store/memory_wallet/GetBalanceTotals ")
+
+	totals := make(map[string]int64)
+	q.m.view(func() {
+		for _, w := range q.m.wallets {
+			totals[w.Currency] += w.Balance
+		}
+	})
+
+	return totals, nil
+}
+
+func (q *memoryLedgerRepository) RebuildWalletBalance(ctx context.Context, address string) (model.Wallet, error) {
+
+	logrus.Println("log  RebuildWalletBalance in store/memory_wallet/RebuildWalletBalance ")
+
+	var w model.Wallet
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		w, ok = tx.wallets[address]
+		if !ok {
+			return local_errors.ErrWalletNotFound
+		}
+
+		balance := tx.postingsBalance(address)
+		if balance != w.Balance {
+			logrus.Printf("rebuilding balance of wallet %s: cached %d, postings %d", address, w.Balance, balance)
+		}
+
+		w.Balance = balance
+		w.UpdatedAt = time.Now()
+		tx.put(tx.wallets, address, w)
+		return nil
+	})
+
+	return w, err
+}
+
+func (q *memoryLedgerRepository) ListBalanceMismatches(ctx context.Context) ([]BalanceMismatch, error) {
+
+	logrus.Println("log  ListBalanceMismatches in store/memory_wallet/ListBalanceMismatches ")
+
+	var rows []BalanceMismatch
+	q.m.view(func() {
+		sums := make(map[string]int64)
+		counts := make(map[string]int64)
+		for _, p := range q.m.postings {
+			sums[p.WalletAddress] += p.Amount
+			counts[p.WalletAddress]++
+		}
+
+		for address, w := range q.m.wallets {
+			if w.Balance != sums[address] {
+				rows = append(rows, BalanceMismatch{
+					WalletAddress:   address,
+					Currency:        w.Currency,
+					Purpose:         w.Purpose,
+					Balance:         w.Balance,
+					PostingsBalance: sums[address],
+					PostingsCount:   counts[address],
+				})
+			}
+		}
+	})
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].WalletAddress < rows[j].WalletAddress })
+	return rows, nil
+}
+
+func (q *memoryLedgerRepository) OpenWalletBalance(ctx context.Context, address string) (PostEntryResult, error) {
+
+	logrus.Println("log  OpenWalletBalance in store/memory_wallet/OpenWalletBalance ")
+
+	var res PostEntryResult
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		w, ok := tx.wallets[address]
+		if !ok {
+			return local_errors.ErrWalletNotFound
+		}
+		if w.IsOrganization() && w.Purpose == model.OrganizationWalletFLOAT {
+			return fmt.Errorf("the float of %s takes the other side of the opening entries, it has none of its own", w.Currency)
+		}
+
+		float, err := tx.organizationWallet(w.Currency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range tx.postings {
+			if p.WalletAddress == address {
+				return local_errors.ErrWalletHasPostings
+			}
+		}
+
+		res.Wallets = map[string]model.Wallet{address: w, float.WalletAddress: float}
+		if w.Balance == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		res.Entry = model.JournalEntry{
+			ID:          tx.nextID("journal_entries"),
+			Kind:        model.JournalEntryKindOPENING,
+			Description: fmt.Sprintf("opening balance of wallet %s", address),
+			CreatedAt:   now,
+		}
+		tx.put(tx.entries, res.Entry.ID, res.Entry)
+
+		res.Postings = []model.Posting{
+			{JournalEntryID: res.Entry.ID, WalletAddress: address, Currency: w.Currency, Amount: w.Balance, CreatedAt: now},
+			{JournalEntryID: res.Entry.ID, WalletAddress: float.WalletAddress, Currency: w.Currency, Amount: -w.Balance, CreatedAt: now},
+		}
+		for i := range res.Postings {
+			res.Postings[i].ID = tx.nextID("postings")
+			tx.put(tx.postings, res.Postings[i].ID, res.Postings[i])
+		}
+
+		// the wallet already holds its balance, only the float gives it out
+		float.Balance -= w.Balance
+		float.UpdatedAt = now
+		tx.put(tx.wallets, float.WalletAddress, float)
+		res.Wallets[float.WalletAddress] = float
+		return nil
+	})
+
+	return res, err
+}
+
+// postingsBalance sums all postings of a wallet
+func (m *MemoryStore) postingsBalance(address string) int64 {
+	var balance int64
+	for _, p := range m.postings {
+		if p.WalletAddress == address {
+			balance += p.Amount
+		}
+	}
+	return balance
+}
+
+type memoryTransRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryTransRepo(m *MemoryStore) TransRepo {
+	return &memoryTransRepository{
+		m: m,
+	}
+}
+
+// WithTx returns the repository itself, the memory store has no database transactions to join
+func (q *memoryTransRepository) WithTx(tx *gorm.DB) TransRepo {
+	return q
+}
+
+func (q *memoryTransRepository) CreateTransfer(ctx context.Context, arg SendMoneyParams) (model.Trans, error) {
+
+	logrus.Println("log  CreateTransfer in store/memory_wallet/CreateTransfer ")
+
+	var i model.Trans
+
+	err := q.m.update(func(tx *memoryTx) error {
+		i = tx.createTransfer(arg)
+		return nil
+	})
+
+	return i, err
+}
+
+func (q *memoryTransRepository) GetTransfer(ctx context.Context, id int64) (model.Trans, error) {
+
+	logrus.Println("log  GetTransfer in store/memory_wallet/GetTransfer ")
+
+	var i model.Trans
+	var ok bool
+	q.m.view(func() {
+		i, ok = q.m.trans[id]
+	})
+
+	if !ok {
+		return i, local_errors.ErrTransNotFound
+	}
+	return i, nil
+}
+
+// ListTransfers returns the transfers of a wallet, newest first
+func (q *memoryTransRepository) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]model.Trans, error) {
+
+	logrus.Println("log  ListTransfers in store/memory_wallet/ListTransfers ")
+
+	match := func(t model.Trans) bool {
+		switch arg.Direction {
+		case TransDirectionIncoming:
+			if t.ToWalletAdd != arg.WalletAddress {
+				return false
+			}
+		case TransDirectionOutgoing:
+			if t.FromWalletAdd != arg.WalletAddress {
+				return false
+			}
+		default:
+			if t.FromWalletAdd != arg.WalletAddress && t.ToWalletAdd != arg.WalletAddress {
+				return false
+			}
+		}
+
+		if !arg.CreatedFrom.IsZero() && t.CreatedAt.Before(arg.CreatedFrom) {
+			return false
+		}
+		if !arg.CreatedTo.IsZero() && !t.CreatedAt.Before(arg.CreatedTo) {
+			return false
+		}
+		if arg.MinAmount > 0 && t.Amount < arg.MinAmount {
+			return false
+		}
+		if arg.MaxAmount > 0 && t.Amount > arg.MaxAmount {
+			return false
+		}
+		return arg.BeforeID <= 0 || t.ID < arg.BeforeID
+	}
+
+	var transfers []model.Trans
+	q.m.view(func() {
+		for _, t := range q.m.trans {
+			if match(t) {
+				transfers = append(transfers, t)
+			}
+		}
+	})
+	sortByID(transfers, true)
+
+	return transfers[:limitRows(len(transfers), arg.Limit)], nil
+}
+
+type memoryFXQuoteRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryFXQuoteRepo(m *MemoryStore) FXQuoteRepo {
+	return &memoryFXQuoteRepository{
+		m: m,
+	}
+}
+
+// WithTx returns the repository itself, the memory store has no database transactions to join
+func (q *memoryFXQuoteRepository) WithTx(tx *gorm.DB) FXQuoteRepo {
+	return q
+}
+
+func (q *memoryFXQuoteRepository) CreateFXQuote(ctx context.Context, arg CreateFXQuoteParams) (model.FXQuote, error) {
+
+	logrus.Println("log  CreateFXQuote in store/memory_wallet/CreateFXQuote ")
+
+	id, err := newWalletAddress()
+	if err != nil {
+		return model.FXQuote{}, err
+	}
+
+	quote := model.FXQuote{
+		ID:            id,
+		Username:      arg.Username,
+		FromWalletAdd: arg.FromWalletAddress,
+		ToWalletAdd:   arg.ToWalletAddress,
+		FromCurrency:  arg.FromCurrency,
+		ToCurrency:    arg.ToCurrency,
+		Rate:          arg.Rate,
+		FromAmount:    arg.FromAmount,
+		ToAmount:      arg.ToAmount,
+		ExpiresAt:     arg.ExpiresAt,
+		CreatedAt:     time.Now(),
+	}
+
+	err = q.m.update(func(tx *memoryTx) error {
+		tx.put(tx.fxQuotes, quote.ID, quote)
+		return nil
+	})
+
+	return quote, err
+}
+
+func (q *memoryFXQuoteRepository) GetFXQuote(ctx context.Context, id string) (model.FXQuote, error) {
+
+	logrus.Println("log  GetFXQuote in store/memory_wallet/GetFXQuote ")
+
+	var quote model.FXQuote
+	var ok bool
+	q.m.view(func() {
+		quote, ok = q.m.fxQuotes[id]
+	})
+
+	if !ok {
+		return quote, local_errors.ErrFXQuoteNotFound
+	}
+	return quote, nil
+}
+
+func (q *memoryFXQuoteRepository) UseFXQuote(ctx context.Context, id string, transID int64, now time.Time) error {
+
+	logrus.Println("log  UseFXQuote in store/memory_wallet/UseFXQuote ")
+
+	return q.m.update(func(tx *memoryTx) error {
+		return tx.useFXQuote(id, transID, now)
+	})
+}
+
+// useFXQuote links an unused, unexpired quote to the transfer made with it
+func (tx *memoryTx) useFXQuote(id string, transID int64, now time.Time) error {
+
+	quote, ok := tx.fxQuotes[id]
+	if !ok {
+		return local_errors.ErrFXQuoteNotFound
+	}
+	if quote.IsUsed() {
+		return local_errors.ErrFXQuoteUsed
+	}
+	if !quote.ExpiresAt.After(now) {
+		return local_errors.ErrFXQuoteExpired
+	}
+
+	quote.TransID = transID
+	tx.put(tx.fxQuotes, id, quote)
+	return nil
+}
+
+type memoryHoldRepository struct {
+	m *MemoryStore
+}
+
+func NewMemoryHoldRepo(m *MemoryStore) HoldRepo {
+	return &memoryHoldRepository{
+		m: m,
+	}
+}
+
+func (q *memoryHoldRepository) CreateHold(ctx context.Context, arg CreateHoldParams) (model.Hold, error) {
+
+	logrus.Println("log  CreateHold in store/memory_wallet/CreateHold ")
+
+	var h model.Hold
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		w, ok := tx.wallets[arg.WalletAddress]
+		if !ok {
+			return local_errors.ErrWalletNotFound
+		}
+
+		if w.Status != model.WalletStatusACTIVE {
+			return local_errors.ErrWalletInactive
+		}
+		if !w.IsBalanceSufficient(arg.Amount) {
+			return local_errors.ErrInsufficientBalance
+		}
+
+		now := time.Now()
+		h = model.Hold{
+			ID:            tx.nextID("holds"),
+			WalletAddress: arg.WalletAddress,
+			ToWalletAdd:   arg.ToWalletAddress,
+			Username:      arg.Username,
+			Amount:        arg.Amount,
+			Currency:      w.Currency,
+			Description:   arg.Description,
+			Status:        model.HoldStatusACTIVE,
+			ExpiresAt:     arg.ExpiresAt,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		tx.put(tx.holds, h.ID, h)
+
+		return tx.addHeldBalance(arg.WalletAddress, arg.Amount)
+	})
+
+	return h, err
+}
+
+func (q *memoryHoldRepository) GetHold(ctx context.Context, id int64) (model.Hold, error) {
+
+	logrus.Println("log  GetHold in store/memory_wallet/GetHold ")
+
+	var h model.Hold
+	var ok bool
+	q.m.view(func() {
+		h, ok = q.m.holds[id]
+	})
+
+	if !ok {
+		return h, local_errors.ErrHoldNotFound
+	}
+	return h, nil
+}
+
+func (q *memoryHoldRepository) VoidHold(ctx context.Context, id int64) (model.Hold, error) {
+
+	logrus.Println("log  VoidHold in store/memory_wallet/VoidHold ")
+
+	var h model.Hold
+
+	err := q.m.update(func(tx *memoryTx) error {
+
+		var ok bool
+		h, ok = tx.holds[id]
+		if !ok {
+			return local_errors.ErrHoldNotFound
+		}
+		if h.Status != model.HoldStatusACTIVE {
+			return local_errors.ErrHoldNotActive
+		}
+
+		var err error
+		h, err = tx.releaseHold(h, model.HoldStatusVOIDED)
+		return err
+	})
+
+	return h, err
+}
+
+func (q *memoryHoldRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int64, error) {
+
+	logrus.Println("log  ReleaseExpiredHolds in store/memory_wallet/ReleaseExpiredHolds ")
+
+	var released int64
+
+	err := q.m.update(func(tx *memoryTx) error {
+		for _, h := range tx.holds {
+			if h.Status != model.HoldStatusACTIVE || h.ExpiresAt.After(now) {
+				continue
+			}
+			if _, err := tx.releaseHold(h, model.HoldStatusEXPIRED); err != nil {
+				return err
+			}
+			released++
+		}
+		return nil
+	})
+	if err != nil {
+		released = 0
+	}
+
+	return released, err
+}
@@ -5,9 +5,11 @@ package mocks
 import (
 	context "context"
 
-	model "github.com/dsthakur2711/wallet/model"
+	gorm "github.com/jinzhu/gorm"
 	mock "github.com/stretchr/testify/mock"
 
+	model "github.com/dsthakur2711/wallet/model"
+
 	store "github.com/dsthakur2711/wallet/store"
 )
 
@@ -17,18 +19,62 @@ type TransRepo struct {
 }
 
 // CreateTransfer provides a mock function with given fields: ctx, arg
-func (_m *TransRepo) CreateTransfer(ctx context.Context, arg store.CreateTransferParams) (model.Trans, error) {
+func (_m *TransRepo) CreateTransfer(ctx context.Context, arg store.SendMoneyParams) (model.Trans, error) {
 	ret := _m.Called(ctx, arg)
 
 	var r0 model.Trans
-	if rf, ok := ret.Get(0).(func(context.Context, store.CreateTransferParams) model.Trans); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, store.SendMoneyParams) model.Trans); ok {
 		r0 = rf(ctx, arg)
 	} else {
 		r0 = ret.Get(0).(model.Trans)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, store.CreateTransferParams) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, store.SendMoneyParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTransfer provides a mock function with given fields: ctx, id
+func (_m *TransRepo) GetTransfer(ctx context.Context, id int64) (model.Trans, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 model.Trans
+	if rf, ok := ret.Get(0).(func(context.Context, int64) model.Trans); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(model.Trans)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTransfers provides a mock function with given fields: ctx, arg
+func (_m *TransRepo) ListTransfers(ctx context.Context, arg store.ListTransfersParams) ([]model.Trans, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 []model.Trans
+	if rf, ok := ret.Get(0).(func(context.Context, store.ListTransfersParams) []model.Trans); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Trans)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.ListTransfersParams) error); ok {
 		r1 = rf(ctx, arg)
 	} else {
 		r1 = ret.Error(1)
@@ -36,3 +82,19 @@ func (_m *TransRepo) CreateTransfer(ctx context.Context, arg store.CreateTransfe
 
 	return r0, r1
 }
+
+// WithTx provides a mock function with given fields: tx
+func (_m *TransRepo) WithTx(tx *gorm.DB) store.TransRepo {
+	ret := _m.Called(tx)
+
+	var r0 store.TransRepo
+	if rf, ok := ret.Get(0).(func(*gorm.DB) store.TransRepo); ok {
+		r0 = rf(tx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.TransRepo)
+		}
+	}
+
+	return r0
+}
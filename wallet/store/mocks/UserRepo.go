@@ -57,3 +57,24 @@ func (_m *UserRepo) GetUserByUsername(ctx context.Context, username string) (mod
 
 	return r0, r1
 }
+
+// UpdateUserStatus provides a mock function with given fields: ctx, arg
+func (_m *UserRepo) UpdateUserStatus(ctx context.Context, arg store.UpdateUserStatusParams) (model.User, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 model.User
+	if rf, ok := ret.Get(0).(func(context.Context, store.UpdateUserStatusParams) model.User); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.UpdateUserStatusParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -16,6 +16,48 @@ type WalletRepo struct {
 	mock.Mock
 }
 
+// AddWalletBalance provides a mock function with given fields: ctx, params
+func (_m *WalletRepo) AddWalletBalance(ctx context.Context, params store.AddWalletBalanceParams) (model.Wallet, error) {
+	ret := _m.Called(ctx, params)
+
+	var r0 model.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, store.AddWalletBalanceParams) model.Wallet); ok {
+		r0 = rf(ctx, params)
+	} else {
+		r0 = ret.Get(0).(model.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.AddWalletBalanceParams) error); ok {
+		r1 = rf(ctx, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CaptureHold provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) CaptureHold(ctx context.Context, arg store.CaptureHoldParams) (store.WalletTransferResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 store.WalletTransferResult
+	if rf, ok := ret.Get(0).(func(context.Context, store.CaptureHoldParams) store.WalletTransferResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(store.WalletTransferResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.CaptureHoldParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateWallet provides a mock function with given fields: ctx, arg
 func (_m *WalletRepo) CreateWallet(ctx context.Context, arg store.CreateWalletParams) (model.Wallet, error) {
 	ret := _m.Called(ctx, arg)
@@ -37,6 +79,48 @@ func (_m *WalletRepo) CreateWallet(ctx context.Context, arg store.CreateWalletPa
 	return r0, r1
 }
 
+// EnsureOrganizationWallet provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) EnsureOrganizationWallet(ctx context.Context, arg store.OrganizationWalletParams) (model.Wallet, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 model.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, store.OrganizationWalletParams) model.Wallet); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(model.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.OrganizationWalletParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrganizationWallet provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) GetOrganizationWallet(ctx context.Context, arg store.OrganizationWalletParams) (model.Wallet, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 model.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, store.OrganizationWalletParams) model.Wallet); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(model.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.OrganizationWalletParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetWalletByAddress provides a mock function with given fields: ctx, address
 func (_m *WalletRepo) GetWalletByAddress(ctx context.Context, address string) (model.Wallet, error) {
 	ret := _m.Called(ctx, address)
@@ -79,6 +163,113 @@ func (_m *WalletRepo) GetWalletByUsername(ctx context.Context, username string)
 	return r0, r1
 }
 
+// GetWalletByUsernameAndCurrency provides a mock function with given fields: ctx, username, currency
+func (_m *WalletRepo) GetWalletByUsernameAndCurrency(ctx context.Context, username string, currency string) (model.Wallet, error) {
+	ret := _m.Called(ctx, username, currency)
+
+	var r0 model.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.Wallet); ok {
+		r0 = rf(ctx, username, currency)
+	} else {
+		r0 = ret.Get(0).(model.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, currency)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWalletsByUsername provides a mock function with given fields: ctx, username
+func (_m *WalletRepo) ListWalletsByUsername(ctx context.Context, username string) ([]model.Wallet, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 []model.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Wallet); ok {
+		r0 = rf(ctx, username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Wallet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PayPaymentRequest provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) PayPaymentRequest(ctx context.Context, arg store.PayPaymentRequestParams) (store.WalletTransferResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 store.WalletTransferResult
+	if rf, ok := ret.Get(0).(func(context.Context, store.PayPaymentRequestParams) store.WalletTransferResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(store.WalletTransferResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.PayPaymentRequestParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefundTransfer provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) RefundTransfer(ctx context.Context, arg store.RefundTransferParams) (store.WalletTransferResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 store.WalletTransferResult
+	if rf, ok := ret.Get(0).(func(context.Context, store.RefundTransferParams) store.WalletTransferResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(store.WalletTransferResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.RefundTransferParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReverseTransfer provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) ReverseTransfer(ctx context.Context, arg store.ReverseTransferParams) (store.WalletTransferResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 store.WalletTransferResult
+	if rf, ok := ret.Get(0).(func(context.Context, store.ReverseTransferParams) store.WalletTransferResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(store.WalletTransferResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.ReverseTransferParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SendMoney provides a mock function with given fields: ctx, arg
 func (_m *WalletRepo) SendMoney(ctx context.Context, arg store.SendMoneyParams) (store.WalletTransferResult, error) {
 	ret := _m.Called(ctx, arg)
@@ -100,6 +291,48 @@ func (_m *WalletRepo) SendMoney(ctx context.Context, arg store.SendMoneyParams)
 	return r0, r1
 }
 
+// SendMoneyFX provides a mock function with given fields: ctx, arg
+func (_m *WalletRepo) SendMoneyFX(ctx context.Context, arg store.SendMoneyFXParams) (store.WalletTransferResult, error) {
+	ret := _m.Called(ctx, arg)
+
+	var r0 store.WalletTransferResult
+	if rf, ok := ret.Get(0).(func(context.Context, store.SendMoneyFXParams) store.WalletTransferResult); ok {
+		r0 = rf(ctx, arg)
+	} else {
+		r0 = ret.Get(0).(store.WalletTransferResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, store.SendMoneyFXParams) error); ok {
+		r1 = rf(ctx, arg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPrimaryWallet provides a mock function with given fields: ctx, username, address
+func (_m *WalletRepo) SetPrimaryWallet(ctx context.Context, username string, address string) (model.Wallet, error) {
+	ret := _m.Called(ctx, username, address)
+
+	var r0 model.Wallet
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) model.Wallet); ok {
+		r0 = rf(ctx, username, address)
+	} else {
+		r0 = ret.Get(0).(model.Wallet)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, username, address)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateWalletStatus provides a mock function with given fields: ctx, arg
 func (_m *WalletRepo) UpdateWalletStatus(ctx context.Context, arg store.UpdateWalletStatusParams) (model.Wallet, error) {
 	ret := _m.Called(ctx, arg)
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/jinzhu/gorm"
+	"github.com/nu7hatch/gouuid"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"time"
+)
+
+type OutboxRepo interface {
+	// ListPendingEvents returns the oldest events that are neither published nor dead yet, in the order they were written
+	ListPendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkEventPublished(ctx context.Context, id int64, at time.Time) error
+	// MarkEventFailed records a failed attempt to publish the event, it stays pending unless dead is set
+	MarkEventFailed(ctx context.Context, id int64, reason string, dead bool) error
+	// ListEvents returns the events after AfterID in the order of their ids
+	ListEvents(ctx context.Context, arg ListEventsParams) ([]model.OutboxEvent, error)
+	// LastEventID returns the id of the latest event, zero when there is none
+	LastEventID(ctx context.Context) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(client *gorm.DB) OutboxRepo {
+	return &outboxRepository{
+		db: client,
+	}
+}
+
+func (q *outboxRepository) ListPendingEvents(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+
+	logrus.Println("log  ListPendingEvents in store/outbox/ListPendingEvents ")
+
+	var events []model.OutboxEvent
+	res := q.db.Where("published = ? AND dead = ?", false, false).Order("id").Limit(limit).Find(&events)
+
+	return events, res.Error
+}
+
+func (q *outboxRepository) MarkEventPublished(ctx context.Context, id int64, at time.Time) error {
+
+	logrus.Println("log  MarkEventPublished in store/outbox/MarkEventPublished ")
+
+	res := q.db.Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"published":    true,
+		"published_at": at,
+		"attempts":     gorm.Expr("attempts + 1"),
+		"last_error":   "",
+	})
+
+	return res.Error
+}
+
+func (q *outboxRepository) MarkEventFailed(ctx context.Context, id int64, reason string, dead bool) error {
+
+	logrus.Println("log  MarkEventFailed in store/outbox/MarkEventFailed ")
+
+	res := q.db.Model(&model.OutboxEvent{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": reason,
+		"dead":       dead,
+	})
+
+	return res.Error
+}
+
+type ListEventsParams struct {
+	// WalletAddress keeps the events about one wallet, all events are returned when it is empty
+	WalletAddress string `json:"wallet_address"`
+	AfterID       int64  `json:"after_id"`
+	// Since only keeps the events written since then, when it is set
+	Since time.Time `json:"since"`
+	Limit int       `json:"limit"`
+}
+
+func (q *outboxRepository) ListEvents(ctx context.Context, arg ListEventsParams) ([]model.OutboxEvent, error) {
+
+	logrus.Println("log  ListEvents in store/outbox/ListEvents ")
+
+	db := q.db
+	if arg.WalletAddress != "" {
+		db = db.Where("wallet_address = ? OR to_wallet_address = ?", arg.WalletAddress, arg.WalletAddress)
+	}
+	db = db.Where("id > ?", arg.AfterID)
+	if !arg.Since.IsZero() {
+		db = db.Where("occurred_at >= ?", arg.Since)
+	}
+
+	var events []model.OutboxEvent
+	res := db.Order("id").Limit(arg.Limit).Find(&events)
+
+	return events, res.Error
+}
+
+func (q *outboxRepository) LastEventID(ctx context.Context) (int64, error) {
+
+	logrus.Println("log  LastEventID in store/outbox/LastEventID ")
+
+	var ids []int64
+	res := q.db.Model(&model.OutboxEvent{}).Order("id DESC").Limit(1).Pluck("id", &ids)
+	if res.Error != nil || len(ids) == 0 {
+		return 0, res.Error
+	}
+
+	return ids[0], nil
+}
+
+// newOutboxEvent builds an event about the aggregate and the wallets it touches
+func newOutboxEvent(eventType model.EventType, aggregateType string, aggregateID string, wallets [2]string, payload interface{}) (model.OutboxEvent, error) {
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.OutboxEvent{}, err
+	}
+
+	eventID, err := uuid.NewV4()
+	if err != nil {
+		return model.OutboxEvent{}, err
+	}
+
+	return model.OutboxEvent{
+		EventID:         eventID.String(),
+		Type:            eventType,
+		AggregateType:   aggregateType,
+		AggregateID:     aggregateID,
+		WalletAddress:   wallets[0],
+		ToWalletAddress: wallets[1],
+		Payload:         string(data),
+		OccurredAt:      time.Now(),
+	}, nil
+}
+
+func walletEvent(eventType model.EventType, w model.Wallet, amount int64) (model.OutboxEvent, error) {
+	return newOutboxEvent(eventType, model.AggregateTypeWALLET, w.WalletAddress, [2]string{w.WalletAddress},
+		model.NewWalletEvent(w, amount))
+}
+
+func transferEvent(eventType model.EventType, t model.Trans, entry PostEntryResult) (model.OutboxEvent, error) {
+	return newOutboxEvent(eventType, model.AggregateTypeTRANS, strconv.FormatInt(t.ID, 10), [2]string{t.FromWalletAdd, t.ToWalletAdd},
+		model.NewTransferEvent(t, entry.Postings, entry.Wallets))
+}
+
+// emitWalletEvent writes an event to the outbox inside the transaction tx, it is published only if tx commits
+func emitWalletEvent(tx *gorm.DB, eventType model.EventType, w model.Wallet, amount int64) error {
+	event, err := walletEvent(eventType, w, amount)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&event).Error
+}
+
+func emitTransferEvent(tx *gorm.DB, eventType model.EventType, t model.Trans, entry PostEntryResult) error {
+	event, err := transferEvent(eventType, t, entry)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&event).Error
+}
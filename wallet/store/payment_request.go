@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type PaymentRequestRepo interface {
+	CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (model.PaymentRequest, error)
+	GetPaymentRequest(ctx context.Context, id int64) (model.PaymentRequest, error)
+	ListPaymentRequestsByPayer(ctx context.Context, payerUsername string, status model.PaymentRequestStatus) ([]model.PaymentRequest, error)
+	UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (model.PaymentRequest, error)
+}
+
+type paymentRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRequestRepo(client *gorm.DB) PaymentRequestRepo {
+	return &paymentRequestRepository{
+		db: client,
+	}
+}
+
+type CreatePaymentRequestParams struct {
+	FromWalletAddress string `json:"from_wallet_address"`
+	ToWalletAddress   string `json:"to_wallet_address"`
+	PayerUsername     string `json:"payer_username"`
+	PayeeUsername     string `json:"payee_username"`
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency"`
+	Note              string `json:"note"`
+}
+
+func (q *paymentRequestRepository) CreatePaymentRequest(ctx context.Context, arg CreatePaymentRequestParams) (model.PaymentRequest, error) {
+
+	logrus.Println("log  CreatePaymentRequest in store/payment_request/CreatePaymentRequest ")
+
+	now := time.Now()
+	p := model.PaymentRequest{
+		FromWalletAdd: arg.FromWalletAddress,
+		ToWalletAdd:   arg.ToWalletAddress,
+		PayerUsername: arg.PayerUsername,
+		PayeeUsername: arg.PayeeUsername,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Note:          arg.Note,
+		Status:        model.PaymentRequestStatusWAITINGAPPROVAL,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	res := q.db.Create(&p)
+
+	return p, res.Error
+}
+
+func (q *paymentRequestRepository) GetPaymentRequest(ctx context.Context, id int64) (model.PaymentRequest, error) {
+
+	logrus.Println("log  GetPaymentRequest in store/payment_request/GetPaymentRequest ")
+
+	var p model.PaymentRequest
+	res := q.db.Where("id = ?", id).Take(&p)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return p, local_errors.ErrPaymentRequestNotFound
+	}
+
+	return p, res.Error
+}
+
+func (q *paymentRequestRepository) ListPaymentRequestsByPayer(ctx context.Context, payerUsername string, status model.PaymentRequestStatus) ([]model.PaymentRequest, error) {
+
+	logrus.Println("log  ListPaymentRequestsByPayer in store/payment_request/ListPaymentRequestsByPayer ")
+
+	var requests []model.PaymentRequest
+	res := q.db.Where("payer_username = ? AND status = ?", payerUsername, status).
+		Order("created_at DESC").
+		Find(&requests)
+
+	return requests, res.Error
+}
+
+// lockPaymentRequest loads a payment request with SELECT ... FOR UPDATE, the request is locked before its wallets
+func lockPaymentRequest(tx *gorm.DB, id int64) (model.PaymentRequest, error) {
+
+	var p model.PaymentRequest
+	res := forUpdate(tx).Where("id = ?", id).Take(&p)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return p, local_errors.ErrPaymentRequestNotFound
+	}
+
+	return p, res.Error
+}
+
+type UpdatePaymentRequestStatusParams struct {
+	ID            int64                      `json:"id"`
+	FromStatus    model.PaymentRequestStatus `json:"from_status"`
+	ToStatus      model.PaymentRequestStatus `json:"to_status"`
+	TransID       int64                      `json:"trans_id"`
+	FailureReason string                     `json:"failure_reason"`
+}
+
+// UpdatePaymentRequestStatus only moves requests still in FromStatus, so two concurrent
+// transitions of the same request can not both succeed
+func (q *paymentRequestRepository) UpdatePaymentRequestStatus(ctx context.Context, arg UpdatePaymentRequestStatusParams) (model.PaymentRequest, error) {
+
+	logrus.Println("log  UpdatePaymentRequestStatus in store/payment_request/UpdatePaymentRequestStatus ")
+
+	res := q.db.Model(&model.PaymentRequest{}).
+		Where("id = ? AND status = ?", arg.ID, arg.FromStatus).
+		Updates(map[string]interface{}{
+			"status":         arg.ToStatus,
+			"trans_id":       arg.TransID,
+			"failure_reason": arg.FailureReason,
+			"updated_at":     time.Now(),
+		})
+	if res.Error != nil {
+		return model.PaymentRequest{}, res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return model.PaymentRequest{}, local_errors.ErrInvalidPaymentRequestTransition
+	}
+
+	return q.GetPaymentRequest(ctx, arg.ID)
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/dsthakur2711/wallet/recurrence"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type ScheduledPaymentRepo interface {
+	CreateScheduledPayment(ctx context.Context, arg CreateScheduledPaymentParams) (model.ScheduledPayment, error)
+	GetScheduledPayment(ctx context.Context, id int64) (model.ScheduledPayment, error)
+	ListScheduledPayments(ctx context.Context, username string) ([]model.ScheduledPayment, error)
+	CancelScheduledPayment(ctx context.Context, id int64) (model.ScheduledPayment, error)
+	// ListDueScheduledPayments returns the ids of the active schedules whose next run is due, oldest first
+	ListDueScheduledPayments(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	// ClaimScheduledPayment records a pending run of a due schedule and moves the schedule on,
+	// it returns ErrScheduledPaymentNotDue when the schedule was changed since it was listed
+	ClaimScheduledPayment(ctx context.Context, id int64, now time.Time) (model.ScheduledPaymentRun, error)
+	// FinishScheduledPaymentRun records the outcome of a claimed run
+	FinishScheduledPaymentRun(ctx context.Context, arg FinishScheduledPaymentRunParams) (model.ScheduledPaymentRun, error)
+	ListScheduledPaymentRuns(ctx context.Context, scheduledPaymentID int64) ([]model.ScheduledPaymentRun, error)
+}
+
+type scheduledPaymentRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledPaymentRepo(client *gorm.DB) ScheduledPaymentRepo {
+	return &scheduledPaymentRepository{
+		db: client,
+	}
+}
+
+type CreateScheduledPaymentParams struct {
+	Username          string               `json:"username"`
+	FromWalletAddress string               `json:"from_wallet_address"`
+	ToWalletAddress   string               `json:"to_wallet_address"`
+	ToUsername        string               `json:"to_username"`
+	Amount            int64                `json:"amount"`
+	Currency          string               `json:"currency"`
+	Note              string               `json:"note"`
+	Frequency         recurrence.Frequency `json:"frequency"`
+	Interval          int                  `json:"interval"`
+	Cron              string               `json:"cron"`
+	StartAt           time.Time            `json:"start_at"`
+	EndAt             time.Time            `json:"end_at"`
+	MaxRuns           int64                `json:"max_runs"`
+	// FirstRunAt is the first occurrence of the recurrence
+	FirstRunAt time.Time `json:"first_run_at"`
+}
+
+func (q *scheduledPaymentRepository) CreateScheduledPayment(ctx context.Context, arg CreateScheduledPaymentParams) (model.ScheduledPayment, error) {
+
+	logrus.Println("log  CreateScheduledPayment in store/scheduled_payment/CreateScheduledPayment ")
+
+	now := time.Now()
+	s := model.ScheduledPayment{
+		Username:      arg.Username,
+		FromWalletAdd: arg.FromWalletAddress,
+		ToWalletAdd:   arg.ToWalletAddress,
+		ToUsername:    arg.ToUsername,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Note:          arg.Note,
+		Frequency:     arg.Frequency,
+		Interval:      arg.Interval,
+		Cron:          arg.Cron,
+		StartAt:       arg.StartAt,
+		EndAt:         arg.EndAt,
+		MaxRuns:       arg.MaxRuns,
+		Status:        model.ScheduledPaymentStatusACTIVE,
+		OccurrenceAt:  arg.FirstRunAt,
+		NextRunAt:     arg.FirstRunAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	res := q.db.Create(&s)
+
+	return s, res.Error
+}
+
+func (q *scheduledPaymentRepository) GetScheduledPayment(ctx context.Context, id int64) (model.ScheduledPayment, error) {
+
+	logrus.Println("log  GetScheduledPayment in store/scheduled_payment/GetScheduledPayment ")
+
+	var s model.ScheduledPayment
+	res := q.db.Where("id = ?", id).Take(&s)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return s, local_errors.ErrScheduledPaymentNotFound
+	}
+
+	return s, res.Error
+}
+
+func (q *scheduledPaymentRepository) ListScheduledPayments(ctx context.Context, username string) ([]model.ScheduledPayment, error) {
+
+	logrus.Println("log  ListScheduledPayments in store/scheduled_payment/ListScheduledPayments ")
+
+	var schedules []model.ScheduledPayment
+	res := q.db.Where("username = ?", username).Order("id DESC").Find(&schedules)
+
+	return schedules, res.Error
+}
+
+func (q *scheduledPaymentRepository) CancelScheduledPayment(ctx context.Context, id int64) (model.ScheduledPayment, error) {
+
+	logrus.Println("log  CancelScheduledPayment in store/scheduled_payment/CancelScheduledPayment ")
+
+	res := q.db.Model(&model.ScheduledPayment{}).
+		Where("id = ? AND status = ?", id, model.ScheduledPaymentStatusACTIVE).
+		Updates(map[string]interface{}{
+			"status":     model.ScheduledPaymentStatusCANCELLED,
+			"updated_at": time.Now(),
+		})
+	if res.Error != nil {
+		return model.ScheduledPayment{}, res.Error
+	}
+
+	s, err := q.GetScheduledPayment(ctx, id)
+	if err != nil {
+		return s, err
+	}
+
+	if res.RowsAffected == 0 {
+		return s, local_errors.ErrScheduledPaymentNotActive
+	}
+	return s, nil
+}
+
+func (q *scheduledPaymentRepository) ListDueScheduledPayments(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+
+	logrus.Println("log  ListDueScheduledPayments in store/scheduled_payment/ListDueScheduledPayments ")
+
+	var ids []int64
+	res := q.db.Model(&model.ScheduledPayment{}).
+		Where("status = ? AND next_run_at <= ?", model.ScheduledPaymentStatusACTIVE, now).
+		Order("next_run_at").
+		Limit(limit).
+		Pluck("id", &ids)
+
+	return ids, res.Error
+}
+
+func (q *scheduledPaymentRepository) ClaimScheduledPayment(ctx context.Context, id int64, now time.Time) (model.ScheduledPaymentRun, error) {
+
+	logrus.Println("log  ClaimScheduledPayment in store/scheduled_payment/ClaimScheduledPayment ")
+
+	var run model.ScheduledPaymentRun
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		s, err := lockScheduledPayment(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !s.IsDue(now) {
+			return local_errors.ErrScheduledPaymentNotDue
+		}
+
+		run = s.Claim(now)
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+
+		return saveScheduledPayment(tx, s, now)
+	})
+
+	return run, err
+}
+
+type FinishScheduledPaymentRunParams struct {
+	RunID   int64  `json:"run_id"`
+	TransID int64  `json:"trans_id"`
+	Error   string `json:"error"`
+	// RetryAt is set when a failed occurrence is tried again
+	RetryAt time.Time `json:"retry_at"`
+}
+
+func (q *scheduledPaymentRepository) FinishScheduledPaymentRun(ctx context.Context, arg FinishScheduledPaymentRunParams) (model.ScheduledPaymentRun, error) {
+
+	logrus.Println("log  FinishScheduledPaymentRun in store/scheduled_payment/FinishScheduledPaymentRun ")
+
+	var run model.ScheduledPaymentRun
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		if err := tx.Where("id = ?", arg.RunID).Take(&run).Error; err != nil {
+			return err
+		}
+
+		s, err := lockScheduledPayment(tx, run.ScheduledPaymentID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch {
+		case arg.Error == "":
+			run.Status = model.ScheduledPaymentRunStatusSUCCEEDED
+		case !arg.RetryAt.IsZero() && s.Status != model.ScheduledPaymentStatusCANCELLED:
+			run.Status = model.ScheduledPaymentRunStatusRETRYING
+			s.Retry(run, arg.RetryAt)
+		default:
+			run.Status = model.ScheduledPaymentRunStatusFAILED
+			if s.Frequency == recurrence.FrequencyONCE && s.Status == model.ScheduledPaymentStatusCOMPLETED {
+				s.Status = model.ScheduledPaymentStatusFAILED
+			}
+		}
+		run.TransID = arg.TransID
+		run.Error = arg.Error
+		run.UpdatedAt = now
+		s.LastError = arg.Error
+
+		res := tx.Model(&model.ScheduledPaymentRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+			"status":     run.Status,
+			"trans_id":   run.TransID,
+			"error":      run.Error,
+			"updated_at": run.UpdatedAt,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		return saveScheduledPayment(tx, s, now)
+	})
+
+	return run, err
+}
+
+func (q *scheduledPaymentRepository) ListScheduledPaymentRuns(ctx context.Context, scheduledPaymentID int64) ([]model.ScheduledPaymentRun, error) {
+
+	logrus.Println("log  ListScheduledPaymentRuns in store/scheduled_payment/ListScheduledPaymentRuns ")
+
+	var runs []model.ScheduledPaymentRun
+	res := q.db.Where("scheduled_payment_id = ?", scheduledPaymentID).Order("id DESC").Find(&runs)
+
+	return runs, res.Error
+}
+
+// lockScheduledPayment loads a schedule with SELECT ... FOR UPDATE
+func lockScheduledPayment(tx *gorm.DB, id int64) (model.ScheduledPayment, error) {
+
+	var s model.ScheduledPayment
+	res := forUpdate(tx).Where("id = ?", id).Take(&s)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return s, local_errors.ErrScheduledPaymentNotFound
+	}
+
+	return s, res.Error
+}
+
+// saveScheduledPayment writes the fields the scheduler moves along
+func saveScheduledPayment(tx *gorm.DB, s model.ScheduledPayment, now time.Time) error {
+
+	res := tx.Model(&model.ScheduledPayment{}).Where("id = ?", s.ID).Updates(map[string]interface{}{
+		"status":        s.Status,
+		"run_count":     s.RunCount,
+		"occurrence_at": s.OccurrenceAt,
+		"next_run_at":   s.NextRunAt,
+		"attempts":      s.Attempts,
+		"last_error":    s.LastError,
+		"updated_at":    now,
+	})
+
+	return res.Error
+}
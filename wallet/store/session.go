@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+type SessionRepo interface {
+	CreateSession(ctx context.Context, arg CreateSessionParams) (model.Session, error)
+	GetSession(ctx context.Context, id string) (model.Session, error)
+	BlockSession(ctx context.Context, id string) error
+	BlockUserSessions(ctx context.Context, username string) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepo(client *gorm.DB) SessionRepo {
+	return &sessionRepository{
+		db: client,
+	}
+}
+
+type CreateSessionParams struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *sessionRepository) CreateSession(ctx context.Context, arg CreateSessionParams) (model.Session, error) {
+
+	logrus.Println("log  CreateSession in store/session/CreateSession ")
+
+	s := model.Session{
+		ID:           arg.ID,
+		Username:     arg.Username,
+		RefreshToken: arg.RefreshToken,
+		UserAgent:    arg.UserAgent,
+		ClientIp:     arg.ClientIp,
+		IsBlocked:    false,
+		ExpiresAt:    arg.ExpiresAt,
+		CreatedAt:    time.Now(),
+	}
+	res := q.db.Create(&s)
+
+	if res.Error != nil {
+		return s, res.Error
+	}
+
+	return s, nil
+}
+
+func (q *sessionRepository) GetSession(ctx context.Context, id string) (model.Session, error) {
+
+	logrus.Println("log  GetSession in store/session/GetSession ")
+
+	var s model.Session
+	res := q.db.Where("id = ?", id).Take(&s)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return s, local_errors.ErrSessionNotFound
+	}
+
+	return s, res.Error
+}
+
+func (q *sessionRepository) BlockSession(ctx context.Context, id string) error {
+
+	logrus.Println("log  BlockSession in store/session/BlockSession ")
+
+	res := q.db.Model(&model.Session{}).Where("id = ?", id).Update("is_blocked", true)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		return local_errors.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (q *sessionRepository) BlockUserSessions(ctx context.Context, username string) error {
+
+	logrus.Println("log  BlockUserSessions in store/session/BlockUserSessions ")
+
+	res := q.db.Model(&model.Session{}).
+		Where("username = ? AND is_blocked = ?", username, false).
+		Update("is_blocked", true)
+
+	return res.Error
+}
@@ -2,8 +2,10 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
 	"github.com/jinzhu/gorm"
 	"github.com/sirupsen/logrus"
 	"time"
@@ -11,6 +13,10 @@ import (
 
 type TransRepo interface {
 	CreateTransfer(ctx context.Context, arg SendMoneyParams) (model.Trans, error)
+	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]model.Trans, error)
+	GetTransfer(ctx context.Context, id int64) (model.Trans, error)
+	// WithTx returns a TransRepo bound to the given transaction
+	WithTx(tx *gorm.DB) TransRepo
 }
 
 type transRepository struct {
@@ -23,6 +29,12 @@ func NewTransRepo(client *gorm.DB) TransRepo {
 	}
 }
 
+func (q *transRepository) WithTx(tx *gorm.DB) TransRepo {
+	return &transRepository{
+		db: tx,
+	}
+}
+
 type CreateTransferParams struct {
 	FromWalletAddress string `json:"from_wallet_address"`
 	ToWalletAddress   string `json:"to_wallet_address"`
@@ -34,11 +46,24 @@ func (q *transRepository) CreateTransfer(ctx context.Context, arg SendMoneyParam
 	logrus.Println("log  CreateTransfer in store/trans/CreateTransfer ")
 
 	// STORE ENTRY FOR TRANSFER
+	kind := arg.Kind
+	if kind == "" {
+		kind = model.TransKindTRANSFER
+	}
+
 	var i model.Trans
 	i = model.Trans{
 		FromWalletAdd: arg.FromWalletAddress,
 		ToWalletAdd: arg.ToWalletAddress,
 		Amount: arg.Amount,
+		Fee: arg.Fee,
+		NetAmount: arg.Amount - arg.Fee,
+		Currency: arg.Currency,
+		Rate: arg.Rate,
+		ToAmount: arg.ToAmount,
+		ToCurrency: arg.ToCurrency,
+		Kind: kind,
+		OriginalTransID: arg.OriginalTransID,
 	 	CreatedAt: time.Now(),
 	}
 	res := q.db.Create(&i) // pass pointer of data to Create
@@ -48,4 +73,90 @@ func (q *transRepository) CreateTransfer(ctx context.Context, arg SendMoneyParam
 	}
 
 	return i, nil
-}
\ No newline at end of file
+}
+
+func (q *transRepository) GetTransfer(ctx context.Context, id int64) (model.Trans, error) {
+
+	logrus.Println("log  GetTransfer in store/trans/GetTransfer ")
+
+	var i model.Trans
+	res := q.db.Where("id = ?", id).Take(&i)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return i, local_errors.ErrTransNotFound
+	}
+
+	return i, res.Error
+}
+
+// lockTransfer loads a transfer with SELECT ... FOR UPDATE, refunds and reversals of one transfer wait for each other
+func lockTransfer(tx *gorm.DB, id int64) (model.Trans, error) {
+
+	var i model.Trans
+	res := forUpdate(tx).Where("id = ?", id).Take(&i)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return i, local_errors.ErrTransNotFound
+	}
+
+	return i, res.Error
+}
+
+type TransDirection string
+
+const (
+	TransDirectionAll      TransDirection = ""
+	TransDirectionIncoming TransDirection = "incoming"
+	TransDirectionOutgoing TransDirection = "outgoing"
+)
+
+type ListTransfersParams struct {
+	WalletAddress string         `json:"wallet_address"`
+	Direction     TransDirection `json:"direction"`
+	// zero values leave the bound open
+	CreatedFrom time.Time `json:"created_from"`
+	CreatedTo   time.Time `json:"created_to"`
+	MinAmount   int64     `json:"min_amount"`
+	MaxAmount   int64     `json:"max_amount"`
+	// BeforeID is the pagination cursor, only transfers with a smaller id are returned
+	BeforeID int64 `json:"before_id"`
+	Limit    int   `json:"limit"`
+}
+
+// ListTransfers returns the transfers of a wallet, newest first
+func (q *transRepository) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]model.Trans, error) {
+
+	logrus.Println("log  ListTransfers in store/trans/ListTransfers ")
+
+	query := q.db.Model(&model.Trans{})
+
+	switch arg.Direction {
+	case TransDirectionIncoming:
+		query = query.Where("to_wallet_add = ?", arg.WalletAddress)
+	case TransDirectionOutgoing:
+		query = query.Where("from_wallet_add = ?", arg.WalletAddress)
+	default:
+		query = query.Where("from_wallet_add = ? OR to_wallet_add = ?", arg.WalletAddress, arg.WalletAddress)
+	}
+
+	if !arg.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", arg.CreatedFrom)
+	}
+	if !arg.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", arg.CreatedTo)
+	}
+	if arg.MinAmount > 0 {
+		query = query.Where("amount >= ?", arg.MinAmount)
+	}
+	if arg.MaxAmount > 0 {
+		query = query.Where("amount <= ?", arg.MaxAmount)
+	}
+	if arg.BeforeID > 0 {
+		query = query.Where("id < ?", arg.BeforeID)
+	}
+
+	var transfers []model.Trans
+	res := query.Order("id DESC").Limit(arg.Limit).Find(&transfers)
+
+	return transfers, res.Error
+}
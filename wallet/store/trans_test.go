@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTransfers(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+		transRepo := repos.trans
+
+		walletA := createTestWallet(t, repos, "INR", 1000)
+		walletB := createTestWallet(t, repos, "INR", 1000)
+
+		var ids []int64
+		for _, amount := range []int64{100, 200, 300} {
+			res, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+				FromWalletAddress: walletA.WalletAddress,
+				ToWalletAddress:   walletB.WalletAddress,
+				Amount:            amount,
+				Currency:          "INR",
+			})
+			require.NoError(t, err)
+			ids = append(ids, res.Trans.ID)
+		}
+		res, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: walletB.WalletAddress,
+			ToWalletAddress:   walletA.WalletAddress,
+			Amount:            50,
+			Currency:          "INR",
+		})
+		require.NoError(t, err)
+
+		all, err := transRepo.ListTransfers(ctx, ListTransfersParams{WalletAddress: walletA.WalletAddress, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, all, 4)
+		require.Equal(t, res.Trans.ID, all[0].ID)
+
+		incoming, err := transRepo.ListTransfers(ctx, ListTransfersParams{WalletAddress: walletA.WalletAddress, Direction: TransDirectionIncoming, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, incoming, 1)
+
+		outgoing, err := transRepo.ListTransfers(ctx, ListTransfersParams{
+			WalletAddress: walletA.WalletAddress,
+			Direction:     TransDirectionOutgoing,
+			MinAmount:     150,
+			Limit:         10,
+		})
+		require.NoError(t, err)
+		require.Len(t, outgoing, 2)
+
+		page, err := transRepo.ListTransfers(ctx, ListTransfersParams{WalletAddress: walletA.WalletAddress, BeforeID: ids[2], Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		require.Equal(t, ids[1], page[0].ID)
+
+		trans, err := transRepo.GetTransfer(ctx, ids[0])
+		require.NoError(t, err)
+		require.Equal(t, int64(100), trans.Amount)
+
+		_, err = transRepo.GetTransfer(ctx, -1)
+		require.ErrorIs(t, err, local_errors.ErrTransNotFound)
+	})
+}
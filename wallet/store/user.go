@@ -15,7 +15,7 @@ import (
 type UserRepo interface {
 	 CreateUser(ctx context.Context, arg CreateUserParams) (model.User, error)
 	GetUserByUsername(ctx context.Context, username string) (model.User, error)
-	//UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (model.User, error)
+	UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (model.User, error)
 }
 
 type userRepository struct {
@@ -33,6 +33,10 @@ type CreateUserParams struct {
 	Username       string            `json:"username"`
 	HashedPassword string            `json:"hashed_password"`
 	Status         model.UserStatus  `json:"status"`
+	// Tier defaults to STANDARD
+	Tier           model.UserTier    `json:"tier"`
+	// Role defaults to USER, admins are never created through the public api
+	Role           model.UserRole    `json:"role"`
 	Email          string            `json:"email"`
 	Address        string    	 	 `json:"address"`
 	Nationality    string   		 `json:"nationality"`
@@ -49,11 +53,21 @@ func (q *userRepository) CreateUser(ctx context.Context, arg CreateUserParams) (
 
 	// check error ErrRecordNotFound
 	if errors.Is(res.Error, gorm.ErrRecordNotFound){
+		tier := arg.Tier
+		if tier == "" {
+			tier = model.UserTierSTANDARD
+		}
+		role := arg.Role
+		if role == "" {
+			role = model.UserRoleUSER
+		}
 		u = model.User{
 			ID: arg.Id,
 			Username: arg.Username,
 			HashedPassword: arg.HashedPassword,
 			Status: arg.Status,
+			Tier: tier,
+			Role: role,
 			Email: arg.Email,
 			Address: arg.Address,
 			Nationality: arg.Nationality,
@@ -84,4 +98,32 @@ func (q *userRepository) GetUserByUsername(ctx context.Context, username string)
 		return u, fmt.Errorf("wrong username")
 	}
 		return u, nil
-}
\ No newline at end of file
+}
+
+
+type UpdateUserStatusParams struct {
+	Username string           `json:"username"`
+	Status   model.UserStatus `json:"status"`
+}
+
+func (q *userRepository) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (model.User, error) {
+
+	logrus.Println("log  UpdateUserStatus in store/user/UpdateUserStatus ")
+
+	var u model.User
+	res := q.db.Where("username = ?", arg.Username).Take(&u)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return u, local_errors.ErrUserNotFound
+	}
+	if res.Error != nil {
+		return u, res.Error
+	}
+
+	res = q.db.Model(&u).Updates(map[string]interface{}{
+		"status":     arg.Status,
+		"updated_at": time.Now(),
+	})
+
+	return u, res.Error
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/nu7hatch/gouuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepo(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		userRepo := repos.user
+
+		user := createTestUser(t, repos)
+		require.NotZero(t, user.ID)
+		require.Equal(t, model.UserTierSTANDARD, user.Tier)
+		require.Equal(t, model.UserRoleUSER, user.Role)
+
+		_, err := userRepo.CreateUser(ctx, CreateUserParams{Username: user.Username, HashedPassword: "other"})
+		require.ErrorIs(t, err, local_errors.ErrUsernameAlreadyTaken)
+
+		found, err := userRepo.GetUserByUsername(ctx, user.Username)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, found.ID)
+		require.Equal(t, user.Email, found.Email)
+
+		_, err = userRepo.GetUserByUsername(ctx, "nobody")
+		require.Error(t, err)
+
+		blocked, err := userRepo.UpdateUserStatus(ctx, UpdateUserStatusParams{Username: user.Username, Status: model.UserStatusBLOCKED})
+		require.NoError(t, err)
+		require.Equal(t, model.UserStatusBLOCKED, blocked.Status)
+		found, err = userRepo.GetUserByUsername(ctx, user.Username)
+		require.NoError(t, err)
+		require.Equal(t, model.UserStatusBLOCKED, found.Status)
+
+		_, err = userRepo.UpdateUserStatus(ctx, UpdateUserStatusParams{Username: "nobody", Status: model.UserStatusBLOCKED})
+		require.ErrorIs(t, err, local_errors.ErrUserNotFound)
+	})
+}
+
+func TestSessionRepo(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		sessionRepo := repos.session
+		user := createTestUser(t, repos)
+
+		newSession := func() model.Session {
+			id, err := uuid.NewV4()
+			require.NoError(t, err)
+			s, err := sessionRepo.CreateSession(ctx, CreateSessionParams{
+				ID:           id.String(),
+				Username:     user.Username,
+				RefreshToken: "token",
+				ExpiresAt:    time.Now().Add(time.Hour),
+			})
+			require.NoError(t, err)
+			return s
+		}
+		first, second := newSession(), newSession()
+
+		found, err := sessionRepo.GetSession(ctx, first.ID)
+		require.NoError(t, err)
+		require.Equal(t, user.Username, found.Username)
+		require.False(t, found.IsBlocked)
+
+		_, err = sessionRepo.GetSession(ctx, "nowhere")
+		require.ErrorIs(t, err, local_errors.ErrSessionNotFound)
+
+		require.NoError(t, sessionRepo.BlockSession(ctx, first.ID))
+		found, err = sessionRepo.GetSession(ctx, first.ID)
+		require.NoError(t, err)
+		require.True(t, found.IsBlocked)
+
+		require.ErrorIs(t, sessionRepo.BlockSession(ctx, "nowhere"), local_errors.ErrSessionNotFound)
+
+		require.NoError(t, sessionRepo.BlockUserSessions(ctx, user.Username))
+		found, err = sessionRepo.GetSession(ctx, second.ID)
+		require.NoError(t, err)
+		require.True(t, found.IsBlocked)
+	})
+}
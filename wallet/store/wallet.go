@@ -5,30 +5,52 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
 	"github.com/jinzhu/gorm"
 	"github.com/nu7hatch/gouuid"
 	"github.com/sirupsen/logrus"
+	"sort"
 	"time"
 )
 
 type WalletRepo interface {
 	CreateWallet(ctx context.Context, arg CreateWalletParams) (model.Wallet, error)
 	GetWalletByUsername(ctx context.Context, username string) (model.Wallet, error)
+	GetWalletByUsernameAndCurrency(ctx context.Context, username string, currency string) (model.Wallet, error)
+	SetPrimaryWallet(ctx context.Context, username string, address string) (model.Wallet, error)
 	GetWalletByAddress(ctx context.Context, address string) (model.Wallet, error)
+	ListWalletsByUsername(ctx context.Context, username string) ([]model.Wallet, error)
 	UpdateWalletStatus(ctx context.Context, arg UpdateWalletStatusParams) (model.Wallet, error)
 	SendMoney(ctx context.Context, arg SendMoneyParams) (WalletTransferResult, error)
+	// SendMoneyFX debits the source wallet in its currency and credits the target wallet in its currency at the quoted rate
+	SendMoneyFX(ctx context.Context, arg SendMoneyFXParams) (WalletTransferResult, error)
+	// RefundTransfer sends back part or all of a transfer from its payee to its payer
+	RefundTransfer(ctx context.Context, arg RefundTransferParams) (WalletTransferResult, error)
+	// ReverseTransfer negates the ledger entry of a transfer, what the payee can not cover is booked to the suspense wallet
+	ReverseTransfer(ctx context.Context, arg ReverseTransferParams) (WalletTransferResult, error)
+	// CaptureHold pays part or all of an active hold to its target wallet and releases the rest
+	CaptureHold(ctx context.Context, arg CaptureHoldParams) (WalletTransferResult, error)
+	// PayPaymentRequest approves a waiting payment request and pays it, the request is linked to the transfer
+	PayPaymentRequest(ctx context.Context, arg PayPaymentRequestParams) (WalletTransferResult, error)
 	AddWalletBalance(ctx context.Context, params AddWalletBalanceParams) (model.Wallet, error)
+	// EnsureOrganizationWallet creates the organization wallet unless it already exists
+	EnsureOrganizationWallet(ctx context.Context, arg OrganizationWalletParams) (model.Wallet, error)
+	GetOrganizationWallet(ctx context.Context, arg OrganizationWalletParams) (model.Wallet, error)
 }
 
 type walletRepository struct {
 	db           *gorm.DB
 	transRepo TransRepo
+	ledgerRepo   LedgerRepo
+	fxQuoteRepo  FXQuoteRepo
 }
 
-func NewWalletRepo(client *gorm.DB, transferRepo TransRepo) WalletRepo {
+func NewWalletRepo(client *gorm.DB, transferRepo TransRepo, ledgerRepo LedgerRepo, fxQuoteRepo FXQuoteRepo) WalletRepo {
 	return &walletRepository{
 		db:           client,
 		transRepo: transferRepo,
+		ledgerRepo:   ledgerRepo,
+		fxQuoteRepo:  fxQuoteRepo,
 	}
 }
 
@@ -44,54 +66,84 @@ func (q *walletRepository) CreateWallet(ctx context.Context, arg CreateWalletPar
 
 	var w model.Wallet
 
-	//// CHECK IF THERE  EXIST A USER OR NOT WITH THIS USERNAME
-	var u model.User
-	res1 := q.db.Where("username = ?", arg.Username).Take(&u)
-	// SELECT * FROM users WHERE name = "jinzhu";
+	err := q.db.Transaction(func(tx *gorm.DB) error {
 
-	// check error ErrRecordNotFound
-	if errors.Is(res1.Error, gorm.ErrRecordNotFound) {
-		logrus.Println("No user exist with this !! ")
-		return w, res1.Error
-	}
+		//// CHECK IF THERE  EXIST A USER OR NOT WITH THIS USERNAME
+		// the user row is locked so that concurrent wallet creations of one user run one after the other
+		var u model.User
+		res1 := forUpdate(tx).Where("username = ?", arg.Username).Take(&u)
+		// SELECT * FROM users WHERE name = "jinzhu";
 
-	wa, err := uuid.NewV4()
-	if err != nil{
-		logrus.Println("error in creating new uuid for wa(wallet_address) !!")
-		return w, err
-	}
-	w = model.Wallet{
-		Username: arg.Username,
-		WalletAddress: wa.String(),
-		Status: model.WalletStatusACTIVE,
-		Balance: 0,
-		Currency: arg.Currency,
-		CreatedAt: time.Now(),
-	}
-	res := q.db.Create(&w) // pass pointer of data to Create
+		// check error ErrRecordNotFound
+		if errors.Is(res1.Error, gorm.ErrRecordNotFound) {
+			logrus.Println("No user exist with this !! ")
+			return local_errors.ErrUserNotFound
+		}
+		if res1.Error != nil {
+			return res1.Error
+		}
 
-	if res.Error != nil {
-		return w, fmt.Errorf("Something wrong happend could not create entry in DB")
-	}
+		// a user holds at most one wallet per currency
+		var count int
+		res := tx.Model(&model.Wallet{}).Where("user_id = ?", u.ID).Count(&count)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		var existing int
+		res = tx.Model(&model.Wallet{}).Where("user_id = ? AND currency = ?", u.ID, arg.Currency).Count(&existing)
+		if res.Error != nil {
+			return res.Error
+		}
+		if existing > 0 {
+			return local_errors.ErrWalletCurrencyExists
+		}
 
-	return w, nil
+		wa, err := uuid.NewV4()
+		if err != nil{
+			logrus.Println("error in creating new uuid for wa(wallet_address) !!")
+			return err
+		}
+		w = model.Wallet{
+			Username: arg.Username,
+			UserID: u.ID,
+			WalletAddress: wa.String(),
+			Status: model.WalletStatusACTIVE,
+			Kind: model.WalletKindUSER,
+			// the first wallet of a user becomes his primary wallet
+			IsPrimary: count == 0,
+			Balance: 0,
+			Currency: arg.Currency,
+			CreatedAt: time.Now(),
+		}
+		res = tx.Create(&w) // pass pointer of data to Create
+
+		if res.Error != nil {
+			return fmt.Errorf("Something wrong happend could not create entry in DB")
+		}
+
+		return emitWalletEvent(tx, model.EventTypeWalletCreated, w, 0)
+	})
+
+	return w, err
 }
 
+// GetWalletByUsername returns the primary wallet of the user
 func (q *walletRepository) GetWalletByUsername(ctx context.Context, username string) (model.Wallet,error) {
 
 	logrus.Println("log  GetWallet in store/wallet/GetWallet")
 
 	var i model.Wallet
-	res := q.db.Where("username = ?", username).Take(&i)
-	// SELECT * FROM wallets WHERE username = "jinzhu";
+	res := q.db.Where("username = ? AND is_primary = ?", username, true).Take(&i)
+	// SELECT * FROM wallets WHERE username = "jinzhu" AND is_primary = true;
 
 	// check error ErrRecordNotFound
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 		logrus.Println("wallet with this id not found !! ")
-		return i, res.Error
+		return i, local_errors.ErrWalletNotFound
 	}
 
-	return i, nil
+	return i, res.Error
 }
 
 func (q *walletRepository) GetWalletByAddress(ctx context.Context, address string) (model.Wallet, error){
@@ -106,10 +158,73 @@ func (q *walletRepository) GetWalletByAddress(ctx context.Context, address strin
 	// check error ErrRecordNotFound
 	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
 		logrus.Println("wallet with this wallet_address not found !! ")
-		return i, fmt.Errorf("wallet_address does not exists")
+		return i, local_errors.ErrWalletNotFound
 	}
 
-	return i,nil
+	return i, res.Error
+}
+
+func (q *walletRepository) GetWalletByUsernameAndCurrency(ctx context.Context, username string, currency string) (model.Wallet, error) {
+
+	logrus.Println("log  GetWalletByUsernameAndCurrency in store/wallet/GetWalletByUsernameAndCurrency")
+
+	var i model.Wallet
+	res := q.db.Where("username = ? AND currency = ?", username, currency).Take(&i)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return i, local_errors.ErrWalletNotFound
+	}
+
+	return i, res.Error
+}
+
+// SetPrimaryWallet makes the wallet the only primary wallet of its owner
+func (q *walletRepository) SetPrimaryWallet(ctx context.Context, username string, address string) (model.Wallet, error) {
+
+	logrus.Println("log  SetPrimaryWallet in store/wallet/SetPrimaryWallet")
+
+	var i model.Wallet
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		res := forUpdate(tx).Where("wallet_address = ? AND username = ?", address, username).Take(&i)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return local_errors.ErrWalletNotFound
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+
+		now := time.Now()
+		res = tx.Model(&model.Wallet{}).
+			Where("username = ? AND is_primary = ? AND id <> ?", username, true, i.ID).
+			Updates(map[string]interface{}{"is_primary": false, "updated_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		res = tx.Model(&model.Wallet{}).Where("id = ?", i.ID).
+			Updates(map[string]interface{}{"is_primary": true, "updated_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+
+		i.IsPrimary = true
+		i.UpdatedAt = now
+		return nil
+	})
+
+	return i, err
+}
+
+func (q *walletRepository) ListWalletsByUsername(ctx context.Context, username string) ([]model.Wallet, error) {
+
+	logrus.Println("log  ListWalletsByUsername in store/wallet/ListWalletsByUsername")
+
+	var wallets []model.Wallet
+	res := q.db.Where("username = ?", username).Order("id").Find(&wallets)
+
+	return wallets, res.Error
 }
 
 
@@ -123,9 +238,34 @@ func (q *walletRepository) UpdateWalletStatus(ctx context.Context, arg UpdateWal
 	logrus.Println("log  UpdateWalletStatus in store/wallet/UpdateWalletStatus ")
 
 	var i model.Wallet
-	q.db.Model(&i).Where("id = ?", arg.ID).Update("status", arg.Status)
 
-	return i, nil
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		res := forUpdate(tx).Where("id = ?", arg.ID).Take(&i)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return local_errors.ErrWalletNotFound
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if i.Status == arg.Status {
+			return nil
+		}
+
+		if err := tx.Model(&model.Wallet{}).Where("id = ?", arg.ID).Update("status", arg.Status).Error; err != nil {
+			return err
+		}
+		i.Status = arg.Status
+
+		eventType := model.EventTypeWalletUnfrozen
+		if arg.Status == model.WalletStatusINACTIVE {
+			eventType = model.EventTypeWalletFrozen
+		}
+		return emitWalletEvent(tx, eventType, i, 0)
+	})
+
+	return i, err
 }
 
 
@@ -137,7 +277,20 @@ type WalletTransferResult struct {
 type SendMoneyParams struct {
 	FromWalletAddress string `json:"from_wallet_address"`
 	ToWalletAddress   string `json:"to_wallet_address"`
+	// Amount is debited from the payer, Amount - Fee is credited to the payee
 	Amount            int64  `json:"amount"`
+	Fee               int64  `json:"fee"`
+	// Currency is recorded on the transfer, the ledger checks that both wallets hold it
+	Currency          string `json:"currency"`
+	// Limit is checked against the payer wallet's transfers of the day and the month
+	Limit             model.TransferLimit `json:"limit"`
+	// Rate, ToAmount and ToCurrency are only set by SendMoneyFX
+	Rate              string `json:"rate"`
+	ToAmount          int64  `json:"to_amount"`
+	ToCurrency        string `json:"to_currency"`
+	// Kind defaults to TRANSFER, refunds and reversals link the transfer they undo
+	Kind              model.TransKind `json:"kind"`
+	OriginalTransID   int64  `json:"original_trans_id"`
 }
 
 func (q *walletRepository) SendMoney(ctx context.Context, arg SendMoneyParams) (WalletTransferResult, error) {
@@ -148,31 +301,471 @@ func (q *walletRepository) SendMoney(ctx context.Context, arg SendMoneyParams) (
 
 	//create a new transaction and handle rollback/commit based on the
 	err := q.db.Transaction(func(tx *gorm.DB) error {
-
 		var err error
+		res, err = q.sendMoney(ctx, tx, arg)
+		return err
+	})
+
+	return res, err
+}
+
+// sendMoney makes a transfer inside the transaction tx
+func (q *walletRepository) sendMoney(ctx context.Context, tx *gorm.DB, arg SendMoneyParams) (WalletTransferResult, error) {
+
+	var res WalletTransferResult
+
+	if arg.Fee < 0 || arg.Fee >= arg.Amount {
+		return res, fmt.Errorf("fee must be less than the amount")
+	}
 
-		//logrus.Println("log  Tx in store/wallet/SendMoney")
+	trans, err := q.transRepo.WithTx(tx).CreateTransfer(ctx, arg)
+	if err != nil {
+		return res, err
+	}
 
-		//TODO: CREATE TRANSFER ENTITY then // STORE ENTRY FOR TRANSFER in CreateTransefer function
-		trans, err := q.transRepo.CreateTransfer(ctx, arg)
+	res.Trans = trans
 
+	postings := []PostingParams{
+		{WalletAddress: arg.FromWalletAddress, Currency: arg.Currency, Amount: -arg.Amount},
+		{WalletAddress: arg.ToWalletAddress, Currency: arg.Currency, Amount: arg.Amount - arg.Fee},
+	}
+	if arg.Fee > 0 {
+		feeWallet, err := organizationWallet(tx, trans.Currency, model.OrganizationWalletFEEINCOME)
+		if err != nil {
+			return res, err
+		}
+		postings = append(postings, PostingParams{WalletAddress: feeWallet.WalletAddress, Currency: arg.Currency, Amount: arg.Fee})
+	}
+
+	if err := recordTransferUsage(tx, arg.FromWalletAddress, arg.Amount, arg.Limit, postings); err != nil {
+		return res, err
+	}
+
+	// the ledger locks the wallets and checks the balance again under the lock
+	entry, err := q.ledgerRepo.WithTx(tx).PostEntry(ctx, PostEntryParams{
+		Kind:     model.JournalEntryKindTRANSFER,
+		TransID:  trans.ID,
+		Postings: postings,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	res.Wallet = entry.Wallets[arg.FromWalletAddress]
+
+	return res, emitTransferEvent(tx, model.EventTypeTransferCompleted, trans, entry)
+}
+
+
+type SendMoneyFXParams struct {
+	QuoteID string              `json:"quote_id"`
+	Limit   model.TransferLimit `json:"limit"`
+}
+
+func (q *walletRepository) SendMoneyFX(ctx context.Context, arg SendMoneyFXParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  SendMoneyFX in store/wallet/SendMoneyFX")
+
+	var res WalletTransferResult
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		quoteRepo := q.fxQuoteRepo.WithTx(tx)
+
+		quote, err := quoteRepo.GetFXQuote(ctx, arg.QuoteID)
+		if err != nil {
+			return err
+		}
+
+		trans, err := q.transRepo.WithTx(tx).CreateTransfer(ctx, SendMoneyParams{
+			FromWalletAddress: quote.FromWalletAdd,
+			ToWalletAddress:   quote.ToWalletAdd,
+			Amount:            quote.FromAmount,
+			Currency:          quote.FromCurrency,
+			Rate:              quote.Rate,
+			ToAmount:          quote.ToAmount,
+			ToCurrency:        quote.ToCurrency,
+		})
 		if err != nil {
 			return err
 		}
 
 		res.Trans = trans
 
-		//TODO: UPDATE BALANCE OF ACCOUNTS
-		fromWallet, _, err := addMoney(ctx, q, arg.FromWalletAddress, -arg.Amount, arg.ToWalletAddress, arg.Amount)
+		// a quote pays for a single transfer
+		if err := quoteRepo.UseFXQuote(ctx, quote.ID, trans.ID, time.Now()); err != nil {
+			return err
+		}
+
+		// the float of each currency takes the other side, so each currency balances on its own
+		fromFloat, err := organizationWallet(tx, quote.FromCurrency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+		toFloat, err := organizationWallet(tx, quote.ToCurrency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
 
-		res.Wallet = fromWallet
+		postings := []PostingParams{
+			{WalletAddress: quote.FromWalletAdd, Currency: quote.FromCurrency, Amount: -quote.FromAmount},
+			{WalletAddress: fromFloat.WalletAddress, Currency: quote.FromCurrency, Amount: quote.FromAmount},
+			{WalletAddress: toFloat.WalletAddress, Currency: quote.ToCurrency, Amount: -quote.ToAmount},
+			{WalletAddress: quote.ToWalletAdd, Currency: quote.ToCurrency, Amount: quote.ToAmount},
+		}
 
-		return err
+		if err := recordTransferUsage(tx, quote.FromWalletAdd, quote.FromAmount, arg.Limit, postings); err != nil {
+			return err
+		}
+
+		entry, err := q.ledgerRepo.WithTx(tx).PostEntry(ctx, PostEntryParams{
+			Kind:        model.JournalEntryKindFXTRANSFER,
+			TransID:     trans.ID,
+			Description: "fx " + quote.FromCurrency + "/" + quote.ToCurrency + " at " + quote.Rate,
+			Postings:    postings,
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Wallet = entry.Wallets[quote.FromWalletAdd]
+
+		return emitTransferEvent(tx, model.EventTypeTransferCompleted, trans, entry)
+	})
+
+	return res, err
+}
+
+
+type RefundTransferParams struct {
+	TransID int64 `json:"trans_id"`
+	Amount  int64 `json:"amount"`
+}
+
+func (q *walletRepository) RefundTransfer(ctx context.Context, arg RefundTransferParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  RefundTransfer in store/wallet/RefundTransfer")
+
+	var res WalletTransferResult
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		original, err := lockTransfer(tx, arg.TransID)
+		if err != nil {
+			return err
+		}
+
+		if original.ReversalTransID != 0 {
+			return local_errors.ErrTransAlreadyReversed
+		}
+		if !original.IsRefundable() {
+			return local_errors.ErrTransNotRefundable
+		}
+		if arg.Amount <= 0 || arg.Amount > original.RefundableAmount() {
+			return local_errors.ErrRefundExceedsAmount
+		}
+
+		trans, err := q.transRepo.WithTx(tx).CreateTransfer(ctx, SendMoneyParams{
+			FromWalletAddress: original.ToWalletAdd,
+			ToWalletAddress:   original.FromWalletAdd,
+			Amount:            arg.Amount,
+			Currency:          original.Currency,
+			Kind:              model.TransKindREFUND,
+			OriginalTransID:   original.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Trans = trans
+
+		upd := tx.Model(&model.Trans{}).Where("id = ?", original.ID).
+			Update("refunded_amount", gorm.Expr("refunded_amount + ?", arg.Amount))
+		if upd.Error != nil {
+			return upd.Error
+		}
+
+		entry, err := q.ledgerRepo.WithTx(tx).PostEntry(ctx, PostEntryParams{
+			Kind:        model.JournalEntryKindREFUND,
+			TransID:     trans.ID,
+			Description: fmt.Sprintf("refund of transaction %d", original.ID),
+			Postings: []PostingParams{
+				{WalletAddress: original.ToWalletAdd, Currency: original.Currency, Amount: -arg.Amount},
+				{WalletAddress: original.FromWalletAdd, Currency: original.Currency, Amount: arg.Amount},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Wallet = entry.Wallets[original.ToWalletAdd]
+
+		return emitTransferEvent(tx, model.EventTypeTransferRefunded, trans, entry)
+	})
+
+	return res, err
+}
+
+type ReverseTransferParams struct {
+	TransID int64  `json:"trans_id"`
+	Reason  string `json:"reason"`
+}
+
+func (q *walletRepository) ReverseTransfer(ctx context.Context, arg ReverseTransferParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  ReverseTransfer in store/wallet/ReverseTransfer")
+
+	var res WalletTransferResult
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		original, err := lockTransfer(tx, arg.TransID)
+		if err != nil {
+			return err
+		}
+
+		if original.ReversalTransID != 0 {
+			return local_errors.ErrTransAlreadyReversed
+		}
+		if original.Kind != model.TransKindTRANSFER {
+			return local_errors.ErrTransNotRefundable
+		}
+		if original.RefundedAmount > 0 {
+			return local_errors.ErrTransRefunded
+		}
+
+		var entry model.JournalEntry
+		if err := tx.Where("trans_id = ? AND kind IN (?)", original.ID,
+			[]model.JournalEntryKind{model.JournalEntryKindTRANSFER, model.JournalEntryKindFXTRANSFER}).Take(&entry).Error; err != nil {
+			return err
+		}
+
+		var postings []model.Posting
+		if err := tx.Where("journal_entry_id = ?", entry.ID).Order("id").Find(&postings).Error; err != nil {
+			return err
+		}
+
+		// the payee is credited in the currency it received, which differs from Currency on cross-currency transfers
+		payeeCurrency := original.Currency
+		if original.ToCurrency != "" {
+			payeeCurrency = original.ToCurrency
+		}
+		suspense, err := organizationWallet(tx, payeeCurrency, model.OrganizationWalletSUSPENSE)
+		if err != nil {
+			return err
+		}
+
+		addresses := []string{suspense.WalletAddress}
+		for _, p := range postings {
+			addresses = append(addresses, p.WalletAddress)
+		}
+		wallets, err := lockWallets(tx, addresses...)
+		if err != nil {
+			return err
+		}
+
+		// the payee may have spent the money, the part it can not give back is parked on the suspense wallet
+		var shortfall int64
+		reversal := make([]PostingParams, 0, len(postings)+1)
+		for _, p := range postings {
+			amount := -p.Amount
+			if p.WalletAddress == original.ToWalletAdd && amount < 0 {
+				w := wallets[p.WalletAddress]
+				balance := w.AvailableBalance()
+				if balance < 0 {
+					balance = 0
+				}
+				if -amount > balance {
+					shortfall = -amount - balance
+					amount = -balance
+				}
+			}
+			if amount != 0 {
+				reversal = append(reversal, PostingParams{WalletAddress: p.WalletAddress, Currency: p.Currency, Amount: amount})
+			}
+		}
+		if shortfall > 0 {
+			reversal = append(reversal, PostingParams{WalletAddress: suspense.WalletAddress, Currency: payeeCurrency, Amount: -shortfall})
+		}
+
+		// the reversal carries the amounts of the transfer it undoes, with the wallets swapped
+		trans, err := q.transRepo.WithTx(tx).CreateTransfer(ctx, SendMoneyParams{
+			FromWalletAddress: original.ToWalletAdd,
+			ToWalletAddress:   original.FromWalletAdd,
+			Amount:            original.Amount,
+			Fee:               original.Fee,
+			Currency:          original.Currency,
+			Rate:              original.Rate,
+			ToAmount:          original.ToAmount,
+			ToCurrency:        original.ToCurrency,
+			Kind:              model.TransKindREVERSAL,
+			OriginalTransID:   original.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if shortfall > 0 {
+			if err := tx.Model(&model.Trans{}).Where("id = ?", trans.ID).Update("shortfall", shortfall).Error; err != nil {
+				return err
+			}
+			trans.Shortfall = shortfall
+		}
+
+		upd := tx.Model(&model.Trans{}).Where("id = ?", original.ID).Update("reversal_trans_id", trans.ID)
+		if upd.Error != nil {
+			return upd.Error
+		}
+
+		description := fmt.Sprintf("reversal of transaction %d", original.ID)
+		if arg.Reason != "" {
+			description += ": " + arg.Reason
+		}
+
+		posted, err := q.ledgerRepo.WithTx(tx).PostEntry(ctx, PostEntryParams{
+			Kind:        model.JournalEntryKindREVERSAL,
+			TransID:     trans.ID,
+			Description: description,
+			Postings:    reversal,
+			// the transfer is undone even when a wallet was frozen since, typically for the fraud being reversed
+			AllowInactive: true,
 		})
+		if err != nil {
+			return err
+		}
+
+		res.Trans = trans
+		res.Wallet = posted.Wallets[original.FromWalletAdd]
+
+		return emitTransferEvent(tx, model.EventTypeTransferReversed, trans, posted)
+	})
+
+	return res, err
+}
+
+type CaptureHoldParams struct {
+	HoldID int64 `json:"hold_id"`
+	// Amount is debited from the held wallet, Amount - Fee is credited to the target wallet
+	Amount int64               `json:"amount"`
+	Fee    int64               `json:"fee"`
+	Limit  model.TransferLimit `json:"limit"`
+}
+
+func (q *walletRepository) CaptureHold(ctx context.Context, arg CaptureHoldParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  CaptureHold in store/wallet/CaptureHold")
+
+	var res WalletTransferResult
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		h, err := lockHold(tx, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		if h.Status != model.HoldStatusACTIVE {
+			return local_errors.ErrHoldNotActive
+		}
+		if h.IsExpired(time.Now()) {
+			return local_errors.ErrHoldExpired
+		}
+		if arg.Amount <= 0 || arg.Amount > h.Amount {
+			return local_errors.ErrCaptureExceedsHold
+		}
+
+		// lock every wallet of the transfer before touching the held one, so the lock order stays the ledger's
+		addresses := []string{h.WalletAddress, h.ToWalletAdd}
+		if arg.Fee > 0 {
+			feeWallet, err := organizationWallet(tx, h.Currency, model.OrganizationWalletFEEINCOME)
+			if err != nil {
+				return err
+			}
+			addresses = append(addresses, feeWallet.WalletAddress)
+		}
+		if _, err := lockWallets(tx, addresses...); err != nil {
+			return err
+		}
+
+		// the whole hold is released, the captured part then leaves the wallet with the transfer
+		h, err = releaseHold(tx, h, model.HoldStatusCAPTURED)
+		if err != nil {
+			return err
+		}
+
+		res, err = q.sendMoney(ctx, tx, SendMoneyParams{
+			FromWalletAddress: h.WalletAddress,
+			ToWalletAddress:   h.ToWalletAdd,
+			Amount:            arg.Amount,
+			Fee:               arg.Fee,
+			Currency:          h.Currency,
+			Limit:             arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		upd := tx.Model(&model.Hold{}).Where("id = ?", h.ID).Updates(map[string]interface{}{
+			"captured_amount": arg.Amount,
+			"trans_id":        res.Trans.ID,
+		})
+		return upd.Error
+	})
 
 	return res, err
 }
 
+type PayPaymentRequestParams struct {
+	RequestID int64 `json:"request_id"`
+	// Amount is debited from the payer, Amount - Fee is credited to the payee
+	Amount int64               `json:"amount"`
+	Fee    int64               `json:"fee"`
+	Limit  model.TransferLimit `json:"limit"`
+}
+
+func (q *walletRepository) PayPaymentRequest(ctx context.Context, arg PayPaymentRequestParams) (WalletTransferResult, error) {
+
+	logrus.Println("log  PayPaymentRequest in store/wallet/PayPaymentRequest")
+
+	var res WalletTransferResult
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+
+		p, err := lockPaymentRequest(tx, arg.RequestID)
+		if err != nil {
+			return err
+		}
+
+		// the request is approved and paid at once, it is never left APPROVED without its transfer
+		if err := p.TransitionTo(model.PaymentRequestStatusAPPROVED); err != nil {
+			return err
+		}
+		if err := p.TransitionTo(model.PaymentRequestStatusPAYMENTSUCCESS); err != nil {
+			return err
+		}
+
+		res, err = q.sendMoney(ctx, tx, SendMoneyParams{
+			FromWalletAddress: p.FromWalletAdd,
+			ToWalletAddress:   p.ToWalletAdd,
+			Amount:            arg.Amount,
+			Fee:               arg.Fee,
+			Currency:          p.Currency,
+			Limit:             arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		upd := tx.Model(&model.PaymentRequest{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+			"status":     p.Status,
+			"trans_id":   res.Trans.ID,
+			"updated_at": time.Now(),
+		})
+		return upd.Error
+	})
+
+	return res, err
+}
 
 type AddWalletBalanceParams struct {
 	WalletAddress 	string 	`json:"wallet_address"`
@@ -184,30 +777,166 @@ func (q *walletRepository) AddWalletBalance(ctx context.Context, params AddWalle
 	logrus.Println("log  AddWalletBalance in store/wallet/AddWalletBalance ")
 
 	var i model.Wallet
-	 q.db.Where("wallet_address = ?", params.WalletAddress).Take(&i)
-	 q.db.Model(&i).Where("wallet_address = ?", params.WalletAddress).Update("balance", i.Balance+params.Amount)
 
-	return i, nil
-}
+	// the currency never changes, it is safe to read it before the ledger locks the wallet
+	w, err := q.GetWalletByAddress(ctx, params.WalletAddress)
+	if err != nil {
+		return i, err
+	}
+
+	err = q.db.Transaction(func(tx *gorm.DB) error {
+
+		// credited money comes from the float of the currency, money is never minted
+		float, err := organizationWallet(tx, w.Currency, model.OrganizationWalletFLOAT)
+		if err != nil {
+			return err
+		}
+
+		entry, err := q.ledgerRepo.WithTx(tx).PostEntry(ctx, PostEntryParams{
+			Kind: model.JournalEntryKindCREDIT,
+			Postings: []PostingParams{
+				{WalletAddress: float.WalletAddress, Amount: -params.Amount},
+				{WalletAddress: params.WalletAddress, Amount: params.Amount},
+			},
+		})
+		if err != nil {
+			return err
+		}
 
-func addMoney(ctx context.Context, q *walletRepository, address1 string, amount1 int64, address2 string, amount2 int64) (wallet1 model.Wallet, wallet2 model.Wallet, err error) {
-	wallet1, err = q.AddWalletBalance(ctx, AddWalletBalanceParams{
-		WalletAddress:    address1,
-		Amount: 		  amount1,
+		i = entry.Wallets[params.WalletAddress]
+		return emitWalletEvent(tx, model.EventTypeWalletCredited, i, params.Amount)
 	})
 
+	return i, err
+}
+
+type OrganizationWalletParams struct {
+	Currency string                          `json:"currency"`
+	Purpose  model.OrganizationWalletPurpose `json:"purpose"`
+}
+
+func (q *walletRepository) EnsureOrganizationWallet(ctx context.Context, arg OrganizationWalletParams) (model.Wallet, error) {
+
+	logrus.Println("log  EnsureOrganizationWallet in store/wallet/EnsureOrganizationWallet ")
+
+	w, err := organizationWallet(q.db, arg.Currency, arg.Purpose)
+	if err != local_errors.ErrOrganizationWalletNotFound {
+		return w, err
+	}
+
+	wa, err := uuid.NewV4()
+	if err != nil {
+		return w, err
+	}
+
+	now := time.Now()
+	w = model.Wallet{
+		WalletAddress: wa.String(),
+		Status:        model.WalletStatusACTIVE,
+		Kind:          model.WalletKindORGANIZATION,
+		Purpose:       arg.Purpose,
+		Balance:       0,
+		Currency:      arg.Currency,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	createErr := q.db.Create(&w).Error
+	if createErr == nil {
+		return w, nil
+	}
+
+	// the unique index rejected the insert, another instance created the wallet first
+	w, err = organizationWallet(q.db, arg.Currency, arg.Purpose)
+	if err == local_errors.ErrOrganizationWalletNotFound {
+		return w, createErr
+	}
+	return w, err
+}
+
+func (q *walletRepository) GetOrganizationWallet(ctx context.Context, arg OrganizationWalletParams) (model.Wallet, error) {
+
+	logrus.Println("log  GetOrganizationWallet in store/wallet/GetOrganizationWallet ")
+
+	return organizationWallet(q.db, arg.Currency, arg.Purpose)
+}
+
+// organizationWallet reads the organization wallet of the currency with the purpose
+func organizationWallet(db *gorm.DB, currency string, purpose model.OrganizationWalletPurpose) (model.Wallet, error) {
+
+	var w model.Wallet
+	res := db.Where("kind = ? AND currency = ? AND purpose = ?", model.WalletKindORGANIZATION, currency, purpose).Take(&w)
+
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return w, local_errors.ErrOrganizationWalletNotFound
+	}
+
+	return w, res.Error
+}
+
+// recordTransferUsage counts an outgoing transfer of amount on the payer wallet after checking it against the limit.
+// All wallets of the postings are locked in the ledger's order first, the usage counters of the payer
+// are then read under its lock so concurrent payments from one wallet see each other.
+func recordTransferUsage(tx *gorm.DB, from string, amount int64, limit model.TransferLimit, postings []PostingParams) error {
+
+	addresses := make([]string, 0, len(postings))
+	for _, p := range postings {
+		addresses = append(addresses, p.WalletAddress)
+	}
+
+	wallets, err := lockWallets(tx, addresses...)
 	if err != nil {
-		return
+		return err
 	}
 
-	wallet2, err = q.AddWalletBalance(ctx, AddWalletBalanceParams{
-		WalletAddress:     address2,
-		Amount: 		   amount2,
+	w := wallets[from]
+	now := time.Now()
+
+	daily, monthly := w.TransferUsage(now)
+	if err := limit.Check(amount, daily, monthly); err != nil {
+		return err
+	}
+
+	w.AddTransferUsage(now, amount)
+	res := tx.Model(&model.Wallet{}).Where("id = ?", w.ID).Updates(map[string]interface{}{
+		"limit_day":          w.LimitDay,
+		"limit_day_count":    w.LimitDayCount,
+		"limit_day_amount":   w.LimitDayAmount,
+		"limit_month":        w.LimitMonth,
+		"limit_month_count":  w.LimitMonthCount,
+		"limit_month_amount": w.LimitMonthAmount,
 	})
 
-	if err != nil {
-		return
+	return res.Error
+}
+
+// lockWallets loads the wallets with SELECT ... FOR UPDATE, ordered by address
+func lockWallets(tx *gorm.DB, addresses ...string) (map[string]model.Wallet, error) {
+
+	sorted := make([]string, 0, len(addresses))
+	seen := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if !seen[address] {
+			seen[address] = true
+			sorted = append(sorted, address)
+		}
 	}
+	sort.Strings(sorted)
+
+	wallets := make(map[string]model.Wallet, len(sorted))
+	for _, address := range sorted {
+		var w model.Wallet
+		res := forUpdate(tx).Where("wallet_address = ?", address).Take(&w)
 
-	return
-}
\ No newline at end of file
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			logrus.Println("wallet with this wallet_address not found !! ")
+			return nil, local_errors.ErrWalletNotFound
+		}
+		if res.Error != nil {
+			return nil, res.Error
+		}
+
+		wallets[address] = w
+	}
+
+	return wallets, nil
+}
@@ -0,0 +1,522 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWallet(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+		user := createTestUser(t, repos)
+
+		inr, err := walletRepo.CreateWallet(ctx, CreateWalletParams{Username: user.Username, Currency: "INR"})
+		require.NoError(t, err)
+		require.True(t, inr.IsPrimary)
+		require.Equal(t, user.ID, inr.UserID)
+
+		usd, err := walletRepo.CreateWallet(ctx, CreateWalletParams{Username: user.Username, Currency: "USD"})
+		require.NoError(t, err)
+		require.False(t, usd.IsPrimary)
+
+		_, err = walletRepo.CreateWallet(ctx, CreateWalletParams{Username: user.Username, Currency: "INR"})
+		require.ErrorIs(t, err, local_errors.ErrWalletCurrencyExists)
+
+		_, err = walletRepo.CreateWallet(ctx, CreateWalletParams{Username: "nobody", Currency: "INR"})
+		require.ErrorIs(t, err, local_errors.ErrUserNotFound)
+
+		wallets, err := walletRepo.ListWalletsByUsername(ctx, user.Username)
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+
+		found, err := walletRepo.GetWalletByAddress(ctx, usd.WalletAddress)
+		require.NoError(t, err)
+		require.Equal(t, "USD", found.Currency)
+
+		_, err = walletRepo.GetWalletByAddress(ctx, "nowhere")
+		require.ErrorIs(t, err, local_errors.ErrWalletNotFound)
+	})
+}
+
+func TestSendMoney(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		from := createTestWallet(t, repos, "INR", 1000)
+		to := createTestWallet(t, repos, "INR", 0)
+
+		res, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: from.WalletAddress,
+			ToWalletAddress:   to.WalletAddress,
+			Amount:            300,
+			Fee:               10,
+			Currency:          "INR",
+		})
+		require.NoError(t, err)
+		require.Equal(t, int64(700), res.Wallet.Balance)
+		require.Equal(t, int64(300), res.Trans.Amount)
+		require.Equal(t, int64(290), res.Trans.NetAmount)
+		require.Equal(t, int64(290), walletOf(t, repos, to.WalletAddress).Balance)
+
+		lastEvent, err := repos.outbox.LastEventID(ctx)
+		require.NoError(t, err)
+
+		// a failed transfer leaves no trace, not even the usage of the payer's limits
+		_, err = walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: from.WalletAddress,
+			ToWalletAddress:   to.WalletAddress,
+			Amount:            701,
+			Currency:          "INR",
+		})
+		require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+
+		transfers, err := repos.trans.ListTransfers(ctx, ListTransfersParams{WalletAddress: from.WalletAddress, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+
+		payer := walletOf(t, repos, from.WalletAddress)
+		require.Equal(t, int64(700), payer.Balance)
+		require.Equal(t, int64(300), payer.LimitDayAmount)
+
+		events, err := repos.outbox.ListEvents(ctx, ListEventsParams{AfterID: lastEvent, Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		requireConsistentLedger(t, repos, from, to)
+	})
+}
+
+// the transfers lock both wallets, more is sent than the wallets hold so some of them fail as a whole
+func TestSendMoneyConcurrent(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		walletA := createTestWallet(t, repos, "INR", 1000)
+		walletB := createTestWallet(t, repos, "INR", 1000)
+
+		const n = 100
+		var wg sync.WaitGroup
+		errs := make(chan error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				from, to := walletA, walletB
+				if i%4 == 3 {
+					from, to = walletB, walletA
+				}
+				_, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+					FromWalletAddress: from.WalletAddress,
+					ToWalletAddress:   to.WalletAddress,
+					Amount:            int64(10 + i),
+					Currency:          "INR",
+				})
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+			}
+		}
+
+		var total int64
+		for _, w := range []model.Wallet{walletA, walletB} {
+			w = walletOf(t, repos, w.WalletAddress)
+			require.GreaterOrEqual(t, w.Balance, int64(0))
+			total += w.Balance
+		}
+		require.Equal(t, int64(2000), total)
+
+		requireConsistentLedger(t, repos, walletA, walletB)
+	})
+}
+
+func TestSendMoneyLimits(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		from := createTestWallet(t, repos, "INR", 10000)
+		to := createTestWallet(t, repos, "INR", 0)
+		limit := model.TransferLimit{PerTransaction: 500, DailyAmount: 800, DailyCount: 3}
+
+		send := func(amount int64) error {
+			_, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+				FromWalletAddress: from.WalletAddress,
+				ToWalletAddress:   to.WalletAddress,
+				Amount:            amount,
+				Currency:          "INR",
+				Limit:             limit,
+			})
+			return err
+		}
+
+		require.ErrorIs(t, send(501), local_errors.ErrPerTransactionLimitExceeded)
+		require.NoError(t, send(500))
+		require.ErrorIs(t, send(301), local_errors.ErrDailyLimitExceeded)
+		require.NoError(t, send(200))
+		require.NoError(t, send(100))
+
+		// the count is reached before the amount
+		limit.DailyAmount = 0
+		require.ErrorIs(t, send(1), local_errors.ErrDailyLimitExceeded)
+
+		payer := walletOf(t, repos, from.WalletAddress)
+		require.Equal(t, int64(3), payer.LimitDayCount)
+		require.Equal(t, int64(800), payer.LimitDayAmount)
+		require.Equal(t, int64(10000-800), payer.Balance)
+	})
+}
+
+func TestSendMoneyFX(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		from := createTestWallet(t, repos, "INR", 10000)
+		to := createTestWallet(t, repos, "USD", 0)
+
+		newQuote := func(expiresAt time.Time) model.FXQuote {
+			quote, err := repos.fxQuote.CreateFXQuote(ctx, CreateFXQuoteParams{
+				FromWalletAddress: from.WalletAddress,
+				ToWalletAddress:   to.WalletAddress,
+				FromCurrency:      "INR",
+				ToCurrency:        "USD",
+				Rate:              "0.012",
+				FromAmount:        8300,
+				ToAmount:          100,
+				ExpiresAt:         expiresAt,
+			})
+			require.NoError(t, err)
+			return quote
+		}
+
+		quote := newQuote(time.Now().Add(time.Minute))
+		found, err := repos.fxQuote.GetFXQuote(ctx, quote.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(8300), found.FromAmount)
+
+		res, err := walletRepo.SendMoneyFX(ctx, SendMoneyFXParams{QuoteID: quote.ID})
+		require.NoError(t, err)
+		require.Equal(t, int64(1700), res.Wallet.Balance)
+		require.Equal(t, int64(100), res.Trans.ToAmount)
+		require.Equal(t, "USD", res.Trans.ToCurrency)
+		require.Equal(t, int64(100), walletOf(t, repos, to.WalletAddress).Balance)
+
+		// a quote pays once, and a failed use leaves no transfer behind
+		_, err = walletRepo.SendMoneyFX(ctx, SendMoneyFXParams{QuoteID: quote.ID})
+		require.ErrorIs(t, err, local_errors.ErrFXQuoteUsed)
+
+		_, err = walletRepo.SendMoneyFX(ctx, SendMoneyFXParams{QuoteID: newQuote(time.Now().Add(-time.Second)).ID})
+		require.ErrorIs(t, err, local_errors.ErrFXQuoteExpired)
+
+		_, err = walletRepo.SendMoneyFX(ctx, SendMoneyFXParams{QuoteID: "nowhere"})
+		require.ErrorIs(t, err, local_errors.ErrFXQuoteNotFound)
+
+		transfers, err := repos.trans.ListTransfers(ctx, ListTransfersParams{WalletAddress: from.WalletAddress, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, int64(1700), walletOf(t, repos, from.WalletAddress).Balance)
+
+		requireConsistentLedger(t, repos, from, to)
+	})
+}
+
+func TestRefundTransfer(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		from := createTestWallet(t, repos, "INR", 1000)
+		to := createTestWallet(t, repos, "INR", 0)
+
+		sent, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: from.WalletAddress,
+			ToWalletAddress:   to.WalletAddress,
+			Amount:            300,
+			Fee:               10,
+			Currency:          "INR",
+		})
+		require.NoError(t, err)
+
+		// the fee is not refunded, the payee only gives back what it received
+		_, err = walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: sent.Trans.ID, Amount: 291})
+		require.ErrorIs(t, err, local_errors.ErrRefundExceedsAmount)
+
+		refund, err := walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: sent.Trans.ID, Amount: 100})
+		require.NoError(t, err)
+		require.Equal(t, model.TransKindREFUND, refund.Trans.Kind)
+		require.Equal(t, int64(190), refund.Wallet.Balance)
+		require.Equal(t, int64(800), walletOf(t, repos, from.WalletAddress).Balance)
+
+		_, err = walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: refund.Trans.ID, Amount: 1})
+		require.ErrorIs(t, err, local_errors.ErrTransNotRefundable)
+
+		_, err = walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: sent.Trans.ID, Amount: 190})
+		require.NoError(t, err)
+		_, err = walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: sent.Trans.ID, Amount: 1})
+		require.ErrorIs(t, err, local_errors.ErrRefundExceedsAmount)
+
+		original, err := repos.trans.GetTransfer(ctx, sent.Trans.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(290), original.RefundedAmount)
+
+		_, err = walletRepo.ReverseTransfer(ctx, ReverseTransferParams{TransID: sent.Trans.ID})
+		require.ErrorIs(t, err, local_errors.ErrTransRefunded)
+
+		_, err = walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: -1, Amount: 1})
+		require.ErrorIs(t, err, local_errors.ErrTransNotFound)
+
+		requireConsistentLedger(t, repos, from, to)
+	})
+}
+
+func TestReverseTransfer(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		from := createTestWallet(t, repos, "INR", 1000)
+		to := createTestWallet(t, repos, "INR", 0)
+		other := createTestWallet(t, repos, "INR", 0)
+
+		suspense, err := walletRepo.GetOrganizationWallet(ctx, OrganizationWalletParams{Currency: "INR", Purpose: model.OrganizationWalletSUSPENSE})
+		require.NoError(t, err)
+
+		sent, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: from.WalletAddress,
+			ToWalletAddress:   to.WalletAddress,
+			Amount:            400,
+			Currency:          "INR",
+		})
+		require.NoError(t, err)
+
+		// the payee spends most of it before the reversal
+		_, err = walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: to.WalletAddress,
+			ToWalletAddress:   other.WalletAddress,
+			Amount:            300,
+			Currency:          "INR",
+		})
+		require.NoError(t, err)
+
+		reversal, err := walletRepo.ReverseTransfer(ctx, ReverseTransferParams{TransID: sent.Trans.ID, Reason: "chargeback"})
+		require.NoError(t, err)
+		require.Equal(t, model.TransKindREVERSAL, reversal.Trans.Kind)
+		require.Equal(t, int64(300), reversal.Trans.Shortfall)
+		require.Equal(t, int64(1000), reversal.Wallet.Balance)
+		require.Zero(t, walletOf(t, repos, to.WalletAddress).Balance)
+		require.Equal(t, suspense.Balance-300, walletOf(t, repos, suspense.WalletAddress).Balance)
+
+		original, err := repos.trans.GetTransfer(ctx, sent.Trans.ID)
+		require.NoError(t, err)
+		require.Equal(t, reversal.Trans.ID, original.ReversalTransID)
+
+		_, err = walletRepo.ReverseTransfer(ctx, ReverseTransferParams{TransID: sent.Trans.ID})
+		require.ErrorIs(t, err, local_errors.ErrTransAlreadyReversed)
+		_, err = walletRepo.RefundTransfer(ctx, RefundTransferParams{TransID: sent.Trans.ID, Amount: 1})
+		require.ErrorIs(t, err, local_errors.ErrTransAlreadyReversed)
+
+		requireConsistentLedger(t, repos, from, to, other, suspense)
+	})
+}
+
+func TestReverseTransferFrozenWallets(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		from := createTestWallet(t, repos, "INR", 1000)
+		to := createTestWallet(t, repos, "INR", 0)
+
+		sent, err := walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: from.WalletAddress,
+			ToWalletAddress:   to.WalletAddress,
+			Amount:            400,
+			Currency:          "INR",
+		})
+		require.NoError(t, err)
+
+		// both wallets are frozen for fraud before the transfer is reversed
+		for _, w := range []model.Wallet{from, to} {
+			_, err = walletRepo.UpdateWalletStatus(ctx, UpdateWalletStatusParams{ID: w.ID, Status: model.WalletStatusINACTIVE})
+			require.NoError(t, err)
+		}
+		_, err = walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: to.WalletAddress,
+			ToWalletAddress:   from.WalletAddress,
+			Amount:            100,
+			Currency:          "INR",
+		})
+		require.ErrorIs(t, err, local_errors.ErrWalletInactive)
+
+		reversal, err := walletRepo.ReverseTransfer(ctx, ReverseTransferParams{TransID: sent.Trans.ID, Reason: "fraud"})
+		require.NoError(t, err)
+		require.Zero(t, reversal.Trans.Shortfall)
+		require.Equal(t, int64(1000), reversal.Wallet.Balance)
+		require.Equal(t, model.WalletStatusINACTIVE, reversal.Wallet.Status)
+		require.Zero(t, walletOf(t, repos, to.WalletAddress).Balance)
+
+		requireConsistentLedger(t, repos, from, to)
+	})
+}
+
+func TestHolds(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+		holdRepo := repos.hold
+
+		from := createTestWallet(t, repos, "INR", 1000)
+		to := createTestWallet(t, repos, "INR", 0)
+
+		newHold := func(amount int64, expiresAt time.Time) (model.Hold, error) {
+			return holdRepo.CreateHold(ctx, CreateHoldParams{
+				WalletAddress:   from.WalletAddress,
+				ToWalletAddress: to.WalletAddress,
+				Amount:          amount,
+				ExpiresAt:       expiresAt,
+			})
+		}
+		later := time.Now().Add(time.Hour)
+
+		hold, err := newHold(600, later)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusACTIVE, hold.Status)
+		payer := walletOf(t, repos, from.WalletAddress)
+		require.Equal(t, int64(400), payer.AvailableBalance())
+
+		// held money can neither be held again nor sent
+		_, err = newHold(401, later)
+		require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+		_, err = walletRepo.SendMoney(ctx, SendMoneyParams{
+			FromWalletAddress: from.WalletAddress,
+			ToWalletAddress:   to.WalletAddress,
+			Amount:            401,
+			Currency:          "INR",
+		})
+		require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+
+		_, err = walletRepo.CaptureHold(ctx, CaptureHoldParams{HoldID: hold.ID, Amount: 601})
+		require.ErrorIs(t, err, local_errors.ErrCaptureExceedsHold)
+
+		// a partial capture releases the rest of the hold
+		res, err := walletRepo.CaptureHold(ctx, CaptureHoldParams{HoldID: hold.ID, Amount: 400, Fee: 10})
+		require.NoError(t, err)
+		require.Equal(t, int64(600), res.Wallet.Balance)
+		require.Equal(t, int64(390), walletOf(t, repos, to.WalletAddress).Balance)
+		require.Zero(t, walletOf(t, repos, from.WalletAddress).HeldBalance)
+
+		hold, err = holdRepo.GetHold(ctx, hold.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusCAPTURED, hold.Status)
+		require.Equal(t, int64(400), hold.CapturedAmount)
+		require.Equal(t, res.Trans.ID, hold.TransID)
+
+		_, err = walletRepo.CaptureHold(ctx, CaptureHoldParams{HoldID: hold.ID, Amount: 1})
+		require.ErrorIs(t, err, local_errors.ErrHoldNotActive)
+
+		voided, err := newHold(100, later)
+		require.NoError(t, err)
+		voided, err = holdRepo.VoidHold(ctx, voided.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusVOIDED, voided.Status)
+		_, err = holdRepo.VoidHold(ctx, voided.ID)
+		require.ErrorIs(t, err, local_errors.ErrHoldNotActive)
+
+		expired, err := newHold(100, time.Now().Add(-time.Second))
+		require.NoError(t, err)
+		_, err = walletRepo.CaptureHold(ctx, CaptureHoldParams{HoldID: expired.ID, Amount: 100})
+		require.ErrorIs(t, err, local_errors.ErrHoldExpired)
+
+		released, err := holdRepo.ReleaseExpiredHolds(ctx, time.Now())
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, released, int64(1))
+		expired, err = holdRepo.GetHold(ctx, expired.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.HoldStatusEXPIRED, expired.Status)
+
+		require.Zero(t, walletOf(t, repos, from.WalletAddress).HeldBalance)
+		_, err = holdRepo.GetHold(ctx, -1)
+		require.ErrorIs(t, err, local_errors.ErrHoldNotFound)
+
+		requireConsistentLedger(t, repos, from, to)
+	})
+}
+
+func TestPayPaymentRequest(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos testRepos) {
+		ctx := context.Background()
+		walletRepo := repos.wallet
+
+		payer := createTestWallet(t, repos, "INR", 1000)
+		payee := createTestWallet(t, repos, "INR", 0)
+
+		newRequest := func(amount int64) model.PaymentRequest {
+			p, err := repos.paymentRequest.CreatePaymentRequest(ctx, CreatePaymentRequestParams{
+				FromWalletAddress: payer.WalletAddress,
+				ToWalletAddress:   payee.WalletAddress,
+				PayerUsername:     payer.Username,
+				PayeeUsername:     payee.Username,
+				Amount:            amount,
+				Currency:          "INR",
+			})
+			require.NoError(t, err)
+			return p
+		}
+		pay := func(p model.PaymentRequest, fee int64) (WalletTransferResult, error) {
+			return walletRepo.PayPaymentRequest(ctx, PayPaymentRequestParams{RequestID: p.ID, Amount: p.Amount, Fee: fee})
+		}
+
+		request := newRequest(300)
+		res, err := pay(request, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(700), res.Wallet.Balance)
+		require.Equal(t, int64(290), walletOf(t, repos, payee.WalletAddress).Balance)
+
+		paid, err := repos.paymentRequest.GetPaymentRequest(ctx, request.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.PaymentRequestStatusPAYMENTSUCCESS, paid.Status)
+		require.Equal(t, res.Trans.ID, paid.TransID)
+
+		_, err = pay(request, 10)
+		require.ErrorIs(t, err, local_errors.ErrInvalidPaymentRequestTransition)
+
+		// a failed payment rolls the approval back with the transfer
+		tooMuch := newRequest(701)
+		_, err = pay(tooMuch, 0)
+		require.ErrorIs(t, err, local_errors.ErrInsufficientBalance)
+		tooMuch, err = repos.paymentRequest.GetPaymentRequest(ctx, tooMuch.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.PaymentRequestStatusWAITINGAPPROVAL, tooMuch.Status)
+		require.Zero(t, tooMuch.TransID)
+
+		refused := newRequest(100)
+		_, err = repos.paymentRequest.UpdatePaymentRequestStatus(ctx, UpdatePaymentRequestStatusParams{
+			ID:         refused.ID,
+			FromStatus: model.PaymentRequestStatusWAITINGAPPROVAL,
+			ToStatus:   model.PaymentRequestStatusREFUSED,
+		})
+		require.NoError(t, err)
+		_, err = pay(refused, 0)
+		require.ErrorIs(t, err, local_errors.ErrInvalidPaymentRequestTransition)
+
+		_, err = walletRepo.PayPaymentRequest(ctx, PayPaymentRequestParams{RequestID: -1, Amount: 1})
+		require.ErrorIs(t, err, local_errors.ErrPaymentRequestNotFound)
+
+		require.Equal(t, int64(700), walletOf(t, repos, payer.WalletAddress).Balance)
+		requireConsistentLedger(t, repos, payer, payee)
+	})
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"time"
+)
+
+type WebhookRepo interface {
+	CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (model.WebhookSubscription, error)
+	GetWebhookSubscription(ctx context.Context, id int64) (model.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context, username string) ([]model.WebhookSubscription, error)
+	// ListActiveWebhookSubscriptions returns the active subscriptions of any of the users
+	ListActiveWebhookSubscriptions(ctx context.Context, usernames []string) ([]model.WebhookSubscription, error)
+	DeactivateWebhookSubscription(ctx context.Context, id int64) (model.WebhookSubscription, error)
+	// CreateWebhookDeliveries queues the deliveries, skipping the events a subscription already has a delivery for
+	CreateWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id int64) (model.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]model.WebhookDelivery, error)
+	// ListDueWebhookDeliveries returns the pending deliveries whose next attempt is due
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error)
+	RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (model.WebhookDelivery, error)
+	// ReplayWebhookDelivery queues a delivery again with all of its attempts, whatever its status
+	ReplayWebhookDelivery(ctx context.Context, id int64, now time.Time) (model.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(client *gorm.DB) WebhookRepo {
+	return &webhookRepository{
+		db: client,
+	}
+}
+
+type CreateWebhookSubscriptionParams struct {
+	Username   string            `json:"username"`
+	URL        string            `json:"url"`
+	EventTypes []model.EventType `json:"event_types"`
+	Secret     string            `json:"secret"`
+}
+
+func (q *webhookRepository) CreateWebhookSubscription(ctx context.Context, arg CreateWebhookSubscriptionParams) (model.WebhookSubscription, error) {
+
+	logrus.Println("log  CreateWebhookSubscription in store/webhook/CreateWebhookSubscription ")
+
+	eventTypes := make([]string, 0, len(arg.EventTypes))
+	for _, t := range arg.EventTypes {
+		eventTypes = append(eventTypes, string(t))
+	}
+
+	now := time.Now()
+	s := model.WebhookSubscription{
+		Username:   arg.Username,
+		URL:        arg.URL,
+		EventTypes: strings.Join(eventTypes, ","),
+		Secret:     arg.Secret,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	res := q.db.Create(&s)
+
+	return s, res.Error
+}
+
+func (q *webhookRepository) GetWebhookSubscription(ctx context.Context, id int64) (model.WebhookSubscription, error) {
+
+	logrus.Println("log  GetWebhookSubscription in store/webhook/GetWebhookSubscription ")
+
+	var s model.WebhookSubscription
+	res := q.db.Where("id = ?", id).Take(&s)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return s, local_errors.ErrWebhookNotFound
+	}
+
+	return s, res.Error
+}
+
+func (q *webhookRepository) ListWebhookSubscriptions(ctx context.Context, username string) ([]model.WebhookSubscription, error) {
+
+	logrus.Println("log  ListWebhookSubscriptions in store/webhook/ListWebhookSubscriptions ")
+
+	var subscriptions []model.WebhookSubscription
+	res := q.db.Where("username = ?", username).Order("id DESC").Find(&subscriptions)
+
+	return subscriptions, res.Error
+}
+
+func (q *webhookRepository) ListActiveWebhookSubscriptions(ctx context.Context, usernames []string) ([]model.WebhookSubscription, error) {
+
+	logrus.Println("log  ListActiveWebhookSubscriptions in store/webhook/ListActiveWebhookSubscriptions ")
+
+	var subscriptions []model.WebhookSubscription
+	if len(usernames) == 0 {
+		return subscriptions, nil
+	}
+	res := q.db.Where("username IN (?) AND active = ?", usernames, true).Order("id").Find(&subscriptions)
+
+	return subscriptions, res.Error
+}
+
+func (q *webhookRepository) DeactivateWebhookSubscription(ctx context.Context, id int64) (model.WebhookSubscription, error) {
+
+	logrus.Println("log  DeactivateWebhookSubscription in store/webhook/DeactivateWebhookSubscription ")
+
+	res := q.db.Model(&model.WebhookSubscription{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"active":     false,
+		"updated_at": time.Now(),
+	})
+	if res.Error != nil {
+		return model.WebhookSubscription{}, res.Error
+	}
+
+	return q.GetWebhookSubscription(ctx, id)
+}
+
+func (q *webhookRepository) CreateWebhookDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+
+	logrus.Println("log  CreateWebhookDeliveries in store/webhook/CreateWebhookDeliveries ")
+
+	return q.db.Transaction(func(tx *gorm.DB) error {
+		for _, d := range deliveries {
+			// the relay delivers an event at least once, the same event must not be queued twice
+			var count int
+			res := tx.Model(&model.WebhookDelivery{}).Where("subscription_id = ? AND event_id = ?", d.SubscriptionID, d.EventID).Count(&count)
+			if res.Error != nil {
+				return res.Error
+			}
+			if count > 0 {
+				continue
+			}
+
+			if err := tx.Create(&d).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (q *webhookRepository) GetWebhookDelivery(ctx context.Context, id int64) (model.WebhookDelivery, error) {
+
+	logrus.Println("log  GetWebhookDelivery in store/webhook/GetWebhookDelivery ")
+
+	var d model.WebhookDelivery
+	res := q.db.Where("id = ?", id).Take(&d)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return d, local_errors.ErrWebhookDeliveryNotFound
+	}
+
+	return d, res.Error
+}
+
+func (q *webhookRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]model.WebhookDelivery, error) {
+
+	logrus.Println("log  ListWebhookDeliveries in store/webhook/ListWebhookDeliveries ")
+
+	var deliveries []model.WebhookDelivery
+	res := q.db.Where("subscription_id = ?", subscriptionID).Order("id DESC").Limit(limit).Find(&deliveries)
+
+	return deliveries, res.Error
+}
+
+func (q *webhookRepository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]model.WebhookDelivery, error) {
+
+	logrus.Println("log  ListDueWebhookDeliveries in store/webhook/ListDueWebhookDeliveries ")
+
+	var deliveries []model.WebhookDelivery
+	res := q.db.Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryStatusPENDING, now).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&deliveries)
+
+	return deliveries, res.Error
+}
+
+type RecordWebhookDeliveryAttemptParams struct {
+	ID         int64  `json:"id"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error"`
+	// NextAttemptAt is set when a failed delivery is tried again, a failure without it is dead
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+}
+
+func (q *webhookRepository) RecordWebhookDeliveryAttempt(ctx context.Context, arg RecordWebhookDeliveryAttemptParams) (model.WebhookDelivery, error) {
+
+	logrus.Println("log  RecordWebhookDeliveryAttempt in store/webhook/RecordWebhookDeliveryAttempt ")
+
+	now := time.Now()
+	fields := map[string]interface{}{
+		"attempts":         gorm.Expr("attempts + 1"),
+		"last_status_code": arg.StatusCode,
+		"last_error":       arg.Error,
+		"updated_at":       now,
+	}
+	switch {
+	case arg.Error == "":
+		fields["status"] = model.WebhookDeliveryStatusSUCCEEDED
+		fields["delivered_at"] = now
+	case !arg.NextAttemptAt.IsZero():
+		fields["next_attempt_at"] = arg.NextAttemptAt
+	default:
+		fields["status"] = model.WebhookDeliveryStatusDEAD
+	}
+
+	// a replay may have queued the delivery again meanwhile, only the pending delivery is updated
+	res := q.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ?", arg.ID, model.WebhookDeliveryStatusPENDING).
+		Updates(fields)
+	if res.Error != nil {
+		return model.WebhookDelivery{}, res.Error
+	}
+
+	return q.GetWebhookDelivery(ctx, arg.ID)
+}
+
+func (q *webhookRepository) ReplayWebhookDelivery(ctx context.Context, id int64, now time.Time) (model.WebhookDelivery, error) {
+
+	logrus.Println("log  ReplayWebhookDelivery in store/webhook/ReplayWebhookDelivery ")
+
+	res := q.db.Model(&model.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          model.WebhookDeliveryStatusPENDING,
+		"attempts":        0,
+		"next_attempt_at": now,
+		"updated_at":      now,
+	})
+	if res.Error != nil {
+		return model.WebhookDelivery{}, res.Error
+	}
+
+	return q.GetWebhookDelivery(ctx, id)
+}
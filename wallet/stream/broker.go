@@ -0,0 +1,184 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CommitWindow is how long a transaction may take to commit the events it wrote. Ids are taken when the
+	// events are written, so an event can become visible after events with higher ids, but not later than this.
+	CommitWindow = 10 * time.Second
+	// pollBatch is the number of events read per query
+	pollBatch = 500
+)
+
+// Broker follows the outbox of every instance and hands the new events to the subscribers of the wallets they are about.
+// Every instance runs its own broker, the outbox is the only thing the instances share.
+type Broker struct {
+	outboxRepo store.OutboxRepo
+	interval   time.Duration
+
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{}
+	// lastID is the highest id seen, seen the ids seen within the commit window
+	lastID int64
+	seen   map[int64]time.Time
+}
+
+func NewBroker(outboxRepo store.OutboxRepo, interval time.Duration) *Broker {
+	return &Broker{
+		outboxRepo: outboxRepo,
+		interval:   interval,
+		subs:       map[string]map[*Subscription]struct{}{},
+		seen:       map[int64]time.Time{},
+	}
+}
+
+// Subscription receives the events of one wallet on C. C is closed when the subscriber falls more than
+// buffer events behind, it then reads what it missed from the outbox.
+type Subscription struct {
+	C       <-chan model.OutboxEvent
+	ch      chan model.OutboxEvent
+	address string
+	broker  *Broker
+	closed  bool
+}
+
+func (b *Broker) Subscribe(address string, buffer int) *Subscription {
+	ch := make(chan model.OutboxEvent, buffer)
+	sub := &Subscription{C: ch, ch: ch, address: address, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[address] == nil {
+		b.subs[address] = map[*Subscription]struct{}{}
+	}
+	b.subs[address][sub] = struct{}{}
+	return sub
+}
+
+// Close stops the subscription, it is safe to call more than once
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+// remove drops a subscription, b.mu must be held
+func (b *Broker) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+
+	delete(b.subs[s.address], s)
+	if len(b.subs[s.address]) == 0 {
+		delete(b.subs, s.address)
+	}
+}
+
+// Run polls the outbox every interval until ctx is done
+func (b *Broker) Run(ctx context.Context) {
+	// only the events written from now on are of interest
+	lastID, err := b.outboxRepo.LastEventID(ctx)
+	if err != nil {
+		logrus.Errorf("failed to read the last outbox event: %v", err)
+	}
+	b.lastID = lastID
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Poll(ctx, time.Now()); err != nil {
+				logrus.Errorf("failed to poll the outbox: %v", err)
+			}
+		}
+	}
+}
+
+// Poll hands out the events that became visible since the last poll
+func (b *Broker) Poll(ctx context.Context, now time.Time) error {
+	since := now.Add(-CommitWindow)
+
+	// the events written after the last one seen, then those of the commit window that
+	// became visible after events with higher ids
+	if err := b.scan(ctx, store.ListEventsParams{AfterID: b.lastID}, now); err != nil {
+		return err
+	}
+	if err := b.scan(ctx, store.ListEventsParams{Since: since}, now); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	for id, at := range b.seen {
+		if at.Before(since) {
+			delete(b.seen, id)
+		}
+	}
+	b.mu.Unlock()
+
+	return nil
+}
+
+// scan hands out the unseen events matching arg, page by page
+func (b *Broker) scan(ctx context.Context, arg store.ListEventsParams, now time.Time) error {
+	arg.Limit = pollBatch
+
+	for {
+		events, err := b.outboxRepo.ListEvents(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		b.mu.Lock()
+		for _, e := range events {
+			if _, ok := b.seen[e.ID]; ok {
+				continue
+			}
+			b.seen[e.ID] = now
+			if e.ID > b.lastID {
+				b.lastID = e.ID
+			}
+			b.dispatch(e)
+		}
+		b.mu.Unlock()
+
+		if len(events) < pollBatch {
+			return nil
+		}
+		arg.AfterID = events[len(events)-1].ID
+	}
+}
+
+// dispatch hands an event to the subscribers of its wallets, b.mu must be held
+func (b *Broker) dispatch(e model.OutboxEvent) {
+	addresses := []string{e.WalletAddress}
+	if e.ToWalletAddress != "" && e.ToWalletAddress != e.WalletAddress {
+		addresses = append(addresses, e.ToWalletAddress)
+	}
+
+	for _, address := range addresses {
+		for sub := range b.subs[address] {
+			select {
+			case sub.ch <- e:
+			default:
+				// a slow subscriber must not hold up the others
+				b.remove(sub)
+			}
+		}
+	}
+}
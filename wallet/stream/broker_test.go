@@ -0,0 +1,105 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/store"
+	"github.com/dsthakur2711/wallet/store/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// received drains what is buffered on a subscription, closed tells whether the broker closed it
+func received(sub *Subscription) (ids []int64, closed bool) {
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				return ids, true
+			}
+			ids = append(ids, e.ID)
+		default:
+			return ids, false
+		}
+	}
+}
+
+func TestBrokerPoll(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	credit := model.OutboxEvent{ID: 1, Type: model.EventTypeWalletCredited, WalletAddress: "a"}
+	transfer := model.OutboxEvent{ID: 2, Type: model.EventTypeTransferCompleted, WalletAddress: "a", ToWalletAddress: "b"}
+	other := model.OutboxEvent{ID: 4, Type: model.EventTypeWalletCreated, WalletAddress: "c"}
+	// the event with id 3 commits after the event with id 4 was seen
+	late := model.OutboxEvent{ID: 3, Type: model.EventTypeWalletCredited, WalletAddress: "b"}
+
+	outboxRepo := &mocks.OutboxRepo{}
+	broker := NewBroker(outboxRepo, time.Second)
+	subA := broker.Subscribe("a", 10)
+	subB := broker.Subscribe("b", 10)
+
+	outboxRepo.On("ListEvents", ctx, store.ListEventsParams{AfterID: 0, Limit: pollBatch}).
+		Return([]model.OutboxEvent{credit, transfer, other}, nil).Once()
+	outboxRepo.On("ListEvents", ctx, store.ListEventsParams{Since: now.Add(-CommitWindow), Limit: pollBatch}).
+		Return([]model.OutboxEvent{credit, transfer, other}, nil).Once()
+	require.NoError(t, broker.Poll(ctx, now))
+
+	// a transfer goes to both of its wallets, an event is handed out once
+	ids, closed := received(subA)
+	require.Equal(t, []int64{1, 2}, ids)
+	require.False(t, closed)
+	ids, _ = received(subB)
+	require.Equal(t, []int64{2}, ids)
+
+	next := now.Add(time.Second)
+	outboxRepo.On("ListEvents", ctx, store.ListEventsParams{AfterID: 4, Limit: pollBatch}).
+		Return([]model.OutboxEvent{}, nil).Once()
+	outboxRepo.On("ListEvents", ctx, store.ListEventsParams{Since: next.Add(-CommitWindow), Limit: pollBatch}).
+		Return([]model.OutboxEvent{credit, transfer, late, other}, nil).Once()
+	require.NoError(t, broker.Poll(ctx, next))
+
+	ids, _ = received(subA)
+	require.Empty(t, ids)
+	ids, _ = received(subB)
+	require.Equal(t, []int64{3}, ids)
+
+	outboxRepo.AssertExpectations(t)
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	events := []model.OutboxEvent{
+		{ID: 1, Type: model.EventTypeWalletCredited, WalletAddress: "a"},
+		{ID: 2, Type: model.EventTypeWalletCredited, WalletAddress: "a"},
+		{ID: 3, Type: model.EventTypeWalletCredited, WalletAddress: "a"},
+	}
+
+	outboxRepo := &mocks.OutboxRepo{}
+	outboxRepo.On("ListEvents", ctx, store.ListEventsParams{AfterID: 0, Limit: pollBatch}).Return(events, nil)
+	outboxRepo.On("ListEvents", ctx, store.ListEventsParams{Since: now.Add(-CommitWindow), Limit: pollBatch}).Return(events, nil)
+
+	broker := NewBroker(outboxRepo, time.Second)
+	slow := broker.Subscribe("a", 2)
+	fast := broker.Subscribe("a", 10)
+	require.NoError(t, broker.Poll(ctx, now))
+
+	// the slow subscriber is closed once its buffer is full, the others keep receiving
+	ids, closed := received(slow)
+	require.Equal(t, []int64{1, 2}, ids)
+	require.True(t, closed)
+	ids, closed = received(fast)
+	require.Equal(t, []int64{1, 2, 3}, ids)
+	require.False(t, closed)
+
+	// closing again is harmless
+	slow.Close()
+	fast.Close()
+	fast.Close()
+	_, closed = received(fast)
+	require.True(t, closed)
+}
@@ -0,0 +1,57 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecretKey = "0123456789abcdef0123456789abcdef"
+
+func TestJWTMaker(t *testing.T) {
+	maker, err := NewJWTMaker(testSecretKey)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken("deepak", "session", TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	verified, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, "session", verified.SessionID)
+	require.Equal(t, "deepak", verified.Username)
+	require.Equal(t, TokenTypeAccess, verified.TokenType)
+	require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+}
+
+func TestExpiredJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(testSecretKey)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken("deepak", "session", TokenTypeAccess, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, local_errors.ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTTokenAlgNone(t *testing.T) {
+	payload, err := NewPayload("deepak", "session", TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	maker, err := NewJWTMaker(testSecretKey)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, local_errors.ErrInvalidToken)
+	require.Nil(t, payload)
+}
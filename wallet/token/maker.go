@@ -0,0 +1,32 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username, session, type and duration
+	CreateToken(username string, sessionID string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string) (*Payload, error)
+}
+
+const (
+	TypeJWT    = "jwt"
+	TypePaseto = "paseto"
+)
+
+// NewMaker creates a token maker of the given type (jwt or paseto)
+func NewMaker(tokenType string, secretKey string) (Maker, error) {
+	switch tokenType {
+	case TypeJWT:
+		return NewJWTMaker(secretKey)
+	case TypePaseto:
+		return NewPasetoMaker(secretKey)
+	default:
+		return nil, fmt.Errorf("unsupported token type %q", tokenType)
+	}
+}
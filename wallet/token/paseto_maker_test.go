@@ -0,0 +1,49 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMaker(t *testing.T) {
+	maker, err := NewPasetoMaker(testSecretKey)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken("deepak", "session", TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	verified, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, "session", verified.SessionID)
+	require.Equal(t, "deepak", verified.Username)
+}
+
+func TestExpiredPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(testSecretKey)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken("deepak", "session", TokenTypeAccess, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, local_errors.ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestPasetoTokenFromOtherKey(t *testing.T) {
+	maker, err := NewPasetoMaker(testSecretKey)
+	require.NoError(t, err)
+	other, err := NewPasetoMaker("fedcba9876543210fedcba9876543210")
+	require.NoError(t, err)
+
+	token, _, err := other.CreateToken("deepak", "session", TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, local_errors.ErrInvalidToken)
+}
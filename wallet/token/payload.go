@@ -0,0 +1,60 @@
+package token
+
+import (
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/nu7hatch/gouuid"
+	"time"
+)
+
+// TokenType tells the short lived access tokens from the refresh tokens, each is only accepted where it is expected
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// Payload contains the payload data of the token
+type Payload struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	// SessionID is the session an access token was issued for, the id of a refresh token is its session id
+	SessionID string    `json:"session_id,omitempty"`
+	TokenType TokenType `json:"token_type"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// NewPayload creates a new token payload with a specific username, session, type and duration
+func NewPayload(username string, sessionID string, tokenType TokenType, duration time.Duration) (*Payload, error) {
+	tokenID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &Payload{
+		ID:        tokenID.String(),
+		Username:  username,
+		SessionID: sessionID,
+		TokenType: tokenType,
+		IssuedAt:  time.Now(),
+		ExpiredAt: time.Now().Add(duration),
+	}
+	return payload, nil
+}
+
+// Valid checks if the token payload is valid or not
+func (payload *Payload) Valid() error {
+	if time.Now().After(payload.ExpiredAt) {
+		return local_errors.ErrExpiredToken
+	}
+	return nil
+}
+
+// Expect returns ErrWrongTokenType unless the token is of the type
+func (payload *Payload) Expect(tokenType TokenType) error {
+	if payload.TokenType != tokenType {
+		return local_errors.ErrWrongTokenType
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"syscall"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+)
+
+// nonPublicNetworks are the ranges a webhook never reaches: the sender runs inside our network, an endpoint there
+// would let a subscriber probe or call our internal services and the cloud metadata endpoint
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",      // this network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, the metadata endpoint 169.254.169.254
+	"172.16.0.0/12",  // private
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // private
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved and broadcast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // NAT64, maps the IPv4 ranges above
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublicIP reports whether ip is an address on the internet, IPv4-mapped IPv6 addresses are checked as IPv4
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL resolves the host of rawURL and fails with ErrWebhookURLNotPublic if any of its addresses is not public
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return local_errors.ErrWebhookURLNotPublic
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return local_errors.ErrWebhookURLNotPublic
+		}
+	}
+	return nil
+}
+
+// dialPublicOnly is the Control of the sender's dialer, it checks the address actually dialed so that a host that
+// resolves elsewhere after CheckURL, or a redirect, cannot reach a non-public address
+func dialPublicOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return local_errors.ErrWebhookURLNotPublic
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+)
+
+const (
+	EventTypeHeader  = "X-Webhook-Event"
+	DeliveryIDHeader = "X-Webhook-Delivery"
+)
+
+// Sender posts deliveries to the endpoints of their subscriptions
+type Sender struct {
+	client     *http.Client
+	publicOnly bool
+}
+
+// NewSender returns a sender that only reaches public addresses. Without a client it dials through a guard that
+// also refuses the addresses the endpoint resolves to at send time.
+func NewSender(client *http.Client) *Sender {
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		// a proxy would dial the endpoint for us, out of reach of the guard
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{Timeout: 10 * time.Second, Control: dialPublicOnly}).DialContext
+		client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	}
+	return &Sender{client: client, publicOnly: true}
+}
+
+// NewUnrestrictedSender returns a sender that reaches any address, for endpoints on a local network
+func NewUnrestrictedSender(client *http.Client) *Sender {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Sender{client: client}
+}
+
+// CheckURL fails with ErrWebhookURLNotPublic if the sender would refuse to post to rawURL
+func (s *Sender) CheckURL(ctx context.Context, rawURL string) error {
+	if !s.publicOnly {
+		return nil
+	}
+	return CheckURL(ctx, rawURL)
+}
+
+// Send posts the delivery signed at the time of sending, it returns the status the endpoint answered with.
+// Any status but a 2xx is an error.
+func (s *Sender) Send(ctx context.Context, sub model.WebhookSubscription, d model.WebhookDelivery) (int, error) {
+	if err := s.CheckURL(ctx, sub.URL); err != nil {
+		return 0, err
+	}
+
+	body := []byte(d.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, string(d.EventType))
+	req.Header.Set(DeliveryIDHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drain the body so that the connection is reused
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
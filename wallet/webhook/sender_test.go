@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/model"
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderSend(t *testing.T) {
+	const secret = "whsec_test"
+
+	status := http.StatusNoContent
+	var verifyErr error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		verifyErr = Verify(secret, r.Header.Get(SignatureHeader), body, time.Now(), time.Minute)
+		require.Equal(t, string(model.EventTypeTransferCompleted), r.Header.Get(EventTypeHeader))
+		require.Equal(t, "7", r.Header.Get(DeliveryIDHeader))
+		w.WriteHeader(status)
+	}))
+	defer receiver.Close()
+
+	sub := model.WebhookSubscription{URL: receiver.URL, Secret: secret}
+	delivery := model.WebhookDelivery{ID: 7, EventType: model.EventTypeTransferCompleted, Payload: `{"id":1}`}
+
+	code, err := NewUnrestrictedSender(nil).Send(context.Background(), sub, delivery)
+	require.NoError(t, err)
+	require.NoError(t, verifyErr)
+	require.Equal(t, http.StatusNoContent, code)
+
+	status = http.StatusInternalServerError
+	code, err = NewUnrestrictedSender(nil).Send(context.Background(), sub, delivery)
+	require.Error(t, err)
+	require.Equal(t, http.StatusInternalServerError, code)
+
+	receiver.Close()
+	code, err = NewUnrestrictedSender(nil).Send(context.Background(), sub, delivery)
+	require.Error(t, err)
+	require.Zero(t, code)
+}
+
+func TestSenderRefusesNonPublicAddresses(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	delivery := model.WebhookDelivery{ID: 7, EventType: model.EventTypeTransferCompleted, Payload: `{"id":1}`}
+
+	// the receiver listens on loopback
+	_, err := NewSender(nil).Send(context.Background(), model.WebhookSubscription{URL: receiver.URL}, delivery)
+	require.ErrorIs(t, err, local_errors.ErrWebhookURLNotPublic)
+	require.False(t, called)
+
+	// the dialer refuses a non-public address even when the url was not checked
+	_, err = NewSender(nil).client.Get(receiver.URL)
+	require.ErrorIs(t, err, local_errors.ErrWebhookURLNotPublic)
+	require.False(t, called)
+
+	for _, rawURL := range []string{
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.1.2.3/hook",
+		"http://172.16.0.1/hook",
+		"https://192.168.1.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://[fd00::1]/hook",
+	} {
+		require.ErrorIs(t, CheckURL(context.Background(), rawURL), local_errors.ErrWebhookURLNotPublic, rawURL)
+	}
+	require.NoError(t, CheckURL(context.Background(), "https://93.184.216.34/hook"))
+	require.NoError(t, NewUnrestrictedSender(nil).CheckURL(context.Background(), receiver.URL))
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+)
+
+// SignatureHeader carries "t=<unix seconds>,v1=<hex hmac>", the HMAC-SHA256 with the
+// subscription secret of the timestamp, a dot and the request body
+const SignatureHeader = "X-Webhook-Signature"
+
+// NewSecret returns a random secret to sign the deliveries of a subscription
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header of body sent at t
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + mac(secret, ts, body)
+}
+
+// Verify checks a signature header, receivers refuse signatures older than tolerance to stop replays
+func Verify(secret string, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			sig = kv[1]
+		}
+	}
+	if ts == "" || sig == "" {
+		return local_errors.ErrInvalidWebhookSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return local_errors.ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp is outside the tolerance", local_errors.ErrInvalidWebhookSignature)
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(mac(secret, ts, body))) {
+		return local_errors.ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+func mac(secret string, ts string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dsthakur2711/wallet/pkg/local_errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(secret, "whsec_"))
+
+	now := time.Now()
+	body := []byte(`{"type":"TransferCompleted"}`)
+	header := Sign(secret, now, body)
+
+	require.NoError(t, Verify(secret, header, body, now.Add(time.Minute), 5*time.Minute))
+
+	testCases := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+	}{
+		{name: "TamperedBody", secret: secret, header: header, body: []byte(`{"type":"WalletFrozen"}`), now: now},
+		{name: "OtherSecret", secret: "whsec_other", header: header, body: body, now: now},
+		{name: "Stale", secret: secret, header: header, body: body, now: now.Add(time.Hour)},
+		{name: "Malformed", secret: secret, header: "v1=abc", body: body, now: now},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, tc.now, 5*time.Minute)
+			require.ErrorIs(t, err, local_errors.ErrInvalidWebhookSignature)
+		})
+	}
+}